@@ -93,6 +93,16 @@ set_kv "NOMAD_TLS_SERVER_NAME" "nomad_tls_server_name"
 set_kv "NOMAD_SKIP_VERIFY" "nomad_skip_verify"
 set_kv "NOMAD_TOKEN" "nomad_token"
 
+# Tenant Secret Store
+echo "🔐 Tenant Secret Store Configuration..."
+set_kv "SECRET_STORE" "secret_store"
+set_kv "SECRET_STORE_PREFIX" "secret_store_prefix"
+set_kv "CONSUL_HTTP_ADDR" "consul_http_addr"
+set_kv "CONSUL_HTTP_TOKEN" "consul_http_token"
+set_kv "VAULT_ADDR" "vault_addr"
+set_kv "VAULT_TOKEN" "vault_token"
+set_kv "VAULT_KV_MOUNT" "vault_kv_mount"
+
 # Railzway Client
 echo "🔌 Railzway Client Configuration..."
 set_kv "RAILZWAY_CLIENT_URL" "railzway_client_url"
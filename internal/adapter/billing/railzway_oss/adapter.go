@@ -3,25 +3,38 @@ package railzwayoss
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
 	"github.com/railzwaylabs/railzway-cloud/pkg/railzwayclient"
 )
 
+// entitlementCacheTTL bounds how long a product entitlement change takes to
+// reach deploys.
+const entitlementCacheTTL = 5 * time.Minute
+
 type Adapter struct {
-	client *railzwayclient.Client
+	client       *railzwayclient.Client
+	entitlements *railzwayclient.Cache
 }
 
 func NewAdapter(client *railzwayclient.Client) *Adapter {
-	return &Adapter{client: client}
+	return &Adapter{
+		client:       client,
+		entitlements: railzwayclient.NewCache(1000, entitlementCacheTTL),
+	}
+}
+
+func (a *Adapter) PauseSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error {
+	return a.client.PauseSubscription(ctx, subscriptionID, idempotencyKey)
 }
 
-func (a *Adapter) PauseSubscription(ctx context.Context, subscriptionID string) error {
-	return a.client.PauseSubscription(ctx, subscriptionID)
+func (a *Adapter) ResumeSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error {
+	return a.client.ResumeSubscription(ctx, subscriptionID, idempotencyKey)
 }
 
-func (a *Adapter) ResumeSubscription(ctx context.Context, subscriptionID string) error {
-	return a.client.ResumeSubscription(ctx, subscriptionID)
+func (a *Adapter) CancelSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error {
+	return a.client.CancelSubscription(ctx, subscriptionID, false, idempotencyKey)
 }
 
 func (a *Adapter) GetSubscriptionStatus(ctx context.Context, subscriptionID string) (string, error) {
@@ -60,7 +73,7 @@ func (a *Adapter) ChangePlan(ctx context.Context, params billing.ChangePlanParam
 		CancelAtPeriodEnd: params.CancelAtPeriodEnd,
 	}
 
-	return a.client.ChangePlan(ctx, params.SubscriptionID, req)
+	return a.client.ChangePlan(ctx, params.SubscriptionID, req, params.IdempotencyKey)
 }
 
 func (a *Adapter) ResolvePriceID(ctx context.Context, tier string) (string, error) {
@@ -79,6 +92,36 @@ func (a *Adapter) ResolvePriceID(ctx context.Context, tier string) (string, erro
 	return price.ID, nil
 }
 
+// ResolveEntitlements reads metadata.entitlements of the product a price
+// belongs to. Results are cached per price for entitlementCacheTTL.
+func (a *Adapter) ResolveEntitlements(ctx context.Context, priceID string) (map[string]any, error) {
+	if cached, ok := a.entitlements.Get(priceID); ok {
+		return cached.(map[string]any), nil
+	}
+
+	price, err := a.client.GetPrice(ctx, priceID)
+	if err != nil {
+		return nil, fmt.Errorf("billing adapter: %w", err)
+	}
+	if price.ProductID == "" {
+		return nil, fmt.Errorf("billing adapter: price %s has no product", priceID)
+	}
+	product, err := a.client.GetProduct(ctx, price.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("billing adapter: %w", err)
+	}
+
+	var entitlements map[string]any
+	if raw, ok := product.Metadata["entitlements"]; ok {
+		entitlements, ok = raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("billing adapter: product %s has malformed entitlements", product.ID)
+		}
+	}
+	a.entitlements.Set(priceID, entitlements)
+	return entitlements, nil
+}
+
 // PriceMap defines the configuration for Tier -> Price Code.
 var PriceMap = map[string]string{
 	"FREE_TRIAL": "free-trial-monthly",
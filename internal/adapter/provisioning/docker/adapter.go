@@ -0,0 +1,35 @@
+package docker
+
+import (
+	"context"
+
+	nomadAdapter "github.com/railzwaylabs/railzway-cloud/internal/adapter/provisioning/nomad"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/pkg/docker"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+// Adapter runs tenant workloads as local containers, for development and CI.
+type Adapter struct {
+	client *docker.Client
+}
+
+func NewAdapter(client *docker.Client) *Adapter {
+	return &Adapter{client: client}
+}
+
+func (a *Adapter) Deploy(ctx context.Context, cfg *provisioning.DeploymentConfig) error {
+	return a.client.DeployInstance(ctx, nomadAdapter.ToJobConfig(cfg))
+}
+
+func (a *Adapter) Stop(ctx context.Context, workload provisioning.Workload) error {
+	return a.client.StopInstance(ctx, containerName(workload))
+}
+
+func (a *Adapter) GetStatus(ctx context.Context, workload provisioning.Workload) (string, error) {
+	return a.client.GetInstanceStatus(ctx, containerName(workload))
+}
+
+func containerName(workload provisioning.Workload) string {
+	return nomad.JobConfig{OrgID: workload.OrgID, JobID: workload.JobID}.JobName()
+}
@@ -0,0 +1,36 @@
+package kubernetes
+
+import (
+	"context"
+
+	nomadAdapter "github.com/railzwaylabs/railzway-cloud/internal/adapter/provisioning/nomad"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/pkg/kube"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+type Adapter struct {
+	client *kube.Client
+}
+
+func NewAdapter(client *kube.Client) *Adapter {
+	return &Adapter{client: client}
+}
+
+func (a *Adapter) Deploy(ctx context.Context, cfg *provisioning.DeploymentConfig) error {
+	return a.client.DeployInstance(ctx, nomadAdapter.ToJobConfig(cfg))
+}
+
+func (a *Adapter) Stop(ctx context.Context, workload provisioning.Workload) error {
+	return a.client.StopInstance(ctx, workloadName(workload))
+}
+
+func (a *Adapter) GetStatus(ctx context.Context, workload provisioning.Workload) (string, error) {
+	return a.client.GetInstanceStatus(ctx, workloadName(workload))
+}
+
+// workloadName matches the Nomad job name so an org keeps its workload name
+// whichever provisioner runs it.
+func workloadName(workload provisioning.Workload) string {
+	return nomad.JobConfig{OrgID: workload.OrgID, JobID: workload.JobID}.JobName()
+}
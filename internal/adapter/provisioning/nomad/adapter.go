@@ -2,21 +2,58 @@ package nomad
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
 	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	"github.com/railzwaylabs/railzway-cloud/pkg/secretstore"
 )
 
+// Adapter runs workloads as Nomad jobs. Their credentials are written to the
+// secret store and rendered into the allocation by a template.
 type Adapter struct {
-	client *nomad.Client
+	client       *nomad.Client
+	secrets      secretstore.Store
+	secretPrefix string
 }
 
-func NewAdapter(client *nomad.Client) *Adapter {
-	return &Adapter{client: client}
+func NewAdapter(client *nomad.Client, secrets secretstore.Store, cfg *config.Config) *Adapter {
+	return &Adapter{client: client, secrets: secrets, secretPrefix: cfg.SecretStorePrefix}
 }
 
 func (a *Adapter) Deploy(ctx context.Context, cfg *provisioning.DeploymentConfig) error {
-	jobCfg := nomad.JobConfig{
+	job, err := a.jobConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return a.client.DeployInstance(job)
+}
+
+// jobConfig stores the credentials of a deployment and points its job at
+// them. They are written before the job, so the template finds them.
+func (a *Adapter) jobConfig(ctx context.Context, cfg *provisioning.DeploymentConfig) (nomad.JobConfig, error) {
+	job := ToJobConfig(cfg)
+	path := a.secretPath(job.JobName())
+	if err := a.secrets.Put(ctx, path, nomad.SecretEnvVars(job)); err != nil {
+		return job, fmt.Errorf("store workload secrets: %w", err)
+	}
+	job.SecretsTemplate = a.secrets.Template(path, nomad.SecretEnvKeys)
+	job.SecretsFromVault = a.secrets.NeedsVaultToken()
+	return job, nil
+}
+
+func (a *Adapter) secretPath(jobName string) string {
+	if a.secretPrefix == "" {
+		return jobName
+	}
+	return a.secretPrefix + "/" + jobName
+}
+
+// ToJobConfig maps a deployment onto the job configuration shared by the
+// Nomad generator and the other provisioners.
+func ToJobConfig(cfg *provisioning.DeploymentConfig) nomad.JobConfig {
+	return nomad.JobConfig{
 		OrgID:   cfg.OrgID,
 		OrgSlug: cfg.OrgSlug,
 		OrgName: cfg.OrgName,
@@ -39,14 +76,32 @@ func (a *Adapter) Deploy(ctx context.Context, cfg *provisioning.DeploymentConfig
 		OAuth2ClientID:              cfg.OAuth2ClientID,
 		OAuth2ClientSecret:          cfg.OAuth2ClientSecret,
 		PaymentProviderConfigSecret: cfg.PaymentProviderConfigSecret,
+
+		PreviousPaymentProviderConfigSecret: cfg.PreviousPaymentProviderConfigSecret,
+
+		JobID:   cfg.JobID,
+		Standby: cfg.Standby,
+
+		Profile: cfg.TierProfile,
+	}
+}
+
+// Stop removes the job and its credentials. Starting it again deploys both.
+func (a *Adapter) Stop(ctx context.Context, workload provisioning.Workload) error {
+	name := jobName(workload)
+	if err := a.client.StopInstance(name); err != nil {
+		return err
+	}
+	if err := a.secrets.Delete(ctx, a.secretPath(name)); err != nil {
+		return fmt.Errorf("delete workload secrets: %w", err)
 	}
-	return a.client.DeployInstance(jobCfg)
+	return nil
 }
 
-func (a *Adapter) Stop(ctx context.Context, orgID int64) error {
-	return a.client.StopInstance(orgID)
+func (a *Adapter) GetStatus(ctx context.Context, workload provisioning.Workload) (string, error) {
+	return a.client.GetInstanceStatus(jobName(workload))
 }
 
-func (a *Adapter) GetStatus(ctx context.Context, orgID int64) (string, error) {
-	return a.client.GetInstanceStatus(orgID)
+func jobName(workload provisioning.Workload) string {
+	return nomad.JobConfig{OrgID: workload.OrgID, JobID: workload.JobID}.JobName()
 }
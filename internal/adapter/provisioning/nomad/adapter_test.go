@@ -0,0 +1,83 @@
+package nomad
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore keeps secrets in memory.
+type fakeStore struct {
+	secrets map[string]map[string]string
+	err     error
+}
+
+func (f *fakeStore) Put(ctx context.Context, path string, values map[string]string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.secrets[path] = values
+	return nil
+}
+
+func (f *fakeStore) Delete(ctx context.Context, path string) error {
+	delete(f.secrets, path)
+	return nil
+}
+
+func (f *fakeStore) Template(path string, keys []string) string {
+	return path + ":" + strings.Join(keys, ",")
+}
+
+func (f *fakeStore) NeedsVaultToken() bool {
+	return false
+}
+
+func testDeployment() *provisioning.DeploymentConfig {
+	return &provisioning.DeploymentConfig{
+		OrgID:         42,
+		Version:       "v1.0.0",
+		Tier:          instance.TierStarter,
+		ComputeEngine: instance.EngineGCP,
+		DBConfig: provisioning.DBConfig{
+			Host:     "db",
+			Port:     5432,
+			Name:     "railzway_org_42",
+			User:     "railzway_user_42",
+			Password: "db-secret",
+		},
+		OAuth2ClientSecret:          "oauth-secret",
+		PaymentProviderConfigSecret: "payment-secret",
+	}
+}
+
+func TestAdapter_StoresSecretsBeforeTheJob(t *testing.T) {
+	store := &fakeStore{secrets: make(map[string]map[string]string)}
+	adapter := NewAdapter(nil, store, &config.Config{SecretStorePrefix: "railzway-cloud/workloads"})
+
+	job, err := adapter.jobConfig(context.Background(), testDeployment())
+	require.NoError(t, err)
+
+	stored := store.secrets["railzway-cloud/workloads/railzway-org-42"]
+	assert.Equal(t, "db-secret", stored["DB_PASSWORD"])
+	assert.Equal(t, "oauth-secret", stored["OAUTH2_CLIENT_SECRET"])
+	assert.Equal(t, "payment-secret", stored["PAYMENT_PROVIDER_CONFIG_SECRET"])
+	assert.Contains(t, stored["DATABASE_URL"], "db-secret")
+	assert.True(t, strings.HasPrefix(job.SecretsTemplate, "railzway-cloud/workloads/railzway-org-42:DB_PASSWORD"))
+}
+
+func TestAdapter_DeployFailsWhenSecretsCannotBeStored(t *testing.T) {
+	store := &fakeStore{err: errors.New("consul unavailable")}
+	// Without a Nomad client, reaching the job registration would panic.
+	adapter := NewAdapter(nil, store, &config.Config{})
+
+	err := adapter.Deploy(context.Background(), testDeployment())
+	assert.ErrorContains(t, err, "consul unavailable")
+}
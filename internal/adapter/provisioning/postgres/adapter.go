@@ -3,10 +3,20 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
 )
 
+// maxIdentifierLength is the longest name Postgres keeps; longer names are
+// silently truncated, which could make two tenants share one.
+const maxIdentifierLength = 63
+
+// Adapter provisions tenant databases on a shared Postgres server. Each tenant
+// gets a role that owns a single database and cannot reach the others. Its
+// workload logs in either as that role or, once its password was rotated, as
+// one of two login roles that act as it.
 type Adapter struct {
 	adminConnString string
 }
@@ -18,67 +28,259 @@ func NewAdapter(adminConnString string) *Adapter {
 }
 
 // Provision implements provisioning.DatabaseProvisioner
-func (a *Adapter) Provision(ctx context.Context, orgID int64, password string) error {
+func (a *Adapter) Provision(ctx context.Context, db provisioning.DBConfig) error {
+	if err := validateNames(db); err != nil {
+		return err
+	}
+	if db.Password == "" {
+		return fmt.Errorf("database password missing for %s", db.User)
+	}
+	// Only the SCRAM verifier is sent, so the password never shows up in the
+	// server's statement logs or pg_stat_activity.
+	verifier, err := scramSHA256(db.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
 	conn, err := pgx.Connect(ctx, a.adminConnString)
 	if err != nil {
 		return fmt.Errorf("failed to connect to admin db: %w", err)
 	}
 	defer conn.Close(ctx)
 
-	userName := fmt.Sprintf("railzway_user_%d", orgID)
-	dbName := fmt.Sprintf("railzway_org_%d", orgID)
+	userName := pgx.Identifier{db.User}.Sanitize()
+	dbName := pgx.Identifier{db.Name}.Sanitize()
+	login := loginUser(db)
+	loginName := pgx.Identifier{login}.Sanitize()
 
 	// 1. Create User (Idempotent)
 	// Check if user exists
-	var exists bool
-	err = conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname=$1)", userName).Scan(&exists)
+	exists, err := roleExists(ctx, conn, db.User)
 	if err != nil {
 		return fmt.Errorf("failed to check user existence: %w", err)
 	}
+	if login != db.User {
+		// The owner is left as it is: until the grace period of a rotation
+		// ends, it may still be the login of running allocations.
+		if !exists {
+			if _, err := conn.Exec(ctx, "CREATE ROLE "+userName+" WITH NOLOGIN NOCREATEDB NOCREATEROLE"); err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+		}
+		if exists, err = roleExists(ctx, conn, login); err != nil {
+			return fmt.Errorf("failed to check login existence: %w", err)
+		}
+	}
 
+	// Roles are created without superuser, replication and RLS bypass by
+	// default; the rest is reset on every run to undo drift.
+	attributes := fmt.Sprintf("LOGIN NOCREATEDB NOCREATEROLE CONNECTION LIMIT %d PASSWORD %s",
+		connectionLimit(db.ConnectionLimit), quoteLiteral(verifier))
 	if !exists {
-		// Create User
-		// NOTE: Parameterized queries for identifiers (like username) are not supported in standard SQL
-		// We securely format the string since we control the inputs (orgID is int)
-		query := fmt.Sprintf("CREATE USER %q WITH PASSWORD '%s'", userName, password)
-		if _, err := conn.Exec(ctx, query); err != nil {
+		if _, err := conn.Exec(ctx, "CREATE ROLE "+loginName+" WITH "+attributes); err != nil {
 			return fmt.Errorf("failed to create user: %w", err)
 		}
 	} else {
-		// Rotate Password
-		query := fmt.Sprintf("ALTER USER %q WITH PASSWORD '%s'", userName, password)
-		if _, err := conn.Exec(ctx, query); err != nil {
-			return fmt.Errorf("failed to rotate password: %w", err)
+		// Rotate password and reapply limits
+		if _, err := conn.Exec(ctx, "ALTER ROLE "+loginName+" WITH "+attributes); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+
+	if login != db.User {
+		// The login acts as the owner, so whatever it creates stays usable
+		// after the next rotation moves the workload to the other login.
+		if _, err := conn.Exec(ctx, "GRANT "+userName+" TO "+loginName); err != nil {
+			return fmt.Errorf("failed to grant user to login: %w", err)
+		}
+		if _, err := conn.Exec(ctx, "ALTER ROLE "+loginName+" SET role = "+quoteLiteral(db.User)); err != nil {
+			return fmt.Errorf("failed to set login role: %w", err)
 		}
 	}
 
+	query := "ALTER ROLE " + loginName + " RESET statement_timeout"
+	if db.StatementTimeout > 0 {
+		query = fmt.Sprintf("ALTER ROLE %s SET statement_timeout = %d", loginName, db.StatementTimeout.Milliseconds())
+	}
+	if _, err := conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+
 	// 2. Create Database (Idempotent)
 	// Check if db exists
-	err = conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname=$1)", dbName).Scan(&exists)
+	err = conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname=$1)", db.Name).Scan(&exists)
 	if err != nil {
 		return fmt.Errorf("failed to check db existence: %w", err)
 	}
 
 	if !exists {
 		// Create Database and assign owner
-		query := fmt.Sprintf("CREATE DATABASE %q OWNER %q", dbName, userName)
-		if _, err := conn.Exec(ctx, query); err != nil {
+		if _, err := conn.Exec(ctx, "CREATE DATABASE "+dbName+" OWNER "+userName); err != nil {
 			return fmt.Errorf("failed to create database: %w", err)
 		}
 	} else {
-		// Ensure owner is correct (Optional but good for drift)
-		query := fmt.Sprintf("ALTER DATABASE %q OWNER TO %q", dbName, userName)
-		if _, err := conn.Exec(ctx, query); err != nil {
-			// Warn but don't fail, maybe? Or fail. Let's fail for correctness.
+		// Ensure owner is correct
+		if _, err := conn.Exec(ctx, "ALTER DATABASE "+dbName+" OWNER TO "+userName); err != nil {
 			return fmt.Errorf("failed to set database owner: %w", err)
 		}
 	}
 
-	// 3. Grant Privileges (Ensure user has access to public schema in their DB)
-	// Note: We need to connect to the NEW database to grant schema privileges inside it.
-	// However, OWNER usually has full rights.
-	// For standard public schema usage, OWNER is sufficient.
-	// If we needed specific grants, we'd loop in a new connection here.
+	// 3. Revoke Public Access
+	// New databases let every role connect and create temporary tables.
+	if _, err := conn.Exec(ctx, "REVOKE ALL ON DATABASE "+dbName+" FROM PUBLIC"); err != nil {
+		return fmt.Errorf("failed to revoke public database access: %w", err)
+	}
+	return a.grantSchema(ctx, db)
+}
 
+// grantSchema leaves the public schema of a tenant database to its user.
+// Before Postgres 15 every role may create objects in it.
+func (a *Adapter) grantSchema(ctx context.Context, db provisioning.DBConfig) error {
+	cfg, err := pgx.ParseConfig(a.adminConnString)
+	if err != nil {
+		return fmt.Errorf("failed to parse admin connection string: %w", err)
+	}
+	cfg.Database = db.Name
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to tenant db: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "REVOKE ALL ON SCHEMA public FROM PUBLIC"); err != nil {
+		return fmt.Errorf("failed to revoke public schema access: %w", err)
+	}
+	if _, err := conn.Exec(ctx, "GRANT ALL ON SCHEMA public TO "+pgx.Identifier{db.User}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to grant schema access: %w", err)
+	}
 	return nil
 }
+
+// DisableLogin implements provisioning.DatabaseProvisioner
+func (a *Adapter) DisableLogin(ctx context.Context, db provisioning.DBConfig) error {
+	login := loginUser(db)
+	if err := validateName("login", login); err != nil {
+		return err
+	}
+
+	conn, err := pgx.Connect(ctx, a.adminConnString)
+	if err != nil {
+		return fmt.Errorf("failed to connect to admin db: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	exists, err := roleExists(ctx, conn, login)
+	if err != nil {
+		return fmt.Errorf("failed to check login existence: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+	if _, err := conn.Exec(ctx, "ALTER ROLE "+pgx.Identifier{login}.Sanitize()+" WITH NOLOGIN PASSWORD NULL"); err != nil {
+		return fmt.Errorf("failed to disable login: %w", err)
+	}
+	// Sessions outliving the grace period would keep the old password alive.
+	if _, err := conn.Exec(ctx, "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE usename = $1", login); err != nil {
+		return fmt.Errorf("failed to close login connections: %w", err)
+	}
+	return nil
+}
+
+// Deprovision implements provisioning.DatabaseProvisioner
+func (a *Adapter) Deprovision(ctx context.Context, db provisioning.DBConfig) error {
+	if err := validateNames(db); err != nil {
+		return err
+	}
+
+	conn, err := pgx.Connect(ctx, a.adminConnString)
+	if err != nil {
+		return fmt.Errorf("failed to connect to admin db: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	// FORCE terminates the connections of a workload that is still shutting down.
+	query := "DROP DATABASE IF EXISTS " + pgx.Identifier{db.Name}.Sanitize() + " WITH (FORCE)"
+	if _, err := conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to drop database: %w", err)
+	}
+
+	// Login roles are the members of the user that cannot manage roles, which
+	// keeps an admin that created the user out.
+	rows, err := conn.Query(ctx,
+		`SELECT m.rolname FROM pg_auth_members am
+		 JOIN pg_roles m ON m.oid = am.member
+		 JOIN pg_roles o ON o.oid = am.roleid
+		 WHERE o.rolname = $1 AND NOT m.rolsuper AND NOT m.rolcreaterole AND m.rolname <> current_user`, db.User)
+	if err != nil {
+		return fmt.Errorf("failed to list logins: %w", err)
+	}
+	logins, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to list logins: %w", err)
+	}
+	for _, login := range append(logins, db.User) {
+		if _, err := conn.Exec(ctx, "DROP ROLE IF EXISTS "+pgx.Identifier{login}.Sanitize()); err != nil {
+			return fmt.Errorf("failed to drop user: %w", err)
+		}
+	}
+	return nil
+}
+
+func roleExists(ctx context.Context, conn *pgx.Conn, name string) (bool, error) {
+	var exists bool
+	err := conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname=$1)", name).Scan(&exists)
+	return exists, err
+}
+
+// loginUser returns the role the workload of db logs in as.
+func loginUser(db provisioning.DBConfig) string {
+	if db.LoginUser != "" {
+		return db.LoginUser
+	}
+	return db.User
+}
+
+// validateNames rejects names Postgres would not store as given.
+func validateNames(db provisioning.DBConfig) error {
+	if err := validateName("database", db.Name); err != nil {
+		return err
+	}
+	if err := validateName("user", db.User); err != nil {
+		return err
+	}
+	if db.LoginUser != "" {
+		return validateName("login", db.LoginUser)
+	}
+	return nil
+}
+
+func validateName(kind, name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%s name missing", kind)
+	case len(name) > maxIdentifierLength:
+		return fmt.Errorf("%s name %q is longer than %d bytes", kind, name, maxIdentifierLength)
+	case strings.ContainsRune(name, 0):
+		return fmt.Errorf("%s name %q contains a NUL byte", kind, name)
+	}
+	return nil
+}
+
+// connectionLimit converts a limit where 0 means none to Postgres', where -1
+// does.
+func connectionLimit(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
+
+// quoteLiteral quotes a string constant the way Postgres' quote_literal does,
+// whatever standard_conforming_strings is set to.
+func quoteLiteral(s string) string {
+	literal := "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	if strings.Contains(s, `\`) {
+		return "E" + strings.ReplaceAll(literal, `\`, `\\`)
+	}
+	return literal
+}
@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestScramVerifier_MatchesRFC7677(t *testing.T) {
+	salt, err := base64.StdEncoding.DecodeString("W22ZaJ0SNY7soEsUEjb6gQ==")
+	require.NoError(t, err)
+
+	verifier, err := scramVerifier("pencil", salt, 4096)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(verifier, "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$"))
+
+	// The server signature of the RFC's exchange proves the ServerKey.
+	keys := strings.Split(verifier[strings.LastIndex(verifier, "$")+1:], ":")
+	require.Len(t, keys, 2)
+	serverKey, err := base64.StdEncoding.DecodeString(keys[1])
+	require.NoError(t, err)
+	authMessage := "n=user,r=rOprNGfwEbeRWgbNEkqO," +
+		"r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096," +
+		"c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	mac := hmac.New(sha256.New, serverKey)
+	mac.Write([]byte(authMessage))
+	assert.Equal(t, "6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	assert.Equal(t, `'secret'`, quoteLiteral(`secret`))
+	assert.Equal(t, `'it''s'`, quoteLiteral(`it's`))
+	assert.Equal(t, `E'a\\'';--'`, quoteLiteral(`a\';--`))
+}
+
+func TestValidateNames(t *testing.T) {
+	assert.NoError(t, validateNames(provisioning.DBConfig{Name: "railzway_org_1", User: "railzway_user_1"}))
+	assert.ErrorContains(t, validateNames(provisioning.DBConfig{Name: "railzway_org_1"}), "user name missing")
+	assert.ErrorContains(t, validateNames(provisioning.DBConfig{Name: strings.Repeat("a", 64), User: "u"}), "longer than 63 bytes")
+}
+
+func setupAdapter(t *testing.T) (*Adapter, *pgx.ConnConfig) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := context.Background()
+	pg, err := testhelper.SetupPostgres(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := pg.Teardown(ctx); err != nil {
+			t.Logf("failed to teardown container: %v", err)
+		}
+	})
+
+	cfg, err := pgx.ParseConfig(pg.DSN)
+	require.NoError(t, err)
+	return NewAdapter(pg.DSN), cfg
+}
+
+// connectAs opens a connection to database as user.
+func connectAs(ctx context.Context, admin *pgx.ConnConfig, database, user, password string) (*pgx.Conn, error) {
+	cfg := admin.Copy()
+	cfg.Database, cfg.User, cfg.Password = database, user, password
+	return pgx.ConnectConfig(ctx, cfg)
+}
+
+func TestAdapter_ProvisionIsolatesTenant(t *testing.T) {
+	adapter, admin := setupAdapter(t)
+	ctx := context.Background()
+
+	// Quotes in names and password must not break out of the statements.
+	db := provisioning.DBConfig{
+		Name:             `tenant"db`,
+		User:             `tenant'user`,
+		Password:         `p'w\"; DROP ROLE testuser; --`,
+		ConnectionLimit:  5,
+		StatementTimeout: 15 * time.Second,
+	}
+	require.NoError(t, adapter.Provision(ctx, db))
+
+	conn, err := connectAs(ctx, admin, db.Name, db.User, db.Password)
+	require.NoError(t, err)
+	var timeout string
+	require.NoError(t, conn.QueryRow(ctx, "SHOW statement_timeout").Scan(&timeout))
+	assert.Equal(t, "15s", timeout)
+	_, err = conn.Exec(ctx, "CREATE TABLE invoices (id bigint)")
+	assert.NoError(t, err)
+	require.NoError(t, conn.Close(ctx))
+
+	adminConn, err := pgx.ConnectConfig(ctx, admin)
+	require.NoError(t, err)
+	defer adminConn.Close(ctx)
+	var connLimit int
+	var password string
+	require.NoError(t, adminConn.QueryRow(ctx,
+		"SELECT rolconnlimit, rolpassword FROM pg_authid WHERE rolname = $1", db.User).Scan(&connLimit, &password))
+	assert.Equal(t, 5, connLimit)
+	assert.True(t, strings.HasPrefix(password, "SCRAM-SHA-256$4096:"))
+
+	// Another tenant cannot connect to the database.
+	other := provisioning.DBConfig{Name: "other_db", User: "other_user", Password: "other-password"}
+	require.NoError(t, adapter.Provision(ctx, other))
+	_, err = connectAs(ctx, admin, db.Name, other.User, other.Password)
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+func TestAdapter_ProvisionRotatesPasswordAndLimits(t *testing.T) {
+	adapter, admin := setupAdapter(t)
+	ctx := context.Background()
+
+	db := provisioning.DBConfig{Name: "railzway_org_1", User: "railzway_user_1", Password: "first", ConnectionLimit: 5}
+	require.NoError(t, adapter.Provision(ctx, db))
+
+	db.Password, db.ConnectionLimit, db.StatementTimeout = "second", 0, 0
+	require.NoError(t, adapter.Provision(ctx, db))
+
+	_, err := connectAs(ctx, admin, db.Name, db.User, "first")
+	assert.Error(t, err)
+	conn, err := connectAs(ctx, admin, db.Name, db.User, "second")
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	var connLimit int
+	require.NoError(t, conn.QueryRow(ctx, "SELECT rolconnlimit FROM pg_roles WHERE rolname = current_user").Scan(&connLimit))
+	assert.Equal(t, -1, connLimit)
+}
+
+func TestAdapter_ProvisionLoginKeepsPreviousLogin(t *testing.T) {
+	adapter, admin := setupAdapter(t)
+	ctx := context.Background()
+
+	db := provisioning.DBConfig{Name: "railzway_org_1", User: "railzway_org_1", Password: "first"}
+	require.NoError(t, adapter.Provision(ctx, db))
+	conn, err := connectAs(ctx, admin, db.Name, db.User, "first")
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "CREATE TABLE invoices (id bigint)")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close(ctx))
+
+	login := db
+	login.LoginUser, login.Password = "railzway_org_1_a", "second"
+	require.NoError(t, adapter.Provision(ctx, login))
+
+	// Both logins work during the grace period and share the owner's objects.
+	conn, err = connectAs(ctx, admin, db.Name, db.User, "first")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close(ctx))
+	conn, err = connectAs(ctx, admin, db.Name, login.LoginUser, "second")
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "INSERT INTO invoices VALUES (1)")
+	assert.NoError(t, err)
+	_, err = conn.Exec(ctx, "CREATE TABLE payments (id bigint)")
+	assert.NoError(t, err)
+	var owner string
+	require.NoError(t, conn.QueryRow(ctx, "SELECT tableowner FROM pg_tables WHERE tablename = 'payments'").Scan(&owner))
+	assert.Equal(t, db.User, owner)
+	require.NoError(t, conn.Close(ctx))
+
+	require.NoError(t, adapter.DisableLogin(ctx, db))
+	_, err = connectAs(ctx, admin, db.Name, db.User, "first")
+	assert.Error(t, err)
+	conn, err = connectAs(ctx, admin, db.Name, login.LoginUser, "second")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close(ctx))
+
+	require.NoError(t, adapter.Deprovision(ctx, db))
+	adminConn, err := pgx.ConnectConfig(ctx, admin)
+	require.NoError(t, err)
+	defer adminConn.Close(ctx)
+	var roles int
+	require.NoError(t, adminConn.QueryRow(ctx, "SELECT count(*) FROM pg_roles WHERE rolname LIKE 'railzway_org_1%'").Scan(&roles))
+	assert.Zero(t, roles)
+}
+
+func TestAdapter_Deprovision(t *testing.T) {
+	adapter, admin := setupAdapter(t)
+	ctx := context.Background()
+
+	db := provisioning.DBConfig{Name: "railzway_org_1", User: "railzway_user_1", Password: "secret"}
+	require.NoError(t, adapter.Provision(ctx, db))
+	require.NoError(t, adapter.Deprovision(ctx, db))
+	require.NoError(t, adapter.Deprovision(ctx, db))
+
+	conn, err := pgx.ConnectConfig(ctx, admin)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	var roles, databases int
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count(*) FROM pg_roles WHERE rolname = $1", db.User).Scan(&roles))
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count(*) FROM pg_database WHERE datname = $1", db.Name).Scan(&databases))
+	assert.Zero(t, roles)
+	assert.Zero(t, databases)
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"gorm.io/gorm"
+)
+
+// DeploySagaModel is the database DTO for deploy sagas.
+type DeploySagaModel struct {
+	ID         int64               `gorm:"column:id;primaryKey"`
+	EventID    int64               `gorm:"column:event_id"`
+	InstanceID int64               `gorm:"column:instance_id"`
+	OrgID      int64               `gorm:"column:org_id"`
+	Status     string              `gorm:"column:status;type:varchar(50)"`
+	Steps      []instance.SagaStep `gorm:"column:steps;type:jsonb;serializer:json"`
+	CreatedAt  time.Time           `gorm:"column:created_at"`
+	UpdatedAt  time.Time           `gorm:"column:updated_at"`
+}
+
+func (DeploySagaModel) TableName() string {
+	return "deploy_sagas"
+}
+
+type DeploySagaRepository struct {
+	db *gorm.DB
+}
+
+func NewDeploySagaRepository(db *gorm.DB) *DeploySagaRepository {
+	return &DeploySagaRepository{db: db}
+}
+
+func (r *DeploySagaRepository) Create(ctx context.Context, entity *instance.DeploySaga) error {
+	model := toDeploySagaModel(entity)
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	entity.ID = model.ID
+	return nil
+}
+
+func (r *DeploySagaRepository) Save(ctx context.Context, entity *instance.DeploySaga) error {
+	model := toDeploySagaModel(entity)
+	return r.db.WithContext(ctx).Save(&model).Error
+}
+
+func (r *DeploySagaRepository) FindByEventID(ctx context.Context, eventID int64) (*instance.DeploySaga, error) {
+	return r.first(r.db.WithContext(ctx).Where("event_id = ?", eventID))
+}
+
+func (r *DeploySagaRepository) FindLatestByInstanceID(ctx context.Context, instanceID int64) (*instance.DeploySaga, error) {
+	return r.first(r.db.WithContext(ctx).Where("instance_id = ?", instanceID).Order("id DESC"))
+}
+
+func (r *DeploySagaRepository) first(query *gorm.DB) (*instance.DeploySaga, error) {
+	var model DeploySagaModel
+	if err := query.First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toDeploySagaDomain(model), nil
+}
+
+func toDeploySagaDomain(m DeploySagaModel) *instance.DeploySaga {
+	return &instance.DeploySaga{
+		ID:         m.ID,
+		EventID:    m.EventID,
+		InstanceID: m.InstanceID,
+		OrgID:      m.OrgID,
+		Status:     instance.DeploySagaStatus(m.Status),
+		Steps:      m.Steps,
+		CreatedAt:  m.CreatedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+}
+
+func toDeploySagaModel(d *instance.DeploySaga) DeploySagaModel {
+	return DeploySagaModel{
+		ID:         d.ID,
+		EventID:    d.EventID,
+		InstanceID: d.InstanceID,
+		OrgID:      d.OrgID,
+		Status:     string(d.Status),
+		Steps:      d.Steps,
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+	}
+}
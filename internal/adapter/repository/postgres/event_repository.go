@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+	"gorm.io/gorm"
+)
+
+const maxEventPageSize = 250
+
+type EventRepository struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+func (r *EventRepository) ListByInstanceID(ctx context.Context, instanceID int64, page pagination.Pagination) ([]*instance.Event, *pagination.PageInfo, error) {
+	if page.PageSize <= 0 {
+		page.PageSize = 10
+	}
+	if page.PageSize > maxEventPageSize {
+		page.PageSize = maxEventPageSize
+	}
+
+	query := r.db.WithContext(ctx).
+		Where("instance_id = ?", instanceID).
+		Order("id DESC").
+		Limit(page.PageSize + 1)
+	if page.PageToken != "" {
+		cursor, err := pagination.DecodeCursor(page.PageToken)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", instance.ErrInvalidPageToken, err)
+		}
+		before, err := strconv.ParseInt(cursor.ID, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", instance.ErrInvalidPageToken, err)
+		}
+		// Event IDs only grow, so the ID alone positions the page.
+		query = query.Where("id < ?", before)
+	}
+
+	var items []*instance.Event
+	if err := query.Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+
+	pageInfo := pagination.BuildCursorPageInfo(items, int32(page.PageSize), func(e *instance.Event) string {
+		token, _ := pagination.EncodeCursor(pagination.Cursor{
+			ID:        strconv.FormatInt(e.ID, 10),
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		})
+		return token
+	})
+	if len(items) > page.PageSize {
+		items = items[:page.PageSize]
+	}
+	return items, pageInfo, nil
+}
@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/railzwaylabs/railzway-cloud/internal/cryptoutils"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
 	"gorm.io/gorm"
 )
@@ -12,7 +14,8 @@ import (
 // InstanceModel is the database DTO with Gorm tags.
 type InstanceModel struct {
 	ID                          int64      `gorm:"column:id;primaryKey"`
-	OrgID                       int64      `gorm:"column:org_id;uniqueIndex"`
+	OrgID                       int64      `gorm:"column:org_id;index"`
+	Environment                 string     `gorm:"column:environment;type:varchar(50)"`
 	NomadJobID                  string     `gorm:"column:nomad_job_id;type:varchar(255)"`
 	DesiredVersion              string     `gorm:"column:desired_version;type:varchar(50)"`
 	CurrentVersion              string     `gorm:"column:current_version;type:varchar(50)"`
@@ -22,23 +25,37 @@ type InstanceModel struct {
 	ReadinessStatus             string     `gorm:"column:readiness_status;type:varchar(50)"`
 	ReadinessCheckedAt          *time.Time `gorm:"column:readiness_checked_at;type:timestamptz"`
 	ReadinessError              string     `gorm:"column:readiness_error;type:text"`
+	NotReadySince               *time.Time `gorm:"column:not_ready_since;type:timestamptz"`
+	LastGoodVersion             string     `gorm:"column:last_good_version;type:varchar(50)"`
 	Tier                        string     `gorm:"column:tier;type:varchar(50)"`
 	ComputeEngine               string     `gorm:"column:compute_engine;type:varchar(50)"`
+	Provisioner                 string     `gorm:"column:provisioner;type:varchar(50)"`
+	TierProfileRevision         int        `gorm:"column:tier_profile_revision;not null;default:0"`
+	EntitlementsHash            string     `gorm:"column:entitlements_hash;type:varchar(64);not null;default:''"`
 	PlanID                      string     `gorm:"column:plan_id;type:varchar(255)"`
 	PriceID                     string     `gorm:"column:price_id;type:varchar(255)"`
 	SubscriptionID              string     `gorm:"column:subscription_id;type:varchar(255)"`
 	LaunchURL                   string     `gorm:"column:launch_url;type:text"`
 	LastError                   string     `gorm:"column:last_error;type:text"`
 	OAuthClientID               string     `gorm:"column:oauth_client_id;type:varchar(255)"`
-	OAuthClientSecret           string     `gorm:"column:oauth_client_secret;type:varchar(255)"`
+	OAuthClientSecret           string     `gorm:"column:oauth_client_secret;type:text"`
 	PaymentProviderConfigSecret string     `gorm:"column:payment_provider_config_secret;type:text"`
 
+	PreviousPaymentProviderSecret          string     `gorm:"column:previous_payment_provider_secret;type:text"`
+	PreviousPaymentProviderSecretExpiresAt *time.Time `gorm:"column:previous_payment_provider_secret_expires_at"`
+
 	// Database Details
 	DBHost     string `gorm:"column:db_host;type:varchar(255)"`
 	DBPort     int    `gorm:"column:db_port;type:int"`
 	DBName     string `gorm:"column:db_name;type:varchar(255)"`
 	DBUser     string `gorm:"column:db_user;type:varchar(255)"`
-	DBPassword string `gorm:"column:db_password;type:varchar(255)"` // Should be encrypted in real app
+	DBPassword string `gorm:"column:db_password;type:text"`
+
+	DBLoginUser              string     `gorm:"column:db_login_user;type:varchar(255)"`
+	PreviousDBLoginUser      string     `gorm:"column:previous_db_login_user;type:varchar(255)"`
+	PreviousDBLoginExpiresAt *time.Time `gorm:"column:previous_db_login_expires_at"`
+
+	ResourceVersion int64 `gorm:"column:resource_version;not null;default:0"`
 
 	CreatedAt time.Time `gorm:"column:created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at"`
@@ -48,42 +65,120 @@ func (InstanceModel) TableName() string {
 	return "instances"
 }
 
+// Repository stores instances. Tenant credentials are sealed on write and
+// opened on read, so they are only ever plaintext in memory.
 type Repository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	secrets *cryptoutils.Secrets
+}
+
+func NewRepository(db *gorm.DB, secrets *cryptoutils.Secrets) *Repository {
+	return &Repository{db: db, secrets: secrets}
 }
 
-func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{db: db}
+func (r *Repository) FindByID(ctx context.Context, id int64) (*instance.Instance, error) {
+	var model InstanceModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return r.open(ctx, model)
 }
 
 func (r *Repository) FindByOrgID(ctx context.Context, orgID int64) (*instance.Instance, error) {
 	var model InstanceModel
-	if err := r.db.WithContext(ctx).Where("org_id = ?", orgID).First(&model).Error; err != nil {
+	err := r.db.WithContext(ctx).
+		Where("org_id = ? AND environment = ? AND role = ?", orgID, instance.DefaultEnvironment, instance.RolePrimary).
+		Order("CASE WHEN status = 'terminated' THEN 1 ELSE 0 END, created_at DESC").
+		First(&model).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return toDomain(model), nil
+	return r.open(ctx, model)
+}
+
+func (r *Repository) ListByOrgID(ctx context.Context, orgID int64) ([]*instance.Instance, error) {
+	var models []InstanceModel
+	if err := r.db.WithContext(ctx).Where("org_id = ?", orgID).Order("created_at asc").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return r.openAll(ctx, models)
 }
 
+// Save persists the instance and appends an instance event in the same
+// transaction when an audited field changed. Updates only apply when the
+// stored resource_version matches the entity.
 func (r *Repository) Save(ctx context.Context, entity *instance.Instance) error {
-	model := toModel(entity)
-	if err := r.db.WithContext(ctx).Save(&model).Error; err != nil {
+	model, err := r.seal(ctx, entity)
+	if err != nil {
+		return err
+	}
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		prev, err := findModel(tx, model.ID)
+		if err != nil {
+			return err
+		}
+		if prev == nil {
+			if err := tx.Create(&model).Error; err != nil {
+				return err
+			}
+			return recordEvent(ctx, tx, nil, toDomain(model))
+		}
+		if prev.ResourceVersion != model.ResourceVersion {
+			return conflictErr(model.ID, model.ResourceVersion, prev.ResourceVersion)
+		}
+
+		model.ResourceVersion++
+		result := tx.Model(&InstanceModel{}).
+			Where("id = ? AND resource_version = ?", model.ID, entity.ResourceVersion).
+			Select("*").
+			Omit("id", "created_at").
+			Updates(&model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return conflictErr(model.ID, entity.ResourceVersion, -1)
+		}
+		return recordEvent(ctx, tx, prev, toDomain(model))
+	})
+	if err != nil {
 		return err
 	}
-	// Propagate ID back to entity if new
+	// Propagate ID and version back to entity
 	entity.ID = model.ID
+	entity.ResourceVersion = model.ResourceVersion
 	return nil
 }
 
-func (r *Repository) UpdateStatus(ctx context.Context, orgID int64, status instance.InstanceStatus) error {
-	return r.db.WithContext(ctx).Model(&InstanceModel{}).
-		Where("org_id = ?", orgID).
-		Updates(map[string]any{
-			"status":     string(status),
-			"updated_at": time.Now().UTC(),
-		}).Error
+func (r *Repository) UpdateStatus(ctx context.Context, id int64, status instance.InstanceStatus) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		prev, err := findModel(tx, id)
+		if err != nil || prev == nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if err := tx.Model(&InstanceModel{}).
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"status":           string(status),
+				"updated_at":       now,
+				"resource_version": gorm.Expr("resource_version + 1"),
+			}).Error; err != nil {
+			return err
+		}
+		next := *prev
+		next.Status = status
+		next.UpdatedAt = now
+		next.ResourceVersion++
+		return recordEvent(ctx, tx, prev, &next)
+	})
 }
 
 func (r *Repository) ListByStatus(ctx context.Context, statuses []instance.InstanceStatus, limit int) ([]*instance.Instance, error) {
@@ -105,14 +200,168 @@ func (r *Repository) ListByStatus(ctx context.Context, statuses []instance.Insta
 		return nil, err
 	}
 
+	return r.openAll(ctx, models)
+}
+
+// SealPlaintextSecrets encrypts the credentials still stored in plaintext, or
+// as ciphertext without a tag, and returns how many instances it updated.
+func (r *Repository) SealPlaintextSecrets(ctx context.Context) (int64, error) {
+	return r.reseal(ctx, func(stored string) bool {
+		return stored != "" && !cryptoutils.IsSealed(stored)
+	})
+}
+
+// RotateSecrets re-encrypts every credential not yet sealed with the active
+// key and returns how many instances it updated. Once it reports none, the
+// retired keys can be dropped from the keyring.
+func (r *Repository) RotateSecrets(ctx context.Context) (int64, error) {
+	return r.reseal(ctx, r.secrets.NeedsRotation)
+}
+
+// reseal seals again, in batches, the instances with a credential that needs
+// it. The resource version is kept, since the instance itself does not change.
+func (r *Repository) reseal(ctx context.Context, needs func(stored string) bool) (int64, error) {
+	var updated int64
+	var lastID int64
+	for {
+		var models []InstanceModel
+		if err := r.db.WithContext(ctx).
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(resealBatchSize).
+			Find(&models).Error; err != nil {
+			return updated, err
+		}
+		if len(models) == 0 {
+			return updated, nil
+		}
+
+		for _, model := range models {
+			lastID = model.ID
+			if !needs(model.DBPassword) && !needs(model.OAuthClientSecret) &&
+				!needs(model.PaymentProviderConfigSecret) && !needs(model.PreviousPaymentProviderSecret) {
+				continue
+			}
+			inst, err := r.open(ctx, model)
+			if err != nil {
+				return updated, err
+			}
+			next, err := r.seal(ctx, inst)
+			if err != nil {
+				return updated, err
+			}
+			// A concurrent Save seals the credentials itself, so a row that
+			// changed meanwhile is skipped.
+			result := r.db.WithContext(ctx).Model(&InstanceModel{}).
+				Where("id = ? AND resource_version = ?", model.ID, model.ResourceVersion).
+				Updates(map[string]any{
+					"db_password":                      next.DBPassword,
+					"oauth_client_secret":              next.OAuthClientSecret,
+					"payment_provider_config_secret":   next.PaymentProviderConfigSecret,
+					"previous_payment_provider_secret": next.PreviousPaymentProviderSecret,
+				})
+			if result.Error != nil {
+				return updated, fmt.Errorf("seal secrets of instance %d: %w", model.ID, result.Error)
+			}
+			updated += result.RowsAffected
+		}
+	}
+}
+
+const resealBatchSize = 100
+
+func conflictErr(id, expected, actual int64) error {
+	if actual < 0 {
+		return fmt.Errorf("%w: instance %d is no longer at version %d", instance.ErrConflict, id, expected)
+	}
+	return fmt.Errorf("%w: instance %d is at version %d, expected %d", instance.ErrConflict, id, actual, expected)
+}
+
+func findModel(tx *gorm.DB, id int64) (*instance.Instance, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var model InstanceModel
+	if err := tx.Where("id = ?", id).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toDomain(model), nil
+}
+
+func recordEvent(ctx context.Context, tx *gorm.DB, prev, next *instance.Instance) error {
+	event := instance.NewEvent(prev, next, instance.ActorFromContext(ctx), time.Now().UTC())
+	if event == nil {
+		return nil
+	}
+	return tx.Create(event).Error
+}
+
+// Mappers
+
+// open maps a stored instance and decrypts its credentials.
+func (r *Repository) open(ctx context.Context, m InstanceModel) (*instance.Instance, error) {
+	inst := toDomain(m)
+	b := binding(inst)
+	var err error
+	if inst.DBPassword, err = r.secrets.Open(ctx, m.DBPassword, b); err != nil {
+		return nil, fmt.Errorf("open db password of instance %d: %w", m.ID, err)
+	}
+	if inst.OAuthClientSecret, err = r.secrets.Open(ctx, m.OAuthClientSecret, b); err != nil {
+		return nil, fmt.Errorf("open oauth client secret of instance %d: %w", m.ID, err)
+	}
+	if inst.PaymentProviderConfigSecret, err = r.secrets.OpenCiphertext(ctx, m.PaymentProviderConfigSecret, b); err != nil {
+		return nil, fmt.Errorf("open payment provider secret of instance %d: %w", m.ID, err)
+	}
+	if inst.PreviousPaymentProviderSecret, err = r.secrets.Open(ctx, m.PreviousPaymentProviderSecret, b); err != nil {
+		return nil, fmt.Errorf("open previous payment provider secret of instance %d: %w", m.ID, err)
+	}
+	return inst, nil
+}
+
+func (r *Repository) openAll(ctx context.Context, models []InstanceModel) ([]*instance.Instance, error) {
 	items := make([]*instance.Instance, 0, len(models))
 	for _, model := range models {
-		items = append(items, toDomain(model))
+		inst, err := r.open(ctx, model)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, inst)
 	}
 	return items, nil
 }
 
-// Mappers
+// seal maps an instance for storage and encrypts its credentials, bound to
+// the instance ID, which must be set by then.
+func (r *Repository) seal(ctx context.Context, d *instance.Instance) (InstanceModel, error) {
+	m := toModel(d)
+	if d.ID == 0 && (d.DBPassword != "" || d.OAuthClientSecret != "" || d.PaymentProviderConfigSecret != "" || d.PreviousPaymentProviderSecret != "") {
+		return m, fmt.Errorf("instance needs an id before its credentials are stored")
+	}
+	b := binding(d)
+	var err error
+	if m.DBPassword, err = r.secrets.Seal(ctx, d.DBPassword, b); err != nil {
+		return m, fmt.Errorf("seal db password of instance %d: %w", d.ID, err)
+	}
+	if m.OAuthClientSecret, err = r.secrets.Seal(ctx, d.OAuthClientSecret, b); err != nil {
+		return m, fmt.Errorf("seal oauth client secret of instance %d: %w", d.ID, err)
+	}
+	if m.PaymentProviderConfigSecret, err = r.secrets.Seal(ctx, d.PaymentProviderConfigSecret, b); err != nil {
+		return m, fmt.Errorf("seal payment provider secret of instance %d: %w", d.ID, err)
+	}
+	if m.PreviousPaymentProviderSecret, err = r.secrets.Seal(ctx, d.PreviousPaymentProviderSecret, b); err != nil {
+		return m, fmt.Errorf("seal previous payment provider secret of instance %d: %w", d.ID, err)
+	}
+	return m, nil
+}
+
+func binding(inst *instance.Instance) cryptoutils.Binding {
+	return cryptoutils.Binding{OrgID: inst.OrgID, InstanceID: inst.ID}
+}
+
+// toDomain and toModel copy credentials as stored; open and seal convert them.
 
 func toDomain(m InstanceModel) *instance.Instance {
 	role := instance.InstanceRole(m.Role)
@@ -127,35 +376,51 @@ func toDomain(m InstanceModel) *instance.Instance {
 	if readiness == "" {
 		readiness = instance.ReadinessUnknown
 	}
+	environment := m.Environment
+	if environment == "" {
+		environment = instance.DefaultEnvironment
+	}
 	return &instance.Instance{
-		ID:                                   m.ID,
-		OrgID:                                m.OrgID,
-		NomadJobID:                           m.NomadJobID,
-		DesiredVersion:                       m.DesiredVersion,
-		CurrentVersion:                       m.CurrentVersion,
-		Status:                               instance.InstanceStatus(m.Status),
-		Role:                                 role,
-		LifecycleState:                       lifecycle,
-		Readiness:                            readiness,
-		ReadinessCheckedAt:                   m.ReadinessCheckedAt,
-		ReadinessError:                       m.ReadinessError,
-		Tier:                                 instance.Tier(m.Tier),
-		ComputeEngine:                        instance.ComputeEngine(m.ComputeEngine),
-		PlanID:                               m.PlanID,
-		PriceID:                              m.PriceID,
-		SubscriptionID:                       m.SubscriptionID,
-		LaunchURL:                            m.LaunchURL,
-		LastError:                            m.LastError,
-		OAuthClientID:                        m.OAuthClientID,
-		OAuthClientSecret:                    m.OAuthClientSecret,
-		PaymentProviderConfigSecretEncrypted: m.PaymentProviderConfigSecret,
-		DBHost:                               m.DBHost,
-		DBPort:                               m.DBPort,
-		DBName:                               m.DBName,
-		DBUser:                               m.DBUser,
-		DBPassword:                           m.DBPassword,
-		CreatedAt:                            m.CreatedAt,
-		UpdatedAt:                            m.UpdatedAt,
+		ID:                                     m.ID,
+		OrgID:                                  m.OrgID,
+		Environment:                            environment,
+		NomadJobID:                             m.NomadJobID,
+		DesiredVersion:                         m.DesiredVersion,
+		CurrentVersion:                         m.CurrentVersion,
+		Status:                                 instance.InstanceStatus(m.Status),
+		Role:                                   role,
+		LifecycleState:                         lifecycle,
+		Readiness:                              readiness,
+		ReadinessCheckedAt:                     m.ReadinessCheckedAt,
+		ReadinessError:                         m.ReadinessError,
+		NotReadySince:                          m.NotReadySince,
+		LastGoodVersion:                        m.LastGoodVersion,
+		Tier:                                   instance.Tier(m.Tier),
+		ComputeEngine:                          instance.ComputeEngine(m.ComputeEngine),
+		Provisioner:                            m.Provisioner,
+		TierProfileRevision:                    m.TierProfileRevision,
+		EntitlementsHash:                       m.EntitlementsHash,
+		PlanID:                                 m.PlanID,
+		PriceID:                                m.PriceID,
+		SubscriptionID:                         m.SubscriptionID,
+		LaunchURL:                              m.LaunchURL,
+		LastError:                              m.LastError,
+		OAuthClientID:                          m.OAuthClientID,
+		OAuthClientSecret:                      m.OAuthClientSecret,
+		PaymentProviderConfigSecret:            m.PaymentProviderConfigSecret,
+		PreviousPaymentProviderSecret:          m.PreviousPaymentProviderSecret,
+		PreviousPaymentProviderSecretExpiresAt: m.PreviousPaymentProviderSecretExpiresAt,
+		DBHost:                                 m.DBHost,
+		DBPort:                                 m.DBPort,
+		DBName:                                 m.DBName,
+		DBUser:                                 m.DBUser,
+		DBPassword:                             m.DBPassword,
+		DBLoginUser:                            m.DBLoginUser,
+		PreviousDBLoginUser:                    m.PreviousDBLoginUser,
+		PreviousDBLoginExpiresAt:               m.PreviousDBLoginExpiresAt,
+		ResourceVersion:                        m.ResourceVersion,
+		CreatedAt:                              m.CreatedAt,
+		UpdatedAt:                              m.UpdatedAt,
 	}
 }
 
@@ -172,34 +437,50 @@ func toModel(d *instance.Instance) InstanceModel {
 	if readiness == "" {
 		readiness = instance.ReadinessUnknown
 	}
+	environment := d.Environment
+	if environment == "" {
+		environment = instance.DefaultEnvironment
+	}
 	return InstanceModel{
-		ID:                          d.ID,
-		OrgID:                       d.OrgID,
-		NomadJobID:                  d.NomadJobID,
-		DesiredVersion:              d.DesiredVersion,
-		CurrentVersion:              d.CurrentVersion,
-		Status:                      string(d.Status),
-		Role:                        string(role),
-		LifecycleState:              string(lifecycle),
-		ReadinessStatus:             string(readiness),
-		ReadinessCheckedAt:          d.ReadinessCheckedAt,
-		ReadinessError:              d.ReadinessError,
-		Tier:                        string(d.Tier),
-		ComputeEngine:               string(d.ComputeEngine),
-		PlanID:                      d.PlanID,
-		PriceID:                     d.PriceID,
-		SubscriptionID:              d.SubscriptionID,
-		LaunchURL:                   d.LaunchURL,
-		LastError:                   d.LastError,
-		OAuthClientID:               d.OAuthClientID,
-		OAuthClientSecret:           d.OAuthClientSecret,
-		PaymentProviderConfigSecret: d.PaymentProviderConfigSecretEncrypted,
-		DBHost:                      d.DBHost,
-		DBPort:                      d.DBPort,
-		DBName:                      d.DBName,
-		DBUser:                      d.DBUser,
-		DBPassword:                  d.DBPassword,
-		CreatedAt:                   d.CreatedAt,
-		UpdatedAt:                   d.UpdatedAt,
+		ID:                                     d.ID,
+		OrgID:                                  d.OrgID,
+		Environment:                            environment,
+		NomadJobID:                             d.NomadJobID,
+		DesiredVersion:                         d.DesiredVersion,
+		CurrentVersion:                         d.CurrentVersion,
+		Status:                                 string(d.Status),
+		Role:                                   string(role),
+		LifecycleState:                         string(lifecycle),
+		ReadinessStatus:                        string(readiness),
+		ReadinessCheckedAt:                     d.ReadinessCheckedAt,
+		ReadinessError:                         d.ReadinessError,
+		NotReadySince:                          d.NotReadySince,
+		LastGoodVersion:                        d.LastGoodVersion,
+		Tier:                                   string(d.Tier),
+		ComputeEngine:                          string(d.ComputeEngine),
+		Provisioner:                            d.Provisioner,
+		TierProfileRevision:                    d.TierProfileRevision,
+		EntitlementsHash:                       d.EntitlementsHash,
+		PlanID:                                 d.PlanID,
+		PriceID:                                d.PriceID,
+		SubscriptionID:                         d.SubscriptionID,
+		LaunchURL:                              d.LaunchURL,
+		LastError:                              d.LastError,
+		OAuthClientID:                          d.OAuthClientID,
+		OAuthClientSecret:                      d.OAuthClientSecret,
+		PaymentProviderConfigSecret:            d.PaymentProviderConfigSecret,
+		PreviousPaymentProviderSecret:          d.PreviousPaymentProviderSecret,
+		PreviousPaymentProviderSecretExpiresAt: d.PreviousPaymentProviderSecretExpiresAt,
+		DBHost:                                 d.DBHost,
+		DBPort:                                 d.DBPort,
+		DBName:                                 d.DBName,
+		DBUser:                                 d.DBUser,
+		DBPassword:                             d.DBPassword,
+		DBLoginUser:                            d.DBLoginUser,
+		PreviousDBLoginUser:                    d.PreviousDBLoginUser,
+		PreviousDBLoginExpiresAt:               d.PreviousDBLoginExpiresAt,
+		ResourceVersion:                        d.ResourceVersion,
+		CreatedAt:                              d.CreatedAt,
+		UpdatedAt:                              d.UpdatedAt,
 	}
 }
@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/cryptoutils"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := db.NewTest()
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&InstanceModel{}, &instance.Event{}))
+	return conn
+}
+
+var legacyTestKey = base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+func testSecrets() *cryptoutils.Secrets {
+	return testSecretsWith(map[string]string{"k1": legacyTestKey}, "k1")
+}
+
+func testSecretsWith(keys map[string]string, active string) *cryptoutils.Secrets {
+	keyring, err := cryptoutils.NewKeyring(active, keys)
+	if err != nil {
+		panic(err)
+	}
+	return cryptoutils.NewSecrets(keyring, legacyTestKey)
+}
+
+func TestRepository_EncryptsCredentialsAtRest(t *testing.T) {
+	conn := setupTestDB(t)
+	repo := NewRepository(conn, testSecrets())
+	ctx := context.Background()
+
+	inst := instance.NewInstance(1, instance.TierStarter, instance.EngineGCP, "v1")
+	inst.ID = 100
+	inst.DBPassword = "db-password"
+	inst.OAuthClientSecret = "oauth-secret"
+	inst.PaymentProviderConfigSecret = "payment-secret"
+	require.NoError(t, repo.Save(ctx, inst))
+
+	var stored InstanceModel
+	require.NoError(t, conn.First(&stored, inst.ID).Error)
+	for _, value := range []string{stored.DBPassword, stored.OAuthClientSecret, stored.PaymentProviderConfigSecret} {
+		assert.True(t, cryptoutils.IsSealed(value), "stored %q in plaintext", value)
+	}
+
+	found, err := repo.FindByID(ctx, inst.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "db-password", found.DBPassword)
+	assert.Equal(t, "oauth-secret", found.OAuthClientSecret)
+	assert.Equal(t, "payment-secret", found.PaymentProviderConfigSecret)
+
+	_, err = NewRepository(conn, cryptoutils.NewSecrets(nil, "")).FindByID(ctx, inst.ID)
+	assert.ErrorIs(t, err, cryptoutils.ErrKeyRequired)
+
+	// Credentials are bound to their instance.
+	require.NoError(t, conn.Model(&InstanceModel{}).Where("id = ?", inst.ID).Update("org_id", 2).Error)
+	_, err = repo.FindByID(ctx, inst.ID)
+	assert.Error(t, err)
+}
+
+func TestRepository_RotateSecrets(t *testing.T) {
+	conn := setupTestDB(t)
+	ctx := context.Background()
+
+	inst := instance.NewInstance(1, instance.TierStarter, instance.EngineGCP, "v1")
+	inst.ID = 100
+	inst.DBPassword = "db-password"
+	require.NoError(t, NewRepository(conn, testSecrets()).Save(ctx, inst))
+
+	newKey := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	rotated := NewRepository(conn, testSecretsWith(map[string]string{"k1": legacyTestKey, "k2": newKey}, "k2"))
+	updated, err := rotated.RotateSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	var stored InstanceModel
+	require.NoError(t, conn.First(&stored, inst.ID).Error)
+	assert.True(t, strings.HasPrefix(stored.DBPassword, "enc:v2:k2:"))
+
+	// Nothing is left under k1, so it can be retired.
+	updated, err = rotated.RotateSecrets(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+	found, err := NewRepository(conn, testSecretsWith(map[string]string{"k2": newKey}, "k2")).FindByID(ctx, inst.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "db-password", found.DBPassword)
+}
+
+func TestRepository_SealPlaintextSecrets(t *testing.T) {
+	conn := setupTestDB(t)
+	secrets := testSecrets()
+	repo := NewRepository(conn, secrets)
+	ctx := context.Background()
+
+	legacyPayment, err := cryptoutils.Encrypt("payment-secret", legacyTestKey)
+	require.NoError(t, err)
+	require.NoError(t, conn.Create(&InstanceModel{
+		ID:                          100,
+		OrgID:                       1,
+		Status:                      string(instance.StatusActive),
+		DBPassword:                  "db-password",
+		OAuthClientSecret:           "oauth-secret",
+		PaymentProviderConfigSecret: legacyPayment,
+		ResourceVersion:             3,
+	}).Error)
+	require.NoError(t, conn.Create(&InstanceModel{ID: 101, OrgID: 2, Status: string(instance.StatusInit)}).Error)
+
+	// Plaintext rows are readable before they are sealed.
+	found, err := repo.FindByID(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "db-password", found.DBPassword)
+	assert.Equal(t, "payment-secret", found.PaymentProviderConfigSecret)
+
+	sealed, err := repo.SealPlaintextSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), sealed)
+
+	var stored InstanceModel
+	require.NoError(t, conn.First(&stored, 100).Error)
+	assert.True(t, cryptoutils.IsSealed(stored.DBPassword))
+	assert.True(t, cryptoutils.IsSealed(stored.OAuthClientSecret))
+	assert.True(t, cryptoutils.IsSealed(stored.PaymentProviderConfigSecret))
+	assert.Equal(t, int64(3), stored.ResourceVersion)
+
+	found, err = repo.FindByID(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "db-password", found.DBPassword)
+	assert.Equal(t, "oauth-secret", found.OAuthClientSecret)
+	assert.Equal(t, "payment-secret", found.PaymentProviderConfigSecret)
+
+	// A second run has nothing left to do.
+	sealed, err = repo.SealPlaintextSecrets(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sealed)
+}
+
+func TestRepository_SaveRecordsEvents(t *testing.T) {
+	conn := setupTestDB(t)
+	repo := NewRepository(conn, testSecrets())
+	events := NewEventRepository(conn)
+
+	ctx := instance.WithActor(context.Background(), instance.Actor{Type: instance.ActorUser, ID: "7"})
+	inst := instance.NewInstance(1, instance.TierStarter, instance.EngineGCP, "v1")
+	inst.ID = 100
+	require.NoError(t, repo.Save(ctx, inst))
+
+	inst.MarkProvisioning()
+	require.NoError(t, repo.Save(ctx, inst))
+
+	// A save without audited changes does not add history.
+	require.NoError(t, repo.Save(ctx, inst))
+
+	reconcilerCtx := instance.WithActor(context.Background(), instance.Actor{Type: instance.ActorReconciler, ID: "instance"})
+	require.NoError(t, repo.UpdateStatus(reconcilerCtx, inst.ID, instance.StatusProvisionFailed))
+
+	items, pageInfo, err := events.ListByInstanceID(context.Background(), inst.ID, pagination.Pagination{PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.False(t, pageInfo.HasMore)
+
+	assert.Equal(t, instance.StatusProvisionFailed, items[0].NewStatus)
+	assert.Equal(t, instance.StatusProvisioning, items[0].OldStatus)
+	assert.Equal(t, instance.ActorReconciler, items[0].ActorType)
+
+	assert.Equal(t, instance.StatusProvisioning, items[1].NewStatus)
+	assert.Equal(t, instance.ActorUser, items[1].ActorType)
+	assert.Equal(t, "7", items[1].ActorID)
+
+	assert.Equal(t, instance.StatusInit, items[2].NewStatus)
+	assert.Empty(t, items[2].OldStatus)
+}
+
+func TestEventRepository_Pagination(t *testing.T) {
+	conn := setupTestDB(t)
+	repo := NewRepository(conn, testSecrets())
+	events := NewEventRepository(conn)
+	ctx := context.Background()
+
+	inst := instance.NewInstance(1, instance.TierStarter, instance.EngineGCP, "v1")
+	inst.ID = 100
+	require.NoError(t, repo.Save(ctx, inst))
+	for _, v := range []string{"v2", "v3", "v4", "v5"} {
+		inst.DesiredVersion = v
+		require.NoError(t, repo.Save(ctx, inst))
+	}
+
+	first, pageInfo, err := events.ListByInstanceID(ctx, inst.ID, pagination.Pagination{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.True(t, pageInfo.HasMore)
+	assert.Equal(t, "v5", first[0].DesiredVersion)
+
+	var versions []string
+	token := pageInfo.NextPageToken
+	for token != "" {
+		page, info, err := events.ListByInstanceID(ctx, inst.ID, pagination.Pagination{PageSize: 2, PageToken: token})
+		require.NoError(t, err)
+		for _, e := range page {
+			versions = append(versions, e.DesiredVersion)
+		}
+		token = ""
+		if info.HasMore {
+			token = info.NextPageToken
+		}
+	}
+	assert.Equal(t, []string{"v3", "v2", "v1"}, versions)
+
+	_, _, err = events.ListByInstanceID(ctx, inst.ID, pagination.Pagination{PageSize: 2, PageToken: "not-a-token"})
+	assert.ErrorIs(t, err, instance.ErrInvalidPageToken)
+}
+
+func TestRepository_SaveDetectsConflicts(t *testing.T) {
+	conn := setupTestDB(t)
+	repo := NewRepository(conn, testSecrets())
+	ctx := context.Background()
+
+	inst := instance.NewInstance(1, instance.TierStarter, instance.EngineGCP, "v1")
+	inst.ID = 100
+	require.NoError(t, repo.Save(ctx, inst))
+
+	first, err := repo.FindByID(ctx, inst.ID)
+	require.NoError(t, err)
+	second, err := repo.FindByID(ctx, inst.ID)
+	require.NoError(t, err)
+
+	first.MarkProvisioning()
+	require.NoError(t, repo.Save(ctx, first))
+	assert.Equal(t, second.ResourceVersion+1, first.ResourceVersion)
+
+	// second was loaded before first was written.
+	second.MarkStopped()
+	assert.ErrorIs(t, repo.Save(ctx, second), instance.ErrConflict)
+
+	stored, err := repo.FindByID(ctx, inst.ID)
+	require.NoError(t, err)
+	assert.Equal(t, instance.StatusProvisioning, stored.Status)
+
+	// UpdateStatus bumps the version too, so saves based on older reads fail.
+	require.NoError(t, repo.UpdateStatus(ctx, inst.ID, instance.StatusActive))
+	first.MarkStopped()
+	assert.ErrorIs(t, repo.Save(ctx, first), instance.ErrConflict)
+}
+
+func TestUpgradeRepository_SaveReadinessKeepsPhase(t *testing.T) {
+	conn := setupTestDB(t)
+	require.NoError(t, conn.AutoMigrate(&UpgradeModel{}))
+	upgrades := NewUpgradeRepository(conn)
+	ctx := context.Background()
+
+	inst := instance.NewInstance(1, instance.TierStarter, instance.EngineGCP, "v1")
+	inst.ID = 100
+	up := instance.NewUpgrade(inst, "v2", inst.Tier, time.Now().Add(time.Minute))
+	require.NoError(t, upgrades.Create(ctx, up))
+	up.MarkWarming()
+	require.NoError(t, upgrades.Save(ctx, up))
+
+	// The lifecycle reconciler probes a warming copy...
+	probed := *up
+	// ...while the upgrade reconciler moves on.
+	up.MarkSwitching()
+	require.NoError(t, upgrades.Save(ctx, up))
+
+	probed.SetReadiness(instance.ReadinessReady, "")
+	require.NoError(t, upgrades.SaveReadiness(ctx, &probed))
+
+	var stored struct {
+		Phase           string
+		ReadinessStatus string
+	}
+	require.NoError(t, conn.Table("instance_upgrades").Select("phase, readiness_status").Where("id = ?", up.ID).Scan(&stored).Error)
+	assert.Equal(t, string(instance.UpgradePhaseSwitching), stored.Phase)
+	assert.Equal(t, string(instance.ReadinessUnknown), stored.ReadinessStatus)
+
+	// A warming upgrade records the probe.
+	up.Phase = instance.UpgradePhaseWarming
+	require.NoError(t, upgrades.Save(ctx, up))
+	require.NoError(t, upgrades.SaveReadiness(ctx, &probed))
+	require.NoError(t, conn.Table("instance_upgrades").Select("phase, readiness_status").Where("id = ?", up.ID).Scan(&stored).Error)
+	assert.Equal(t, string(instance.ReadinessReady), stored.ReadinessStatus)
+}
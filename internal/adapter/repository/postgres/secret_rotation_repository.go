@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"gorm.io/gorm"
+)
+
+type SecretRotationRepository struct {
+	db *gorm.DB
+}
+
+func NewSecretRotationRepository(db *gorm.DB) *SecretRotationRepository {
+	return &SecretRotationRepository{db: db}
+}
+
+func (r *SecretRotationRepository) Create(ctx context.Context, rotation *instance.SecretRotation) error {
+	return r.db.WithContext(ctx).Create(rotation).Error
+}
+
+func (r *SecretRotationRepository) Save(ctx context.Context, rotation *instance.SecretRotation) error {
+	return r.db.WithContext(ctx).Save(rotation).Error
+}
+
+func (r *SecretRotationRepository) ListByEventID(ctx context.Context, eventID int64) ([]*instance.SecretRotation, error) {
+	var items []*instance.SecretRotation
+	if err := r.db.WithContext(ctx).
+		Where("event_id = ?", eventID).
+		Order("id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *SecretRotationRepository) ListByInstanceID(ctx context.Context, instanceID int64, limit int) ([]*instance.SecretRotation, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var items []*instance.SecretRotation
+	if err := r.db.WithContext(ctx).
+		Where("instance_id = ?", instanceID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/tierprofile"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db"
+	"gorm.io/gorm"
+)
+
+const maxTierProfileHistory = 100
+
+type TierProfileRepository struct {
+	db *gorm.DB
+}
+
+func NewTierProfileRepository(db *gorm.DB) *TierProfileRepository {
+	return &TierProfileRepository{db: db}
+}
+
+func (r *TierProfileRepository) Current(ctx context.Context, tier instance.Tier) (*tierprofile.Profile, error) {
+	var profile tierprofile.Profile
+	err := r.db.WithContext(ctx).
+		Where("tier = ?", tier).
+		Order("revision DESC").
+		First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *TierProfileRepository) ListCurrent(ctx context.Context) ([]*tierprofile.Profile, error) {
+	var items []*tierprofile.Profile
+	err := r.db.WithContext(ctx).
+		Joins("JOIN (SELECT tier AS latest_tier, MAX(revision) AS latest_revision FROM tier_profiles GROUP BY tier) latest ON latest.latest_tier = tier_profiles.tier AND latest.latest_revision = tier_profiles.revision").
+		Order("tier_profiles.tier ASC").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *TierProfileRepository) History(ctx context.Context, tier instance.Tier, limit int) ([]*tierprofile.Profile, error) {
+	if limit <= 0 || limit > maxTierProfileHistory {
+		limit = maxTierProfileHistory
+	}
+	var items []*tierprofile.Profile
+	err := r.db.WithContext(ctx).
+		Where("tier = ?", tier).
+		Order("revision DESC").
+		Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *TierProfileRepository) Create(ctx context.Context, profile *tierprofile.Profile) error {
+	if err := r.db.WithContext(ctx).Create(profile).Error; err != nil {
+		if db.IsDuplicateKeyErr(err) {
+			return tierprofile.ErrRevisionConflict
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db"
+	"gorm.io/gorm"
+)
+
+// UpgradeModel is the database DTO for blue/green upgrades.
+type UpgradeModel struct {
+	ID                 int64      `gorm:"column:id;primaryKey"`
+	InstanceID         int64      `gorm:"column:instance_id"`
+	OrgID              int64      `gorm:"column:org_id"`
+	FromVersion        string     `gorm:"column:from_version;type:varchar(50)"`
+	ToVersion          string     `gorm:"column:to_version;type:varchar(50)"`
+	FromTier           string     `gorm:"column:from_tier;type:varchar(50)"`
+	ToTier             string     `gorm:"column:to_tier;type:varchar(50)"`
+	FromJobID          string     `gorm:"column:from_job_id;type:varchar(255)"`
+	ToJobID            string     `gorm:"column:to_job_id;type:varchar(255)"`
+	Provisioner        string     `gorm:"column:provisioner;type:varchar(50)"`
+	Phase              string     `gorm:"column:phase;type:varchar(50)"`
+	ReadinessStatus    string     `gorm:"column:readiness_status;type:varchar(50)"`
+	ReadinessCheckedAt *time.Time `gorm:"column:readiness_checked_at;type:timestamptz"`
+	ReadinessError     string     `gorm:"column:readiness_error;type:text"`
+	LastError          string     `gorm:"column:last_error;type:text"`
+	DeadlineAt         *time.Time `gorm:"column:deadline_at;type:timestamptz"`
+	DrainUntil         *time.Time `gorm:"column:drain_until;type:timestamptz"`
+	CompletedAt        *time.Time `gorm:"column:completed_at;type:timestamptz"`
+	CreatedAt          time.Time  `gorm:"column:created_at"`
+	UpdatedAt          time.Time  `gorm:"column:updated_at"`
+}
+
+func (UpgradeModel) TableName() string {
+	return "instance_upgrades"
+}
+
+var finishedUpgradePhases = []string{
+	string(instance.UpgradePhaseCompleted),
+	string(instance.UpgradePhaseRolledBack),
+}
+
+type UpgradeRepository struct {
+	db *gorm.DB
+}
+
+func NewUpgradeRepository(db *gorm.DB) *UpgradeRepository {
+	return &UpgradeRepository{db: db}
+}
+
+func (r *UpgradeRepository) Create(ctx context.Context, entity *instance.Upgrade) error {
+	model := toUpgradeModel(entity)
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		if db.IsDuplicateKeyErr(err) {
+			return instance.ErrUpgradeInProgress
+		}
+		return err
+	}
+	entity.ID = model.ID
+	return nil
+}
+
+func (r *UpgradeRepository) Save(ctx context.Context, entity *instance.Upgrade) error {
+	model := toUpgradeModel(entity)
+	return r.db.WithContext(ctx).Save(&model).Error
+}
+
+func (r *UpgradeRepository) SaveReadiness(ctx context.Context, entity *instance.Upgrade) error {
+	return r.db.WithContext(ctx).
+		Model(&UpgradeModel{}).
+		Where("id = ? AND phase = ?", entity.ID, string(instance.UpgradePhaseWarming)).
+		Updates(map[string]any{
+			"readiness_status":     string(entity.Readiness),
+			"readiness_checked_at": entity.ReadinessCheckedAt,
+			"readiness_error":      entity.ReadinessError,
+			"updated_at":           entity.UpdatedAt,
+		}).Error
+}
+
+func (r *UpgradeRepository) FindActiveByInstanceID(ctx context.Context, instanceID int64) (*instance.Upgrade, error) {
+	var model UpgradeModel
+	err := r.db.WithContext(ctx).
+		Where("instance_id = ? AND phase NOT IN ?", instanceID, finishedUpgradePhases).
+		First(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toUpgradeDomain(model), nil
+}
+
+func (r *UpgradeRepository) ListActive(ctx context.Context, limit int) ([]*instance.Upgrade, error) {
+	query := r.db.WithContext(ctx).
+		Where("phase NOT IN ?", finishedUpgradePhases).
+		Order("updated_at asc")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var models []UpgradeModel
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]*instance.Upgrade, 0, len(models))
+	for _, model := range models {
+		items = append(items, toUpgradeDomain(model))
+	}
+	return items, nil
+}
+
+func toUpgradeDomain(m UpgradeModel) *instance.Upgrade {
+	readiness := instance.ReadinessStatus(m.ReadinessStatus)
+	if readiness == "" {
+		readiness = instance.ReadinessUnknown
+	}
+	return &instance.Upgrade{
+		ID:                 m.ID,
+		InstanceID:         m.InstanceID,
+		OrgID:              m.OrgID,
+		FromVersion:        m.FromVersion,
+		ToVersion:          m.ToVersion,
+		FromTier:           instance.Tier(m.FromTier),
+		ToTier:             instance.Tier(m.ToTier),
+		FromJobID:          m.FromJobID,
+		ToJobID:            m.ToJobID,
+		Provisioner:        m.Provisioner,
+		Phase:              instance.UpgradePhase(m.Phase),
+		Readiness:          readiness,
+		ReadinessCheckedAt: m.ReadinessCheckedAt,
+		ReadinessError:     m.ReadinessError,
+		LastError:          m.LastError,
+		DeadlineAt:         m.DeadlineAt,
+		DrainUntil:         m.DrainUntil,
+		CompletedAt:        m.CompletedAt,
+		CreatedAt:          m.CreatedAt,
+		UpdatedAt:          m.UpdatedAt,
+	}
+}
+
+func toUpgradeModel(d *instance.Upgrade) UpgradeModel {
+	readiness := d.Readiness
+	if readiness == "" {
+		readiness = instance.ReadinessUnknown
+	}
+	return UpgradeModel{
+		ID:                 d.ID,
+		InstanceID:         d.InstanceID,
+		OrgID:              d.OrgID,
+		FromVersion:        d.FromVersion,
+		ToVersion:          d.ToVersion,
+		FromTier:           string(d.FromTier),
+		ToTier:             string(d.ToTier),
+		FromJobID:          d.FromJobID,
+		ToJobID:            d.ToJobID,
+		Provisioner:        d.Provisioner,
+		Phase:              string(d.Phase),
+		ReadinessStatus:    string(readiness),
+		ReadinessCheckedAt: d.ReadinessCheckedAt,
+		ReadinessError:     d.ReadinessError,
+		LastError:          d.LastError,
+		DeadlineAt:         d.DeadlineAt,
+		DrainUntil:         d.DrainUntil,
+		CompletedAt:        d.CompletedAt,
+		CreatedAt:          d.CreatedAt,
+		UpdatedAt:          d.UpdatedAt,
+	}
+}
@@ -1,9 +1,15 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/rollout"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"github.com/railzwaylabs/railzway-cloud/internal/version"
 )
 
 func (r *Router) RolloutVersion(c *gin.Context) {
@@ -23,8 +29,179 @@ func (r *Router) RolloutVersion(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":         "rollout_enqueued",
+		"rollout_id":     strconv.FormatInt(result.RolloutID, 10),
 		"target_version": result.TargetVersion,
 		"updated_count":  result.UpdatedCount,
 		"enqueued_count": result.EnqueuedCount,
 	})
 }
+
+type rolloutWaveRequest struct {
+	Name              string          `json:"name"`
+	Percent           int             `json:"percent"`
+	Tiers             []instance.Tier `json:"tiers"`
+	MinSuccessPercent *int            `json:"min_success_percent"`
+	MaxFailurePercent *int            `json:"max_failure_percent"`
+	BakeSeconds       *int            `json:"bake_seconds"`
+	TimeoutSeconds    *int            `json:"timeout_seconds"`
+}
+
+func (r *Router) CreateRollout(c *gin.Context) {
+	var req struct {
+		Version string               `json:"version"`
+		Waves   []rolloutWaveRequest `json:"waves"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	params := deployment.CreateRolloutParams{Version: req.Version}
+	for _, w := range req.Waves {
+		wave := r.rolloutUC.DefaultWave()
+		wave.Name = w.Name
+		wave.Percent = w.Percent
+		wave.Tiers = w.Tiers
+		if w.MinSuccessPercent != nil {
+			wave.MinSuccessPercent = *w.MinSuccessPercent
+		}
+		if w.MaxFailurePercent != nil {
+			wave.MaxFailurePercent = *w.MaxFailurePercent
+		}
+		if w.BakeSeconds != nil {
+			wave.BakeSeconds = *w.BakeSeconds
+		}
+		if w.TimeoutSeconds != nil {
+			wave.TimeoutSeconds = *w.TimeoutSeconds
+		}
+		params.Waves = append(params.Waves, wave)
+	}
+
+	item, err := r.rolloutUC.Create(c.Request.Context(), params)
+	if err != nil {
+		r.rolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+func (r *Router) ListRollouts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	items, err := r.rolloutUC.List(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rollouts": items})
+}
+
+func (r *Router) GetRollout(c *gin.Context) {
+	id, ok := rolloutID(c)
+	if !ok {
+		return
+	}
+	view, err := r.rolloutUC.Get(c.Request.Context(), id)
+	if err != nil {
+		r.rolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+func (r *Router) PauseRollout(c *gin.Context) {
+	id, ok := rolloutID(c)
+	if !ok {
+		return
+	}
+	item, err := r.rolloutUC.Pause(c.Request.Context(), id)
+	if err != nil {
+		r.rolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (r *Router) ResumeRollout(c *gin.Context) {
+	id, ok := rolloutID(c)
+	if !ok {
+		return
+	}
+	item, err := r.rolloutUC.Resume(c.Request.Context(), id)
+	if err != nil {
+		r.rolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (r *Router) AbortRollout(c *gin.Context) {
+	id, ok := rolloutID(c)
+	if !ok {
+		return
+	}
+	item, err := r.rolloutUC.Abort(c.Request.Context(), id)
+	if err != nil {
+		r.rolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (r *Router) ListInstanceRollbacks(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid instance id"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	items, err := r.rollbackUC.ListByInstance(c.Request.Context(), id, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rollbacks": items})
+}
+
+func (r *Router) ListSuspectVersions(c *gin.Context) {
+	items, err := r.rollbackUC.ListSuspectVersions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": items})
+}
+
+func (r *Router) ClearSuspectVersion(c *gin.Context) {
+	err := r.rollbackUC.ClearSuspectVersion(c.Request.Context(), c.Param("version"))
+	if errors.Is(err, version.ErrVersionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "suspect_cleared", "version": c.Param("version")})
+}
+
+func rolloutID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rollout id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (r *Router) rolloutError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rollout.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, rollout.ErrRolloutInProgress), errors.Is(err, rollout.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, rollout.ErrInvalidWavePlan), errors.Is(err, rollout.ErrNothingToRollOut):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -14,9 +16,11 @@ import (
 type instanceStatusPayload struct {
 	ID                 int64                    `json:"id,string"`
 	OrgID              int64                    `json:"org_id,string"`
+	Environment        string                   `json:"environment"`
 	NomadJobID         string                   `json:"nomad_job_id"`
 	DesiredVersion     string                   `json:"desired_version"`
 	CurrentVersion     string                   `json:"current_version"`
+	LastGoodVersion    string                   `json:"last_good_version,omitempty"`
 	Status             instance.InstanceStatus  `json:"status"`
 	Role               instance.InstanceRole    `json:"role"`
 	LifecycleState     instance.LifecycleState  `json:"lifecycle_state"`
@@ -25,6 +29,7 @@ type instanceStatusPayload struct {
 	ReadinessError     string                   `json:"readiness_error,omitempty"`
 	Tier               instance.Tier            `json:"tier"`
 	ComputeEngine      instance.ComputeEngine   `json:"compute_engine"`
+	Provisioner        string                   `json:"provisioner,omitempty"`
 	PlanID             string                   `json:"plan_id"`
 	PriceID            string                   `json:"price_id"`
 	SubscriptionID     string                   `json:"subscription_id"`
@@ -33,6 +38,13 @@ type instanceStatusPayload struct {
 	LastError          string                   `json:"last_error,omitempty"`
 	CreatedAt          time.Time                `json:"created_at"`
 	UpdatedAt          time.Time                `json:"updated_at"`
+	Deploy             *deployProgressPayload   `json:"deploy,omitempty"`
+}
+
+type deployProgressPayload struct {
+	Status      instance.DeploySagaStatus `json:"status"`
+	CurrentStep instance.DeployStep       `json:"current_step,omitempty"`
+	Steps       []instance.SagaStep       `json:"steps"`
 }
 
 func instanceStatusResponse(inst *instance.Instance, subscriptionStatus string) *instanceStatusPayload {
@@ -42,9 +54,11 @@ func instanceStatusResponse(inst *instance.Instance, subscriptionStatus string)
 	return &instanceStatusPayload{
 		ID:                 inst.ID,
 		OrgID:              inst.OrgID,
+		Environment:        inst.Environment,
 		NomadJobID:         inst.NomadJobID,
 		DesiredVersion:     inst.DesiredVersion,
 		CurrentVersion:     inst.CurrentVersion,
+		LastGoodVersion:    inst.LastGoodVersion,
 		Status:             inst.Status,
 		Role:               inst.Role,
 		LifecycleState:     inst.LifecycleState,
@@ -53,6 +67,7 @@ func instanceStatusResponse(inst *instance.Instance, subscriptionStatus string)
 		ReadinessError:     inst.ReadinessError,
 		Tier:               inst.Tier,
 		ComputeEngine:      inst.ComputeEngine,
+		Provisioner:        inst.Provisioner,
 		PlanID:             inst.PlanID,
 		PriceID:            inst.PriceID,
 		SubscriptionID:     inst.SubscriptionID,
@@ -64,6 +79,27 @@ func instanceStatusResponse(inst *instance.Instance, subscriptionStatus string)
 	}
 }
 
+// withDeployProgress attaches the step progress of the instance's latest
+// deploy. Progress is informational, so a lookup failure is only logged.
+func (r *Router) withDeployProgress(ctx context.Context, payload *instanceStatusPayload) *instanceStatusPayload {
+	if payload == nil {
+		return nil
+	}
+	saga, err := r.eventUC.LatestDeploy(ctx, payload.ID)
+	if err != nil {
+		r.logger.Warn("failed to fetch deploy progress", zap.Error(err), zap.Int64("instance_id", payload.ID))
+		return payload
+	}
+	if saga != nil {
+		payload.Deploy = &deployProgressPayload{
+			Status:      saga.Status,
+			CurrentStep: saga.CurrentStep(),
+			Steps:       saga.Steps,
+		}
+	}
+	return payload
+}
+
 func (r *Router) GetInstanceStatus(c *gin.Context) {
 	orgID, ok := r.resolveOrgID(c)
 	if !ok {
@@ -91,7 +127,7 @@ func (r *Router) GetInstanceStatus(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, instanceStatusResponse(status, subStatus))
+	c.JSON(http.StatusOK, r.withDeployProgress(c.Request.Context(), instanceStatusResponse(status, subStatus)))
 }
 
 func (r *Router) StreamInstanceStatus(c *gin.Context) {
@@ -142,7 +178,7 @@ func (r *Router) StreamInstanceStatus(c *gin.Context) {
 					subStatus = s
 				}
 			}
-			payload = instanceStatusResponse(status, subStatus)
+			payload = r.withDeployProgress(ctx, instanceStatusResponse(status, subStatus))
 		}
 
 		encoded, err := json.Marshal(payload)
@@ -194,62 +230,64 @@ func (r *Router) DeployInstance(c *gin.Context) {
 		return
 	}
 
-	orgID, ok := r.resolveOrgID(c)
+	inst, ok := r.resolvePrimaryInstance(c)
 	if !ok {
 		return
 	}
 
-	if err := r.deployUC.Execute(c.Request.Context(), orgID, req.Version); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"status": "deployment_triggered"})
+	eventID, err := r.operationUC.Deploy(c.Request.Context(), inst.ID, req.Version)
+	respondOperation(c, eventID, err, "deployment_triggered")
 }
 
 func (r *Router) StartInstance(c *gin.Context) {
-	orgID, ok := r.resolveOrgID(c)
+	inst, ok := r.resolvePrimaryInstance(c)
 	if !ok {
 		return
 	}
 
-	if err := r.lifecycleUC.Start(c.Request.Context(), orgID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"status": "start_triggered"})
+	eventID, err := r.operationUC.Start(c.Request.Context(), inst.ID)
+	respondOperation(c, eventID, err, "start_triggered")
 }
 
 func (r *Router) StopInstance(c *gin.Context) {
-	orgID, ok := r.resolveOrgID(c)
+	inst, ok := r.resolvePrimaryInstance(c)
 	if !ok {
 		return
 	}
 
-	if err := r.lifecycleUC.Stop(c.Request.Context(), orgID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	eventID, err := r.operationUC.Stop(c.Request.Context(), inst.ID)
+	respondOperation(c, eventID, err, "stop_triggered")
+}
+
+func (r *Router) PauseInstance(c *gin.Context) {
+	inst, ok := r.resolvePrimaryInstance(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "stop_triggered"})
+	eventID, err := r.operationUC.Stop(c.Request.Context(), inst.ID)
+	respondOperation(c, eventID, err, "pause_triggered")
 }
 
-func (r *Router) PauseInstance(c *gin.Context) {
-	orgID, ok := r.resolveOrgID(c)
-	if !ok {
+func (r *Router) UpgradeInstance(c *gin.Context) {
+	var req struct {
+		Tier string `json:"tier"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
 		return
 	}
 
-	if err := r.lifecycleUC.Pause(c.Request.Context(), orgID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	inst, ok := r.resolvePrimaryInstance(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "pause_triggered"})
+	eventID, err := r.operationUC.Upgrade(c.Request.Context(), inst.ID, instance.Tier(req.Tier))
+	respondOperation(c, eventID, err, "upgrade_initiated")
 }
 
-func (r *Router) UpgradeInstance(c *gin.Context) {
+func (r *Router) DowngradeInstance(c *gin.Context) {
 	var req struct {
 		Tier string `json:"tier"`
 	}
@@ -258,37 +296,70 @@ func (r *Router) UpgradeInstance(c *gin.Context) {
 		return
 	}
 
-	orgID, ok := r.resolveOrgID(c)
+	inst, ok := r.resolvePrimaryInstance(c)
 	if !ok {
 		return
 	}
 
-	if err := r.upgradeUC.Upgrade(c.Request.Context(), orgID, instance.Tier(req.Tier)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	eventID, err := r.operationUC.Downgrade(c.Request.Context(), inst.ID, instance.Tier(req.Tier))
+	respondOperation(c, eventID, err, "downgrade_scheduled")
+}
+
+func (r *Router) TerminateInstance(c *gin.Context) {
+	inst, ok := r.resolvePrimaryInstance(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "upgrade_initiated"})
+	eventID, err := r.operationUC.Terminate(c.Request.Context(), inst.ID)
+	respondOperation(c, eventID, err, "terminate_triggered")
 }
 
-func (r *Router) DowngradeInstance(c *gin.Context) {
-	var req struct {
-		Tier string `json:"tier"`
+func (r *Router) RotateInstanceSecrets(c *gin.Context) {
+	secrets, ok := bindSecretKinds(c)
+	if !ok {
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+	inst, ok := r.resolvePrimaryInstance(c)
+	if !ok {
 		return
 	}
 
+	eventID, err := r.operationUC.RotateSecrets(c.Request.Context(), inst.ID, secrets)
+	respondOperation(c, eventID, err, "rotation_triggered")
+}
+
+// resolvePrimaryInstance loads the production instance of the caller's org.
+func (r *Router) resolvePrimaryInstance(c *gin.Context) (*instance.Instance, bool) {
 	orgID, ok := r.resolveOrgID(c)
 	if !ok {
-		return
+		return nil, false
 	}
 
-	if err := r.upgradeUC.Downgrade(c.Request.Context(), orgID, instance.Tier(req.Tier)); err != nil {
+	inst, err := r.lifecycleUC.GetStatus(c.Request.Context(), orgID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, false
+	}
+	if inst == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "instance not found"})
+		return nil, false
 	}
+	return inst, true
+}
 
-	c.JSON(http.StatusOK, gin.H{"status": "downgrade_scheduled"})
+// respondOperation reports an instance operation enqueued on the outbox. The
+// operation runs asynchronously; its progress shows in the instance status
+// and events. Operations ruled out by the instance's state are conflicts.
+func respondOperation(c *gin.Context, eventID int64, err error, status string) {
+	switch {
+	case errors.Is(err, instance.ErrInvalidState),
+		errors.Is(err, instance.ErrInvalidTierUpgrade),
+		errors.Is(err, instance.ErrUpgradeInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"status": status, "event_id": eventID})
+	}
 }
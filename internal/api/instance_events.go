@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+)
+
+// ListInstanceEvents returns the audit timeline of the production instance.
+func (r *Router) ListInstanceEvents(c *gin.Context) {
+	page, ok := bindEventPage(c)
+	if !ok {
+		return
+	}
+	orgID, ok := r.resolveOrgID(c)
+	if !ok {
+		return
+	}
+
+	items, pageInfo, err := r.eventUC.ListByOrgID(c.Request.Context(), orgID, page)
+	if err != nil {
+		r.eventError(c, err)
+		return
+	}
+	if pageInfo == nil {
+		pageInfo = &pagination.PageInfo{}
+	}
+	respondEvents(c, items, pageInfo)
+}
+
+func (r *Router) ListInstanceEventsByID(c *gin.Context) {
+	page, ok := bindEventPage(c)
+	if !ok {
+		return
+	}
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+
+	items, pageInfo, err := r.eventUC.ListByInstanceID(c.Request.Context(), inst.ID, page)
+	if err != nil {
+		r.eventError(c, err)
+		return
+	}
+	respondEvents(c, items, pageInfo)
+}
+
+func (r *Router) AdminListInstanceEvents(c *gin.Context) {
+	page, ok := bindEventPage(c)
+	if !ok {
+		return
+	}
+	instanceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid instance id"})
+		return
+	}
+
+	items, pageInfo, err := r.eventUC.ListByInstanceID(c.Request.Context(), instanceID, page)
+	if err != nil {
+		r.eventError(c, err)
+		return
+	}
+	respondEvents(c, items, pageInfo)
+}
+
+func bindEventPage(c *gin.Context) (pagination.Pagination, bool) {
+	var page pagination.Pagination
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return page, false
+	}
+	if page.PageSize < 1 || page.PageSize > 250 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page_size must be between 1 and 250"})
+		return page, false
+	}
+	return page, true
+}
+
+func respondEvents(c *gin.Context, items []*instance.Event, pageInfo *pagination.PageInfo) {
+	if items == nil {
+		items = []*instance.Event{}
+	}
+	c.JSON(http.StatusOK, gin.H{"events": items, "page_info": pageInfo})
+}
+
+func (r *Router) eventError(c *gin.Context, err error) {
+	if errors.Is(err, instance.ErrInvalidPageToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
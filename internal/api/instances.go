@@ -0,0 +1,193 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+)
+
+// Instance-scoped endpoints for organizations running more than one
+// environment. The /user/instance routes keep targeting the production instance.
+
+func (r *Router) ListInstances(c *gin.Context) {
+	orgID, ok := r.resolveOrgID(c)
+	if !ok {
+		return
+	}
+
+	insts, err := r.lifecycleUC.ListInstances(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]*instanceStatusPayload, 0, len(insts))
+	for _, inst := range insts {
+		items = append(items, instanceStatusResponse(inst, ""))
+	}
+	c.JSON(http.StatusOK, gin.H{"instances": items})
+}
+
+func (r *Router) CreateInstance(c *gin.Context) {
+	var req struct {
+		Environment string `json:"environment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	orgID, ok := r.resolveOrgID(c)
+	if !ok {
+		return
+	}
+
+	inst, err := r.onboardingSvc.CreateEnvironment(c.Request.Context(), orgID, req.Environment)
+	if err != nil {
+		switch {
+		case errors.Is(err, instance.ErrInvalidEnvironment):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, instance.ErrEnvironmentExists), errors.Is(err, instance.ErrInstanceLimitReached):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, instanceStatusResponse(inst, ""))
+}
+
+func (r *Router) GetInstanceByID(c *gin.Context) {
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, r.withDeployProgress(c.Request.Context(), instanceStatusResponse(inst, "")))
+}
+
+func (r *Router) DeployInstanceByID(c *gin.Context) {
+	var req struct {
+		Version string `json:"version"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := r.operationUC.Deploy(c.Request.Context(), inst.ID, req.Version)
+	respondOperation(c, eventID, err, "deployment_triggered")
+}
+
+func (r *Router) StartInstanceByID(c *gin.Context) {
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := r.operationUC.Start(c.Request.Context(), inst.ID)
+	respondOperation(c, eventID, err, "start_triggered")
+}
+
+func (r *Router) StopInstanceByID(c *gin.Context) {
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := r.operationUC.Stop(c.Request.Context(), inst.ID)
+	respondOperation(c, eventID, err, "stop_triggered")
+}
+
+func (r *Router) UpgradeInstanceByID(c *gin.Context) {
+	var req struct {
+		Tier string `json:"tier"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := r.operationUC.Upgrade(c.Request.Context(), inst.ID, instance.Tier(req.Tier))
+	respondOperation(c, eventID, err, "upgrade_initiated")
+}
+
+func (r *Router) DowngradeInstanceByID(c *gin.Context) {
+	var req struct {
+		Tier string `json:"tier"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := r.operationUC.Downgrade(c.Request.Context(), inst.ID, instance.Tier(req.Tier))
+	respondOperation(c, eventID, err, "downgrade_scheduled")
+}
+
+func (r *Router) TerminateInstanceByID(c *gin.Context) {
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := r.operationUC.Terminate(c.Request.Context(), inst.ID)
+	respondOperation(c, eventID, err, "terminate_triggered")
+}
+
+func (r *Router) RotateInstanceSecretsByID(c *gin.Context) {
+	secrets, ok := bindSecretKinds(c)
+	if !ok {
+		return
+	}
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := r.operationUC.RotateSecrets(c.Request.Context(), inst.ID, secrets)
+	respondOperation(c, eventID, err, "rotation_triggered")
+}
+
+// resolveInstance loads the :id instance and checks it belongs to the caller's org.
+func (r *Router) resolveInstance(c *gin.Context) (*instance.Instance, bool) {
+	orgID, ok := r.resolveOrgID(c)
+	if !ok {
+		return nil, false
+	}
+
+	instanceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid instance id"})
+		return nil, false
+	}
+
+	inst, err := r.lifecycleUC.GetInstanceStatus(c.Request.Context(), orgID, instanceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if inst == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "instance not found"})
+		return nil, false
+	}
+	return inst, true
+}
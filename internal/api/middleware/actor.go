@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+)
+
+// Actor records who is making the request so instance events can be
+// attributed. Signed-in users are identified by their user ID.
+func Actor(actorType instance.ActorType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := instance.Actor{Type: actorType}
+		if userID, ok := c.Get("UserID"); ok {
+			if id, ok := userID.(int64); ok {
+				actor.ID = strconv.FormatInt(id, 10)
+			}
+		}
+		if actorType == instance.ActorAdmin {
+			actor.ID = "admin_token"
+		}
+
+		c.Request = c.Request.WithContext(instance.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
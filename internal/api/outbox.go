@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/outbox"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+)
+
+type deadEventPayload struct {
+	ID         int64            `json:"id,string"`
+	EventType  outboxevent.Type `json:"event_type"`
+	OrgID      int64            `json:"org_id,string"`
+	InstanceID int64            `json:"instance_id,string"`
+	Attempts   int              `json:"attempts"`
+	LastError  string           `json:"last_error"`
+	Payload    json.RawMessage  `json:"payload,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// ListDeadEvents returns the outbox events that used up their attempts.
+func (r *Router) ListDeadEvents(c *gin.Context) {
+	page, ok := bindEventPage(c)
+	if !ok {
+		return
+	}
+
+	items, pageInfo, err := r.deadLetters.List(c.Request.Context(), page)
+	if err != nil {
+		r.eventError(c, err)
+		return
+	}
+
+	events := make([]deadEventPayload, 0, len(items))
+	for _, e := range items {
+		events = append(events, deadEventPayload{
+			ID:         e.ID,
+			EventType:  e.EventType,
+			OrgID:      e.OrgID,
+			InstanceID: e.InstanceID,
+			Attempts:   e.Attempts,
+			LastError:  e.LastError,
+			Payload:    e.Payload,
+			CreatedAt:  e.CreatedAt,
+			UpdatedAt:  e.UpdatedAt,
+		})
+	}
+	if pageInfo == nil {
+		pageInfo = &pagination.PageInfo{}
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events, "page_info": pageInfo})
+}
+
+// ReplayDeadEvents queues dead events again with their attempts reset.
+func (r *Router) ReplayDeadEvents(c *gin.Context) {
+	var req struct {
+		EventIDs []string `json:"event_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	ids, ok := parseEventIDs(c, req.EventIDs)
+	if !ok {
+		return
+	}
+
+	replayed, err := r.deadLetters.Replay(c.Request.Context(), ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "replayed", "count": replayed})
+}
+
+// DiscardDeadEvents drops dead events, recording the reason on their instances.
+func (r *Router) DiscardDeadEvents(c *gin.Context) {
+	var req struct {
+		EventIDs []string `json:"event_ids"`
+		Reason   string   `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	ids, ok := parseEventIDs(c, req.EventIDs)
+	if !ok {
+		return
+	}
+
+	discarded, err := r.deadLetters.Discard(c.Request.Context(), ids, req.Reason)
+	if errors.Is(err, outbox.ErrReasonRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "discarded", "count": discarded})
+}
+
+func parseEventIDs(c *gin.Context, raw []string) ([]int64, bool) {
+	if len(raw) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event_ids is required"})
+		return nil, false
+	}
+	ids := make([]int64, 0, len(raw))
+	for _, s := range raw {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id " + strconv.Quote(s)})
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
@@ -14,7 +14,9 @@ import (
 	"github.com/railzwaylabs/railzway-cloud/internal/auth"
 	"github.com/railzwaylabs/railzway-cloud/internal/config"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
 	"github.com/railzwaylabs/railzway-cloud/internal/onboarding"
+	"github.com/railzwaylabs/railzway-cloud/internal/outbox"
 	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
 	"github.com/railzwaylabs/railzway-cloud/internal/user"
 	"github.com/railzwaylabs/railzway-cloud/pkg/railzwayclient"
@@ -27,8 +29,12 @@ type Router struct {
 	cfg           *config.Config
 	deployUC      *deployment.DeployUseCase
 	lifecycleUC   *deployment.LifecycleUseCase
-	upgradeUC     *deployment.UpgradeUseCase
+	operationUC   *deployment.OperationUseCase
 	rolloutUC     *deployment.RolloutUseCase
+	rollbackUC    *deployment.RollbackUseCase
+	eventUC       *deployment.EventUseCase
+	tierProfileUC *deployment.TierProfileUseCase
+	deadLetters   *outbox.DeadLetters
 	onboardingSvc *onboarding.Service
 	userSvc       *user.Service
 	sessionMgr    *auth.SessionManager
@@ -41,8 +47,12 @@ func NewRouter(
 	cfg *config.Config,
 	deployUC *deployment.DeployUseCase,
 	lifecycleUC *deployment.LifecycleUseCase,
-	upgradeUC *deployment.UpgradeUseCase,
+	operationUC *deployment.OperationUseCase,
 	rolloutUC *deployment.RolloutUseCase,
+	rollbackUC *deployment.RollbackUseCase,
+	eventUC *deployment.EventUseCase,
+	tierProfileUC *deployment.TierProfileUseCase,
+	deadLetters *outbox.DeadLetters,
 	onboardingSvc *onboarding.Service,
 	userSvc *user.Service,
 	sessionMgr *auth.SessionManager,
@@ -67,8 +77,12 @@ func NewRouter(
 		cfg:           cfg,
 		deployUC:      deployUC,
 		lifecycleUC:   lifecycleUC,
-		upgradeUC:     upgradeUC,
+		operationUC:   operationUC,
 		rolloutUC:     rolloutUC,
+		rollbackUC:    rollbackUC,
+		eventUC:       eventUC,
+		tierProfileUC: tierProfileUC,
+		deadLetters:   deadLetters,
 		onboardingSvc: onboardingSvc,
 		userSvc:       userSvc,
 		sessionMgr:    sessionMgr,
@@ -111,19 +125,37 @@ func (r *Router) RegisterRoutes() {
 
 	// User Routes (Protected)
 	user := r.engine.Group("/user")
-	user.Use(r.sessionMgr.Middleware())
+	user.Use(r.sessionMgr.Middleware(), middleware.Actor(instance.ActorUser))
 	{
 		user.GET("/organizations", r.GetUserOrganizations)
 		user.GET("/profile", r.GetUserProfile)
 		user.PUT("/profile", r.UpdateUserProfile)
 		user.GET("/instance", r.GetInstanceStatus)
 		user.GET("/instance/stream", r.StreamInstanceStatus)
+		user.GET("/instance/events", r.ListInstanceEvents)
 		user.POST("/instance/deploy", r.DeployInstance)
 		user.POST("/instance/start", r.StartInstance)
 		user.POST("/instance/pause", r.PauseInstance)
 		user.POST("/instance/stop", r.StopInstance)
 		user.POST("/instance/upgrade", r.UpgradeInstance)
 		user.POST("/instance/downgrade", r.DowngradeInstance)
+		user.POST("/instance/terminate", r.TerminateInstance)
+		user.POST("/instance/rotate-secrets", r.RotateInstanceSecrets)
+		user.GET("/instance/secret-rotations", r.ListSecretRotations)
+
+		user.GET("/instances", r.ListInstances)
+		user.POST("/instances", r.CreateInstance)
+		user.GET("/instances/:id", r.GetInstanceByID)
+		user.GET("/instances/:id/events", r.ListInstanceEventsByID)
+		user.POST("/instances/:id/deploy", r.DeployInstanceByID)
+		user.POST("/instances/:id/start", r.StartInstanceByID)
+		user.POST("/instances/:id/pause", r.StopInstanceByID)
+		user.POST("/instances/:id/stop", r.StopInstanceByID)
+		user.POST("/instances/:id/upgrade", r.UpgradeInstanceByID)
+		user.POST("/instances/:id/downgrade", r.DowngradeInstanceByID)
+		user.POST("/instances/:id/terminate", r.TerminateInstanceByID)
+		user.POST("/instances/:id/rotate-secrets", r.RotateInstanceSecretsByID)
+		user.GET("/instances/:id/secret-rotations", r.ListSecretRotationsByID)
 
 		// Onboarding Endpoints (Protected)
 		onboardGroup := user.Group("/onboarding")
@@ -135,9 +167,29 @@ func (r *Router) RegisterRoutes() {
 
 	// Admin Routes (Protected by ADMIN_API_TOKEN)
 	admin := r.engine.Group("/admin")
-	admin.Use(r.adminAuth())
+	admin.Use(r.adminAuth(), middleware.Actor(instance.ActorAdmin))
 	{
 		admin.POST("/rollout", r.RolloutVersion)
+		admin.GET("/rollouts", r.ListRollouts)
+		admin.POST("/rollouts", r.CreateRollout)
+		admin.GET("/rollouts/:id", r.GetRollout)
+		admin.POST("/rollouts/:id/pause", r.PauseRollout)
+		admin.POST("/rollouts/:id/resume", r.ResumeRollout)
+		admin.POST("/rollouts/:id/abort", r.AbortRollout)
+		admin.GET("/instances/:id/rollbacks", r.ListInstanceRollbacks)
+		admin.GET("/versions/suspect", r.ListSuspectVersions)
+		admin.POST("/versions/:version/clear-suspect", r.ClearSuspectVersion)
+		admin.GET("/instances/:id/events", r.AdminListInstanceEvents)
+		admin.GET("/instances/:id/secret-rotations", r.AdminListSecretRotations)
+		admin.POST("/secrets/rotate", r.AdminRotateSecrets)
+		admin.GET("/tier-profiles", r.ListTierProfiles)
+		admin.GET("/tier-profiles/:tier", r.GetTierProfile)
+		admin.PUT("/tier-profiles/:tier", r.SaveTierProfile)
+		admin.GET("/tier-profiles/:tier/revisions", r.ListTierProfileRevisions)
+		admin.POST("/tier-profiles/:tier/redeploy", r.RedeployTierProfile)
+		admin.GET("/outbox/dead", r.ListDeadEvents)
+		admin.POST("/outbox/dead/replay", r.ReplayDeadEvents)
+		admin.POST("/outbox/dead/discard", r.DiscardDeadEvents)
 	}
 
 	// SPA Fallback
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+)
+
+// ListSecretRotations returns the credential rotation history of the
+// production instance.
+func (r *Router) ListSecretRotations(c *gin.Context) {
+	inst, ok := r.resolvePrimaryInstance(c)
+	if !ok {
+		return
+	}
+	r.respondSecretRotations(c, inst.ID)
+}
+
+func (r *Router) ListSecretRotationsByID(c *gin.Context) {
+	inst, ok := r.resolveInstance(c)
+	if !ok {
+		return
+	}
+	r.respondSecretRotations(c, inst.ID)
+}
+
+func (r *Router) AdminListSecretRotations(c *gin.Context) {
+	instanceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid instance id"})
+		return
+	}
+	r.respondSecretRotations(c, instanceID)
+}
+
+// AdminRotateSecrets enqueues a credential rotation for every provisioned
+// instance, optionally of one tier.
+func (r *Router) AdminRotateSecrets(c *gin.Context) {
+	var req struct {
+		Secrets []string `json:"secrets"`
+		Tier    string   `json:"tier"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	secrets, err := instance.ParseSecretKinds(req.Secrets)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tier := instance.Tier(req.Tier)
+	if _, ok := instance.TierRank[tier]; tier != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown tier"})
+		return
+	}
+
+	result, err := r.operationUC.RotateFleetSecrets(c.Request.Context(), deployment.FleetSecretRotation{
+		Secrets: secrets,
+		Tier:    tier,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":         "rotation_enqueued",
+		"secrets":        secrets,
+		"enqueued_count": result.EnqueuedCount,
+		"skipped_count":  result.SkippedCount,
+	})
+}
+
+func (r *Router) respondSecretRotations(c *gin.Context, instanceID int64) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	items, err := r.deployUC.ListSecretRotations(c.Request.Context(), instanceID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rotations": items})
+}
+
+// bindSecretKinds reads the optional list of secrets to rotate. A request
+// without a body rotates all of them.
+func bindSecretKinds(c *gin.Context) ([]instance.SecretKind, bool) {
+	var req struct {
+		Secrets []string `json:"secrets"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return nil, false
+	}
+	secrets, err := instance.ParseSecretKinds(req.Secrets)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return secrets, true
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/tierprofile"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+func (r *Router) ListTierProfiles(c *gin.Context) {
+	items, err := r.tierProfileUC.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profiles": items})
+}
+
+func (r *Router) GetTierProfile(c *gin.Context) {
+	item, err := r.tierProfileUC.Get(c.Request.Context(), instance.Tier(c.Param("tier")))
+	if err != nil {
+		r.tierProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (r *Router) ListTierProfileRevisions(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	items, err := r.tierProfileUC.History(c.Request.Context(), instance.Tier(c.Param("tier")), limit)
+	if err != nil {
+		r.tierProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": items})
+}
+
+func (r *Router) SaveTierProfile(c *gin.Context) {
+	var req struct {
+		Spec         nomad.TierProfile `json:"spec"`
+		Comment      string            `json:"comment"`
+		BaseRevision int               `json:"base_revision"`
+		Redeploy     bool              `json:"redeploy"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	tier := instance.Tier(c.Param("tier"))
+	item, err := r.tierProfileUC.Save(c.Request.Context(), deployment.SaveTierProfileParams{
+		Tier:         tier,
+		Spec:         req.Spec,
+		Comment:      req.Comment,
+		BaseRevision: req.BaseRevision,
+	})
+	if err != nil {
+		r.tierProfileError(c, err)
+		return
+	}
+
+	resp := gin.H{"profile": item}
+	if req.Redeploy {
+		enqueued, err := r.tierProfileUC.Redeploy(c.Request.Context(), tier)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp["enqueued_count"] = enqueued
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (r *Router) RedeployTierProfile(c *gin.Context) {
+	tier := instance.Tier(c.Param("tier"))
+	enqueued, err := r.tierProfileUC.Redeploy(c.Request.Context(), tier)
+	if err != nil {
+		r.tierProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "redeploy_enqueued",
+		"tier":           tier,
+		"enqueued_count": enqueued,
+	})
+}
+
+func (r *Router) tierProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, tierprofile.ErrUnknownTier):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, tierprofile.ErrRevisionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, tierprofile.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
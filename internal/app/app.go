@@ -12,17 +12,22 @@ import (
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	railzwayoss "github.com/railzwaylabs/railzway-cloud/internal/adapter/billing/railzway_oss"
+	dockerAdapter "github.com/railzwaylabs/railzway-cloud/internal/adapter/provisioning/docker"
+	kubernetesAdapter "github.com/railzwaylabs/railzway-cloud/internal/adapter/provisioning/kubernetes"
 	nomadAdapter "github.com/railzwaylabs/railzway-cloud/internal/adapter/provisioning/nomad"
 	postgresProvisioner "github.com/railzwaylabs/railzway-cloud/internal/adapter/provisioning/postgres"
 	"github.com/railzwaylabs/railzway-cloud/internal/adapter/repository/postgres"
 	"github.com/railzwaylabs/railzway-cloud/internal/api"
 	"github.com/railzwaylabs/railzway-cloud/internal/auth"
 	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/cryptoutils"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/tierprofile"
 	"github.com/railzwaylabs/railzway-cloud/internal/onboarding"
 	"github.com/railzwaylabs/railzway-cloud/internal/organization"
 	"github.com/railzwaylabs/railzway-cloud/internal/outbox"
@@ -32,9 +37,12 @@ import (
 	"github.com/railzwaylabs/railzway-cloud/internal/version"
 	"github.com/railzwaylabs/railzway-cloud/pkg/authclient"
 	"github.com/railzwaylabs/railzway-cloud/pkg/db"
+	"github.com/railzwaylabs/railzway-cloud/pkg/docker"
+	"github.com/railzwaylabs/railzway-cloud/pkg/kube"
 	zaplog "github.com/railzwaylabs/railzway-cloud/pkg/log"
 	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
 	"github.com/railzwaylabs/railzway-cloud/pkg/railzwayclient"
+	"github.com/railzwaylabs/railzway-cloud/pkg/secretstore"
 	"github.com/railzwaylabs/railzway-cloud/pkg/snowflake"
 	"github.com/railzwaylabs/railzway-cloud/sql/migrations"
 )
@@ -51,15 +59,36 @@ func RunServer() {
 			railzwayclient.NewFromEnv,
 			authclient.NewFromEnv,
 
+			newSecrets,
+
 			// Domain Adapters (Bind Interfaces)
 			fx.Annotate(
 				postgres.NewRepository,
 				fx.As(new(instance.Repository)),
 			),
 			fx.Annotate(
-				nomadAdapter.NewAdapter,
-				fx.As(new(provisioning.Provisioner)),
+				postgres.NewUpgradeRepository,
+				fx.As(new(instance.UpgradeRepository)),
+			),
+			fx.Annotate(
+				postgres.NewEventRepository,
+				fx.As(new(instance.EventRepository)),
+			),
+			fx.Annotate(
+				postgres.NewDeploySagaRepository,
+				fx.As(new(instance.DeploySagaRepository)),
+			),
+			fx.Annotate(
+				postgres.NewSecretRotationRepository,
+				fx.As(new(instance.SecretRotationRepository)),
 			),
+			fx.Annotate(
+				postgres.NewTierProfileRepository,
+				fx.As(new(tierprofile.Repository)),
+			),
+			newSecretStore,
+			nomadAdapter.NewAdapter,
+			newProvisioner,
 			fx.Annotate(
 				newPostgresProvisioner,
 				fx.As(new(provisioning.DatabaseProvisioner)),
@@ -68,6 +97,7 @@ func RunServer() {
 				railzwayoss.NewAdapter,
 				fx.As(new(billing.Engine)),
 				fx.As(new(billing.PriceResolver)),
+				fx.As(new(billing.EntitlementResolver)),
 			),
 
 			// Database Config for tenant provisioning
@@ -75,19 +105,34 @@ func RunServer() {
 			newRuntimeConfig,
 
 			// Use Cases
+			deployment.NewProfileResolver,
+			deployment.NewBlueGreenUseCase,
 			deployment.NewDeployUseCase,
 			deployment.NewLifecycleUseCase,
 			deployment.NewUpgradeUseCase,
 			deployment.NewRolloutUseCase,
+			deployment.NewRollbackUseCase,
+			deployment.NewEventUseCase,
+			deployment.NewTierProfileUseCase,
+			deployment.NewEntitlementUseCase,
+			deployment.NewOperationUseCase,
 
 			// Legacy / Other Services
 			user.NewService,
 			onboarding.NewService,
 			organization.NewService,
 			version.NewRegistry,
+			outbox.NewRegistry,
+			outbox.NewDeployHandler,
+			outbox.NewDeadLetters,
 			outbox.NewProcessor,
 			reconciler.NewInstanceReconciler,
 			reconciler.NewLifecycleReconciler,
+			reconciler.NewUpgradeReconciler,
+			reconciler.NewRolloutReconciler,
+			reconciler.NewRollbackReconciler,
+			reconciler.NewEntitlementReconciler,
+			reconciler.NewSecretReconciler,
 
 			// Auth & Session
 			auth.NewSessionManager,
@@ -98,6 +143,7 @@ func RunServer() {
 		db.Module,        // Database Module
 		snowflake.Module, // Snowflake ID Module
 		zaplog.Module,    // Logger Module
+		fx.Invoke(outbox.RegisterHandlers),
 		fx.Invoke(registerHooks),
 	)
 
@@ -158,10 +204,57 @@ func RunMigrations(command string) error {
 	return nil
 }
 
-func registerHooks(lc fx.Lifecycle, router *api.Router, processor *outbox.Processor, instanceReconciler *reconciler.InstanceReconciler, lifecycleReconciler *reconciler.LifecycleReconciler, client *railzwayclient.Client, logger *zap.Logger) {
+// RunDeadLetters connects to the database and runs fn against the outbox
+// dead-letter queue. Changes are attributed to an admin acting from the CLI.
+func RunDeadLetters(fn func(ctx context.Context, deadLetters *outbox.DeadLetters) error) error {
+	return runWithDatabase(func(ctx context.Context, cfg *config.Config, conn *gorm.DB) error {
+		return fn(ctx, outbox.NewDeadLetters(conn))
+	})
+}
+
+// RunInstanceSecrets connects to the database and runs fn against the
+// instance repository, for maintenance of the stored tenant credentials.
+func RunInstanceSecrets(fn func(ctx context.Context, repo *postgres.Repository) error) error {
+	return runWithDatabase(func(ctx context.Context, cfg *config.Config, conn *gorm.DB) error {
+		secrets, err := newSecrets(cfg)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, postgres.NewRepository(conn, secrets))
+	})
+}
+
+// runWithDatabase connects to the database for a CLI command. Changes are
+// attributed to an admin acting from the CLI.
+func runWithDatabase(fn func(ctx context.Context, cfg *config.Config, conn *gorm.DB) error) error {
+	cfg := config.Load()
+	dialector, err := db.Dialect(cfg)
+	if err != nil {
+		return err
+	}
+	conn, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	ctx := instance.WithActor(context.Background(), instance.Actor{Type: instance.ActorAdmin, ID: "cli"})
+	return fn(ctx, cfg, conn)
+}
+
+func registerHooks(lc fx.Lifecycle, router *api.Router, processor *outbox.Processor, instanceReconciler *reconciler.InstanceReconciler, lifecycleReconciler *reconciler.LifecycleReconciler, upgradeReconciler *reconciler.UpgradeReconciler, rolloutReconciler *reconciler.RolloutReconciler, rollbackReconciler *reconciler.RollbackReconciler, entitlementReconciler *reconciler.EntitlementReconciler, secretReconciler *reconciler.SecretReconciler, client *railzwayclient.Client, logger *zap.Logger) {
 	var processorCancel context.CancelFunc
 	var reconcilerCancel context.CancelFunc
 	var lifecycleCancel context.CancelFunc
+	var upgradeCancel context.CancelFunc
+	var rolloutCancel context.CancelFunc
+	var rollbackCancel context.CancelFunc
+	var entitlementCancel context.CancelFunc
+	var secretCancel context.CancelFunc
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -179,6 +272,26 @@ func registerHooks(lc fx.Lifecycle, router *api.Router, processor *outbox.Proces
 			lifecycleCancel = cancel
 			go lifecycleReconciler.Run(lifecycleCtx)
 
+			upgradeCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			upgradeCancel = cancel
+			go upgradeReconciler.Run(upgradeCtx)
+
+			rolloutCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			rolloutCancel = cancel
+			go rolloutReconciler.Run(rolloutCtx)
+
+			rollbackCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			rollbackCancel = cancel
+			go rollbackReconciler.Run(rollbackCtx)
+
+			entitlementCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			entitlementCancel = cancel
+			go entitlementReconciler.Run(entitlementCtx)
+
+			secretCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			secretCancel = cancel
+			go secretReconciler.Run(secretCtx)
+
 			go func() {
 				if err := router.Run(); err != nil && err != http.ErrServerClosed {
 					logger.Fatal("Server failed to start", zap.Error(err))
@@ -199,6 +312,21 @@ func registerHooks(lc fx.Lifecycle, router *api.Router, processor *outbox.Proces
 			if lifecycleCancel != nil {
 				lifecycleCancel()
 			}
+			if upgradeCancel != nil {
+				upgradeCancel()
+			}
+			if rolloutCancel != nil {
+				rolloutCancel()
+			}
+			if rollbackCancel != nil {
+				rollbackCancel()
+			}
+			if entitlementCancel != nil {
+				entitlementCancel()
+			}
+			if secretCancel != nil {
+				secretCancel()
+			}
 
 			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 			defer cancel()
@@ -214,6 +342,31 @@ func registerHooks(lc fx.Lifecycle, router *api.Router, processor *outbox.Proces
 	})
 }
 
+// defaultKeyID names INSTANCE_SECRET_ENCRYPTION_KEY when it is the only key.
+const defaultKeyID = "default"
+
+// newSecrets seals credentials with the active key of the keyring file, or
+// with INSTANCE_SECRET_ENCRYPTION_KEY when there is none. That key also opens
+// credentials written before key IDs existed.
+func newSecrets(cfg *config.Config) (*cryptoutils.Secrets, error) {
+	var kms cryptoutils.KMS
+	switch {
+	case cfg.InstanceSecretKeyringFile != "":
+		keyring, err := cryptoutils.LoadKeyringFile(cfg.InstanceSecretKeyringFile)
+		if err != nil {
+			return nil, err
+		}
+		kms = keyring
+	case cfg.InstanceSecretEncryptionKey != "":
+		keyring, err := cryptoutils.NewKeyring(defaultKeyID, map[string]string{defaultKeyID: cfg.InstanceSecretEncryptionKey})
+		if err != nil {
+			return nil, fmt.Errorf("INSTANCE_SECRET_ENCRYPTION_KEY: %w", err)
+		}
+		kms = keyring
+	}
+	return cryptoutils.NewSecrets(kms, cfg.InstanceSecretEncryptionKey), nil
+}
+
 // newDBConfig creates database configuration for tenant provisioning.
 func newDBConfig(cfg *config.Config) provisioning.DBConfig {
 	return provisioning.DBConfig{
@@ -244,6 +397,58 @@ func newPostgresProvisioner(cfg *config.Config) *postgresProvisioner.Adapter {
 	return postgresProvisioner.NewAdapter(adminConnString)
 }
 
+// newProvisioner dispatches each instance to the provisioner recorded on it.
+// Nomad is always available; Kubernetes and Docker only when enabled.
+func newProvisioner(cfg *config.Config, nomadProvisioner *nomadAdapter.Adapter) (provisioning.Provisioner, error) {
+	registry := provisioning.NewRegistry(cfg.DefaultProvisioner)
+	registry.Register(provisioning.ProvisionerNomad, nomadProvisioner)
+
+	if cfg.KubernetesEnabled {
+		client, err := kube.NewClientFromConfig(cfg.KubernetesConfigPath, cfg.KubernetesNamespace, kube.Options{
+			IngressClass: cfg.KubernetesIngressClass,
+		})
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(provisioning.ProvisionerKubernetes, kubernetesAdapter.NewAdapter(client))
+	}
+
+	if cfg.DockerEnabled {
+		runtime, err := docker.NewEngineRuntime()
+		if err != nil {
+			return nil, err
+		}
+		client := docker.NewClient(runtime, docker.Options{
+			Network:        cfg.DockerNetwork,
+			ExtraHosts:     []string{"host.docker.internal:host-gateway"},
+			DBHost:         cfg.DockerDBHost,
+			PortRangeStart: cfg.DockerPortRangeStart,
+			PortRangeEnd:   cfg.DockerPortRangeEnd,
+		})
+		registry.Register(provisioning.ProvisionerDocker, dockerAdapter.NewAdapter(client))
+	}
+
+	if !registry.Has(cfg.DefaultProvisioner) {
+		return nil, fmt.Errorf("default provisioner %q is not enabled", cfg.DefaultProvisioner)
+	}
+	return registry, nil
+}
+
+// newSecretStore selects where Nomad workloads read their credentials from.
+func newSecretStore(cfg *config.Config) (secretstore.Store, error) {
+	switch cfg.SecretStore {
+	case "", "consul":
+		return secretstore.NewConsul(cfg.ConsulHTTPAddr, cfg.ConsulHTTPToken), nil
+	case "vault":
+		if cfg.VaultToken == "" {
+			return nil, fmt.Errorf("VAULT_TOKEN is required for SECRET_STORE=vault")
+		}
+		return secretstore.NewVault(cfg.VaultAddr, cfg.VaultToken, cfg.VaultKVMount), nil
+	default:
+		return nil, fmt.Errorf("unknown SECRET_STORE %q", cfg.SecretStore)
+	}
+}
+
 func mustParseInt(s string) int {
 	val, err := strconv.Atoi(s)
 	if err != nil {
@@ -0,0 +1,233 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	railzwayoss "github.com/railzwaylabs/railzway-cloud/internal/adapter/billing/railzway_oss"
+	dockerAdapter "github.com/railzwaylabs/railzway-cloud/internal/adapter/provisioning/docker"
+	"github.com/railzwaylabs/railzway-cloud/internal/adapter/repository/postgres"
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/rollout"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/tierprofile"
+	"github.com/railzwaylabs/railzway-cloud/internal/onboarding"
+	"github.com/railzwaylabs/railzway-cloud/internal/organization"
+	"github.com/railzwaylabs/railzway-cloud/internal/outbox"
+	"github.com/railzwaylabs/railzway-cloud/internal/reconciler"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"github.com/railzwaylabs/railzway-cloud/internal/user"
+	"github.com/railzwaylabs/railzway-cloud/pkg/authclient"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db"
+	"github.com/railzwaylabs/railzway-cloud/pkg/docker"
+	"github.com/railzwaylabs/railzway-cloud/pkg/railzwayclient"
+	"github.com/railzwaylabs/railzway-cloud/pkg/snowflake"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+)
+
+// TestLocalLoop runs onboarding -> outbox -> deploy -> reconciler against the
+// Docker provisioner backed by an in-memory container runtime.
+func TestLocalLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gdb, err := db.NewTest()
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	// Every connection to :memory: opens a separate database.
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, gdb.AutoMigrate(
+		&user.User{},
+		&onboarding.Organization{},
+		&postgres.InstanceModel{},
+		&postgres.UpgradeModel{},
+		&postgres.DeploySagaModel{},
+		&instance.Event{},
+		&outbox.Event{},
+		&rollout.Rollout{},
+		&tierprofile.Profile{},
+		&instance.SecretRotation{},
+	))
+
+	oss := testhelper.NewMockRailzwayServer(t)
+	oss.SetEntitlements("price_starter", map[string]any{"billing.customers.max": 1000})
+	auth := testhelper.NewMockAuthServer(t)
+	cfg := &config.Config{
+		Environment:                 "development",
+		AppRootDomain:               "localhost",
+		DefaultRailzwayOSSVersion:   "v1.6.0",
+		DefaultProvisioner:          provisioning.ProvisionerDocker,
+		InstanceSecretEncryptionKey: base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		OutboxPollIntervalSeconds:   1,
+	}
+
+	runtime := docker.NewMemoryRuntime()
+	registry := provisioning.NewRegistry(cfg.DefaultProvisioner)
+	registry.Register(provisioning.ProvisionerDocker, dockerAdapter.NewAdapter(
+		docker.NewClient(runtime, docker.Options{DBHost: "host.docker.internal", PortRangeStart: 20000}),
+	))
+
+	ossClient := railzwayclient.New(railzwayclient.Config{
+		BaseURL:   oss.Server.URL,
+		Timeout:   5 * time.Second,
+		RateLimit: 6000,
+		RateBurst: 10,
+	})
+	authClient := authclient.New(authclient.Config{
+		BaseURL:      auth.Server.URL,
+		TenantSlug:   "railzway",
+		ClientID:     "cloud",
+		ClientSecret: "secret",
+	})
+	secrets, err := newSecrets(cfg)
+	require.NoError(t, err)
+	repo := postgres.NewRepository(gdb, secrets)
+	billingAdapter := railzwayoss.NewAdapter(ossClient)
+	profiles := deployment.NewProfileResolver(postgres.NewTierProfileRepository(gdb), billingAdapter)
+	orgService := organization.NewService(gdb)
+	blueGreen := deployment.NewBlueGreenUseCase(repo, postgres.NewUpgradeRepository(gdb), registry, profiles, billingAdapter, billingAdapter, orgService, deployment.RuntimeConfig{}, cfg)
+	deployUC := deployment.NewDeployUseCase(
+		repo,
+		registry,
+		profiles,
+		&testhelper.MockDatabaseProvisioner{},
+		provisioning.DBConfig{Host: "localhost", Port: 5432},
+		deployment.RuntimeConfig{},
+		orgService,
+		billingAdapter,
+		cfg,
+		authClient,
+		nil,
+		postgres.NewSecretRotationRepository(gdb),
+	)
+	lifecycleUC := deployment.NewLifecycleUseCase(repo, registry, profiles, billingAdapter, orgService, deployment.RuntimeConfig{}, cfg, blueGreen)
+	upgradeUC := deployment.NewUpgradeUseCase(repo, registry, profiles, billingAdapter, billingAdapter, orgService, deployment.RuntimeConfig{}, cfg, blueGreen)
+
+	// 1. Onboarding writes the org, the instance and a deploy event.
+	u := user.User{ID: 1, Email: "owner@acme.test", AuthID: "auth-1"}
+	require.NoError(t, gdb.Create(&u).Error)
+	node, err := snowflake.NewNode()
+	require.NoError(t, err)
+	org, err := onboarding.NewService(gdb, cfg, nil, node).InitializeOrganization(ctx, onboarding.InitRequest{
+		UserID:  u.ID,
+		PlanID:  "starter",
+		PriceID: "price_starter",
+		OrgName: "Acme",
+		OrgSlug: "acme",
+	})
+	require.NoError(t, err)
+
+	// 2. The outbox processor bills and deploys the instance.
+	sagas := postgres.NewDeploySagaRepository(gdb)
+	handlers := outbox.NewRegistry()
+	outbox.RegisterHandlers(handlers, outbox.NewDeployHandler(gdb, deployUC, sagas, ossClient, zap.NewNop()), deployUC, lifecycleUC, upgradeUC)
+	processor := outbox.NewProcessor(gdb, handlers, cfg, zap.NewNop())
+	go processor.Run(ctx)
+	require.Eventually(t, func() bool {
+		var event outbox.Event
+		return gdb.Where("org_id = ?", org.ID).First(&event).Error == nil && event.Status == outboxevent.StatusCompleted
+	}, 5*time.Second, 20*time.Millisecond)
+
+	inst, err := repo.FindByOrgID(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, instance.StatusProvisioning, inst.Status)
+	assert.Equal(t, provisioning.ProvisionerDocker, inst.Provisioner)
+	assert.Equal(t, "active", oss.SubscriptionStatus(inst.SubscriptionID))
+
+	saga, err := sagas.FindLatestByInstanceID(ctx, inst.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saga)
+	assert.Equal(t, instance.DeploySagaCompleted, saga.Status)
+	require.Len(t, saga.Steps, len(instance.BillingDeploySteps))
+	for _, step := range saga.Steps {
+		assert.Equal(t, instance.SagaStepCompleted, step.Status, step.Name)
+	}
+
+	container, err := runtime.Inspect(ctx, inst.JobID())
+	require.NoError(t, err)
+	assert.Equal(t, "host.docker.internal", container.Env["DB_HOST"])
+	assert.Equal(t, "test-client-id", container.Env["OAUTH2_CLIENT_ID"])
+	assert.Equal(t, "1000", container.Env["QUOTA_ORG_CUSTOMER"], "product entitlements override the tier quota")
+	assert.Equal(t, []docker.PortBinding{{HostPort: 20000, ContainerPort: 8080}}, container.Ports)
+
+	// 3. The reconciler sees the running container and activates the instance.
+	instances := reconciler.NewInstanceReconciler(repo, postgres.NewUpgradeRepository(gdb), registry, zap.NewNop())
+	go instances.Run(ctx)
+	require.Eventually(t, func() bool {
+		current, err := repo.FindByID(ctx, inst.ID)
+		return err == nil && current.Status == instance.StatusActive
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// 4. Stop and start go through the outbox as well.
+	operations := deployment.NewOperationUseCase(gdb, repo)
+	_, err = operations.Start(ctx, inst.ID)
+	assert.ErrorIs(t, err, instance.ErrInvalidState, "a running instance cannot be started")
+
+	_, err = operations.Stop(ctx, inst.ID)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		current, err := repo.FindByID(ctx, inst.ID)
+		return err == nil && current.Status == instance.StatusStopped
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, "paused", oss.SubscriptionStatus(inst.SubscriptionID))
+	_, err = runtime.Inspect(ctx, inst.JobID())
+	assert.ErrorIs(t, err, docker.ErrNotFound)
+
+	_, err = operations.Start(ctx, inst.ID)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		current, err := repo.FindByID(ctx, inst.ID)
+		return err == nil && current.Status == instance.StatusRunning
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, "active", oss.SubscriptionStatus(inst.SubscriptionID))
+
+	// 5. Rotating secrets redeploys the container with new credentials and
+	// keeps the previous payment provider secret during its grace period.
+	_, err = operations.RotateSecrets(ctx, inst.ID, instance.SecretKinds)
+	require.NoError(t, err)
+	// The rotations are completed once the container runs with them.
+	require.Eventually(t, func() bool {
+		history, err := deployUC.ListSecretRotations(ctx, inst.ID, 0)
+		if err != nil || len(history) != len(instance.SecretKinds) {
+			return false
+		}
+		for _, record := range history {
+			if record.Status != instance.SecretRotationCompleted {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 20*time.Millisecond)
+	rotated, err := repo.FindByID(ctx, inst.ID)
+	require.NoError(t, err)
+	container, err = runtime.Inspect(ctx, inst.JobID())
+	require.NoError(t, err)
+	assert.Equal(t, rotated.DBPassword, container.Env["DB_PASSWORD"])
+	assert.Equal(t, rotated.DatabaseLogin(), container.Env["DB_USER"])
+	assert.Equal(t, inst.DBUser, rotated.PreviousDBLoginUser)
+	assert.Equal(t, "test-client-secret-1", container.Env["OAUTH2_CLIENT_SECRET"])
+	assert.NotEqual(t, inst.PaymentProviderConfigSecret, container.Env["PAYMENT_PROVIDER_CONFIG_SECRET"])
+	assert.Equal(t, inst.PaymentProviderConfigSecret, container.Env["PAYMENT_PROVIDER_CONFIG_PREVIOUS_SECRET"])
+
+	assert.NotEqual(t, inst.DBPassword, container.Env["DB_PASSWORD"])
+
+	// 6. The previous database login is disabled once its grace period ends.
+	require.NoError(t, gdb.Table("instances").Where("id = ?", inst.ID).
+		Update("previous_db_login_expires_at", time.Now().UTC().Add(-time.Minute)).Error)
+	enqueued, err := operations.ExpireSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), enqueued)
+	require.Eventually(t, func() bool {
+		current, err := repo.FindByID(ctx, inst.ID)
+		return err == nil && current.PreviousDBLoginUser == ""
+	}, 5*time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/app"
+	"github.com/railzwaylabs/railzway-cloud/internal/outbox"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+	"github.com/spf13/cobra"
+)
+
+func newOutboxCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outbox",
+		Short: "Inspect the outbox",
+	}
+	cmd.AddCommand(newDeadLettersCmd())
+	return cmd
+}
+
+func newDeadLettersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dead",
+		Short: "Manage events that used up their attempts",
+	}
+	cmd.AddCommand(newDeadListCmd(), newDeadReplayCmd(), newDeadDiscardCmd())
+	return cmd
+}
+
+func newDeadListCmd() *cobra.Command {
+	var limit int
+	var pageToken string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List dead events with their last error",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.RunDeadLetters(func(ctx context.Context, deadLetters *outbox.DeadLetters) error {
+				items, pageInfo, err := deadLetters.List(ctx, pagination.Pagination{PageToken: pageToken, PageSize: limit})
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tTYPE\tINSTANCE\tATTEMPTS\tUPDATED\tLAST ERROR")
+				for _, e := range items {
+					fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\t%s\n",
+						e.ID, e.EventType, e.InstanceID, e.Attempts, e.UpdatedAt.UTC().Format("2006-01-02 15:04:05"), e.LastError)
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				if pageInfo.HasMore {
+					fmt.Fprintf(cmd.OutOrStdout(), "\nmore: --page-token %s\n", pageInfo.NextPageToken)
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 50, "Maximum number of events to list")
+	cmd.Flags().StringVar(&pageToken, "page-token", "", "Continue a previous listing")
+	return cmd
+}
+
+func newDeadReplayCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay EVENT_ID...",
+		Short: "Queue dead events again with their attempts reset",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseEventIDs(args)
+			if err != nil {
+				return err
+			}
+			return app.RunDeadLetters(func(ctx context.Context, deadLetters *outbox.DeadLetters) error {
+				replayed, err := deadLetters.Replay(ctx, ids)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "replayed %d of %d events\n", replayed, len(ids))
+				return nil
+			})
+		},
+	}
+}
+
+func newDeadDiscardCmd() *cobra.Command {
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "discard EVENT_ID...",
+		Short: "Drop dead events, recording the reason in the instance history",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(reason) == "" {
+				return outbox.ErrReasonRequired
+			}
+			ids, err := parseEventIDs(args)
+			if err != nil {
+				return err
+			}
+			return app.RunDeadLetters(func(ctx context.Context, deadLetters *outbox.DeadLetters) error {
+				discarded, err := deadLetters.Discard(ctx, ids, reason)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "discarded %d of %d events\n", discarded, len(ids))
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "Why the events are dropped (required)")
+	return cmd
+}
+
+func parseEventIDs(args []string) ([]int64, error) {
+	ids := make([]int64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
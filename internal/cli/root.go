@@ -29,4 +29,6 @@ func Execute() {
 func init() {
 	rootCmd.AddCommand(newServeCmd())
 	rootCmd.AddCommand(newMigrateCmd())
+	rootCmd.AddCommand(newOutboxCmd())
+	rootCmd.AddCommand(newSecretsCmd())
 }
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/adapter/repository/postgres"
+	"github.com/railzwaylabs/railzway-cloud/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func newSecretsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage the tenant credentials stored for instances",
+	}
+	cmd.AddCommand(newSecretsEncryptCmd(), newSecretsRotateCmd())
+	return cmd
+}
+
+func newSecretsEncryptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt tenant credentials still stored in plaintext",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.RunInstanceSecrets(func(ctx context.Context, repo *postgres.Repository) error {
+				sealed, err := repo.SealPlaintextSecrets(ctx)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "encrypted the credentials of %d instances\n", sealed)
+				return nil
+			})
+		},
+	}
+}
+
+func newSecretsRotateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate",
+		Short: "Re-encrypt tenant credentials with the active key of the keyring",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.RunInstanceSecrets(func(ctx context.Context, repo *postgres.Repository) error {
+				rotated, err := repo.RotateSecrets(ctx)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "re-encrypted the credentials of %d instances\n", rotated)
+				return nil
+			})
+		},
+	}
+}
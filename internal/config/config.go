@@ -21,6 +21,7 @@ type Config struct {
 	AuthCookieDomain            string
 	AdminAPIToken               string
 	InstanceSecretEncryptionKey string
+	InstanceSecretKeyringFile   string
 	AppRootDomain               string
 	AppRootScheme               string
 
@@ -61,6 +62,55 @@ type Config struct {
 	TenantOAuth2ClientSecret string // Shared OAuth secret
 	TenantAuthJWTSecretKey   string // Master key for generating per-org JWT secrets
 
+	// Blue/green upgrades
+	UpgradeWarmupTimeoutSeconds int // How long a standby may take to report ready
+	UpgradeDrainSeconds         int // How long the old job keeps running after traffic is switched
+
+	// Staged rollouts
+	RolloutDefaultWaves       string // Cumulative fleet percentages, e.g. "1,10,50,100"
+	RolloutMinSuccessPercent  int    // Ready instances required before a wave advances
+	RolloutMaxFailurePercent  int    // Failed instances that halt a rollout
+	RolloutWaveBakeSeconds    int    // How long a passed wave soaks before the next one starts
+	RolloutWaveTimeoutSeconds int    // Instances still pending after this count as failed
+
+	// Automatic rollbacks
+	AutoRollbackEnabled          bool
+	RollbackNotReadyGraceSeconds int // How long a serving instance may stay not ready before rolling back
+	RollbackSuspectThreshold     int // Rolled back instances that mark a version suspect
+	RollbackSuspectWindowSeconds int // Window in which rollbacks count towards the threshold
+
+	// Credential rotation
+	SecretRotationGraceSeconds int // How long a rotated credential stays valid next to its successor
+
+	// Product entitlements
+	EntitlementSyncIntervalSeconds int // How often product entitlements are checked for changes; 0 disables
+
+	// Outbox processing
+	OutboxPollIntervalSeconds int // Fallback poll; inserted events wake the processor through LISTEN/NOTIFY
+	OutboxBatchSize           int // Most events claimed at once
+	OutboxConcurrency         int // Events handled at once, one at a time per instance
+
+	// Provisioners
+	DefaultProvisioner     string // Provisioner assigned to new instances ("nomad" or "kubernetes")
+	KubernetesEnabled      bool
+	KubernetesConfigPath   string // Kubeconfig path; empty uses the in-cluster config
+	KubernetesNamespace    string // Namespace holding tenant workloads
+	KubernetesIngressClass string
+	DockerEnabled          bool
+	DockerNetwork          string // Network shared with a local Traefik, if any
+	DockerDBHost           string // Tenant DB host as seen from containers
+	DockerPortRangeStart   int    // Host ports published for tenant containers
+	DockerPortRangeEnd     int
+
+	// Secret delivery to Nomad workloads
+	SecretStore       string // "consul" or "vault"
+	SecretStorePrefix string // Path under which each job keeps its credentials
+	ConsulHTTPAddr    string
+	ConsulHTTPToken   string
+	VaultAddr         string
+	VaultToken        string
+	VaultKVMount      string // KV version 2 engine holding the credentials
+
 	StaticDir string
 }
 
@@ -104,6 +154,7 @@ func Load() *Config {
 		AuthCookieSecret:                strings.TrimSpace(getenv("AUTH_COOKIE_SECRET", "")),
 		AuthCookieDomain:                getenv("AUTH_COOKIE_DOMAIN", ".railzway.com"),
 		InstanceSecretEncryptionKey:     strings.TrimSpace(getenv("INSTANCE_SECRET_ENCRYPTION_KEY", "")),
+		InstanceSecretKeyringFile:       strings.TrimSpace(getenv("INSTANCE_SECRET_KEYRING_FILE", "")),
 		AppRootDomain:                   strings.TrimLeft(strings.TrimSpace(getenv("APP_ROOT_DOMAIN", "")), "."),
 		AppRootScheme:                   strings.TrimSpace(getenv("APP_ROOT_SCHEME", "")),
 		OTLPEndpoint:                    getenv("OTLP_ENDPOINT", "localhost:4317"),
@@ -133,10 +184,46 @@ func Load() *Config {
 		OAuth2URI:                       strings.TrimSpace(getenv("OAUTH2_URI", "")),
 		OAuth2CallbackURL:               callbackURL,
 		// Tenant OAuth for deployed instances
-		TenantOAuth2ClientID:     strings.TrimSpace(getenv("TENANT_OAUTH2_CLIENT_ID", "")),
-		TenantOAuth2ClientSecret: strings.TrimSpace(getenv("TENANT_OAUTH2_CLIENT_SECRET", "")),
-		TenantAuthJWTSecretKey:   strings.TrimSpace(getenv("TENANT_AUTH_JWT_SECRET_KEY", "")),
-		StaticDir:                getenv("STATIC_DIR", "apps/railzway/dist"), // Assumes running from repo root
+		TenantOAuth2ClientID:         strings.TrimSpace(getenv("TENANT_OAUTH2_CLIENT_ID", "")),
+		TenantOAuth2ClientSecret:     strings.TrimSpace(getenv("TENANT_OAUTH2_CLIENT_SECRET", "")),
+		TenantAuthJWTSecretKey:       strings.TrimSpace(getenv("TENANT_AUTH_JWT_SECRET_KEY", "")),
+		UpgradeWarmupTimeoutSeconds:  getenvInt("UPGRADE_WARMUP_TIMEOUT_SECONDS", 600),
+		UpgradeDrainSeconds:          getenvInt("UPGRADE_DRAIN_SECONDS", 60),
+		RolloutDefaultWaves:          getenv("ROLLOUT_DEFAULT_WAVES", "1,10,50,100"),
+		RolloutMinSuccessPercent:     getenvInt("ROLLOUT_MIN_SUCCESS_PERCENT", 95),
+		RolloutMaxFailurePercent:     getenvInt("ROLLOUT_MAX_FAILURE_PERCENT", 5),
+		RolloutWaveBakeSeconds:       getenvInt("ROLLOUT_WAVE_BAKE_SECONDS", 300),
+		RolloutWaveTimeoutSeconds:    getenvInt("ROLLOUT_WAVE_TIMEOUT_SECONDS", 1800),
+		AutoRollbackEnabled:          getenvBool("AUTO_ROLLBACK_ENABLED", true),
+		RollbackNotReadyGraceSeconds: getenvInt("ROLLBACK_NOT_READY_GRACE_SECONDS", 300),
+		RollbackSuspectThreshold:     getenvInt("ROLLBACK_SUSPECT_THRESHOLD", 3),
+		RollbackSuspectWindowSeconds: getenvInt("ROLLBACK_SUSPECT_WINDOW_SECONDS", 86400),
+		DefaultProvisioner:           strings.TrimSpace(getenv("DEFAULT_PROVISIONER", "nomad")),
+		KubernetesEnabled:            getenvBool("KUBERNETES_ENABLED", false),
+		KubernetesConfigPath:         strings.TrimSpace(getenv("KUBECONFIG", "")),
+		KubernetesNamespace:          getenv("KUBERNETES_NAMESPACE", "railzway-tenants"),
+		KubernetesIngressClass:       getenv("KUBERNETES_INGRESS_CLASS", "nginx"),
+		DockerEnabled:                getenvBool("DOCKER_ENABLED", false),
+		DockerNetwork:                strings.TrimSpace(getenv("DOCKER_NETWORK", "")),
+		DockerDBHost:                 strings.TrimSpace(getenv("DOCKER_DB_HOST", "host.docker.internal")),
+		DockerPortRangeStart:         getenvInt("DOCKER_PORT_RANGE_START", 20000),
+		DockerPortRangeEnd:           getenvInt("DOCKER_PORT_RANGE_END", 20999),
+		SecretStore:                  strings.TrimSpace(getenv("SECRET_STORE", "consul")),
+		SecretStorePrefix:            strings.Trim(getenv("SECRET_STORE_PREFIX", "railzway-cloud/workloads"), "/"),
+		ConsulHTTPAddr:               strings.TrimSpace(getenv("CONSUL_HTTP_ADDR", "http://127.0.0.1:8500")),
+		ConsulHTTPToken:              strings.TrimSpace(getenv("CONSUL_HTTP_TOKEN", "")),
+		VaultAddr:                    strings.TrimSpace(getenv("VAULT_ADDR", "http://127.0.0.1:8200")),
+		VaultToken:                   strings.TrimSpace(getenv("VAULT_TOKEN", "")),
+		VaultKVMount:                 strings.TrimSpace(getenv("VAULT_KV_MOUNT", "secret")),
+		StaticDir:                    getenv("STATIC_DIR", "apps/railzway/dist"), // Assumes running from repo root
+
+		EntitlementSyncIntervalSeconds: getenvInt("ENTITLEMENT_SYNC_INTERVAL_SECONDS", 300),
+
+		SecretRotationGraceSeconds: getenvInt("SECRET_ROTATION_GRACE_SECONDS", 86400),
+
+		OutboxPollIntervalSeconds: getenvInt("OUTBOX_POLL_INTERVAL_SECONDS", 5),
+		OutboxBatchSize:           getenvInt("OUTBOX_BATCH_SIZE", 20),
+		OutboxConcurrency:         getenvInt("OUTBOX_CONCURRENCY", 4),
 	}
 
 	return &cfg
@@ -0,0 +1,89 @@
+package cryptoutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrUnknownKey is returned for a key ID the KMS does not hold.
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// KMS wraps the data keys that encrypt credentials under key encryption keys
+// it never hands out. Implementations may call a remote key service.
+type KMS interface {
+	// ActiveKeyID returns the key new data keys are wrapped with.
+	ActiveKeyID() string
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Keyring is a KMS holding its key encryption keys in memory. Retired keys
+// stay in the keyring until nothing is wrapped with them anymore.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// NewKeyring builds a keyring from base64 encoded 32 byte keys by ID.
+func NewKeyring(activeKeyID string, keys map[string]string) (*Keyring, error) {
+	k := &Keyring{active: activeKeyID, keys: make(map[string][]byte, len(keys))}
+	for id, encoded := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		k.keys[id] = key
+	}
+	if _, ok := k.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active key %q: %w", activeKeyID, ErrUnknownKey)
+	}
+	return k, nil
+}
+
+// keyringFile is the JSON layout read by LoadKeyringFile.
+type keyringFile struct {
+	ActiveKeyID string            `json:"active_key_id"`
+	Keys        map[string]string `json:"keys"`
+}
+
+// LoadKeyringFile reads a keyring from a JSON file such as
+//
+//	{"active_key_id": "2026-10", "keys": {"2026-01": "<base64>", "2026-10": "<base64>"}}
+func LoadKeyringFile(path string) (*Keyring, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keyring: %w", err)
+	}
+	var file keyringFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse keyring %s: %w", path, err)
+	}
+	return NewKeyring(file.ActiveKeyID, file.Keys)
+}
+
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+func (k *Keyring) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %q: %w", keyID, ErrUnknownKey)
+	}
+	return sealGCM(key, dataKey, []byte(keyID))
+}
+
+func (k *Keyring) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %q: %w", keyID, ErrUnknownKey)
+	}
+	return openGCM(key, wrapped, []byte(keyID))
+}
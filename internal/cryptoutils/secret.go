@@ -15,30 +15,14 @@ func Encrypt(plaintext, base64Key string) (string, error) {
 	if plaintext == "" {
 		return "", nil
 	}
-	key, err := base64.StdEncoding.DecodeString(base64Key)
+	key, err := decodeKey(base64Key)
 	if err != nil {
-		return "", fmt.Errorf("decode encryption key: %w", err)
-	}
-	if len(key) != keySize {
-		return "", fmt.Errorf("encryption key must be %d bytes", keySize)
+		return "", err
 	}
-
-	block, err := aes.NewCipher(key)
+	payload, err := sealGCM(key, []byte(plaintext), nil)
 	if err != nil {
-		return "", fmt.Errorf("new cipher: %w", err)
-	}
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", fmt.Errorf("new gcm: %w", err)
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", fmt.Errorf("nonce: %w", err)
+		return "", err
 	}
-
-	ciphertext := gcm.Seal(nil, nonce, []byte(plaintext), nil)
-	payload := append(nonce, ciphertext...)
 	return base64.StdEncoding.EncodeToString(payload), nil
 }
 
@@ -46,36 +30,74 @@ func Decrypt(ciphertextB64, base64Key string) (string, error) {
 	if ciphertextB64 == "" {
 		return "", nil
 	}
-	key, err := base64.StdEncoding.DecodeString(base64Key)
+	key, err := decodeKey(base64Key)
 	if err != nil {
-		return "", fmt.Errorf("decode encryption key: %w", err)
-	}
-	if len(key) != keySize {
-		return "", fmt.Errorf("encryption key must be %d bytes", keySize)
+		return "", err
 	}
 
 	raw, err := base64.StdEncoding.DecodeString(ciphertextB64)
 	if err != nil {
 		return "", fmt.Errorf("decode ciphertext: %w", err)
 	}
+	plaintext, err := openGCM(key, raw, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
 
-	block, err := aes.NewCipher(key)
+func decodeKey(base64Key string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(base64Key)
 	if err != nil {
-		return "", fmt.Errorf("new cipher: %w", err)
+		return nil, fmt.Errorf("decode encryption key: %w", err)
 	}
-	gcm, err := cipher.NewGCM(block)
+	if len(key) != keySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes", keySize)
+	}
+	return key, nil
+}
+
+// sealGCM encrypts with AES-256-GCM and returns the nonce followed by the
+// ciphertext.
+func sealGCM(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return "", fmt.Errorf("new gcm: %w", err)
+		return nil, err
 	}
-	if len(raw) < gcm.NonceSize() {
-		return "", fmt.Errorf("ciphertext too short")
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
 	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
 
-	nonce := raw[:gcm.NonceSize()]
-	ciphertext := raw[gcm.NonceSize():]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+func openGCM(key, payload, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return "", fmt.Errorf("decrypt: %w", err)
+		return nil, err
 	}
-	return string(plaintext), nil
+	if len(payload) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce := payload[:gcm.NonceSize()]
+	ciphertext := payload[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("new cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("new gcm: %w", err)
+	}
+	return gcm, nil
 }
@@ -0,0 +1,156 @@
+package cryptoutils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Stored credentials are tagged with their format. Values without a tag are
+// plaintext written before credentials were encrypted at rest.
+//
+//	enc:v1:<nonce+ciphertext>                          the legacy single key
+//	enc:v2:<key id>:<wrapped data key>:<nonce+ciphertext>
+const (
+	sealedPrefix = "enc:"
+	v1Prefix     = sealedPrefix + "v1:"
+	v2Prefix     = sealedPrefix + "v2:"
+)
+
+// ErrKeyRequired is returned when a credential is sealed or opened without the
+// key it needs.
+var ErrKeyRequired = errors.New("INSTANCE_SECRET_ENCRYPTION_KEY or INSTANCE_SECRET_KEYRING_FILE is required")
+
+// Binding is the instance a credential belongs to. It is authenticated with
+// the ciphertext, so a value copied to another instance does not open.
+type Binding struct {
+	OrgID      int64
+	InstanceID int64
+}
+
+func (b Binding) aad() []byte {
+	return fmt.Appendf(nil, "org:%d/instance:%d", b.OrgID, b.InstanceID)
+}
+
+// Secrets encrypts tenant credentials on their way into the database and
+// decrypts them on their way out. Every value gets its own data key, wrapped
+// by the active key of the KMS.
+type Secrets struct {
+	kms       KMS
+	legacyKey string
+}
+
+// NewSecrets creates Secrets backed by kms. legacyKey opens values written
+// before key IDs, until they are rotated; either may be empty.
+func NewSecrets(kms KMS, legacyKey string) *Secrets {
+	return &Secrets{kms: kms, legacyKey: strings.TrimSpace(legacyKey)}
+}
+
+// Seal encrypts a credential for storage. Empty values stay empty.
+func (s *Secrets) Seal(ctx context.Context, plaintext string, b Binding) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	if s.kms == nil {
+		return "", ErrKeyRequired
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("data key: %w", err)
+	}
+	ciphertext, err := sealGCM(dataKey, []byte(plaintext), b.aad())
+	if err != nil {
+		return "", err
+	}
+	keyID := s.kms.ActiveKeyID()
+	wrapped, err := s.kms.WrapKey(ctx, keyID, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("wrap data key: %w", err)
+	}
+	return v2Prefix + keyID + ":" + encode(wrapped) + ":" + encode(ciphertext), nil
+}
+
+// Open decrypts a stored credential. Values that were never sealed are
+// plaintext and returned as they are.
+func (s *Secrets) Open(ctx context.Context, stored string, b Binding) (string, error) {
+	switch {
+	case strings.HasPrefix(stored, v2Prefix):
+		return s.openV2(ctx, strings.TrimPrefix(stored, v2Prefix), b)
+	case strings.HasPrefix(stored, v1Prefix):
+		return s.openLegacy(strings.TrimPrefix(stored, v1Prefix))
+	case IsSealed(stored):
+		return "", fmt.Errorf("unsupported credential format %q", strings.SplitN(stored, ":", 3)[1])
+	default:
+		return stored, nil
+	}
+}
+
+// OpenCiphertext decrypts a credential that was always stored encrypted, so a
+// value without a tag is ciphertext under the legacy key.
+func (s *Secrets) OpenCiphertext(ctx context.Context, stored string, b Binding) (string, error) {
+	if stored == "" || IsSealed(stored) {
+		return s.Open(ctx, stored, b)
+	}
+	return s.openLegacy(stored)
+}
+
+// NeedsRotation reports whether a stored credential is not yet sealed with
+// the active key.
+func (s *Secrets) NeedsRotation(stored string) bool {
+	if stored == "" {
+		return false
+	}
+	if s.kms == nil {
+		return !IsSealed(stored)
+	}
+	return !strings.HasPrefix(stored, v2Prefix+s.kms.ActiveKeyID()+":")
+}
+
+// IsSealed reports whether a stored value was sealed by Secrets.
+func IsSealed(stored string) bool {
+	return strings.HasPrefix(stored, sealedPrefix)
+}
+
+func (s *Secrets) openV2(ctx context.Context, value string, b Binding) (string, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed credential")
+	}
+	if s.kms == nil {
+		return "", ErrKeyRequired
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("decode data key: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("decode ciphertext: %w", err)
+	}
+
+	dataKey, err := s.kms.UnwrapKey(ctx, parts[0], wrapped)
+	if err != nil {
+		return "", fmt.Errorf("unwrap data key: %w", err)
+	}
+	plaintext, err := openGCM(dataKey, ciphertext, b.aad())
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func (s *Secrets) openLegacy(ciphertext string) (string, error) {
+	if s.legacyKey == "" {
+		return "", ErrKeyRequired
+	}
+	return Decrypt(ciphertext, s.legacyKey)
+}
+
+func encode(raw []byte) string {
+	return base64.StdEncoding.EncodeToString(raw)
+}
@@ -0,0 +1,86 @@
+package cryptoutils
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeyring(t *testing.T, active string, keys map[string]string) *Keyring {
+	t.Helper()
+
+	var entries []string
+	for id, key := range keys {
+		entries = append(entries, `"`+id+`": "`+key+`"`)
+	}
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	body := `{"active_key_id": "` + active + `", "keys": {` + strings.Join(entries, ", ") + `}}`
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+
+	keyring, err := LoadKeyringFile(path)
+	require.NoError(t, err)
+	return keyring
+}
+
+func testKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(fill), keySize)))
+}
+
+func TestSecrets_RotatesBetweenKeys(t *testing.T) {
+	ctx := context.Background()
+	b := Binding{OrgID: 1, InstanceID: 2}
+
+	old := NewSecrets(writeKeyring(t, "k1", map[string]string{"k1": testKey('a')}), "")
+	sealed, err := old.Seal(ctx, "hunter2", b)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, "enc:v2:k1:"))
+	assert.False(t, old.NeedsRotation(sealed))
+
+	rotated := NewSecrets(writeKeyring(t, "k2", map[string]string{"k1": testKey('a'), "k2": testKey('b')}), "")
+	assert.True(t, rotated.NeedsRotation(sealed))
+	opened, err := rotated.Open(ctx, sealed, b)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", opened)
+
+	retired := NewSecrets(writeKeyring(t, "k2", map[string]string{"k2": testKey('b')}), "")
+	_, err = retired.Open(ctx, sealed, b)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestSecrets_BindsCiphertextToInstance(t *testing.T) {
+	ctx := context.Background()
+	secrets := NewSecrets(writeKeyring(t, "k1", map[string]string{"k1": testKey('a')}), "")
+
+	sealed, err := secrets.Seal(ctx, "hunter2", Binding{OrgID: 1, InstanceID: 2})
+	require.NoError(t, err)
+	_, err = secrets.Open(ctx, sealed, Binding{OrgID: 1, InstanceID: 3})
+	assert.Error(t, err)
+}
+
+func TestSecrets_OpensLegacyValues(t *testing.T) {
+	ctx := context.Background()
+	legacyKey := testKey('z')
+	secrets := NewSecrets(writeKeyring(t, "k1", map[string]string{"k1": testKey('a')}), legacyKey)
+
+	legacy, err := Encrypt("hunter2", legacyKey)
+	require.NoError(t, err)
+
+	opened, err := secrets.Open(ctx, "enc:v1:"+legacy, Binding{})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", opened)
+	assert.True(t, secrets.NeedsRotation("enc:v1:"+legacy))
+
+	opened, err = secrets.OpenCiphertext(ctx, legacy, Binding{})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", opened)
+
+	plaintext, err := secrets.Open(ctx, "hunter2", Binding{})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+}
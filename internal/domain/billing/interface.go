@@ -19,6 +19,10 @@ type ChangePlanParams struct {
 	ProrationBehavior ProrationBehavior
 	EffectiveDate     string // "immediate" or specific date
 	CancelAtPeriodEnd bool
+
+	// IdempotencyKey, when set, makes repeating the change with the same key
+	// apply it once.
+	IdempotencyKey string
 }
 
 // PriceResolver defines the interface for resolving Price IDs for given tiers.
@@ -26,13 +30,26 @@ type PriceResolver interface {
 	ResolvePriceID(ctx context.Context, tier string) (string, error)
 }
 
+// EntitlementResolver resolves the entitlements granted by the product of a
+// price, as described in docs/entitlements.md.
+type EntitlementResolver interface {
+	// ResolveEntitlements returns the product's metadata.entitlements, or nil
+	// when the product defines none.
+	ResolveEntitlements(ctx context.Context, priceID string) (map[string]any, error)
+}
+
 // Engine defines the interface for interacting with the billing system (Railzway OSS).
+// Mutating methods take an idempotency key: repeating a call with the same
+// non-empty key applies it once.
 type Engine interface {
 	// PauseSubscription pauses billing for a subscription.
-	PauseSubscription(ctx context.Context, subscriptionID string) error
+	PauseSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error
 
 	// ResumeSubscription resumes billing for a subscription.
-	ResumeSubscription(ctx context.Context, subscriptionID string) error
+	ResumeSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error
+
+	// CancelSubscription ends billing for a subscription immediately.
+	CancelSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error
 	GetSubscriptionStatus(ctx context.Context, subscriptionID string) (string, error)
 
 	// ChangePlan updates the subscription plan/price.
@@ -0,0 +1,209 @@
+package instance
+
+import (
+	"slices"
+	"time"
+)
+
+// DeployStep is one step of the first deploy of an instance. Every step is
+// idempotent and has a compensating action, run in reverse order when the
+// deploy is given up. Only what the saga created is compensated; a database
+// or subscription that existed before it is left alone.
+type DeployStep string
+
+const (
+	DeployStepEnsureCustomer       DeployStep = "ensure_customer"       // Compensation: none, the customer is reused
+	DeployStepCreateSubscription   DeployStep = "create_subscription"   // Compensation: cancel the subscription
+	DeployStepActivateSubscription DeployStep = "activate_subscription" // Compensation: covered by the cancellation
+	DeployStepProvisionDatabase    DeployStep = "provision_database"    // Compensation: drop the database and user
+	DeployStepDeployWorkload       DeployStep = "deploy_workload"       // Compensation: stop the workload
+)
+
+// BillingDeploySteps deploy the default environment, which carries the
+// organization's subscription.
+var BillingDeploySteps = []DeployStep{
+	DeployStepEnsureCustomer,
+	DeployStepCreateSubscription,
+	DeployStepActivateSubscription,
+	DeployStepProvisionDatabase,
+	DeployStepDeployWorkload,
+}
+
+// EnvironmentDeploySteps deploy additional environments, which are covered by
+// the production subscription.
+var EnvironmentDeploySteps = []DeployStep{
+	DeployStepProvisionDatabase,
+	DeployStepDeployWorkload,
+}
+
+// SagaStepStatus is the progress of a single deploy step.
+type SagaStepStatus string
+
+const (
+	SagaStepPending     SagaStepStatus = "pending"
+	SagaStepCompleted   SagaStepStatus = "completed"
+	SagaStepFailed      SagaStepStatus = "failed"
+	SagaStepCompensated SagaStepStatus = "compensated"
+)
+
+// DeploySagaStatus is the progress of a deploy as a whole.
+type DeploySagaStatus string
+
+const (
+	DeploySagaRunning     DeploySagaStatus = "running"
+	DeploySagaCompleted   DeploySagaStatus = "completed"
+	DeploySagaCompensated DeploySagaStatus = "compensated"
+)
+
+// SagaStep is the checkpoint of one deploy step.
+type SagaStep struct {
+	Name          DeployStep     `json:"name"`
+	Status        SagaStepStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	Created       bool           `json:"created,omitempty"` // The step found its resource missing, so the saga owns what it made
+	Error         string         `json:"error,omitempty"`
+	CompletedAt   *time.Time     `json:"completed_at,omitempty"`
+	CompensatedAt *time.Time     `json:"compensated_at,omitempty"`
+}
+
+// DeploySaga records the steps of the first deploy of an instance, as run by
+// one outbox event. A retried event resumes at the first step that has not
+// completed.
+type DeploySaga struct {
+	ID         int64
+	EventID    int64
+	InstanceID int64
+	OrgID      int64
+	Status     DeploySagaStatus
+	Steps      []SagaStep
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// NewDeploySaga creates a saga for the deploy run by an outbox event.
+func NewDeploySaga(eventID int64, inst *Instance, steps []DeployStep, now time.Time) *DeploySaga {
+	saga := &DeploySaga{
+		EventID:    eventID,
+		InstanceID: inst.ID,
+		OrgID:      inst.OrgID,
+		Status:     DeploySagaRunning,
+		Steps:      make([]SagaStep, 0, len(steps)),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	for _, name := range steps {
+		saga.Steps = append(saga.Steps, SagaStep{Name: name, Status: SagaStepPending})
+	}
+	return saga
+}
+
+// CurrentStep returns the first step that has not completed, or "" when all
+// have.
+func (s *DeploySaga) CurrentStep() DeployStep {
+	for _, step := range s.Steps {
+		if step.Status != SagaStepCompleted {
+			return step.Name
+		}
+	}
+	return ""
+}
+
+// StartStep counts an attempt of a step. creates tells whether the resource
+// of the step is missing, so the step creates it. A retry that finds what an
+// earlier attempt created keeps the step as its creator.
+func (s *DeploySaga) StartStep(name DeployStep, creates bool, now time.Time) {
+	s.update(name, now, func(step *SagaStep) {
+		step.Attempts++
+		step.Created = step.Created || creates
+	})
+}
+
+// Created reports whether a step created its resource.
+func (s *DeploySaga) Created(name DeployStep) bool {
+	for _, step := range s.Steps {
+		if step.Name == name {
+			return step.Created
+		}
+	}
+	return false
+}
+
+// CompleteStep checkpoints a step as done, so retries skip it.
+func (s *DeploySaga) CompleteStep(name DeployStep, now time.Time) {
+	s.update(name, now, func(step *SagaStep) {
+		step.Status = SagaStepCompleted
+		step.Error = ""
+		step.CompletedAt = &now
+	})
+}
+
+// FailStep records the error of a step. A retry runs it again.
+func (s *DeploySaga) FailStep(name DeployStep, errMsg string, now time.Time) {
+	s.update(name, now, func(step *SagaStep) {
+		step.Status = SagaStepFailed
+		step.Error = errMsg
+	})
+}
+
+// CompensateStep records that the effect of a step was undone.
+func (s *DeploySaga) CompensateStep(name DeployStep, now time.Time) {
+	s.update(name, now, func(step *SagaStep) {
+		step.Status = SagaStepCompensated
+		step.CompensatedAt = &now
+	})
+}
+
+// StepsToCompensate returns the steps that may have created their resource,
+// last first.
+func (s *DeploySaga) StepsToCompensate() []DeployStep {
+	var steps []DeployStep
+	for _, step := range slices.Backward(s.Steps) {
+		if !step.Created {
+			continue
+		}
+		if step.Status == SagaStepCompleted || step.Status == SagaStepFailed {
+			steps = append(steps, step.Name)
+		}
+	}
+	return steps
+}
+
+// MarkCompleted transitions the saga to completed.
+func (s *DeploySaga) MarkCompleted(now time.Time) {
+	s.Status = DeploySagaCompleted
+	s.UpdatedAt = now
+}
+
+// MarkCompensated transitions the saga to compensated.
+func (s *DeploySaga) MarkCompensated(now time.Time) {
+	s.Status = DeploySagaCompensated
+	s.UpdatedAt = now
+}
+
+// Restart runs a compensated saga again from the first step, as when its
+// dead event is replayed. Attempts keep counting.
+func (s *DeploySaga) Restart(now time.Time) {
+	s.Status = DeploySagaRunning
+	for i := range s.Steps {
+		s.Steps[i].Status = SagaStepPending
+		s.Steps[i].Error = ""
+		s.Steps[i].CompletedAt = nil
+		s.Steps[i].CompensatedAt = nil
+	}
+	s.UpdatedAt = now
+}
+
+// IsFinished reports whether the saga completed or was compensated.
+func (s *DeploySaga) IsFinished() bool {
+	return s.Status == DeploySagaCompleted || s.Status == DeploySagaCompensated
+}
+
+func (s *DeploySaga) update(name DeployStep, now time.Time, mutate func(*SagaStep)) {
+	for i := range s.Steps {
+		if s.Steps[i].Name == name {
+			mutate(&s.Steps[i])
+			s.UpdatedAt = now
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploySaga_ResumesAtFailedStep(t *testing.T) {
+	now := time.Now().UTC()
+	saga := NewDeploySaga(5, &Instance{ID: 1, OrgID: 2}, BillingDeploySteps, now)
+	assert.Equal(t, DeployStepEnsureCustomer, saga.CurrentStep())
+
+	saga.StartStep(DeployStepEnsureCustomer, true, now)
+	saga.CompleteStep(DeployStepEnsureCustomer, now)
+	saga.StartStep(DeployStepCreateSubscription, true, now)
+	saga.FailStep(DeployStepCreateSubscription, "oss unavailable", now)
+
+	assert.Equal(t, DeployStepCreateSubscription, saga.CurrentStep())
+	assert.Equal(t, "oss unavailable", saga.Steps[1].Error)
+	assert.False(t, saga.IsFinished())
+
+	saga.StartStep(DeployStepCreateSubscription, false, now)
+	saga.CompleteStep(DeployStepCreateSubscription, now)
+	assert.Equal(t, 2, saga.Steps[1].Attempts)
+	assert.True(t, saga.Created(DeployStepCreateSubscription))
+	assert.Empty(t, saga.Steps[1].Error)
+	assert.Equal(t, DeployStepActivateSubscription, saga.CurrentStep())
+}
+
+func TestDeploySaga_CompensatesStepsInReverse(t *testing.T) {
+	now := time.Now().UTC()
+	saga := NewDeploySaga(5, &Instance{ID: 1, OrgID: 2}, BillingDeploySteps, now)
+	for _, step := range BillingDeploySteps[:3] {
+		saga.StartStep(step, true, now)
+		saga.CompleteStep(step, now)
+	}
+	saga.StartStep(DeployStepProvisionDatabase, true, now)
+	saga.FailStep(DeployStepProvisionDatabase, "disk full", now)
+
+	assert.Equal(t, []DeployStep{
+		DeployStepProvisionDatabase,
+		DeployStepActivateSubscription,
+		DeployStepCreateSubscription,
+		DeployStepEnsureCustomer,
+	}, saga.StepsToCompensate())
+
+	saga.CompensateStep(DeployStepProvisionDatabase, now)
+	saga.MarkCompensated(now)
+	assert.Equal(t, SagaStepCompensated, saga.Steps[3].Status)
+	assert.Equal(t, SagaStepPending, saga.Steps[4].Status)
+	assert.True(t, saga.IsFinished())
+}
+
+func TestDeploySaga_KeepsResourcesItDidNotCreate(t *testing.T) {
+	now := time.Now().UTC()
+	saga := NewDeploySaga(5, &Instance{ID: 1, OrgID: 2}, BillingDeploySteps, now)
+	saga.StartStep(DeployStepEnsureCustomer, false, now)
+	saga.CompleteStep(DeployStepEnsureCustomer, now)
+	saga.StartStep(DeployStepCreateSubscription, false, now)
+	saga.CompleteStep(DeployStepCreateSubscription, now)
+	saga.StartStep(DeployStepActivateSubscription, false, now)
+	saga.CompleteStep(DeployStepActivateSubscription, now)
+	saga.StartStep(DeployStepProvisionDatabase, false, now)
+	saga.CompleteStep(DeployStepProvisionDatabase, now)
+	saga.StartStep(DeployStepDeployWorkload, true, now)
+	saga.FailStep(DeployStepDeployWorkload, "nomad unavailable", now)
+
+	assert.Equal(t, []DeployStep{DeployStepDeployWorkload}, saga.StepsToCompensate())
+}
@@ -2,6 +2,9 @@ package instance
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -77,9 +80,46 @@ const (
 	StatusTerminated         InstanceStatus = "terminated"
 )
 
+// DefaultEnvironment is the environment of the instance created during onboarding.
+const DefaultEnvironment = "production"
+
+var environmentPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,19}$`)
+
+// MaxInstancesPerTier caps non-terminated environments per organization
+// (infra.namespaces.max in docs/entitlements.md).
+var MaxInstancesPerTier = map[Tier]int{
+	TierFreeTrial:  1,
+	TierStarter:    1,
+	TierPro:        1,
+	TierTeam:       3,
+	TierEnterprise: 3,
+}
+
+// DatabaseLimits bound what the database user of an instance may use, so one
+// tenant cannot exhaust the shared Postgres server.
+type DatabaseLimits struct {
+	ConnectionLimit  int           // Concurrent connections of the user
+	StatementTimeout time.Duration // Longest statement the user may run
+}
+
+// DatabaseLimitsPerTier are the database limits of each tier. Workloads keep
+// their connection pools below ConnectionLimit, leaving room for a rolling
+// update to run old and new allocations side by side.
+var DatabaseLimitsPerTier = map[Tier]DatabaseLimits{
+	TierFreeTrial:  {ConnectionLimit: 10, StatementTimeout: 15 * time.Second},
+	TierStarter:    {ConnectionLimit: 20, StatementTimeout: 30 * time.Second},
+	TierPro:        {ConnectionLimit: 50, StatementTimeout: 60 * time.Second},
+	TierTeam:       {ConnectionLimit: 100, StatementTimeout: 2 * time.Minute},
+	TierEnterprise: {ConnectionLimit: 200, StatementTimeout: 5 * time.Minute},
+}
+
 var (
-	ErrInvalidTierUpgrade = errors.New("invalid tier upgrade")
-	ErrInvalidState       = errors.New("invalid instance state for operation")
+	ErrInvalidTierUpgrade   = errors.New("invalid tier upgrade")
+	ErrInvalidState         = errors.New("invalid instance state for operation")
+	ErrInvalidEnvironment   = errors.New("invalid environment name")
+	ErrEnvironmentExists    = errors.New("environment already exists")
+	ErrInstanceLimitReached = errors.New("instance limit reached for tier")
+	ErrConflict             = errors.New("instance was modified concurrently")
 )
 
 // Instance is the core domain entity.
@@ -87,6 +127,7 @@ var (
 type Instance struct {
 	ID                 int64           `gorm:"column:id" json:"id,string"`
 	OrgID              int64           `gorm:"column:org_id" json:"org_id,string"`
+	Environment        string          `gorm:"column:environment" json:"environment"`
 	NomadJobID         string          `gorm:"column:nomad_job_id" json:"nomad_job_id"`
 	DesiredVersion     string          `gorm:"column:desired_version" json:"desired_version"`
 	CurrentVersion     string          `gorm:"column:current_version" json:"current_version"`
@@ -96,17 +137,26 @@ type Instance struct {
 	Readiness          ReadinessStatus `gorm:"column:readiness_status" json:"readiness_status"`
 	ReadinessCheckedAt *time.Time      `gorm:"column:readiness_checked_at" json:"readiness_checked_at,omitempty"`
 	ReadinessError     string          `gorm:"column:readiness_error" json:"readiness_error,omitempty"`
+	NotReadySince      *time.Time      `gorm:"column:not_ready_since" json:"not_ready_since,omitempty"`
+	LastGoodVersion    string          `gorm:"column:last_good_version" json:"last_good_version"` // Last version observed serving and ready
 	Tier               Tier            `gorm:"column:tier" json:"tier"`
 	ComputeEngine      ComputeEngine   `gorm:"column:compute_engine" json:"compute_engine"`
+	Provisioner        string          `gorm:"column:provisioner" json:"provisioner"` // Orchestrator running the workloads, empty means the default
 	PlanID             string          `gorm:"column:plan_id" json:"plan_id"`
 	PriceID            string          `gorm:"column:price_id" json:"price_id"`
 	SubscriptionID     string          `gorm:"column:subscription_id" json:"subscription_id"` // Reference to Railzway OSS Subscription
 	LaunchURL          string          `gorm:"column:launch_url" json:"launch_url"`
 	LastError          string          `gorm:"column:last_error" json:"last_error,omitempty"`
 
-	OAuthClientID                        string `gorm:"column:oauth_client_id" json:"-"`
-	OAuthClientSecret                    string `gorm:"column:oauth_client_secret" json:"-"`
-	PaymentProviderConfigSecretEncrypted string `gorm:"column:payment_provider_config_secret" json:"-"` // Encrypted at rest
+	// Credentials are encrypted at rest by the repository and plaintext here.
+	OAuthClientID               string `gorm:"column:oauth_client_id" json:"-"`
+	OAuthClientSecret           string `gorm:"column:oauth_client_secret" json:"-"`
+	PaymentProviderConfigSecret string `gorm:"column:payment_provider_config_secret" json:"-"`
+
+	// PreviousPaymentProviderSecret is the payment provider secret replaced by
+	// the last rotation, deployed next to the new one until it expires.
+	PreviousPaymentProviderSecret          string     `gorm:"column:previous_payment_provider_secret" json:"-"`
+	PreviousPaymentProviderSecretExpiresAt *time.Time `gorm:"column:previous_payment_provider_secret_expires_at" json:"-"`
 
 	// Database Details (Managed by Railzway Cloud)
 	DBHost     string `gorm:"column:db_host" json:"db_host"`
@@ -115,6 +165,26 @@ type Instance struct {
 	DBUser     string `gorm:"column:db_user" json:"db_user"`
 	DBPassword string `gorm:"column:db_password" json:"-"` // Encrypted at rest, do not expose
 
+	// DBLoginUser is the role the workload logs in as with DBPassword. It is a
+	// member of DBUser, which owns the database. Empty means DBUser itself,
+	// until the password is first rotated.
+	DBLoginUser string `gorm:"column:db_login_user" json:"-"`
+	// PreviousDBLoginUser is the login replaced by the last rotation. It keeps
+	// its password until PreviousDBLoginExpiresAt, then it is disabled.
+	PreviousDBLoginUser      string     `gorm:"column:previous_db_login_user" json:"-"`
+	PreviousDBLoginExpiresAt *time.Time `gorm:"column:previous_db_login_expires_at" json:"-"`
+
+	// ResourceVersion is bumped on every write; Save only succeeds when it
+	// still matches the stored row.
+	ResourceVersion int64 `gorm:"column:resource_version" json:"resource_version,string"`
+
+	// TierProfileRevision is the tier profile revision of the last deploy,
+	// 0 when it used the built-in profile.
+	TierProfileRevision int `gorm:"column:tier_profile_revision" json:"tier_profile_revision"`
+	// EntitlementsHash fingerprints the product entitlements of the last
+	// deploy, empty when none applied.
+	EntitlementsHash string `gorm:"column:entitlements_hash" json:"-"`
+
 	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
@@ -123,6 +193,7 @@ type Instance struct {
 func NewInstance(orgID int64, tier Tier, engine ComputeEngine, version string) *Instance {
 	return &Instance{
 		OrgID:          orgID,
+		Environment:    DefaultEnvironment,
 		Tier:           tier,
 		ComputeEngine:  engine,
 		DesiredVersion: version,
@@ -135,6 +206,55 @@ func NewInstance(orgID int64, tier Tier, engine ComputeEngine, version string) *
 	}
 }
 
+// ValidateEnvironment checks that an environment name is safe to use in job,
+// database and host names.
+func ValidateEnvironment(env string) error {
+	if !environmentPattern.MatchString(env) {
+		return fmt.Errorf("%w: %q", ErrInvalidEnvironment, env)
+	}
+	return nil
+}
+
+// MaxInstances returns how many environments an org on the given tier may run.
+func MaxInstances(tier Tier) int {
+	if limit, ok := MaxInstancesPerTier[tier]; ok {
+		return limit
+	}
+	return 1
+}
+
+// DatabaseLimits returns the database limits of the instance's tier. Unknown
+// tiers get the Free Trial limits.
+func (i *Instance) DatabaseLimits() DatabaseLimits {
+	if limits, ok := DatabaseLimitsPerTier[i.Tier]; ok {
+		return limits
+	}
+	return DatabaseLimitsPerTier[TierFreeTrial]
+}
+
+// IsDefaultEnvironment reports whether the instance is the org's production environment.
+// Its job, database and host names keep the original per-org naming.
+func (i *Instance) IsDefaultEnvironment() bool {
+	return i.Environment == "" || i.Environment == DefaultEnvironment
+}
+
+// HostLabel returns the subdomain label the instance is served under.
+func (i *Instance) HostLabel(orgSlug string) string {
+	if i.IsDefaultEnvironment() {
+		return orgSlug
+	}
+	return orgSlug + "-" + i.Environment
+}
+
+// DatabaseNames returns the tenant database and user names of the instance.
+func (i *Instance) DatabaseNames() (dbName string, dbUser string) {
+	if i.IsDefaultEnvironment() {
+		return fmt.Sprintf("railzway_org_%d", i.OrgID), fmt.Sprintf("railzway_user_%d", i.OrgID)
+	}
+	suffix := strings.ReplaceAll(i.Environment, "-", "_")
+	return fmt.Sprintf("railzway_org_%d_%s", i.OrgID, suffix), fmt.Sprintf("railzway_user_%d_%s", i.OrgID, suffix)
+}
+
 // CanTransitionLifecycle enforces the lifecycle state machine.
 func CanTransitionLifecycle(current, target LifecycleState) bool {
 	if current == target || target == "" {
@@ -214,6 +334,12 @@ func (i *Instance) MarkStopped() {
 	i.UpdatedAt = time.Now().UTC()
 }
 
+// MarkTerminated transitions the instance to Terminated state.
+func (i *Instance) MarkTerminated() {
+	i.Status = StatusTerminated
+	i.UpdatedAt = time.Now().UTC()
+}
+
 // MarkUpgrading transitions to Upgrading state.
 func (i *Instance) MarkUpgrading(targetTier Tier) {
 	i.Tier = targetTier
@@ -175,3 +175,39 @@ func TestErrors(t *testing.T) {
 	assert.Contains(t, ErrInvalidTierUpgrade.Error(), "invalid tier upgrade")
 	assert.Contains(t, ErrInvalidState.Error(), "invalid instance state")
 }
+
+func TestValidateEnvironment(t *testing.T) {
+	assert.NoError(t, ValidateEnvironment("production"))
+	assert.NoError(t, ValidateEnvironment("staging"))
+	assert.NoError(t, ValidateEnvironment("qa-2"))
+
+	assert.ErrorIs(t, ValidateEnvironment(""), ErrInvalidEnvironment)
+	assert.ErrorIs(t, ValidateEnvironment("Staging"), ErrInvalidEnvironment)
+	assert.ErrorIs(t, ValidateEnvironment("2nd"), ErrInvalidEnvironment)
+	assert.ErrorIs(t, ValidateEnvironment("staging_eu"), ErrInvalidEnvironment)
+}
+
+func TestEnvironmentNaming(t *testing.T) {
+	prod := &Instance{OrgID: 42, Environment: DefaultEnvironment}
+	staging := &Instance{OrgID: 42, Environment: "pre-prod"}
+
+	assert.Equal(t, "acme", prod.HostLabel("acme"))
+	assert.Equal(t, "acme-pre-prod", staging.HostLabel("acme"))
+
+	name, user := prod.DatabaseNames()
+	assert.Equal(t, "railzway_org_42", name)
+	assert.Equal(t, "railzway_user_42", user)
+
+	name, user = staging.DatabaseNames()
+	assert.Equal(t, "railzway_org_42_pre_prod", name)
+	assert.Equal(t, "railzway_user_42_pre_prod", user)
+
+	assert.Equal(t, "railzway-org-42", prod.JobID())
+	assert.Equal(t, "railzway-org-42-pre-prod", staging.JobID())
+}
+
+func TestMaxInstances(t *testing.T) {
+	assert.Equal(t, 1, MaxInstances(TierStarter))
+	assert.Equal(t, 3, MaxInstances(TierTeam))
+	assert.Equal(t, 1, MaxInstances(Tier("unknown")))
+}
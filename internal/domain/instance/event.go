@@ -0,0 +1,125 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidPageToken is returned when an event page token cannot be decoded.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// ActorType identifies who caused an instance state change.
+type ActorType string
+
+const (
+	ActorUser       ActorType = "user"
+	ActorAdmin      ActorType = "admin"
+	ActorReconciler ActorType = "reconciler"
+	ActorOutbox     ActorType = "outbox"
+	ActorSystem     ActorType = "system"
+)
+
+// Actor is recorded on every instance event. ID is the user ID, the outbox
+// event ID or the reconciler name depending on the type.
+type Actor struct {
+	Type ActorType
+	ID   string
+}
+
+type actorKey struct{}
+
+// WithActor attaches the actor responsible for state changes made with ctx.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor attached to ctx, or the system actor.
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok && actor.Type != "" {
+		return actor
+	}
+	return Actor{Type: ActorSystem}
+}
+
+// Event is an append-only record of an instance state change, or of an
+// operator action on the instance that carries a reason.
+type Event struct {
+	ID                int64          `gorm:"primaryKey" json:"id,string"`
+	InstanceID        int64          `gorm:"column:instance_id" json:"instance_id,string"`
+	OrgID             int64          `gorm:"column:org_id" json:"org_id,string"`
+	ActorType         ActorType      `gorm:"column:actor_type" json:"actor_type"`
+	ActorID           string         `gorm:"column:actor_id" json:"actor_id,omitempty"`
+	OldStatus         InstanceStatus `gorm:"column:old_status" json:"old_status,omitempty"`
+	NewStatus         InstanceStatus `gorm:"column:new_status" json:"new_status"`
+	OldLifecycleState LifecycleState `gorm:"column:old_lifecycle_state" json:"old_lifecycle_state,omitempty"`
+	LifecycleState    LifecycleState `gorm:"column:lifecycle_state" json:"lifecycle_state"`
+	DesiredVersion    string         `gorm:"column:desired_version" json:"desired_version,omitempty"`
+	CurrentVersion    string         `gorm:"column:current_version" json:"current_version,omitempty"`
+	Error             string         `gorm:"column:error" json:"error,omitempty"`
+	Reason            string         `gorm:"column:reason" json:"reason,omitempty"`
+	CreatedAt         time.Time      `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Event) TableName() string {
+	return "instance_events"
+}
+
+// NewEvent describes the change from prev to next, or returns nil when none of
+// the audited fields changed. prev is nil when the instance is created.
+// Readiness probes are not audited; they change on every reconcile.
+func NewEvent(prev, next *Instance, actor Actor, now time.Time) *Event {
+	if next == nil {
+		return nil
+	}
+
+	event := &Event{
+		InstanceID:     next.ID,
+		OrgID:          next.OrgID,
+		ActorType:      actor.Type,
+		ActorID:        actor.ID,
+		NewStatus:      next.Status,
+		LifecycleState: next.LifecycleState,
+		DesiredVersion: next.DesiredVersion,
+		CurrentVersion: next.CurrentVersion,
+		CreatedAt:      now,
+	}
+	if prev == nil {
+		event.Error = next.LastError
+		return event
+	}
+
+	event.OldStatus = prev.Status
+	event.OldLifecycleState = prev.LifecycleState
+	if next.LastError != prev.LastError {
+		event.Error = next.LastError
+	}
+
+	if prev.Status == next.Status &&
+		prev.LifecycleState == next.LifecycleState &&
+		prev.DesiredVersion == next.DesiredVersion &&
+		prev.CurrentVersion == next.CurrentVersion &&
+		prev.LastError == next.LastError {
+		return nil
+	}
+	return event
+}
+
+// NewActionEvent records an operator action that leaves the instance state
+// unchanged, such as discarding one of its queued operations.
+func NewActionEvent(inst *Instance, actor Actor, reason string, now time.Time) *Event {
+	return &Event{
+		InstanceID:        inst.ID,
+		OrgID:             inst.OrgID,
+		ActorType:         actor.Type,
+		ActorID:           actor.ID,
+		OldStatus:         inst.Status,
+		NewStatus:         inst.Status,
+		OldLifecycleState: inst.LifecycleState,
+		LifecycleState:    inst.LifecycleState,
+		DesiredVersion:    inst.DesiredVersion,
+		CurrentVersion:    inst.CurrentVersion,
+		Reason:            reason,
+		CreatedAt:         now,
+	}
+}
@@ -0,0 +1,53 @@
+package instance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, Actor{Type: ActorSystem}, ActorFromContext(context.Background()))
+
+	ctx := WithActor(context.Background(), Actor{Type: ActorUser, ID: "42"})
+	assert.Equal(t, Actor{Type: ActorUser, ID: "42"}, ActorFromContext(ctx))
+}
+
+func TestNewEvent(t *testing.T) {
+	now := time.Now().UTC()
+	actor := Actor{Type: ActorOutbox, ID: "9"}
+	inst := NewInstance(1, TierStarter, EngineGCP, "v1")
+	inst.ID = 5
+
+	created := NewEvent(nil, inst, actor, now)
+	require.NotNil(t, created)
+	assert.Empty(t, created.OldStatus)
+	assert.Equal(t, StatusInit, created.NewStatus)
+	assert.Equal(t, ActorOutbox, created.ActorType)
+	assert.Equal(t, "9", created.ActorID)
+
+	prev := *inst
+	inst.MarkProvisionFailed("nomad unavailable")
+	failed := NewEvent(&prev, inst, actor, now)
+	require.NotNil(t, failed)
+	assert.Equal(t, StatusInit, failed.OldStatus)
+	assert.Equal(t, StatusProvisionFailed, failed.NewStatus)
+	assert.Equal(t, "nomad unavailable", failed.Error)
+	assert.Equal(t, "v1", failed.DesiredVersion)
+
+	// Readiness probes alone are not audited.
+	prev = *inst
+	inst.ObserveReadiness(ReadinessNotReady, "connection refused", now)
+	assert.Nil(t, NewEvent(&prev, inst, actor, now))
+
+	prev = *inst
+	inst.LifecycleState = LifecycleMigrating
+	moved := NewEvent(&prev, inst, actor, now)
+	require.NotNil(t, moved)
+	assert.Equal(t, LifecycleReady, moved.OldLifecycleState)
+	assert.Equal(t, LifecycleMigrating, moved.LifecycleState)
+	assert.Empty(t, moved.Error, "unchanged error is not repeated")
+}
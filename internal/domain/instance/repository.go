@@ -1,18 +1,91 @@
 package instance
 
-import "context"
+import (
+	"context"
+
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+)
 
 // Repository defines the interface for persisting Instance entities.
 type Repository interface {
-	// FindByOrgID retrieves an instance by its Organization ID.
+	// FindByID retrieves an instance by its ID.
+	FindByID(ctx context.Context, id int64) (*Instance, error)
+
+	// FindByOrgID retrieves the primary production instance of an Organization.
 	FindByOrgID(ctx context.Context, orgID int64) (*Instance, error)
 
-	// Save persists an instance (create or update).
+	// ListByOrgID retrieves all instances of an Organization.
+	ListByOrgID(ctx context.Context, orgID int64) ([]*Instance, error)
+
+	// Save persists an instance (create or update). Updates are compare-and-swap
+	// on ResourceVersion and return ErrConflict when the instance changed since
+	// it was loaded; on success ResourceVersion is advanced.
 	Save(ctx context.Context, instance *Instance) error
 
-	// UpdateStatus updates only the status of an instance.
-	UpdateStatus(ctx context.Context, orgID int64, status InstanceStatus) error
+	// UpdateStatus updates only the status of an instance, regardless of its
+	// ResourceVersion.
+	UpdateStatus(ctx context.Context, id int64, status InstanceStatus) error
 
 	// ListByStatus retrieves instances matching any of the provided statuses.
 	ListByStatus(ctx context.Context, statuses []InstanceStatus, limit int) ([]*Instance, error)
 }
+
+// UpgradeRepository defines the interface for persisting blue/green upgrades.
+type UpgradeRepository interface {
+	// Create persists a new upgrade. It returns ErrUpgradeInProgress when the
+	// instance already has an active upgrade.
+	Create(ctx context.Context, upgrade *Upgrade) error
+
+	// Save updates an existing upgrade.
+	Save(ctx context.Context, upgrade *Upgrade) error
+
+	// SaveReadiness records the standby readiness of an upgrade that is still
+	// warming. It never touches the phase, so a probe that raced with a phase
+	// change is dropped instead of moving the upgrade back.
+	SaveReadiness(ctx context.Context, upgrade *Upgrade) error
+
+	// FindActiveByInstanceID retrieves the active upgrade of an instance, if any.
+	FindActiveByInstanceID(ctx context.Context, instanceID int64) (*Upgrade, error)
+
+	// ListActive retrieves upgrades that have not completed or rolled back.
+	ListActive(ctx context.Context, limit int) ([]*Upgrade, error)
+}
+
+// DeploySagaRepository persists the step checkpoints of instance deploys.
+type DeploySagaRepository interface {
+	// Create persists a new saga.
+	Create(ctx context.Context, saga *DeploySaga) error
+
+	// Save updates an existing saga.
+	Save(ctx context.Context, saga *DeploySaga) error
+
+	// FindByEventID retrieves the saga run by an outbox event, if any.
+	FindByEventID(ctx context.Context, eventID int64) (*DeploySaga, error)
+
+	// FindLatestByInstanceID retrieves the most recent saga of an instance, if any.
+	FindLatestByInstanceID(ctx context.Context, instanceID int64) (*DeploySaga, error)
+}
+
+// EventRepository reads the instance event history. Events are written by
+// Repository in the same transaction as the state change.
+type EventRepository interface {
+	// ListByInstanceID retrieves events of an instance, newest first.
+	ListByInstanceID(ctx context.Context, instanceID int64, page pagination.Pagination) ([]*Event, *pagination.PageInfo, error)
+}
+
+// SecretRotationRepository persists the credential rotation history.
+type SecretRotationRepository interface {
+	// Create persists a new rotation record.
+	Create(ctx context.Context, rotation *SecretRotation) error
+
+	// Save updates an existing rotation record.
+	Save(ctx context.Context, rotation *SecretRotation) error
+
+	// ListByEventID retrieves the rotations recorded by an outbox event, so a
+	// retried event does not rotate the same credential twice.
+	ListByEventID(ctx context.Context, eventID int64) ([]*SecretRotation, error)
+
+	// ListByInstanceID retrieves the most recent rotations of an instance,
+	// newest first.
+	ListByInstanceID(ctx context.Context, instanceID int64, limit int) ([]*SecretRotation, error)
+}
@@ -0,0 +1,91 @@
+package instance
+
+import (
+	"fmt"
+	"time"
+)
+
+// RollbackTrigger names the health signal that caused an automatic rollback.
+type RollbackTrigger string
+
+const (
+	RollbackProvisionFailed RollbackTrigger = "provision_failed"
+	RollbackNotReady        RollbackTrigger = "not_ready"
+)
+
+// Rollback records an automatic revert to the last known-good version.
+type Rollback struct {
+	ID          int64           `gorm:"primaryKey" json:"id,string"`
+	InstanceID  int64           `gorm:"column:instance_id" json:"instance_id,string"`
+	OrgID       int64           `gorm:"column:org_id" json:"org_id,string"`
+	FromVersion string          `gorm:"column:from_version" json:"from_version"` // Version that failed
+	ToVersion   string          `gorm:"column:to_version" json:"to_version"`     // Last known-good version
+	Trigger     RollbackTrigger `gorm:"column:trigger" json:"trigger"`
+	Reason      string          `gorm:"column:reason" json:"reason"`
+	CreatedAt   time.Time       `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Rollback) TableName() string {
+	return "instance_rollbacks"
+}
+
+// ObserveReadiness records a readiness probe result. A serving instance that
+// is ready on its current version makes that version the last known-good one.
+func (i *Instance) ObserveReadiness(status ReadinessStatus, errMsg string, now time.Time) {
+	i.Readiness = status
+	i.ReadinessCheckedAt = &now
+	i.ReadinessError = errMsg
+
+	if status != ReadinessNotReady {
+		i.NotReadySince = nil
+	} else if i.NotReadySince == nil {
+		i.NotReadySince = &now
+	}
+
+	if status == ReadinessReady && i.IsServing() && i.CurrentVersion != "" {
+		i.LastGoodVersion = i.CurrentVersion
+	}
+}
+
+// NeedsRollback reports whether the instance should be reverted to its last
+// known-good version, and which version failed. Instances without a
+// known-good version, or already targeting it, are left alone.
+func (i *Instance) NeedsRollback(now time.Time, grace time.Duration) (RollbackTrigger, string, bool) {
+	if i.LastGoodVersion == "" {
+		return "", "", false
+	}
+
+	if i.Status == StatusProvisionFailed && i.DesiredVersion != "" && i.DesiredVersion != i.LastGoodVersion {
+		return RollbackProvisionFailed, i.DesiredVersion, true
+	}
+
+	if i.IsServing() &&
+		i.Readiness == ReadinessNotReady &&
+		i.NotReadySince != nil &&
+		now.Sub(*i.NotReadySince) >= grace &&
+		i.CurrentVersion != i.LastGoodVersion &&
+		i.DesiredVersion != i.LastGoodVersion {
+		return RollbackNotReady, i.CurrentVersion, true
+	}
+
+	return "", "", false
+}
+
+// RollBack points the instance at its last known-good version and returns
+// the record describing why.
+func (i *Instance) RollBack(trigger RollbackTrigger, failedVersion string, reason string, now time.Time) *Rollback {
+	i.DesiredVersion = i.LastGoodVersion
+	i.LastError = fmt.Sprintf("rolled back from %s to %s: %s", failedVersion, i.LastGoodVersion, reason)
+	i.NotReadySince = nil
+	i.UpdatedAt = now
+
+	return &Rollback{
+		InstanceID:  i.ID,
+		OrgID:       i.OrgID,
+		FromVersion: failedVersion,
+		ToVersion:   i.LastGoodVersion,
+		Trigger:     trigger,
+		Reason:      reason,
+		CreatedAt:   now,
+	}
+}
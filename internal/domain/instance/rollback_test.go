@@ -0,0 +1,105 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveReadiness(t *testing.T) {
+	now := time.Now().UTC()
+	inst := &Instance{Status: StatusActive, CurrentVersion: "v1", DesiredVersion: "v1"}
+
+	inst.ObserveReadiness(ReadinessReady, "", now)
+	assert.Equal(t, "v1", inst.LastGoodVersion)
+	assert.Nil(t, inst.NotReadySince)
+
+	inst.CurrentVersion = "v2"
+	inst.ObserveReadiness(ReadinessNotReady, "status 503", now)
+	require.NotNil(t, inst.NotReadySince)
+	assert.Equal(t, "status 503", inst.ReadinessError)
+	assert.Equal(t, "v1", inst.LastGoodVersion)
+
+	// The first failed probe marks the start of the outage.
+	inst.ObserveReadiness(ReadinessNotReady, "status 503", now.Add(time.Minute))
+	assert.Equal(t, now, *inst.NotReadySince)
+
+	inst.ObserveReadiness(ReadinessReady, "", now.Add(2*time.Minute))
+	assert.Nil(t, inst.NotReadySince)
+	assert.Equal(t, "v2", inst.LastGoodVersion)
+
+	stopped := &Instance{Status: StatusStopped, CurrentVersion: "v3"}
+	stopped.ObserveReadiness(ReadinessReady, "", now)
+	assert.Empty(t, stopped.LastGoodVersion)
+}
+
+func TestNeedsRollback(t *testing.T) {
+	now := time.Now().UTC()
+	grace := 5 * time.Minute
+
+	failed := &Instance{Status: StatusProvisionFailed, DesiredVersion: "v2", CurrentVersion: "v1", LastGoodVersion: "v1"}
+	trigger, version, ok := failed.NeedsRollback(now, grace)
+	assert.True(t, ok)
+	assert.Equal(t, RollbackProvisionFailed, trigger)
+	assert.Equal(t, "v2", version)
+
+	// Nothing known-good to go back to.
+	fresh := &Instance{Status: StatusProvisionFailed, DesiredVersion: "v2"}
+	_, _, ok = fresh.NeedsRollback(now, grace)
+	assert.False(t, ok)
+
+	// Already targeting the known-good version.
+	retrying := &Instance{Status: StatusProvisionFailed, DesiredVersion: "v1", LastGoodVersion: "v1"}
+	_, _, ok = retrying.NeedsRollback(now, grace)
+	assert.False(t, ok)
+
+	since := now.Add(-time.Minute)
+	unhealthy := &Instance{
+		Status:          StatusActive,
+		Readiness:       ReadinessNotReady,
+		NotReadySince:   &since,
+		CurrentVersion:  "v2",
+		DesiredVersion:  "v2",
+		LastGoodVersion: "v1",
+	}
+	_, _, ok = unhealthy.NeedsRollback(now, grace)
+	assert.False(t, ok, "within grace period")
+
+	trigger, version, ok = unhealthy.NeedsRollback(now.Add(grace), grace)
+	assert.True(t, ok)
+	assert.Equal(t, RollbackNotReady, trigger)
+	assert.Equal(t, "v2", version)
+
+	unhealthy.CurrentVersion = "v1"
+	_, _, ok = unhealthy.NeedsRollback(now.Add(grace), grace)
+	assert.False(t, ok, "known-good version being unhealthy is not a rollout problem")
+}
+
+func TestRollBack(t *testing.T) {
+	now := time.Now().UTC()
+	since := now.Add(-10 * time.Minute)
+	inst := &Instance{
+		ID:              7,
+		OrgID:           3,
+		Status:          StatusActive,
+		NotReadySince:   &since,
+		CurrentVersion:  "v2",
+		DesiredVersion:  "v2",
+		LastGoodVersion: "v1",
+	}
+
+	rb := inst.RollBack(RollbackNotReady, "v2", "not ready for 10m", now)
+
+	assert.Equal(t, "v1", inst.DesiredVersion)
+	assert.Nil(t, inst.NotReadySince)
+	assert.Equal(t, "rolled back from v2 to v1: not ready for 10m", inst.LastError)
+
+	assert.Equal(t, int64(7), rb.InstanceID)
+	assert.Equal(t, int64(3), rb.OrgID)
+	assert.Equal(t, "v2", rb.FromVersion)
+	assert.Equal(t, "v1", rb.ToVersion)
+	assert.Equal(t, RollbackNotReady, rb.Trigger)
+	assert.Equal(t, now, rb.CreatedAt)
+}
@@ -0,0 +1,173 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrUnknownSecret is returned when a rotation names a credential that
+// cannot be rotated.
+var ErrUnknownSecret = errors.New("unknown secret")
+
+// SecretKind names a tenant credential that can be rotated.
+type SecretKind string
+
+const (
+	SecretDBPassword            SecretKind = "db_password"             // Grace: the previous login role keeps its password
+	SecretOAuthClientSecret     SecretKind = "oauth_client_secret"     // Grace: the auth service accepts the previous secret
+	SecretPaymentProviderSecret SecretKind = "payment_provider_secret" // Grace: the previous secret is deployed next to the new one
+)
+
+// SecretKinds are all rotatable credentials, in the order they are rotated.
+var SecretKinds = []SecretKind{
+	SecretDBPassword,
+	SecretOAuthClientSecret,
+	SecretPaymentProviderSecret,
+}
+
+// ParseSecretKinds validates the names of credentials to rotate. No names
+// mean all of them; duplicates are dropped.
+func ParseSecretKinds(names []string) ([]SecretKind, error) {
+	if len(names) == 0 {
+		return SecretKinds, nil
+	}
+	requested := make(map[SecretKind]bool, len(names))
+	for _, name := range names {
+		kind := SecretKind(name)
+		if !slices.Contains(SecretKinds, kind) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSecret, name)
+		}
+		requested[kind] = true
+	}
+	var kinds []SecretKind
+	for _, kind := range SecretKinds {
+		if requested[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+// SecretRotationStatus is the progress of one rotated credential.
+type SecretRotationStatus string
+
+const (
+	SecretRotationRotated   SecretRotationStatus = "rotated"   // New credential stored, workload not yet redeployed
+	SecretRotationCompleted SecretRotationStatus = "completed" // Workload runs with the new credential
+)
+
+// SecretRotation records the rotation of one credential of an instance by a
+// rotate_secrets outbox event. It never holds the credential itself.
+type SecretRotation struct {
+	ID          int64                `gorm:"primaryKey" json:"id,string"`
+	InstanceID  int64                `gorm:"column:instance_id" json:"instance_id,string"`
+	OrgID       int64                `gorm:"column:org_id" json:"org_id,string"`
+	EventID     int64                `gorm:"column:event_id" json:"event_id,string"`
+	Secret      SecretKind           `gorm:"column:secret" json:"secret"`
+	Status      SecretRotationStatus `gorm:"column:status" json:"status"`
+	ActorType   ActorType            `gorm:"column:actor_type" json:"actor_type"` // Who requested the rotation
+	ActorID     string               `gorm:"column:actor_id" json:"actor_id,omitempty"`
+	GraceUntil  *time.Time           `gorm:"column:grace_until" json:"grace_until,omitempty"` // The previous credential is accepted until then
+	CreatedAt   time.Time            `gorm:"column:created_at" json:"created_at"`
+	CompletedAt *time.Time           `gorm:"column:completed_at" json:"completed_at,omitempty"`
+}
+
+func (SecretRotation) TableName() string {
+	return "instance_secret_rotations"
+}
+
+// Complete marks the rotation done once the workload runs with the new
+// credential.
+func (r *SecretRotation) Complete(now time.Time) {
+	r.Status = SecretRotationCompleted
+	r.CompletedAt = &now
+}
+
+// DatabaseLogin returns the role the workload logs in to its database as.
+func (i *Instance) DatabaseLogin() string {
+	if i.DBLoginUser != "" {
+		return i.DBLoginUser
+	}
+	return i.DBUser
+}
+
+// RotateDBLogin moves the workload to the other of its two login roles, with
+// a new password. The current login keeps working until the grace period
+// ends, so running allocations are replaced before it is disabled.
+func (i *Instance) RotateDBLogin(password string, grace time.Duration, now time.Time) error {
+	if err := i.CanRotateDBLogin(now); err != nil {
+		return err
+	}
+	next := i.DBUser + "_a"
+	if i.DBLoginUser == next {
+		next = i.DBUser + "_b"
+	}
+	expiresAt := now.Add(grace)
+	i.PreviousDBLoginUser = i.DatabaseLogin()
+	i.PreviousDBLoginExpiresAt = &expiresAt
+	i.DBLoginUser = next
+	i.DBPassword = password
+	return nil
+}
+
+// CanRotateDBLogin rejects a rotation until the previous login is disabled,
+// since the next rotation moves the workload back to it.
+func (i *Instance) CanRotateDBLogin(now time.Time) error {
+	if i.PreviousDBLoginUser == "" {
+		return nil
+	}
+	if i.PreviousDBLoginExpired(now) {
+		return fmt.Errorf("previous database login of instance %d is not disabled yet: %w", i.ID, ErrInvalidState)
+	}
+	return fmt.Errorf("previous database login of instance %d is in use until %s: %w",
+		i.ID, i.PreviousDBLoginExpiresAt.Format(time.RFC3339), ErrInvalidState)
+}
+
+// PreviousDBLoginExpired reports whether the login replaced by the last
+// rotation is due to be disabled.
+func (i *Instance) PreviousDBLoginExpired(now time.Time) bool {
+	if i.PreviousDBLoginUser == "" {
+		return false
+	}
+	return i.PreviousDBLoginExpiresAt == nil || !now.Before(*i.PreviousDBLoginExpiresAt)
+}
+
+// RotatePaymentProviderSecret replaces the payment provider secret. The
+// previous one stays deployed until the grace period ends, so the tenant can
+// still read what it encrypted with it.
+func (i *Instance) RotatePaymentProviderSecret(next string, grace time.Duration, now time.Time) error {
+	if err := i.CanRotatePaymentProviderSecret(now); err != nil {
+		return err
+	}
+	if i.PaymentProviderConfigSecret == "" {
+		i.PaymentProviderConfigSecret = next
+		return nil
+	}
+	expiresAt := now.Add(grace)
+	i.PreviousPaymentProviderSecret = i.PaymentProviderConfigSecret
+	i.PreviousPaymentProviderSecretExpiresAt = &expiresAt
+	i.PaymentProviderConfigSecret = next
+	return nil
+}
+
+// CanRotatePaymentProviderSecret rejects a rotation while the previous secret
+// is still deployed, since rotating again would drop it before the tenant
+// stopped needing it.
+func (i *Instance) CanRotatePaymentProviderSecret(now time.Time) error {
+	if i.PreviousPaymentProviderSecretExpiresAt == nil || !now.Before(*i.PreviousPaymentProviderSecretExpiresAt) {
+		return nil
+	}
+	return fmt.Errorf("previous payment provider secret of instance %d is in use until %s: %w",
+		i.ID, i.PreviousPaymentProviderSecretExpiresAt.Format(time.RFC3339), ErrInvalidState)
+}
+
+// PreviousPaymentProviderSecretAt returns the payment provider secret replaced
+// by the last rotation while its grace period lasts, or "".
+func (i *Instance) PreviousPaymentProviderSecretAt(now time.Time) string {
+	if i.PreviousPaymentProviderSecretExpiresAt == nil || !now.Before(*i.PreviousPaymentProviderSecretExpiresAt) {
+		return ""
+	}
+	return i.PreviousPaymentProviderSecret
+}
@@ -0,0 +1,67 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecretKinds(t *testing.T) {
+	kinds, err := ParseSecretKinds(nil)
+	require.NoError(t, err)
+	assert.Equal(t, SecretKinds, kinds)
+
+	kinds, err = ParseSecretKinds([]string{"payment_provider_secret", "db_password", "db_password"})
+	require.NoError(t, err)
+	assert.Equal(t, []SecretKind{SecretDBPassword, SecretPaymentProviderSecret}, kinds)
+
+	_, err = ParseSecretKinds([]string{"jwt_secret"})
+	assert.ErrorIs(t, err, ErrUnknownSecret)
+}
+
+func TestRotatePaymentProviderSecret(t *testing.T) {
+	now := time.Now().UTC()
+	inst := &Instance{ID: 1, PaymentProviderConfigSecret: "old"}
+
+	require.NoError(t, inst.RotatePaymentProviderSecret("new", time.Hour, now))
+	assert.Equal(t, "new", inst.PaymentProviderConfigSecret)
+	assert.Equal(t, "old", inst.PreviousPaymentProviderSecretAt(now.Add(59*time.Minute)))
+	assert.Empty(t, inst.PreviousPaymentProviderSecretAt(now.Add(time.Hour)))
+
+	// The previous secret is still deployed, so it cannot be replaced yet.
+	err := inst.RotatePaymentProviderSecret("newer", time.Hour, now.Add(time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidState)
+	assert.Equal(t, "new", inst.PaymentProviderConfigSecret)
+
+	require.NoError(t, inst.RotatePaymentProviderSecret("newer", time.Hour, now.Add(time.Hour)))
+	assert.Equal(t, "new", inst.PreviousPaymentProviderSecretAt(now.Add(time.Hour)))
+}
+
+func TestRotateDBLogin_AlternatesLogins(t *testing.T) {
+	now := time.Now().UTC()
+	inst := &Instance{ID: 1, DBUser: "railzway_org_1", DBPassword: "old"}
+
+	require.NoError(t, inst.RotateDBLogin("first", time.Hour, now))
+	assert.Equal(t, "railzway_org_1_a", inst.DatabaseLogin())
+	assert.Equal(t, "first", inst.DBPassword)
+	assert.Equal(t, "railzway_org_1", inst.PreviousDBLoginUser)
+	assert.False(t, inst.PreviousDBLoginExpired(now.Add(59*time.Minute)))
+	assert.True(t, inst.PreviousDBLoginExpired(now.Add(time.Hour)))
+
+	// The previous login is rejected until it was disabled, even after its
+	// grace period ended.
+	assert.ErrorIs(t, inst.RotateDBLogin("second", time.Hour, now.Add(time.Minute)), ErrInvalidState)
+	assert.ErrorIs(t, inst.RotateDBLogin("second", time.Hour, now.Add(time.Hour)), ErrInvalidState)
+	assert.Equal(t, "first", inst.DBPassword)
+
+	inst.PreviousDBLoginUser, inst.PreviousDBLoginExpiresAt = "", nil
+	require.NoError(t, inst.RotateDBLogin("second", time.Hour, now.Add(time.Hour)))
+	assert.Equal(t, "railzway_org_1_b", inst.DatabaseLogin())
+	assert.Equal(t, "railzway_org_1_a", inst.PreviousDBLoginUser)
+
+	inst.PreviousDBLoginUser, inst.PreviousDBLoginExpiresAt = "", nil
+	require.NoError(t, inst.RotateDBLogin("third", time.Hour, now.Add(2*time.Hour)))
+	assert.Equal(t, "railzway_org_1_a", inst.DatabaseLogin())
+}
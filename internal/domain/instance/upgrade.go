@@ -0,0 +1,179 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UpgradePhase represents the progress of a blue/green upgrade.
+type UpgradePhase string
+
+const (
+	UpgradePhaseDeploying  UpgradePhase = "deploying"
+	UpgradePhaseWarming    UpgradePhase = "warming"
+	UpgradePhaseSwitching  UpgradePhase = "switching"
+	UpgradePhaseDraining   UpgradePhase = "draining"
+	UpgradePhaseCompleted  UpgradePhase = "completed"
+	UpgradePhaseRolledBack UpgradePhase = "rolled_back"
+)
+
+var ErrUpgradeInProgress = errors.New("upgrade already in progress")
+
+// Upgrade tracks a blue/green switch from the serving job to a standby job.
+// The standby job runs the target version next to the serving one and only
+// receives traffic once it reports ready.
+type Upgrade struct {
+	ID          int64
+	InstanceID  int64
+	OrgID       int64
+	FromVersion string
+	ToVersion   string
+	FromTier    Tier
+	ToTier      Tier
+	FromJobID   string // Job serving traffic when the upgrade started
+	ToJobID     string // Standby job running the target version
+	Provisioner string // Orchestrator running both jobs
+	Phase       UpgradePhase
+
+	// Standby readiness, recorded by the lifecycle reconciler.
+	Readiness          ReadinessStatus
+	ReadinessCheckedAt *time.Time
+	ReadinessError     string
+
+	LastError   string
+	DeadlineAt  *time.Time // Standby must be ready before this time
+	DrainUntil  *time.Time // Old job is stopped after this time
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// DefaultJobID returns the job name used by the first deployment of an org.
+func DefaultJobID(orgID int64) string {
+	return fmt.Sprintf("railzway-org-%d", orgID)
+}
+
+// JobIDFor returns the initial job name of an org environment.
+func JobIDFor(orgID int64, environment string) string {
+	if environment == "" || environment == DefaultEnvironment {
+		return DefaultJobID(orgID)
+	}
+	return fmt.Sprintf("%s-%s", DefaultJobID(orgID), environment)
+}
+
+// StandbyJobID alternates between blue and green job names so the standby
+// never collides with the job currently serving traffic.
+func StandbyJobID(currentJobID string) string {
+	if base, ok := strings.CutSuffix(currentJobID, "-blue"); ok {
+		return base + "-green"
+	}
+	return strings.TrimSuffix(currentJobID, "-green") + "-blue"
+}
+
+// JobID returns the job currently serving the instance.
+func (i *Instance) JobID() string {
+	if strings.TrimSpace(i.NomadJobID) != "" {
+		return i.NomadJobID
+	}
+	return JobIDFor(i.OrgID, i.Environment)
+}
+
+// IsServing reports whether the instance is live and must not be restarted in place.
+func (i *Instance) IsServing() bool {
+	return i.Status == StatusActive || i.Status == StatusRunning
+}
+
+// PromoteStandby points the instance at the upgraded job once it takes traffic.
+func (i *Instance) PromoteStandby(up *Upgrade) {
+	i.NomadJobID = up.ToJobID
+	i.Tier = up.ToTier
+	i.DesiredVersion = up.ToVersion
+	i.CurrentVersion = up.ToVersion
+	i.Status = StatusActive
+	i.Role = RolePrimary
+	i.LastError = ""
+	i.UpdatedAt = time.Now().UTC()
+}
+
+// NewUpgrade starts a blue/green upgrade of inst to the given version and tier.
+func NewUpgrade(inst *Instance, toVersion string, toTier Tier, deadline time.Time) *Upgrade {
+	now := time.Now().UTC()
+	fromVersion := inst.CurrentVersion
+	if fromVersion == "" {
+		fromVersion = inst.DesiredVersion
+	}
+	return &Upgrade{
+		InstanceID:  inst.ID,
+		OrgID:       inst.OrgID,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		FromTier:    inst.Tier,
+		ToTier:      toTier,
+		FromJobID:   inst.JobID(),
+		ToJobID:     StandbyJobID(inst.JobID()),
+		Provisioner: inst.Provisioner,
+		Phase:       UpgradePhaseDeploying,
+		Readiness:   ReadinessUnknown,
+		DeadlineAt:  &deadline,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// IsActive reports whether the upgrade still owns the instance.
+func (u *Upgrade) IsActive() bool {
+	return u.Phase != UpgradePhaseCompleted && u.Phase != UpgradePhaseRolledBack
+}
+
+// DeadlineExceeded reports whether the standby ran out of time to become ready.
+func (u *Upgrade) DeadlineExceeded(now time.Time) bool {
+	return u.DeadlineAt != nil && now.After(*u.DeadlineAt)
+}
+
+// MarkWarming records that the standby job is running and waiting for /ready.
+func (u *Upgrade) MarkWarming() {
+	u.Phase = UpgradePhaseWarming
+	u.UpdatedAt = time.Now().UTC()
+}
+
+// MarkSwitching records that traffic is being moved to the standby job.
+func (u *Upgrade) MarkSwitching() {
+	u.Phase = UpgradePhaseSwitching
+	u.UpdatedAt = time.Now().UTC()
+}
+
+// MarkDraining records that the old job no longer takes new traffic.
+func (u *Upgrade) MarkDraining(drainUntil time.Time) {
+	u.Phase = UpgradePhaseDraining
+	u.DrainUntil = &drainUntil
+	u.UpdatedAt = time.Now().UTC()
+}
+
+// MarkCompleted records that the old job has been terminated.
+func (u *Upgrade) MarkCompleted() {
+	now := time.Now().UTC()
+	u.Phase = UpgradePhaseCompleted
+	u.LastError = ""
+	u.CompletedAt = &now
+	u.UpdatedAt = now
+}
+
+// MarkRolledBack records that the standby was discarded and the old job kept serving.
+func (u *Upgrade) MarkRolledBack(reason string) {
+	now := time.Now().UTC()
+	u.Phase = UpgradePhaseRolledBack
+	u.LastError = reason
+	u.CompletedAt = &now
+	u.UpdatedAt = now
+}
+
+// SetReadiness records the latest standby /ready evaluation.
+func (u *Upgrade) SetReadiness(status ReadinessStatus, errMsg string) {
+	now := time.Now().UTC()
+	u.Readiness = status
+	u.ReadinessCheckedAt = &now
+	u.ReadinessError = errMsg
+	u.UpdatedAt = now
+}
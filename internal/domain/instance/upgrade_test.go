@@ -0,0 +1,80 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandbyJobID_Alternates(t *testing.T) {
+	assert.Equal(t, "railzway-org-7-blue", StandbyJobID("railzway-org-7"))
+	assert.Equal(t, "railzway-org-7-green", StandbyJobID("railzway-org-7-blue"))
+	assert.Equal(t, "railzway-org-7-blue", StandbyJobID("railzway-org-7-green"))
+	assert.Equal(t, "railzway-org-7-staging-blue", StandbyJobID("railzway-org-7-staging"))
+}
+
+func TestInstance_JobID_DefaultsToOrgJob(t *testing.T) {
+	inst := &Instance{OrgID: 9}
+	assert.Equal(t, "railzway-org-9", inst.JobID())
+
+	inst.NomadJobID = "railzway-org-9-green"
+	assert.Equal(t, "railzway-org-9-green", inst.JobID())
+
+	staging := &Instance{OrgID: 9, Environment: "staging"}
+	assert.Equal(t, "railzway-org-9-staging", staging.JobID())
+}
+
+func TestNewUpgrade(t *testing.T) {
+	inst := &Instance{ID: 1, OrgID: 9, Tier: TierStarter, CurrentVersion: "v1.0.0", DesiredVersion: "v1.0.0"}
+	deadline := time.Now().Add(time.Minute)
+
+	up := NewUpgrade(inst, "v1.1.0", TierPro, deadline)
+
+	assert.Equal(t, UpgradePhaseDeploying, up.Phase)
+	assert.Equal(t, "v1.0.0", up.FromVersion)
+	assert.Equal(t, "v1.1.0", up.ToVersion)
+	assert.Equal(t, TierStarter, up.FromTier)
+	assert.Equal(t, TierPro, up.ToTier)
+	assert.Equal(t, "railzway-org-9", up.FromJobID)
+	assert.Equal(t, "railzway-org-9-blue", up.ToJobID)
+	assert.True(t, up.IsActive())
+	assert.False(t, up.DeadlineExceeded(time.Now()))
+	assert.True(t, up.DeadlineExceeded(deadline.Add(time.Second)))
+}
+
+func TestUpgrade_PhaseTransitions(t *testing.T) {
+	inst := &Instance{ID: 1, OrgID: 9, Tier: TierStarter, CurrentVersion: "v1.0.0", Status: StatusActive}
+	up := NewUpgrade(inst, "v1.1.0", TierStarter, time.Now().Add(time.Minute))
+
+	up.MarkWarming()
+	assert.Equal(t, UpgradePhaseWarming, up.Phase)
+
+	up.SetReadiness(ReadinessReady, "")
+	assert.Equal(t, ReadinessReady, up.Readiness)
+	assert.NotNil(t, up.ReadinessCheckedAt)
+
+	up.MarkSwitching()
+	inst.PromoteStandby(up)
+	assert.Equal(t, "railzway-org-9-blue", inst.NomadJobID)
+	assert.Equal(t, "v1.1.0", inst.CurrentVersion)
+	assert.Equal(t, StatusActive, inst.Status)
+
+	up.MarkDraining(time.Now().Add(time.Minute))
+	assert.Equal(t, UpgradePhaseDraining, up.Phase)
+	assert.True(t, up.IsActive())
+
+	up.MarkCompleted()
+	assert.Equal(t, UpgradePhaseCompleted, up.Phase)
+	assert.False(t, up.IsActive())
+	assert.NotNil(t, up.CompletedAt)
+}
+
+func TestUpgrade_MarkRolledBack(t *testing.T) {
+	up := &Upgrade{Phase: UpgradePhaseWarming}
+	up.MarkRolledBack("standby_not_ready")
+
+	assert.Equal(t, UpgradePhaseRolledBack, up.Phase)
+	assert.Equal(t, "standby_not_ready", up.LastError)
+	assert.False(t, up.IsActive())
+}
@@ -0,0 +1,44 @@
+// Package outboxevent names the types and statuses of outbox events. The
+// processor and the use cases that enqueue or inspect events share them.
+package outboxevent
+
+import "fmt"
+
+// Type selects the handler of an event.
+type Type string
+
+const (
+	TypeDeployInstance    Type = "deploy_instance" // Payload: deployment.DeployRequest, optional
+	TypeStopInstance      Type = "stop_instance"
+	TypeStartInstance     Type = "start_instance"
+	TypeUpgradeInstance   Type = "upgrade_instance"   // Payload: deployment.TierChange
+	TypeDowngradeInstance Type = "downgrade_instance" // Payload: deployment.TierChange
+	TypeTerminateInstance Type = "terminate_instance"
+	TypeRotateSecrets     Type = "rotate_secrets" // Payload: deployment.SecretRotation
+	TypeExpireSecrets     Type = "expire_secrets"
+)
+
+// Status is the progress of an event.
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusProcessing Status = "processing"
+	StatusCompleted  Status = "completed"
+	StatusFailed     Status = "failed"
+	StatusCancelled  Status = "cancelled"
+	StatusDead       Status = "dead"      // Used up its attempts; waits for an operator to replay or discard it
+	StatusDiscarded  Status = "discarded" // Dropped by an operator, see the instance audit history for the reason
+)
+
+// IdempotencyKey keys a mutating OSS call made while processing event
+// eventID, so retrying the event repeats the call exactly once from OSS's
+// point of view. Each call of an event needs its own step; steps acting on a
+// subscription include its ID. Calls made outside an event (eventID 0) are
+// not keyed.
+func IdempotencyKey(eventID int64, step string) string {
+	if eventID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("outbox:%d:%s", eventID, step)
+}
@@ -2,12 +2,24 @@ package provisioning
 
 import (
 	"context"
+	"time"
 
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+// Provisioner names. Instances record the provisioner that runs their workloads.
+const (
+	ProvisionerNomad      = "nomad"
+	ProvisionerKubernetes = "kubernetes"
+	ProvisionerDocker     = "docker" // Local containers, for development and CI
 )
 
 // DeploymentConfig defines the parameters required for a deployment.
 type DeploymentConfig struct {
+	// Provisioner selects the orchestrator. Empty means the default one.
+	Provisioner string
+
 	OrgID                  int64
 	OrgSlug                string
 	OrgName                string
@@ -24,6 +36,32 @@ type DeploymentConfig struct {
 	OAuth2ClientID              string
 	OAuth2ClientSecret          string
 	PaymentProviderConfigSecret string
+	// PreviousPaymentProviderConfigSecret is the payment provider secret
+	// replaced by a rotation, deployed until its grace period ends.
+	PreviousPaymentProviderConfigSecret string
+
+	// JobID names the workload. Empty means the org's default job.
+	JobID string
+	// Standby deploys the workload without production traffic. It is only
+	// reachable through the standby route used for readiness probes.
+	Standby bool
+
+	// TierProfile sizes, places and limits the workload. Nil means the
+	// built-in profile of Tier.
+	TierProfile *nomad.TierProfile
+	// TierProfileRevision is the catalog revision of TierProfile, recorded on
+	// the instance once deployed.
+	TierProfileRevision int
+	// EntitlementsHash fingerprints the product entitlements applied to
+	// TierProfile, recorded on the instance once deployed.
+	EntitlementsHash string
+}
+
+// Workload identifies a single tenant workload managed by a Provisioner.
+type Workload struct {
+	OrgID       int64
+	JobID       string
+	Provisioner string
 }
 
 // DBConfig holds the database connection details for the instance.
@@ -31,15 +69,34 @@ type DBConfig struct {
 	Host     string
 	Port     int
 	Name     string
-	User     string
+	User     string // Owns the database
 	Password string
+
+	// LoginUser is the role the workload logs in as with Password, a member
+	// of User. Empty means User logs in itself.
+	LoginUser string
+
+	// ConnectionLimit caps the concurrent connections of User; 0 means no
+	// limit.
+	ConnectionLimit int
+	// StatementTimeout aborts statements of User running longer; 0 means no
+	// timeout.
+	StatementTimeout time.Duration
 }
 
 // DatabaseProvisioner defines the interface for provisioning tenant databases.
 type DatabaseProvisioner interface {
-	// Provision creates the database and user for the given organization.
+	// Provision creates the database and user described by db and syncs the user's password.
+	// It must be idempotent.
+	Provision(ctx context.Context, db DBConfig) error
+
+	// DisableLogin stops db.LoginUser from logging in and closes its
+	// connections, once a rotation replaced it. It must be idempotent.
+	DisableLogin(ctx context.Context, db DBConfig) error
+
+	// Deprovision drops the database and user described by db.
 	// It must be idempotent.
-	Provision(ctx context.Context, orgID int64, password string) error
+	Deprovision(ctx context.Context, db DBConfig) error
 }
 
 // Provisioner defines the interface for the underlying infrastructure orchestrator (e.g., Nomad).
@@ -48,8 +105,8 @@ type Provisioner interface {
 	Deploy(ctx context.Context, config *DeploymentConfig) error
 
 	// Stop stops the workload.
-	Stop(ctx context.Context, orgID int64) error
+	Stop(ctx context.Context, workload Workload) error
 
 	// GetStatus retrieves the current status of the workload.
-	GetStatus(ctx context.Context, orgID int64) (string, error)
+	GetStatus(ctx context.Context, workload Workload) (string, error)
 }
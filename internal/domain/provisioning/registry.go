@@ -0,0 +1,72 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+)
+
+// Registry dispatches workloads to the provisioner named on them. Workloads
+// without a provisioner name go to the default one.
+type Registry struct {
+	defaultName  string
+	provisioners map[string]Provisioner
+}
+
+// NewRegistry creates a registry whose default provisioner is defaultName.
+func NewRegistry(defaultName string) *Registry {
+	return &Registry{
+		defaultName:  defaultName,
+		provisioners: make(map[string]Provisioner),
+	}
+}
+
+// Register makes p available under name.
+func (r *Registry) Register(name string, p Provisioner) {
+	r.provisioners[name] = p
+}
+
+// Has reports whether a provisioner is registered under name.
+func (r *Registry) Has(name string) bool {
+	_, ok := r.provisioners[r.resolve(name)]
+	return ok
+}
+
+func (r *Registry) Deploy(ctx context.Context, config *DeploymentConfig) error {
+	p, err := r.get(config.Provisioner)
+	if err != nil {
+		return err
+	}
+	return p.Deploy(ctx, config)
+}
+
+func (r *Registry) Stop(ctx context.Context, workload Workload) error {
+	p, err := r.get(workload.Provisioner)
+	if err != nil {
+		return err
+	}
+	return p.Stop(ctx, workload)
+}
+
+func (r *Registry) GetStatus(ctx context.Context, workload Workload) (string, error) {
+	p, err := r.get(workload.Provisioner)
+	if err != nil {
+		return "", err
+	}
+	return p.GetStatus(ctx, workload)
+}
+
+func (r *Registry) get(name string) (Provisioner, error) {
+	name = r.resolve(name)
+	p, ok := r.provisioners[name]
+	if !ok {
+		return nil, fmt.Errorf("provisioner %q is not enabled", name)
+	}
+	return p, nil
+}
+
+func (r *Registry) resolve(name string) string {
+	if name == "" {
+		return r.defaultName
+	}
+	return name
+}
@@ -0,0 +1,44 @@
+package provisioning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingProvisioner struct {
+	deployed []string
+}
+
+func (p *recordingProvisioner) Deploy(ctx context.Context, config *DeploymentConfig) error {
+	p.deployed = append(p.deployed, config.JobID)
+	return nil
+}
+
+func (p *recordingProvisioner) Stop(ctx context.Context, workload Workload) error {
+	return nil
+}
+
+func (p *recordingProvisioner) GetStatus(ctx context.Context, workload Workload) (string, error) {
+	return "running", nil
+}
+
+func TestRegistry_Dispatch(t *testing.T) {
+	nomad := &recordingProvisioner{}
+	kube := &recordingProvisioner{}
+	registry := NewRegistry(ProvisionerNomad)
+	registry.Register(ProvisionerNomad, nomad)
+	registry.Register(ProvisionerKubernetes, kube)
+
+	ctx := context.Background()
+	require.NoError(t, registry.Deploy(ctx, &DeploymentConfig{JobID: "a"}))
+	require.NoError(t, registry.Deploy(ctx, &DeploymentConfig{JobID: "b", Provisioner: ProvisionerKubernetes}))
+
+	assert.Equal(t, []string{"a"}, nomad.deployed)
+	assert.Equal(t, []string{"b"}, kube.deployed)
+
+	_, err := registry.GetStatus(ctx, Workload{Provisioner: "docker"})
+	assert.ErrorContains(t, err, `provisioner "docker" is not enabled`)
+}
@@ -0,0 +1,394 @@
+package rollout
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+)
+
+// Status represents the state of a fleet rollout.
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusPaused    Status = "paused"
+	StatusHalted    Status = "halted" // Stopped automatically by a failing wave
+	StatusAborted   Status = "aborted"
+	StatusCompleted Status = "completed"
+)
+
+// TargetStatus represents the progress of a single instance within a rollout.
+type TargetStatus string
+
+const (
+	TargetPending   TargetStatus = "pending"   // Wave not active yet
+	TargetDeploying TargetStatus = "deploying" // Wave active, waiting for the instance
+	TargetSucceeded TargetStatus = "succeeded"
+	TargetFailed    TargetStatus = "failed"
+	TargetSkipped   TargetStatus = "skipped"   // Failure accepted by an operator on resume
+	TargetCancelled TargetStatus = "cancelled" // Rollout aborted or instance terminated
+)
+
+var (
+	ErrNotFound          = errors.New("rollout not found")
+	ErrRolloutInProgress = errors.New("another rollout is in progress")
+	ErrInvalidTransition = errors.New("invalid rollout state transition")
+	ErrInvalidWavePlan   = errors.New("invalid wave plan")
+	ErrNothingToRollOut  = errors.New("no instances to roll out")
+)
+
+// Wave is one step of a rollout plan. A wave selects instances either by a
+// cumulative share of the fleet (Percent) or by tier (Tiers), never both.
+type Wave struct {
+	Name              string          `json:"name,omitempty"`
+	Percent           int             `json:"percent,omitempty"`
+	Tiers             []instance.Tier `json:"tiers,omitempty"`
+	MinSuccessPercent int             `json:"min_success_percent"` // Ready instances required to advance
+	MaxFailurePercent int             `json:"max_failure_percent"` // Failed instances that halt the rollout
+	BakeSeconds       int             `json:"bake_seconds,omitempty"`
+	TimeoutSeconds    int             `json:"timeout_seconds,omitempty"`
+}
+
+// Rollout moves the fleet to a target version wave by wave.
+type Rollout struct {
+	ID            int64      `gorm:"primaryKey" json:"id,string"`
+	TargetVersion string     `gorm:"column:target_version" json:"target_version"`
+	Status        Status     `gorm:"column:status" json:"status"`
+	Waves         []Wave     `gorm:"column:waves;type:jsonb;serializer:json" json:"waves"`
+	CurrentWave   int        `gorm:"column:current_wave" json:"current_wave"`
+	WaveStartedAt *time.Time `gorm:"column:wave_started_at" json:"wave_started_at,omitempty"`
+	WavePassedAt  *time.Time `gorm:"column:wave_passed_at" json:"wave_passed_at,omitempty"` // Wave met its thresholds; baking
+	HaltReason    string     `gorm:"column:halt_reason" json:"halt_reason,omitempty"`
+	CompletedAt   *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Rollout) TableName() string {
+	return "rollouts"
+}
+
+// Target is an instance assigned to a rollout wave.
+type Target struct {
+	RolloutID   int64         `gorm:"primaryKey;column:rollout_id" json:"rollout_id,string"`
+	InstanceID  int64         `gorm:"primaryKey;column:instance_id" json:"instance_id,string"`
+	OrgID       int64         `gorm:"column:org_id" json:"org_id,string"`
+	Tier        instance.Tier `gorm:"column:tier" json:"tier"`
+	FromVersion string        `gorm:"column:from_version" json:"from_version"`
+	Wave        int           `gorm:"column:wave" json:"wave"`
+	Status      TargetStatus  `gorm:"column:status" json:"status"`
+	LastError   string        `gorm:"column:last_error" json:"last_error,omitempty"`
+	UpdatedAt   time.Time     `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Target) TableName() string {
+	return "rollout_targets"
+}
+
+// IsFinal reports whether the target no longer changes with the instance state.
+func (s TargetStatus) IsFinal() bool {
+	return s == TargetSucceeded || s == TargetSkipped || s == TargetCancelled
+}
+
+// IsActive reports whether the rollout still holds the fleet.
+func (r *Rollout) IsActive() bool {
+	return r.Status == StatusRunning || r.Status == StatusPaused || r.Status == StatusHalted
+}
+
+// ActiveWave returns the wave currently being rolled out.
+func (r *Rollout) ActiveWave() Wave {
+	if r.CurrentWave < 0 || r.CurrentWave >= len(r.Waves) {
+		return Wave{}
+	}
+	return r.Waves[r.CurrentWave]
+}
+
+// IsLastWave reports whether the active wave is the final one.
+func (r *Rollout) IsLastWave() bool {
+	return r.CurrentWave >= len(r.Waves)-1
+}
+
+// StartWave makes wave the active wave.
+func (r *Rollout) StartWave(wave int, now time.Time) {
+	r.CurrentWave = wave
+	r.WaveStartedAt = &now
+	r.WavePassedAt = nil
+	r.UpdatedAt = now
+}
+
+// WaveTimedOut reports whether the active wave exceeded its timeout.
+func (r *Rollout) WaveTimedOut(now time.Time) bool {
+	timeout := r.ActiveWave().TimeoutSeconds
+	if timeout <= 0 || r.WaveStartedAt == nil {
+		return false
+	}
+	return now.After(r.WaveStartedAt.Add(time.Duration(timeout) * time.Second))
+}
+
+// Baked reports whether a passed wave has soaked long enough to advance.
+// The first call records when the wave passed.
+func (r *Rollout) Baked(now time.Time) bool {
+	if r.WavePassedAt == nil {
+		r.WavePassedAt = &now
+		r.UpdatedAt = now
+	}
+	bake := time.Duration(r.ActiveWave().BakeSeconds) * time.Second
+	return !now.Before(r.WavePassedAt.Add(bake))
+}
+
+func (r *Rollout) Pause() error {
+	if r.Status != StatusRunning {
+		return fmt.Errorf("%w: cannot pause %s rollout", ErrInvalidTransition, r.Status)
+	}
+	r.Status = StatusPaused
+	r.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// Resume continues a paused or halted rollout.
+func (r *Rollout) Resume() error {
+	if r.Status != StatusPaused && r.Status != StatusHalted {
+		return fmt.Errorf("%w: cannot resume %s rollout", ErrInvalidTransition, r.Status)
+	}
+	r.Status = StatusRunning
+	r.HaltReason = ""
+	r.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (r *Rollout) Abort() error {
+	if !r.IsActive() {
+		return fmt.Errorf("%w: cannot abort %s rollout", ErrInvalidTransition, r.Status)
+	}
+	now := time.Now().UTC()
+	r.Status = StatusAborted
+	r.CompletedAt = &now
+	r.UpdatedAt = now
+	return nil
+}
+
+func (r *Rollout) Halt(reason string) {
+	r.Status = StatusHalted
+	r.HaltReason = reason
+	r.UpdatedAt = time.Now().UTC()
+}
+
+func (r *Rollout) Complete(now time.Time) {
+	r.Status = StatusCompleted
+	r.CompletedAt = &now
+	r.UpdatedAt = now
+}
+
+// ValidateWaves checks a wave plan. Percent plans must be strictly increasing
+// and end at 100; tier plans must not list a tier twice.
+func ValidateWaves(waves []Wave) error {
+	if len(waves) == 0 {
+		return fmt.Errorf("%w: at least one wave is required", ErrInvalidWavePlan)
+	}
+
+	byTier := len(waves[0].Tiers) > 0
+	seen := map[instance.Tier]bool{}
+	prev := 0
+	for i, w := range waves {
+		if w.MinSuccessPercent < 0 || w.MinSuccessPercent > 100 || w.MaxFailurePercent < 0 || w.MaxFailurePercent > 100 {
+			return fmt.Errorf("%w: wave %d thresholds must be between 0 and 100", ErrInvalidWavePlan, i)
+		}
+		if byTier {
+			if len(w.Tiers) == 0 || w.Percent != 0 {
+				return fmt.Errorf("%w: wave %d mixes tier and percent selection", ErrInvalidWavePlan, i)
+			}
+			for _, tier := range w.Tiers {
+				if _, ok := instance.TierRank[tier]; !ok {
+					return fmt.Errorf("%w: unknown tier %s", ErrInvalidWavePlan, tier)
+				}
+				if seen[tier] {
+					return fmt.Errorf("%w: tier %s listed twice", ErrInvalidWavePlan, tier)
+				}
+				seen[tier] = true
+			}
+			continue
+		}
+		if len(w.Tiers) > 0 {
+			return fmt.Errorf("%w: wave %d mixes tier and percent selection", ErrInvalidWavePlan, i)
+		}
+		if w.Percent <= prev || w.Percent > 100 {
+			return fmt.Errorf("%w: wave percentages must increase up to 100", ErrInvalidWavePlan)
+		}
+		prev = w.Percent
+	}
+	if !byTier && prev != 100 {
+		return fmt.Errorf("%w: last wave must cover 100%%", ErrInvalidWavePlan)
+	}
+	return nil
+}
+
+// Candidate is an instance eligible for a rollout.
+type Candidate struct {
+	InstanceID  int64
+	OrgID       int64
+	Tier        instance.Tier
+	FromVersion string
+}
+
+// AssignWaves distributes candidates over the wave plan.
+// Percent plans fill waves from the lowest tier up, so canaries land on
+// free and starter instances first. In tier plans, tiers not listed in any
+// wave go out with the last wave.
+func AssignWaves(rolloutID int64, waves []Wave, candidates []Candidate, now time.Time) []Target {
+	ordered := slices.Clone(candidates)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, rj := instance.TierRank[ordered[i].Tier], instance.TierRank[ordered[j].Tier]
+		if ri != rj {
+			return ri < rj
+		}
+		return ordered[i].InstanceID < ordered[j].InstanceID
+	})
+
+	targets := make([]Target, 0, len(ordered))
+	add := func(c Candidate, wave int) {
+		targets = append(targets, Target{
+			RolloutID:   rolloutID,
+			InstanceID:  c.InstanceID,
+			OrgID:       c.OrgID,
+			Tier:        c.Tier,
+			FromVersion: c.FromVersion,
+			Wave:        wave,
+			Status:      TargetPending,
+			UpdatedAt:   now,
+		})
+	}
+
+	if len(waves) > 0 && len(waves[0].Tiers) > 0 {
+		for _, c := range ordered {
+			wave := len(waves) - 1
+			for i, w := range waves {
+				if slices.Contains(w.Tiers, c.Tier) {
+					wave = i
+					break
+				}
+			}
+			add(c, wave)
+		}
+		return targets
+	}
+
+	n := len(ordered)
+	next := 0
+	for i, w := range waves {
+		cutoff := (n*w.Percent + 99) / 100 // ceil, so a 1% canary is never empty
+		for ; next < cutoff && next < n; next++ {
+			add(ordered[next], i)
+		}
+	}
+	return targets
+}
+
+// WaveStats summarizes the targets of a wave. Skipped and cancelled targets
+// are excluded from the rates.
+type WaveStats struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+	Cancelled int `json:"cancelled"`
+}
+
+func (s *WaveStats) Add(status TargetStatus) {
+	switch status {
+	case TargetSkipped:
+		s.Skipped++
+		return
+	case TargetCancelled:
+		s.Cancelled++
+		return
+	case TargetSucceeded:
+		s.Succeeded++
+	case TargetFailed:
+		s.Failed++
+	default:
+		s.Pending++
+	}
+	s.Total++
+}
+
+func (s WaveStats) FailurePercent() int {
+	if s.Total == 0 {
+		return 0
+	}
+	return s.Failed * 100 / s.Total
+}
+
+func (s WaveStats) SuccessPercent() int {
+	if s.Total == 0 {
+		return 100
+	}
+	return s.Succeeded * 100 / s.Total
+}
+
+// Decision is the outcome of evaluating a wave.
+type Decision string
+
+const (
+	DecisionWait    Decision = "wait"
+	DecisionAdvance Decision = "advance"
+	DecisionHalt    Decision = "halt"
+)
+
+// Evaluate decides whether a wave may advance. A wave halts as soon as its
+// failure rate crosses the threshold, and advances once every target settled
+// and enough of them are ready.
+func Evaluate(w Wave, stats WaveStats) (Decision, string) {
+	if stats.Total > 0 && stats.FailurePercent() > w.MaxFailurePercent {
+		return DecisionHalt, fmt.Sprintf("failure rate %d%% exceeds %d%% (%d/%d failed)",
+			stats.FailurePercent(), w.MaxFailurePercent, stats.Failed, stats.Total)
+	}
+	if stats.Pending > 0 {
+		return DecisionWait, ""
+	}
+	if stats.SuccessPercent() < w.MinSuccessPercent {
+		return DecisionHalt, fmt.Sprintf("success rate %d%% below %d%% (%d/%d ready)",
+			stats.SuccessPercent(), w.MinSuccessPercent, stats.Succeeded, stats.Total)
+	}
+	return DecisionAdvance, ""
+}
+
+// Classify derives the target status from the instance it points at.
+// An instance counts as succeeded once it serves the target version and
+// reports ready; a deploy event that used up its retries or a reverted
+// desired version (e.g. a rolled back blue/green upgrade) counts as failed.
+// Events still waiting for a retry are not failures yet.
+func Classify(inst *instance.Instance, targetVersion string, eventExhausted bool, timedOut bool) (TargetStatus, string) {
+	if inst == nil || inst.Status == instance.StatusTerminated {
+		return TargetCancelled, "instance terminated"
+	}
+	if inst.CurrentVersion == targetVersion && inst.IsServing() && inst.Readiness == instance.ReadinessReady {
+		return TargetSucceeded, ""
+	}
+	if inst.Status == instance.StatusProvisionFailed {
+		return TargetFailed, inst.LastError
+	}
+	if inst.DesiredVersion != targetVersion {
+		return TargetFailed, firstNonEmpty(inst.LastError, "desired version changed to "+inst.DesiredVersion)
+	}
+	if eventExhausted {
+		return TargetFailed, firstNonEmpty(inst.LastError, "deploy event exhausted its retries")
+	}
+	if timedOut {
+		return TargetFailed, "wave timed out"
+	}
+	return TargetDeploying, ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
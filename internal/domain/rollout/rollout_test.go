@@ -0,0 +1,196 @@
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func percentWaves(percents ...int) []Wave {
+	waves := make([]Wave, 0, len(percents))
+	for _, p := range percents {
+		waves = append(waves, Wave{Percent: p, MinSuccessPercent: 90, MaxFailurePercent: 10})
+	}
+	return waves
+}
+
+func TestValidateWaves(t *testing.T) {
+	assert.NoError(t, ValidateWaves(percentWaves(1, 10, 50, 100)))
+	assert.NoError(t, ValidateWaves([]Wave{
+		{Tiers: []instance.Tier{instance.TierFreeTrial, instance.TierStarter}},
+		{Tiers: []instance.Tier{instance.TierEnterprise}},
+	}))
+
+	invalid := map[string][]Wave{
+		"empty":          nil,
+		"not increasing": percentWaves(10, 10, 100),
+		"not complete":   percentWaves(10, 50),
+		"over 100":       percentWaves(50, 150),
+		"mixed":          {{Percent: 10}, {Tiers: []instance.Tier{instance.TierPro}}},
+		"duplicate tier": {{Tiers: []instance.Tier{instance.TierPro}}, {Tiers: []instance.Tier{instance.TierPro}}},
+		"unknown tier":   {{Tiers: []instance.Tier{"GOLD"}}},
+		"bad threshold":  {{Percent: 100, MaxFailurePercent: 101}},
+	}
+	for name, waves := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, ValidateWaves(waves), ErrInvalidWavePlan)
+		})
+	}
+}
+
+func TestAssignWaves_Percent(t *testing.T) {
+	var candidates []Candidate
+	for i := int64(1); i <= 20; i++ {
+		tier := instance.TierEnterprise
+		if i%2 == 0 {
+			tier = instance.TierFreeTrial
+		}
+		candidates = append(candidates, Candidate{InstanceID: i, OrgID: i, Tier: tier})
+	}
+
+	targets := AssignWaves(7, percentWaves(1, 10, 50, 100), candidates, time.Now())
+	require.Len(t, targets, 20)
+
+	perWave := map[int][]Target{}
+	for _, tg := range targets {
+		assert.Equal(t, int64(7), tg.RolloutID)
+		assert.Equal(t, TargetPending, tg.Status)
+		perWave[tg.Wave] = append(perWave[tg.Wave], tg)
+	}
+
+	// ceil(1% of 20) = 1 canary, then up to 2, 10 and 20 cumulative.
+	assert.Len(t, perWave[0], 1)
+	assert.Len(t, perWave[1], 1)
+	assert.Len(t, perWave[2], 8)
+	assert.Len(t, perWave[3], 10)
+
+	// Canaries are taken from the lowest tier.
+	assert.Equal(t, instance.TierFreeTrial, perWave[0][0].Tier)
+	for _, tg := range perWave[3] {
+		assert.Equal(t, instance.TierEnterprise, tg.Tier)
+	}
+}
+
+func TestAssignWaves_Tier(t *testing.T) {
+	candidates := []Candidate{
+		{InstanceID: 1, Tier: instance.TierEnterprise},
+		{InstanceID: 2, Tier: instance.TierStarter},
+		{InstanceID: 3, Tier: instance.TierPro},
+	}
+	waves := []Wave{
+		{Tiers: []instance.Tier{instance.TierStarter}},
+		{Tiers: []instance.Tier{instance.TierEnterprise}},
+	}
+
+	byInstance := map[int64]int{}
+	for _, tg := range AssignWaves(1, waves, candidates, time.Now()) {
+		byInstance[tg.InstanceID] = tg.Wave
+	}
+	assert.Equal(t, 0, byInstance[2])
+	assert.Equal(t, 1, byInstance[1])
+	// Unlisted tiers go out with the last wave.
+	assert.Equal(t, 1, byInstance[3])
+}
+
+func TestEvaluate(t *testing.T) {
+	wave := Wave{MinSuccessPercent: 90, MaxFailurePercent: 10}
+
+	stats := func(statuses ...TargetStatus) WaveStats {
+		var s WaveStats
+		for _, st := range statuses {
+			s.Add(st)
+		}
+		return s
+	}
+
+	decision, _ := Evaluate(wave, stats())
+	assert.Equal(t, DecisionAdvance, decision, "empty wave")
+
+	decision, _ = Evaluate(wave, stats(TargetSucceeded, TargetDeploying))
+	assert.Equal(t, DecisionWait, decision)
+
+	decision, reason := Evaluate(wave, stats(TargetFailed, TargetDeploying, TargetDeploying))
+	assert.Equal(t, DecisionHalt, decision, "failure rate crosses threshold before the wave settles")
+	assert.Contains(t, reason, "failure rate 33%")
+
+	decision, _ = Evaluate(wave, stats(TargetSucceeded, TargetSucceeded, TargetSkipped, TargetCancelled))
+	assert.Equal(t, DecisionAdvance, decision, "skipped and cancelled targets are ignored")
+
+	lenient := Wave{MinSuccessPercent: 90, MaxFailurePercent: 100}
+	decision, reason = Evaluate(lenient, stats(TargetSucceeded, TargetFailed))
+	assert.Equal(t, DecisionHalt, decision)
+	assert.Contains(t, reason, "success rate 50%")
+}
+
+func TestClassify(t *testing.T) {
+	serving := func() *instance.Instance {
+		return &instance.Instance{
+			Status:         instance.StatusActive,
+			CurrentVersion: "v2",
+			DesiredVersion: "v2",
+			Readiness:      instance.ReadinessReady,
+		}
+	}
+
+	st, _ := Classify(serving(), "v2", false, false)
+	assert.Equal(t, TargetSucceeded, st)
+
+	notReady := serving()
+	notReady.Readiness = instance.ReadinessNotReady
+	st, _ = Classify(notReady, "v2", false, false)
+	assert.Equal(t, TargetDeploying, st)
+	st, msg := Classify(notReady, "v2", false, true)
+	assert.Equal(t, TargetFailed, st)
+	assert.Equal(t, "wave timed out", msg)
+
+	rolledBack := serving()
+	rolledBack.CurrentVersion = "v1"
+	rolledBack.DesiredVersion = "v1"
+	rolledBack.LastError = "upgrade to v2 rolled back: standby_not_ready"
+	st, msg = Classify(rolledBack, "v2", false, false)
+	assert.Equal(t, TargetFailed, st)
+	assert.Equal(t, rolledBack.LastError, msg)
+
+	upgrading := serving()
+	upgrading.CurrentVersion = "v1"
+	st, _ = Classify(upgrading, "v2", true, false)
+	assert.Equal(t, TargetFailed, st)
+
+	st, _ = Classify(&instance.Instance{Status: instance.StatusTerminated}, "v2", false, false)
+	assert.Equal(t, TargetCancelled, st)
+}
+
+func TestRolloutTransitions(t *testing.T) {
+	r := &Rollout{Status: StatusRunning, Waves: percentWaves(50, 100)}
+
+	require.NoError(t, r.Pause())
+	assert.ErrorIs(t, r.Pause(), ErrInvalidTransition)
+	require.NoError(t, r.Resume())
+
+	r.Halt("wave 0: failure rate 50% exceeds 10% (1/2 failed)")
+	assert.True(t, r.IsActive())
+	require.NoError(t, r.Resume())
+	assert.Empty(t, r.HaltReason)
+
+	require.NoError(t, r.Abort())
+	assert.False(t, r.IsActive())
+	assert.ErrorIs(t, r.Resume(), ErrInvalidTransition)
+	assert.ErrorIs(t, r.Abort(), ErrInvalidTransition)
+}
+
+func TestRolloutBakeAndTimeout(t *testing.T) {
+	start := time.Now().UTC()
+	r := &Rollout{Status: StatusRunning, Waves: []Wave{{Percent: 100, BakeSeconds: 60, TimeoutSeconds: 600}}}
+	r.StartWave(0, start)
+
+	assert.False(t, r.WaveTimedOut(start.Add(time.Minute)))
+	assert.True(t, r.WaveTimedOut(start.Add(11*time.Minute)))
+
+	assert.False(t, r.Baked(start))
+	assert.False(t, r.Baked(start.Add(30*time.Second)))
+	assert.True(t, r.Baked(start.Add(time.Minute)))
+	assert.True(t, r.IsLastWave())
+}
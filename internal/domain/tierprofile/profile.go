@@ -0,0 +1,92 @@
+package tierprofile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+// BuiltinRevision is recorded on instances deployed from the built-in profile
+// because the catalog had no revision for their tier.
+const BuiltinRevision = 0
+
+var (
+	ErrUnknownTier      = errors.New("unknown tier")
+	ErrInvalidProfile   = errors.New("invalid tier profile")
+	ErrRevisionConflict = errors.New("tier profile was modified concurrently")
+)
+
+// Tiers lists every tier the catalog must hold a profile for.
+var Tiers = []instance.Tier{
+	instance.TierFreeTrial,
+	instance.TierStarter,
+	instance.TierPro,
+	instance.TierTeam,
+	instance.TierEnterprise,
+}
+
+// Profile is one revision of a tier's resources, placement, update strategy
+// and quotas. Revisions are append-only; the highest one is current.
+type Profile struct {
+	Tier      instance.Tier     `gorm:"primaryKey;column:tier" json:"tier"`
+	Revision  int               `gorm:"primaryKey;column:revision" json:"revision"`
+	Spec      nomad.TierProfile `gorm:"column:spec;type:jsonb;serializer:json" json:"spec"`
+	Comment   string            `gorm:"column:comment" json:"comment,omitempty"`
+	CreatedBy string            `gorm:"column:created_by" json:"created_by,omitempty"` // Actor that saved the revision
+	CreatedAt time.Time         `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Profile) TableName() string {
+	return "tier_profiles"
+}
+
+// Validate checks that the tier is known and the spec defines every key.
+func (p *Profile) Validate() error {
+	if err := ValidateTier(p.Tier); err != nil {
+		return err
+	}
+	if err := p.Spec.Validate(); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidProfile, p.Tier, err)
+	}
+	return nil
+}
+
+// ValidateTier fails for tiers outside Tiers.
+func ValidateTier(tier instance.Tier) error {
+	for _, known := range Tiers {
+		if tier == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownTier, tier)
+}
+
+// Builtin returns the profile used when the catalog has no revision for tier.
+func Builtin(tier instance.Tier) *Profile {
+	return &Profile{
+		Tier:     tier,
+		Revision: BuiltinRevision,
+		Spec:     nomad.DefaultTierProfile(nomad.Tier(tier)),
+	}
+}
+
+// Repository persists the tier profile catalog.
+type Repository interface {
+	// Current retrieves the latest revision of a tier, or nil when the catalog
+	// has none.
+	Current(ctx context.Context, tier instance.Tier) (*Profile, error)
+
+	// ListCurrent retrieves the latest revision of every tier in the catalog.
+	ListCurrent(ctx context.Context) ([]*Profile, error)
+
+	// History retrieves the revisions of a tier, newest first.
+	History(ctx context.Context, tier instance.Tier, limit int) ([]*Profile, error)
+
+	// Create appends a revision. It returns ErrRevisionConflict when the
+	// revision already exists.
+	Create(ctx context.Context, profile *Profile) error
+}
@@ -0,0 +1,119 @@
+package onboarding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/outbox"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db"
+	"gorm.io/gorm"
+)
+
+// CreateEnvironment adds a non-production instance (e.g. staging) to an organization.
+// The new instance inherits tier and plan from the production instance and is
+// deployed asynchronously through the outbox.
+func (s *Service) CreateEnvironment(ctx context.Context, orgID int64, environment string) (*instance.Instance, error) {
+	env := strings.ToLower(strings.TrimSpace(environment))
+	if err := instance.ValidateEnvironment(env); err != nil {
+		return nil, err
+	}
+	if env == instance.DefaultEnvironment {
+		return nil, instance.ErrEnvironmentExists
+	}
+
+	var inst instance.Instance
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var org Organization
+		if err := tx.First(&org, "id = ?", orgID).Error; err != nil {
+			return fmt.Errorf("failed to fetch organization: %w", err)
+		}
+
+		var live []instance.Instance
+		if err := tx.Where("org_id = ? AND status <> ?", orgID, instance.StatusTerminated).
+			Order("created_at ASC").
+			Find(&live).Error; err != nil {
+			return fmt.Errorf("failed to list instances: %w", err)
+		}
+
+		var primary *instance.Instance
+		for i := range live {
+			if live[i].Environment == env {
+				return instance.ErrEnvironmentExists
+			}
+			if primary == nil && live[i].IsDefaultEnvironment() && live[i].Role == instance.RolePrimary {
+				primary = &live[i]
+			}
+		}
+		if primary == nil {
+			return fmt.Errorf("organization has no production instance")
+		}
+		if len(live) >= instance.MaxInstances(primary.Tier) {
+			return instance.ErrInstanceLimitReached
+		}
+
+		// Environment hosts share the org slug namespace, so make sure no
+		// other organization already owns the derived subdomain.
+		hostLabel := (&instance.Instance{Environment: env}).HostLabel(org.Slug)
+		var taken int64
+		if err := tx.Model(&Organization{}).Where("slug = ?", hostLabel).Count(&taken).Error; err != nil {
+			return fmt.Errorf("failed to check environment host: %w", err)
+		}
+		if taken > 0 {
+			return fmt.Errorf("environment host %s is already taken", hostLabel)
+		}
+
+		now := time.Now()
+		inst = instance.Instance{
+			ID:             s.snowflake.GenerateID(),
+			OrgID:          orgID,
+			Environment:    env,
+			Status:         instance.StatusInit,
+			Role:           instance.RolePrimary,
+			LifecycleState: instance.LifecycleReady,
+			Readiness:      instance.ReadinessUnknown,
+			NomadJobID:     instance.JobIDFor(orgID, env),
+			DesiredVersion: primary.CurrentVersion,
+			Tier:           primary.Tier,
+			ComputeEngine:  primary.ComputeEngine,
+			Provisioner:    primary.Provisioner,
+			PlanID:         primary.PlanID,
+			PriceID:        primary.PriceID,
+			LaunchURL:      buildLaunchURL(s.cfg, hostLabel),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if inst.DesiredVersion == "" {
+			inst.DesiredVersion = primary.DesiredVersion
+		}
+		if err := tx.Create(&inst).Error; err != nil {
+			if db.IsDuplicateKeyErr(err) {
+				return instance.ErrEnvironmentExists
+			}
+			return fmt.Errorf("failed to create instance: %w", err)
+		}
+		if err := tx.Create(instance.NewEvent(nil, &inst, instance.ActorFromContext(ctx), inst.CreatedAt)).Error; err != nil {
+			return fmt.Errorf("failed to record instance event: %w", err)
+		}
+
+		event := outbox.Event{
+			EventType:  outboxevent.TypeDeployInstance,
+			OrgID:      orgID,
+			InstanceID: inst.ID,
+			Status:     outboxevent.StatusPending,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			return fmt.Errorf("failed to create outbox event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
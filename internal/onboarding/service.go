@@ -9,6 +9,7 @@ import (
 
 	"github.com/railzwaylabs/railzway-cloud/internal/config"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
 	"github.com/railzwaylabs/railzway-cloud/internal/outbox"
 	"github.com/railzwaylabs/railzway-cloud/internal/user"
 	"github.com/railzwaylabs/railzway-cloud/internal/version"
@@ -172,10 +173,12 @@ func (s *Service) InitializeOrganization(ctx context.Context, req InitRequest) (
 			Role:           instance.RolePrimary,
 			LifecycleState: instance.LifecycleReady,
 			Readiness:      instance.ReadinessUnknown,
-			NomadJobID:     fmt.Sprintf("railzway-org-%d", org.ID),
+			Environment:    instance.DefaultEnvironment,
+			NomadJobID:     instance.DefaultJobID(org.ID),
 			DesiredVersion: desiredVersion,
 			Tier:           tier,
 			ComputeEngine:  instance.EngineGCP,
+			Provisioner:    s.cfg.DefaultProvisioner,
 			PlanID:         req.PlanID,
 			PriceID:        priceID,
 			LaunchURL:      buildLaunchURL(s.cfg, slug),
@@ -185,13 +188,16 @@ func (s *Service) InitializeOrganization(ctx context.Context, req InitRequest) (
 		if err := tx.Create(&inst).Error; err != nil {
 			return fmt.Errorf("failed to create instance: %w", err)
 		}
+		if err := tx.Create(instance.NewEvent(nil, &inst, instance.ActorFromContext(ctx), inst.CreatedAt)).Error; err != nil {
+			return fmt.Errorf("failed to record instance event: %w", err)
+		}
 
 		// 6. Create outbox event in the same transaction to make side effects durable.
 		event := outbox.Event{
-			EventType:  outbox.EventTypeDeployInstance,
+			EventType:  outboxevent.TypeDeployInstance,
 			OrgID:      org.ID,
 			InstanceID: inst.ID,
-			Status:     outbox.StatusPending,
+			Status:     outboxevent.StatusPending,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
 		}
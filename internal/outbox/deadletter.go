@@ -0,0 +1,162 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+	"gorm.io/gorm"
+)
+
+const maxDeadEventPageSize = 250
+
+// ErrReasonRequired is returned when dead events are discarded without a reason.
+var ErrReasonRequired = errors.New("a reason is required to discard events")
+
+// DeadLetters lets operators inspect the events that used up their attempts
+// and either replay or discard them.
+type DeadLetters struct {
+	db *gorm.DB
+}
+
+func NewDeadLetters(db *gorm.DB) *DeadLetters {
+	return &DeadLetters{db: db}
+}
+
+// List returns dead events, newest first.
+func (d *DeadLetters) List(ctx context.Context, page pagination.Pagination) ([]*Event, *pagination.PageInfo, error) {
+	if page.PageSize <= 0 {
+		page.PageSize = 10
+	}
+	if page.PageSize > maxDeadEventPageSize {
+		page.PageSize = maxDeadEventPageSize
+	}
+
+	query := d.db.WithContext(ctx).
+		Where("status = ?", outboxevent.StatusDead).
+		Order("id DESC").
+		Limit(page.PageSize + 1)
+	if page.PageToken != "" {
+		cursor, err := pagination.DecodeCursor(page.PageToken)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", instance.ErrInvalidPageToken, err)
+		}
+		before, err := strconv.ParseInt(cursor.ID, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", instance.ErrInvalidPageToken, err)
+		}
+		query = query.Where("id < ?", before)
+	}
+
+	var items []*Event
+	if err := query.Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+
+	pageInfo := pagination.BuildCursorPageInfo(items, int32(page.PageSize), func(e *Event) string {
+		token, _ := pagination.EncodeCursor(pagination.Cursor{
+			ID:        strconv.FormatInt(e.ID, 10),
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		})
+		return token
+	})
+	if len(items) > page.PageSize {
+		items = items[:page.PageSize]
+	}
+	return items, pageInfo, nil
+}
+
+// Replay moves dead events back to pending with their attempts reset, and
+// returns how many were replayed. IDs that are not dead are skipped.
+func (d *DeadLetters) Replay(ctx context.Context, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := d.db.WithContext(ctx).Model(&Event{}).
+		Where("id IN ? AND status = ?", ids, outboxevent.StatusDead).
+		Updates(map[string]any{
+			"status":          outboxevent.StatusPending,
+			"attempts":        0,
+			"locked_at":       nil,
+			"next_attempt_at": nil,
+			"updated_at":      time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("replay dead events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Discard drops dead events for good and records the reason in the audit
+// history of their instances. It returns how many were discarded; IDs that
+// are not dead are skipped.
+func (d *DeadLetters) Discard(ctx context.Context, ids []int64, reason string) (int64, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return 0, ErrReasonRequired
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var discarded int64
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []Event
+		if err := tx.Raw(
+			`SELECT * FROM outbox_events WHERE id IN ? AND status = ? ORDER BY id `+forUpdate(tx),
+			ids,
+			outboxevent.StatusDead,
+		).Scan(&events).Error; err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		actor := instance.ActorFromContext(ctx)
+		for _, event := range events {
+			if err := tx.Model(&Event{}).
+				Where("id = ?", event.ID).
+				Updates(map[string]any{
+					"status":       outboxevent.StatusDiscarded,
+					"processed_at": now,
+					"updated_at":   now,
+				}).Error; err != nil {
+				return fmt.Errorf("discard event %d: %w", event.ID, err)
+			}
+
+			var inst instance.Instance
+			err := tx.First(&inst, "id = ?", event.InstanceID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			note := fmt.Sprintf("discarded %s event %d: %s", event.EventType, event.ID, reason)
+			if err := tx.Create(instance.NewActionEvent(&inst, actor, note, now)).Error; err != nil {
+				return fmt.Errorf("record instance event: %w", err)
+			}
+		}
+
+		discarded = int64(len(events))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return discarded, nil
+}
+
+// forUpdate locks the selected rows on Postgres. SQLite has a single writer.
+func forUpdate(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "FOR UPDATE"
+	}
+	return ""
+}
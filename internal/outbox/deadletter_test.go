@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/adapter/repository/postgres"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessor_LastAttemptMovesEventToDeadLetters(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		return errors.New("nomad unavailable")
+	}))
+	event := seedEvent(t, gdb, outboxevent.TypeStopInstance, nil)
+	require.NoError(t, gdb.Model(&Event{}).Where("id = ?", event.ID).Update("attempts", processor.maxAttempts-1).Error)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	dead := reloadEvent(t, gdb, event.ID)
+	assert.Equal(t, outboxevent.StatusDead, dead.Status)
+	assert.Equal(t, "nomad unavailable", dead.LastError)
+	assert.Nil(t, dead.NextAttemptAt)
+
+	items, _, err := NewDeadLetters(gdb).List(context.Background(), pagination.Pagination{PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, event.ID, items[0].ID)
+}
+
+func TestDeadLetters_ReplayResetsAttempts(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+	deadLetters := NewDeadLetters(gdb)
+
+	var runs int
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		runs++
+		return nil
+	}))
+	dead := seedEvent(t, gdb, outboxevent.TypeStopInstance, nil)
+	require.NoError(t, gdb.Model(&Event{}).Where("id = ?", dead.ID).
+		Updates(map[string]any{"status": outboxevent.StatusDead, "attempts": processor.maxAttempts}).Error)
+	pending := seedEvent(t, gdb, outboxevent.TypeStartInstance, nil)
+
+	replayed, err := deadLetters.Replay(context.Background(), []int64{dead.ID, pending.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), replayed, "only dead events are replayed")
+
+	replay := reloadEvent(t, gdb, dead.ID)
+	assert.Equal(t, outboxevent.StatusPending, replay.Status)
+	assert.Zero(t, replay.Attempts)
+
+	require.NoError(t, processor.drain(context.Background()))
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, outboxevent.StatusCompleted, reloadEvent(t, gdb, dead.ID).Status)
+}
+
+func TestDeadLetters_DiscardRecordsReason(t *testing.T) {
+	_, _, gdb := newTestProcessor(t)
+	require.NoError(t, gdb.AutoMigrate(&postgres.InstanceModel{}, &instance.Event{}))
+	deadLetters := NewDeadLetters(gdb)
+
+	require.NoError(t, gdb.Create(&postgres.InstanceModel{ID: 2, OrgID: 1, Status: string(instance.StatusProvisionFailed)}).Error)
+	event := seedEvent(t, gdb, outboxevent.TypeDeployInstance, nil)
+	require.NoError(t, gdb.Model(&Event{}).Where("id = ?", event.ID).Update("status", outboxevent.StatusDead).Error)
+
+	_, err := deadLetters.Discard(context.Background(), []int64{event.ID}, " ")
+	assert.ErrorIs(t, err, ErrReasonRequired)
+
+	ctx := instance.WithActor(context.Background(), instance.Actor{Type: instance.ActorAdmin, ID: "admin_token"})
+	discarded, err := deadLetters.Discard(ctx, []int64{event.ID}, "org was deleted")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), discarded)
+	assert.Equal(t, outboxevent.StatusDiscarded, reloadEvent(t, gdb, event.ID).Status)
+
+	var audit instance.Event
+	require.NoError(t, gdb.Where("instance_id = ?", 2).First(&audit).Error)
+	assert.Equal(t, instance.ActorAdmin, audit.ActorType)
+	assert.Equal(t, instance.StatusProvisionFailed, audit.NewStatus)
+	assert.Equal(t, "discarded deploy_instance event 1: org was deleted", audit.Reason)
+}
@@ -0,0 +1,500 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"github.com/railzwaylabs/railzway-cloud/pkg/railzwayclient"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DeployHandler handles deploy_instance events: it bills the default
+// environment of an organization in Railzway OSS and deploys the instance as
+// a saga. Every step is checkpointed, so a retried event resumes at the step
+// that failed, and what the steps created is compensated once the event runs
+// out of attempts.
+type DeployHandler struct {
+	db          *gorm.DB
+	deployUC    *deployment.DeployUseCase
+	sagas       instance.DeploySagaRepository
+	ossClient   *railzwayclient.Client
+	logger      *zap.Logger
+	maxAttempts int
+}
+
+func NewDeployHandler(db *gorm.DB, deployUC *deployment.DeployUseCase, sagas instance.DeploySagaRepository, ossClient *railzwayclient.Client, logger *zap.Logger) *DeployHandler {
+	return &DeployHandler{
+		db:          db,
+		deployUC:    deployUC,
+		sagas:       sagas,
+		ossClient:   ossClient,
+		logger:      logger,
+		maxAttempts: deployment.MaxDeployAttempts,
+	}
+}
+
+type organizationRecord struct {
+	ID            int64  `gorm:"primaryKey"`
+	Name          string `gorm:"type:varchar(255)"`
+	OSSCustomerID string `gorm:"column:oss_customer_id"`
+}
+
+func (organizationRecord) TableName() string {
+	return "organizations"
+}
+
+// Handle fails the instance's provisioning when the deploy fails, so the
+// failure is visible on the instance while the event is retried.
+func (h *DeployHandler) Handle(ctx context.Context, event Event) error {
+	err := h.deploy(ctx, event)
+	if err != nil && event.InstanceID != 0 {
+		_ = h.markInstanceProvisionFailed(ctx, event.InstanceID, err.Error())
+	}
+	return err
+}
+
+func (h *DeployHandler) deploy(ctx context.Context, event Event) error {
+	inst, err := h.loadInstance(ctx, event.InstanceID)
+	if err != nil {
+		return fmt.Errorf("load instance: %w", err)
+	}
+	if inst == nil {
+		return fmt.Errorf("instance not found")
+	}
+	if inst.OrgID != event.OrgID {
+		return fmt.Errorf("instance org mismatch")
+	}
+	if len(event.Payload) > 0 {
+		var req deployment.DeployRequest
+		if err := event.DecodePayload(&req); err != nil {
+			return err
+		}
+		// The requested version becomes the desired one once it is deployed.
+		if req.Version != "" {
+			inst.DesiredVersion = req.Version
+		}
+	}
+
+	// Serving instances are already billed; a version or tier profile change
+	// is rolled out as a blue/green upgrade by the deploy use case.
+	if inst.IsServing() {
+		redeploy, err := h.deployUC.NeedsRedeploy(ctx, inst)
+		if err != nil {
+			return err
+		}
+		if redeploy {
+			if err := h.deployUC.ExecuteInstance(ctx, inst.ID, inst.DesiredVersion); err != nil {
+				return fmt.Errorf("upgrade failed: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if err := h.markInstanceProvisioning(ctx, inst.ID); err != nil {
+		return err
+	}
+
+	saga, err := h.loadSaga(ctx, event, inst)
+	if err != nil {
+		return err
+	}
+	if saga.IsFinished() {
+		return nil
+	}
+
+	var org *organizationRecord
+	if inst.IsDefaultEnvironment() {
+		org, err = h.loadOrganization(ctx, event.OrgID)
+		if err != nil {
+			return fmt.Errorf("load organization: %w", err)
+		}
+		if org == nil {
+			return fmt.Errorf("organization not found")
+		}
+	}
+
+	for _, step := range saga.Steps {
+		if step.Status == instance.SagaStepCompleted {
+			continue
+		}
+
+		saga.StartStep(step.Name, createsResource(saga, step.Name, inst, org), time.Now().UTC())
+		if err := h.sagas.Save(ctx, saga); err != nil {
+			return fmt.Errorf("checkpoint deploy saga: %w", err)
+		}
+
+		stepErr := h.runStep(ctx, event, step.Name, inst, org)
+		now := time.Now().UTC()
+		if stepErr != nil {
+			saga.FailStep(step.Name, stepErr.Error(), now)
+			if event.Attempts >= h.maxAttempts {
+				h.compensate(ctx, event, saga, inst)
+			}
+			if err := h.sagas.Save(ctx, saga); err != nil {
+				h.logger.Error("failed_to_checkpoint_deploy_saga", zap.Int64("saga_id", saga.ID), zap.Error(err))
+			}
+			return stepErr
+		}
+
+		saga.CompleteStep(step.Name, now)
+		if err := h.sagas.Save(ctx, saga); err != nil {
+			return fmt.Errorf("checkpoint deploy saga: %w", err)
+		}
+	}
+
+	saga.MarkCompleted(time.Now().UTC())
+	if err := h.sagas.Save(ctx, saga); err != nil {
+		return fmt.Errorf("checkpoint deploy saga: %w", err)
+	}
+	return nil
+}
+
+// loadSaga returns the saga of the event, starting one on its first attempt.
+// A compensated saga belongs to a replayed dead event and starts over.
+func (h *DeployHandler) loadSaga(ctx context.Context, event Event, inst *instance.Instance) (*instance.DeploySaga, error) {
+	saga, err := h.sagas.FindByEventID(ctx, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load deploy saga: %w", err)
+	}
+	if saga != nil {
+		if saga.Status == instance.DeploySagaCompensated {
+			saga.Restart(time.Now().UTC())
+			if err := h.sagas.Save(ctx, saga); err != nil {
+				return nil, fmt.Errorf("restart deploy saga: %w", err)
+			}
+		}
+		return saga, nil
+	}
+
+	steps := instance.EnvironmentDeploySteps
+	if inst.IsDefaultEnvironment() {
+		steps = instance.BillingDeploySteps
+	}
+	saga = instance.NewDeploySaga(event.ID, inst, steps, time.Now().UTC())
+	if err := h.sagas.Create(ctx, saga); err != nil {
+		return nil, fmt.Errorf("create deploy saga: %w", err)
+	}
+	return saga, nil
+}
+
+func (h *DeployHandler) runStep(ctx context.Context, event Event, step instance.DeployStep, inst *instance.Instance, org *organizationRecord) error {
+	switch step {
+	case instance.DeployStepEnsureCustomer:
+		return h.ensureCustomer(ctx, event, org)
+	case instance.DeployStepCreateSubscription:
+		return h.ensureSubscription(ctx, event, inst, org)
+	case instance.DeployStepActivateSubscription:
+		// Activate subscription BEFORE deployment to ensure billing is active
+		// before the instance starts running
+		if inst.SubscriptionID == "" {
+			return nil
+		}
+		key := outboxevent.IdempotencyKey(event.ID, "activate-subscription:"+inst.SubscriptionID)
+		if err := h.ossClient.ActivateSubscription(ctx, inst.SubscriptionID, key); err != nil {
+			return fmt.Errorf("activate subscription: %w", err)
+		}
+		h.logger.Info("subscription_activated",
+			zap.String("subscription_id", inst.SubscriptionID),
+			zap.Int64("org_id", inst.OrgID),
+		)
+		return nil
+	case instance.DeployStepProvisionDatabase:
+		return h.deployUC.ProvisionDatabase(ctx, inst.ID)
+	case instance.DeployStepDeployWorkload:
+		if err := h.deployUC.DeployWorkload(ctx, inst.ID, inst.DesiredVersion); err != nil {
+			return fmt.Errorf("deployment failed: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown deploy step %s", step)
+	}
+}
+
+// createsResource reports whether step finds its resource missing, so the
+// saga creates it rather than reusing one that existed before.
+func createsResource(saga *instance.DeploySaga, step instance.DeployStep, inst *instance.Instance, org *organizationRecord) bool {
+	switch step {
+	case instance.DeployStepEnsureCustomer:
+		return org.OSSCustomerID == ""
+	case instance.DeployStepCreateSubscription:
+		return inst.SubscriptionID == ""
+	case instance.DeployStepActivateSubscription:
+		return saga.Created(instance.DeployStepCreateSubscription)
+	case instance.DeployStepProvisionDatabase:
+		return inst.DBUser == ""
+	default:
+		return true
+	}
+}
+
+// compensate undoes what the steps created, last first, and gives the saga
+// up. A compensation that fails leaves its step as it was, so the status API
+// shows what is left behind. An instance that served before keeps everything:
+// the saga is left as it is, and replaying the dead event resumes it.
+func (h *DeployHandler) compensate(ctx context.Context, event Event, saga *instance.DeploySaga, inst *instance.Instance) {
+	if inst.LastGoodVersion != "" {
+		h.logger.Warn("deploy_compensation_skipped",
+			zap.Int64("saga_id", saga.ID),
+			zap.Int64("instance_id", inst.ID),
+			zap.String("last_good_version", inst.LastGoodVersion),
+		)
+		return
+	}
+	for _, step := range saga.StepsToCompensate() {
+		if err := h.compensateStep(ctx, event, step, saga.InstanceID); err != nil {
+			h.logger.Error("deploy_compensation_failed",
+				zap.Int64("saga_id", saga.ID),
+				zap.String("step", string(step)),
+				zap.Error(err),
+			)
+			continue
+		}
+		saga.CompensateStep(step, time.Now().UTC())
+	}
+	saga.MarkCompensated(time.Now().UTC())
+}
+
+func (h *DeployHandler) compensateStep(ctx context.Context, event Event, step instance.DeployStep, instanceID int64) error {
+	switch step {
+	case instance.DeployStepDeployWorkload:
+		return h.deployUC.RemoveWorkload(ctx, instanceID)
+	case instance.DeployStepProvisionDatabase:
+		return h.deployUC.DeprovisionDatabase(ctx, instanceID)
+	case instance.DeployStepCreateSubscription:
+		inst, err := h.loadInstance(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+		if inst != nil {
+			h.rollbackSubscription(ctx, event, inst.SubscriptionID)
+		}
+		return nil
+	default:
+		// The customer is reused by the next deploy, and cancelling the
+		// subscription also undoes its activation.
+		return nil
+	}
+}
+
+func (h *DeployHandler) loadInstance(ctx context.Context, instanceID int64) (*instance.Instance, error) {
+	var inst instance.Instance
+	if err := h.db.WithContext(ctx).First(&inst, "id = ?", instanceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &inst, nil
+}
+
+func (h *DeployHandler) loadOrganization(ctx context.Context, orgID int64) (*organizationRecord, error) {
+	var org organizationRecord
+	if err := h.db.WithContext(ctx).First(&org, "id = ?", orgID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (h *DeployHandler) ensureCustomer(ctx context.Context, event Event, org *organizationRecord) error {
+	if org.OSSCustomerID != "" {
+		return nil
+	}
+
+	externalID := fmt.Sprintf("org_%d", org.ID)
+	orgEmail := fmt.Sprintf("org_%d@railzway.com", org.ID)
+
+	customer, err := h.ossClient.EnsureCustomer(ctx, org.Name, orgEmail, externalID, outboxevent.IdempotencyKey(event.ID, "ensure-customer"))
+	if err != nil {
+		return fmt.Errorf("ensure oss customer: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if err := h.db.WithContext(ctx).Model(&organizationRecord{}).
+		Where("id = ?", org.ID).
+		Updates(map[string]any{
+			"oss_customer_id": customer.ID,
+			"updated_at":      now,
+		}).Error; err != nil {
+		return fmt.Errorf("update organization oss customer id: %w", err)
+	}
+
+	org.OSSCustomerID = customer.ID
+	return nil
+}
+
+func (h *DeployHandler) ensureSubscription(ctx context.Context, event Event, inst *instance.Instance, org *organizationRecord) error {
+	// A replacement gets its own key; reusing the first one would make OSS
+	// answer with the subscription being replaced.
+	step := "create-subscription"
+	if inst.SubscriptionID != "" {
+		step += ":replace:" + inst.SubscriptionID
+		subscription, err := h.ossClient.GetSubscription(ctx, inst.SubscriptionID)
+		switch {
+		case errors.Is(err, railzwayclient.ErrNotFound):
+			h.logger.Warn("subscription_missing_replacing",
+				zap.String("subscription_id", inst.SubscriptionID),
+				zap.Int64("org_id", inst.OrgID),
+			)
+			inst.SubscriptionID = ""
+		case err != nil:
+			return fmt.Errorf("load subscription: %w", err)
+		case shouldReplaceSubscription(subscription.Status):
+			h.logger.Warn("subscription_inactive_replacing",
+				zap.String("subscription_id", inst.SubscriptionID),
+				zap.String("status", subscription.Status),
+				zap.Int64("org_id", inst.OrgID),
+			)
+			inst.SubscriptionID = ""
+		default:
+			return nil
+		}
+	}
+
+	priceID := strings.TrimSpace(inst.PriceID)
+	if priceID == "" {
+		return fmt.Errorf("missing price_id in instance - price must be selected during provisioning")
+	}
+
+	items := []railzwayclient.CreateSubscriptionItemRequest{
+		{
+			PriceID:  priceID,
+			Quantity: 1,
+		},
+	}
+
+	subscription, err := h.ossClient.CreateSubscription(ctx, org.OSSCustomerID, "monthly", items, outboxevent.IdempotencyKey(event.ID, step))
+	if err != nil {
+		return fmt.Errorf("create subscription: %w", err)
+	}
+
+	now := time.Now().UTC()
+	updates := map[string]any{
+		"subscription_id":  subscription.ID,
+		"updated_at":       now,
+		"resource_version": gorm.Expr("resource_version + 1"),
+	}
+	if inst.PriceID == "" && priceID != "" {
+		updates["price_id"] = priceID
+	}
+
+	if err := h.db.WithContext(ctx).Model(&instance.Instance{}).
+		Where("id = ?", inst.ID).
+		Updates(updates).Error; err != nil {
+		return fmt.Errorf("update subscription id: %w", err)
+	}
+
+	inst.SubscriptionID = subscription.ID
+	inst.ResourceVersion++
+	if inst.PriceID == "" && priceID != "" {
+		inst.PriceID = priceID
+	}
+	return nil
+}
+
+func shouldReplaceSubscription(status string) bool {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "CANCELED", "CANCELLED", "ENDED":
+		return true
+	default:
+		return false
+	}
+}
+
+func (h *DeployHandler) markInstanceProvisioning(ctx context.Context, instanceID int64) error {
+	allowed := []instance.InstanceStatus{instance.StatusInit, instance.StatusProvisionFailed}
+	return h.markInstanceStatus(ctx, instanceID, allowed, instance.StatusProvisioning, "")
+}
+
+func (h *DeployHandler) markInstanceProvisionFailed(ctx context.Context, instanceID int64, errMsg string) error {
+	allowed := []instance.InstanceStatus{instance.StatusInit, instance.StatusProvisioning}
+	return h.markInstanceStatus(ctx, instanceID, allowed, instance.StatusProvisionFailed, errMsg)
+}
+
+func (h *DeployHandler) markInstanceStatus(ctx context.Context, instanceID int64, allowed []instance.InstanceStatus, next instance.InstanceStatus, errMsg string) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var inst instance.Instance
+		if err := tx.First(&inst, "id = ?", instanceID).Error; err != nil {
+			return err
+		}
+		if inst.Status == next {
+			return nil
+		}
+		if !slices.Contains(allowed, inst.Status) {
+			return fmt.Errorf("invalid state transition from %s to %s", inst.Status, next)
+		}
+
+		now := time.Now().UTC()
+		updates := map[string]any{
+			"status":           next,
+			"updated_at":       now,
+			"resource_version": gorm.Expr("resource_version + 1"),
+		}
+		if errMsg == "" {
+			updates["last_error"] = nil
+		} else {
+			updates["last_error"] = errMsg
+		}
+
+		result := tx.Model(&instance.Instance{}).
+			Where("id = ? AND status = ?", instanceID, inst.Status).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("instance %d changed status concurrently", instanceID)
+		}
+
+		prev := inst
+		inst.Status = next
+		inst.LastError = errMsg
+		inst.UpdatedAt = now
+		if event := instance.NewEvent(&prev, &inst, instance.ActorFromContext(ctx), now); event != nil {
+			return tx.Create(event).Error
+		}
+		return nil
+	})
+}
+
+// rollbackSubscription cancels a subscription immediately when deployment fails.
+// This prevents users from being charged for instances that failed to deploy.
+func (h *DeployHandler) rollbackSubscription(ctx context.Context, event Event, subscriptionID string) {
+	if subscriptionID == "" {
+		return
+	}
+
+	h.logger.Info("rolling_back_subscription",
+		zap.String("subscription_id", subscriptionID),
+	)
+
+	// Cancel immediately (not at period end) since deployment failed.
+	// A missing or already canceled subscription bills nothing either.
+	err := h.ossClient.CancelSubscription(ctx, subscriptionID, false, outboxevent.IdempotencyKey(event.ID, "cancel-subscription:"+subscriptionID))
+	switch {
+	case err == nil:
+		h.logger.Info("subscription_rolled_back",
+			zap.String("subscription_id", subscriptionID),
+		)
+	case errors.Is(err, railzwayclient.ErrNotFound), errors.Is(err, railzwayclient.ErrConflict):
+		h.logger.Info("subscription_already_inactive",
+			zap.String("subscription_id", subscriptionID),
+			zap.Error(err),
+		)
+	default:
+		h.logger.Error("failed_to_rollback_subscription",
+			zap.String("subscription_id", subscriptionID),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/adapter/repository/postgres"
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/cryptoutils"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/internal/organization"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// newTestDeployHandler deploys environments whose workload always fails:
+// their organization is missing, so its slug cannot be resolved.
+func newTestDeployHandler(t *testing.T) (*DeployHandler, *testhelper.MockDatabaseProvisioner, *gorm.DB) {
+	t.Helper()
+
+	gdb, err := db.NewTest()
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, gdb.AutoMigrate(
+		&Event{},
+		&postgres.InstanceModel{},
+		&postgres.DeploySagaModel{},
+		&instance.Event{},
+		&organization.Organization{},
+	))
+
+	keyring, err := cryptoutils.NewKeyring("default", map[string]string{
+		"default": base64.StdEncoding.EncodeToString(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+	repo := postgres.NewRepository(gdb, cryptoutils.NewSecrets(keyring, ""))
+	dbProvisioner := &testhelper.MockDatabaseProvisioner{}
+	deployUC := deployment.NewDeployUseCase(
+		repo,
+		&testhelper.MockProvisioner{},
+		nil,
+		dbProvisioner,
+		provisioning.DBConfig{Host: "localhost", Port: 5432},
+		deployment.RuntimeConfig{},
+		organization.NewService(gdb),
+		nil,
+		&config.Config{},
+		nil,
+		nil,
+		nil,
+	)
+	handler := NewDeployHandler(gdb, deployUC, postgres.NewDeploySagaRepository(gdb), nil, zap.NewNop())
+	return handler, dbProvisioner, gdb
+}
+
+func seedEnvironment(t *testing.T, gdb *gorm.DB, model postgres.InstanceModel) {
+	t.Helper()
+
+	model.ID, model.OrgID = 2, 1
+	model.Environment = "staging"
+	model.Status = string(instance.StatusProvisionFailed)
+	model.Tier = string(instance.TierFreeTrial)
+	model.DesiredVersion = "v1.6.0"
+	require.NoError(t, gdb.Create(&model).Error)
+}
+
+// exhaustDeploy runs the last attempt of a deploy event.
+func exhaustDeploy(t *testing.T, handler *DeployHandler) *instance.DeploySaga {
+	t.Helper()
+
+	event := Event{ID: 9, EventType: outboxevent.TypeDeployInstance, OrgID: 1, InstanceID: 2, Attempts: deployment.MaxDeployAttempts}
+	assert.ErrorContains(t, handler.Handle(context.Background(), event), "failed to resolve org slug")
+	saga, err := handler.sagas.FindByEventID(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saga)
+	return saga
+}
+
+func TestDeployHandler_CompensationDropsDatabaseItCreated(t *testing.T) {
+	handler, dbProvisioner, gdb := newTestDeployHandler(t)
+	seedEnvironment(t, gdb, postgres.InstanceModel{})
+
+	saga := exhaustDeploy(t, handler)
+
+	assert.Equal(t, instance.DeploySagaCompensated, saga.Status)
+	assert.Equal(t, instance.SagaStepCompensated, saga.Steps[0].Status)
+	assert.Len(t, dbProvisioner.DeprovisionCalls, 1)
+}
+
+func TestDeployHandler_CompensationKeepsExistingDatabase(t *testing.T) {
+	handler, dbProvisioner, gdb := newTestDeployHandler(t)
+	seedEnvironment(t, gdb, postgres.InstanceModel{
+		DBHost:     "localhost",
+		DBPort:     5432,
+		DBName:     "railzway_org_1_staging",
+		DBUser:     "railzway_user_1_staging",
+		DBPassword: "existing-password",
+	})
+
+	saga := exhaustDeploy(t, handler)
+
+	assert.Equal(t, instance.DeploySagaCompensated, saga.Status)
+	assert.Equal(t, instance.SagaStepCompleted, saga.Steps[0].Status)
+	assert.Empty(t, dbProvisioner.DeprovisionCalls)
+	var stored postgres.InstanceModel
+	require.NoError(t, gdb.First(&stored, 2).Error)
+	assert.Equal(t, "railzway_user_1_staging", stored.DBUser)
+	assert.Equal(t, "railzway_org_1_staging", stored.DBName)
+}
+
+func TestDeployHandler_CompensationSkipsInstanceThatServed(t *testing.T) {
+	handler, dbProvisioner, gdb := newTestDeployHandler(t)
+	seedEnvironment(t, gdb, postgres.InstanceModel{LastGoodVersion: "v1.5.0"})
+
+	saga := exhaustDeploy(t, handler)
+
+	// The saga stays open, so replaying the dead event resumes it.
+	assert.Equal(t, instance.DeploySagaRunning, saga.Status)
+	assert.Equal(t, instance.SagaStepCompleted, saga.Steps[0].Status)
+	assert.Empty(t, dbProvisioner.DeprovisionCalls)
+}
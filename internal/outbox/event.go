@@ -1,33 +1,27 @@
 package outbox
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
 
-type EventType string
-
-type EventStatus string
-
-const (
-	EventTypeDeployInstance EventType = "deploy_instance"
-)
-
-const (
-	StatusPending    EventStatus = "pending"
-	StatusProcessing EventStatus = "processing"
-	StatusCompleted  EventStatus = "completed"
-	StatusFailed     EventStatus = "failed"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
 )
 
 // Event represents a durable outbox entry for control-plane actions.
 type Event struct {
-	ID            int64       `gorm:"primaryKey"`
-	EventType     EventType   `gorm:"type:varchar(100);not null"`
-	OrgID         int64       `gorm:"not null"`
-	InstanceID    int64       `gorm:"not null"`
-	Status        EventStatus `gorm:"type:varchar(50);not null"`
-	Attempts      int         `gorm:"not null;default:0"`
-	LastError     string      `gorm:"type:text"`
+	ID            int64              `gorm:"primaryKey"`
+	EventType     outboxevent.Type   `gorm:"type:varchar(100);not null"`
+	OrgID         int64              `gorm:"not null"`
+	InstanceID    int64              `gorm:"not null"`
+	Status        outboxevent.Status `gorm:"type:varchar(50);not null"`
+	Attempts      int                `gorm:"not null;default:0"`
+	LastError     string             `gorm:"type:text"`
+	Payload       json.RawMessage    `gorm:"type:jsonb;serializer:json"` // Handler arguments, nil when the event takes none
 	LockedAt      *time.Time
 	NextAttemptAt *time.Time
+	RolloutID     *int64 // Set for events gated by a staged rollout
+	RolloutWave   *int
 	ProcessedAt   *time.Time
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
@@ -36,3 +30,14 @@ type Event struct {
 func (Event) TableName() string {
 	return "outbox_events"
 }
+
+// DecodePayload unmarshals the event payload into v.
+func (e Event) DecodePayload(v any) error {
+	if len(e.Payload) == 0 {
+		return fmt.Errorf("event %d has no payload", e.ID)
+	}
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("decode payload of event %d: %w", e.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+)
+
+// RegisterHandlers registers the built-in handlers. Other modules add their
+// event types the same way, from an fx.Invoke taking the *Registry.
+func RegisterHandlers(
+	registry *Registry,
+	deploy *DeployHandler,
+	deployUC *deployment.DeployUseCase,
+	lifecycleUC *deployment.LifecycleUseCase,
+	upgradeUC *deployment.UpgradeUseCase,
+) {
+	registry.Register(outboxevent.TypeDeployInstance, deploy)
+	registry.Register(outboxevent.TypeStopInstance, instanceHandler(lifecycleUC.StopInstance))
+	registry.Register(outboxevent.TypeStartInstance, instanceHandler(lifecycleUC.StartInstance))
+	registry.Register(outboxevent.TypeUpgradeInstance, tierChangeHandler(upgradeUC.UpgradeInstance))
+	registry.Register(outboxevent.TypeDowngradeInstance, tierChangeHandler(upgradeUC.DowngradeInstance))
+	registry.Register(outboxevent.TypeTerminateInstance, instanceHandler(lifecycleUC.TerminateInstance))
+	registry.Register(outboxevent.TypeRotateSecrets, secretRotationHandler(deployUC.RotateSecrets))
+	registry.Register(outboxevent.TypeExpireSecrets, HandlerFunc(func(ctx context.Context, event Event) error {
+		return deployUC.ExpireSecrets(ctx, event.InstanceID)
+	}))
+}
+
+// instanceHandler runs an operation on the instance of the event. The
+// operation keys its OSS calls with outboxevent.IdempotencyKey and the event
+// ID.
+func instanceHandler(op func(ctx context.Context, eventID, instanceID int64) error) Handler {
+	return HandlerFunc(func(ctx context.Context, event Event) error {
+		return op(ctx, event.ID, event.InstanceID)
+	})
+}
+
+// tierChangeHandler runs a tier change carried in a deployment.TierChange payload.
+func tierChangeHandler(op func(ctx context.Context, eventID, instanceID int64, tier instance.Tier) error) Handler {
+	return HandlerFunc(func(ctx context.Context, event Event) error {
+		var change deployment.TierChange
+		if err := event.DecodePayload(&change); err != nil {
+			return err
+		}
+		return op(ctx, event.ID, event.InstanceID, change.Tier)
+	})
+}
+
+// secretRotationHandler runs a rotation carried in a deployment.SecretRotation
+// payload. Events queued without a payload rotate the database password.
+func secretRotationHandler(op func(ctx context.Context, eventID, instanceID int64, rotation deployment.SecretRotation) error) Handler {
+	return HandlerFunc(func(ctx context.Context, event Event) error {
+		var rotation deployment.SecretRotation
+		if len(event.Payload) > 0 {
+			if err := event.DecodePayload(&rotation); err != nil {
+				return err
+			}
+		}
+		return op(ctx, event.ID, event.InstanceID, rotation)
+	})
+}
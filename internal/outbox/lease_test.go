@@ -0,0 +1,107 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+)
+
+func TestProcessor_ReclaimsEventOfKilledHandler(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+	processor.leaseDuration = 100 * time.Millisecond
+	processor.heartbeatInterval = 20 * time.Millisecond
+
+	started := make(chan struct{})
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	event := seedEvent(t, gdb, outboxevent.TypeStopInstance, nil)
+
+	// Killing the processor mid-handler leaves the event processing: the
+	// failure cannot be recorded and the heartbeat stops.
+	ctx, kill := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = processor.drain(ctx)
+	}()
+	<-started
+	kill()
+	<-done
+	assert.Equal(t, outboxevent.StatusProcessing, reloadEvent(t, gdb, event.ID).Status)
+
+	// A lease that is still fresh is left alone.
+	require.NoError(t, processor.reclaimExpiredLeases(context.Background()))
+	assert.Equal(t, outboxevent.StatusProcessing, reloadEvent(t, gdb, event.ID).Status)
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, processor.reclaimExpiredLeases(context.Background()))
+		return reloadEvent(t, gdb, event.ID).Status == outboxevent.StatusFailed
+	}, 2*time.Second, 20*time.Millisecond)
+
+	reclaimed := reloadEvent(t, gdb, event.ID)
+	assert.Contains(t, reclaimed.LastError, "lease expired")
+	require.NotNil(t, reclaimed.NextAttemptAt)
+	assert.True(t, reclaimed.NextAttemptAt.After(time.Now()), "the retry waits for its backoff")
+
+	// Once the backoff is over the event runs again.
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		return nil
+	}))
+	require.NoError(t, gdb.Model(&Event{}).Where("id = ?", event.ID).Update("next_attempt_at", time.Now().UTC().Add(-time.Second)).Error)
+	require.NoError(t, processor.drain(context.Background()))
+
+	completed := reloadEvent(t, gdb, event.ID)
+	assert.Equal(t, outboxevent.StatusCompleted, completed.Status)
+	assert.Equal(t, 2, completed.Attempts)
+}
+
+func TestProcessor_HeartbeatKeepsLongHandlerLeased(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+	processor.leaseDuration = 100 * time.Millisecond
+	processor.heartbeatInterval = 20 * time.Millisecond
+
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		time.Sleep(300 * time.Millisecond)
+		return processor.reclaimExpiredLeases(context.Background())
+	}))
+	event := seedEvent(t, gdb, outboxevent.TypeStopInstance, nil)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	processed := reloadEvent(t, gdb, event.ID)
+	assert.Equal(t, outboxevent.StatusCompleted, processed.Status)
+	assert.Equal(t, 1, processed.Attempts)
+}
+
+func TestProcessor_LostLeaseCancelsHandler(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+	processor.heartbeatInterval = 20 * time.Millisecond
+
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		// Another processor reclaimed and claimed the event meanwhile.
+		require.NoError(t, gdb.Model(&Event{}).Where("id = ?", event.ID).Update("attempts", event.Attempts+1).Error)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+			t.Error("handler kept running after its lease was lost")
+			return nil
+		}
+	}))
+	event := seedEvent(t, gdb, outboxevent.TypeStopInstance, nil)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	// The failure of the fenced-off handler does not touch the new claim.
+	processed := reloadEvent(t, gdb, event.ID)
+	assert.Equal(t, outboxevent.StatusProcessing, processed.Status)
+	assert.Equal(t, 2, processed.Attempts)
+}
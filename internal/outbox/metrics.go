@@ -0,0 +1,59 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+)
+
+var (
+	deadEvents = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "railzway_outbox_dead_events",
+			Help: "Number of outbox events in the dead-letter queue",
+		},
+		[]string{"event_type"},
+	)
+
+	deadEventsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "railzway_outbox_dead_events_total",
+			Help: "Total number of outbox events moved to the dead-letter queue",
+		},
+		[]string{"event_type"},
+	)
+
+	supersededEventsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "railzway_outbox_superseded_events_total",
+			Help: "Total number of queued outbox events cancelled in favour of a newer one",
+		},
+		[]string{"event_type"},
+	)
+)
+
+// observeDeadEvents sets the dead-letter gauge from the outbox table, so it
+// also drops when events are replayed or discarded from another process.
+func observeDeadEvents(ctx context.Context, db *gorm.DB) error {
+	var rows []struct {
+		EventType outboxevent.Type
+		Count     int64
+	}
+	if err := db.WithContext(ctx).Model(&Event{}).
+		Select("event_type, COUNT(*) AS count").
+		Where("status = ?", outboxevent.StatusDead).
+		Group("event_type").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	deadEvents.Reset()
+	for _, row := range rows {
+		deadEvents.WithLabelValues(string(row.EventType)).Set(float64(row.Count))
+	}
+	return nil
+}
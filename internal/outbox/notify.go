@@ -0,0 +1,72 @@
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+)
+
+// notifyChannel is notified by the outbox_events trigger whenever an event
+// becomes pending.
+const notifyChannel = "outbox_events"
+
+// listen wakes the processor on every notification until ctx is done. When
+// the connection breaks it listens again after a poll interval; polling
+// covers the gap.
+func (p *Processor) listen(ctx context.Context) {
+	if p.db.Dialector.Name() != "postgres" {
+		return
+	}
+
+	for {
+		err := p.waitForNotifications(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		p.logger.Warn("outbox_listen_failed", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// waitForNotifications holds a pooled connection for LISTEN. The connection
+// is discarded afterwards rather than returned to the pool still listening.
+func (p *Processor) waitForNotifications(ctx context.Context) error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("listen needs a pgx connection, got %T", driverConn)
+		}
+		if _, err := c.Conn().Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+			return fmt.Errorf("%w: %v", driver.ErrBadConn, err)
+		}
+		p.logger.Info("outbox_listening", zap.String("channel", notifyChannel))
+
+		// Events inserted before LISTEN took effect are picked up here.
+		p.wake()
+		for {
+			if _, err := c.Conn().WaitForNotification(ctx); err != nil {
+				return fmt.Errorf("%w: %v", driver.ErrBadConn, err)
+			}
+			p.wake()
+		}
+	})
+}
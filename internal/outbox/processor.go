@@ -3,43 +3,79 @@ package outbox
 import (
 	"context"
 	"fmt"
-	"strings"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/rollout"
 	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
-	"github.com/railzwaylabs/railzway-cloud/pkg/railzwayclient"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// Processor leases events by marking them processing. A running handler
+// renews the lease every heartbeatInterval; a lease older than leaseDuration
+// belongs to a processor that died and is reclaimed by the sweeper.
+//
+// Claimed events run on up to concurrency workers. A worker that finishes
+// frees its slot for the next due event right away, so a slow event only
+// holds up its own instance. At most one event per instance is in flight,
+// and the events of an instance run in the order they were inserted.
 type Processor struct {
-	db           *gorm.DB
-	deployUC     *deployment.DeployUseCase
-	ossClient    *railzwayclient.Client
-	logger       *zap.Logger
-	pollInterval time.Duration
-	batchSize    int
-	maxAttempts  int
+	db                *gorm.DB
+	handlers          *Registry
+	logger            *zap.Logger
+	pollInterval      time.Duration
+	batchSize         int
+	concurrency       int
+	maxAttempts       int
+	leaseDuration     time.Duration
+	heartbeatInterval time.Duration
+	wakeup            chan struct{}
+	freed             chan struct{} // Signalled when a worker finishes an event
+	inFlight          atomic.Int32
+	workers           sync.WaitGroup
 }
 
-func NewProcessor(db *gorm.DB, deployUC *deployment.DeployUseCase, ossClient *railzwayclient.Client, logger *zap.Logger) *Processor {
-	return &Processor{
-		db:           db,
-		deployUC:     deployUC,
-		ossClient:    ossClient,
-		logger:       logger,
-		pollInterval: 5 * time.Second,
-		batchSize:    5,
-		maxAttempts:  10,
-	}
+func NewProcessor(db *gorm.DB, handlers *Registry, cfg *config.Config, logger *zap.Logger) *Processor {
+	p := &Processor{
+		db:                db,
+		handlers:          handlers,
+		logger:            logger,
+		pollInterval:      time.Duration(cfg.OutboxPollIntervalSeconds) * time.Second,
+		batchSize:         cfg.OutboxBatchSize,
+		concurrency:       cfg.OutboxConcurrency,
+		maxAttempts:       deployment.MaxDeployAttempts,
+		leaseDuration:     2 * time.Minute,
+		heartbeatInterval: 30 * time.Second,
+		wakeup:            make(chan struct{}, 1),
+		freed:             make(chan struct{}, 1),
+	}
+	if p.pollInterval <= 0 {
+		p.pollInterval = 5 * time.Second
+	}
+	if p.batchSize <= 0 {
+		p.batchSize = 20
+	}
+	if p.concurrency <= 0 {
+		p.concurrency = 4
+	}
+	return p
 }
 
-// Run polls the outbox so side effects happen after durable writes, keeping DB state authoritative.
+// Run processes the outbox so side effects happen after durable writes,
+// keeping DB state authoritative. Inserted events wake it through
+// LISTEN/NOTIFY on Postgres; polling picks up retries and anything a missed
+// notification left behind. Once ctx is done it returns after the running
+// handlers did.
 func (p *Processor) Run(ctx context.Context) {
-	if err := p.processBatch(ctx); err != nil {
-		p.logger.Error("outbox_initial_poll_failed", zap.Error(err))
-	}
+	go p.listen(ctx)
+
+	p.poll(ctx)
 
 	ticker := time.NewTicker(p.pollInterval)
 	defer ticker.Stop()
@@ -47,22 +83,77 @@ func (p *Processor) Run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			p.workers.Wait()
 			return
 		case <-ticker.C:
-			if err := p.processBatch(ctx); err != nil {
+			p.poll(ctx)
+		case <-p.wakeup:
+			p.poll(ctx)
+		case <-p.freed:
+			if err := p.fill(ctx); err != nil {
 				p.logger.Error("outbox_poll_failed", zap.Error(err))
 			}
 		}
 	}
 }
 
-func (p *Processor) processBatch(ctx context.Context) error {
-	events, err := p.fetchAndLockPending(ctx)
-	if err != nil {
-		return err
+// wake asks Run to poll now. Wakeups arriving while it polls collapse into
+// one more poll.
+func (p *Processor) wake() {
+	select {
+	case p.wakeup <- struct{}{}:
+	default:
 	}
+}
 
-	for _, event := range events {
+func (p *Processor) poll(ctx context.Context) {
+	if err := p.reclaimExpiredLeases(ctx); err != nil {
+		p.logger.Error("outbox_lease_sweep_failed", zap.Error(err))
+	}
+	if err := p.fill(ctx); err != nil {
+		p.logger.Error("outbox_poll_failed", zap.Error(err))
+	}
+	if err := observeDeadEvents(ctx, p.db); err != nil {
+		p.logger.Warn("outbox_dead_events_gauge_failed", zap.Error(err))
+	}
+}
+
+// fill claims due events for the idle workers and starts them, until every
+// worker is busy or nothing more is due. It does not wait for the handlers:
+// each finishing worker signals freed, and its slot is filled again.
+func (p *Processor) fill(ctx context.Context) error {
+	for ctx.Err() == nil {
+		limit := min(p.concurrency-int(p.inFlight.Load()), p.batchSize)
+		if limit <= 0 {
+			return nil
+		}
+		events, err := p.fetchAndLockPending(ctx, limit)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			p.start(ctx, event)
+		}
+		if len(events) < limit {
+			return nil
+		}
+	}
+	return nil
+}
+
+// start handles a claimed event on a new worker.
+func (p *Processor) start(ctx context.Context, event Event) {
+	p.inFlight.Add(1)
+	p.workers.Add(1)
+	go func() {
+		defer func() {
+			p.inFlight.Add(-1)
+			select {
+			case p.freed <- struct{}{}:
+			default:
+			}
+			p.workers.Done()
+		}()
 		if err := p.processEvent(ctx, event); err != nil {
 			p.logger.Error("outbox_event_processing_failed",
 				zap.Error(err),
@@ -70,29 +161,61 @@ func (p *Processor) processBatch(ctx context.Context) error {
 				zap.String("event_type", string(event.EventType)),
 			)
 		}
-	}
-
-	return nil
+	}()
 }
 
-func (p *Processor) fetchAndLockPending(ctx context.Context) ([]Event, error) {
+// fetchAndLockPending claims due events. Only the oldest unfinished event
+// of an instance can be claimed, and none while another one of the instance
+// is processing, so replicas never run two events of an instance at once. A
+// failed event holds back the later ones until it succeeds or is dead. Events
+// waiting for their rollout wave are scheduled rather than queued and hold
+// nothing back. IDs follow insertion order. At most limit events are claimed.
+func (p *Processor) fetchAndLockPending(ctx context.Context, limit int) ([]Event, error) {
 	var events []Event
 	now := time.Now().UTC()
 
 	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := p.cancelSupersededDeploys(ctx, tx, now); err != nil {
+			return err
+		}
+
 		if err := tx.Raw(
 			`SELECT * FROM outbox_events
 			 WHERE status IN (?, ?)
 			   AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
 			   AND attempts < ?
-			 ORDER BY created_at ASC
-			 LIMIT ?
-			 FOR UPDATE SKIP LOCKED`,
-			StatusPending,
-			StatusFailed,
+			   AND (rollout_id IS NULL OR EXISTS (
+			     SELECT 1 FROM rollouts r
+			     WHERE r.id = outbox_events.rollout_id
+			       AND r.status = ?
+			       AND r.current_wave = outbox_events.rollout_wave
+			   ))
+			   AND NOT EXISTS (
+			     SELECT 1 FROM outbox_events prior
+			     WHERE prior.instance_id = outbox_events.instance_id
+			       AND (prior.status = ? OR (
+			         prior.status IN (?, ?)
+			         AND prior.id < outbox_events.id
+			         AND (prior.rollout_id IS NULL OR EXISTS (
+			           SELECT 1 FROM rollouts pr
+			           WHERE pr.id = prior.rollout_id
+			             AND pr.status = ?
+			             AND pr.current_wave = prior.rollout_wave
+			         ))
+			       ))
+			   )
+			 ORDER BY created_at ASC, id ASC
+			 LIMIT ? `+skipLocked(tx),
+			outboxevent.StatusPending,
+			outboxevent.StatusFailed,
 			now,
 			p.maxAttempts,
-			p.batchSize,
+			rollout.StatusRunning,
+			outboxevent.StatusProcessing,
+			outboxevent.StatusPending,
+			outboxevent.StatusFailed,
+			rollout.StatusRunning,
+			limit,
 		).Scan(&events).Error; err != nil {
 			return err
 		}
@@ -110,7 +233,7 @@ func (p *Processor) fetchAndLockPending(ctx context.Context) ([]Event, error) {
 		return tx.Model(&Event{}).
 			Where("id IN ?", ids).
 			Updates(map[string]any{
-				"status":     StatusProcessing,
+				"status":     outboxevent.StatusProcessing,
 				"attempts":   gorm.Expr("attempts + 1"),
 				"locked_at":  now,
 				"updated_at": now,
@@ -121,258 +244,182 @@ func (p *Processor) fetchAndLockPending(ctx context.Context) ([]Event, error) {
 	return events, err
 }
 
-func (p *Processor) processEvent(ctx context.Context, event Event) error {
-	switch event.EventType {
-	case EventTypeDeployInstance:
-		return p.handleDeployInstance(ctx, event)
-	default:
-		return p.markEventFailed(ctx, event, fmt.Errorf("unsupported event type: %s", event.EventType))
+// cancelSupersededDeploys cancels queued deploys followed by a newer queued
+// deploy of the same instance with no other operation in between. A deploy
+// event rolls out the desired version at the time it runs, or the version it
+// requests, so the newer one covers both unless only the older one requests a
+// version. Deploys already processing are left to finish, and rollout deploys
+// are left to their rollout. A rollout deploy never supersedes another one
+// either: its wave may be paused or aborted, so it may never run.
+func (p *Processor) cancelSupersededDeploys(ctx context.Context, tx *gorm.DB, now time.Time) error {
+	result := tx.Exec(
+		`UPDATE outbox_events
+		 SET status = ?, last_error = ?, processed_at = ?, updated_at = ?
+		 WHERE event_type = ?
+		   AND status IN (?, ?)
+		   AND rollout_id IS NULL
+		   AND EXISTS (
+		     SELECT 1 FROM outbox_events newer
+		     WHERE newer.instance_id = outbox_events.instance_id
+		       AND newer.event_type = ?
+		       AND newer.status IN (?, ?)
+		       AND newer.id > outbox_events.id
+		       AND newer.rollout_id IS NULL
+		       AND (outbox_events.payload IS NULL OR newer.payload IS NOT NULL)
+		       AND NOT EXISTS (
+		         SELECT 1 FROM outbox_events other
+		         WHERE other.instance_id = outbox_events.instance_id
+		           AND other.event_type <> ?
+		           AND other.status IN (?, ?, ?)
+		           AND other.id > outbox_events.id
+		           AND other.id < newer.id
+		       )
+		   )`,
+		outboxevent.StatusCancelled,
+		"superseded by a newer deploy",
+		now,
+		now,
+		outboxevent.TypeDeployInstance,
+		outboxevent.StatusPending,
+		outboxevent.StatusFailed,
+		outboxevent.TypeDeployInstance,
+		outboxevent.StatusPending,
+		outboxevent.StatusFailed,
+		outboxevent.TypeDeployInstance,
+		outboxevent.StatusPending,
+		outboxevent.StatusFailed,
+		outboxevent.StatusProcessing,
+	)
+	if result.Error != nil {
+		return fmt.Errorf("cancel superseded deploys: %w", result.Error)
 	}
+	if result.RowsAffected > 0 {
+		supersededEventsTotal.WithLabelValues(string(outboxevent.TypeDeployInstance)).Add(float64(result.RowsAffected))
+		p.logger.Info("outbox_superseded_deploys_cancelled", zap.Int64("count", result.RowsAffected))
+	}
+	return nil
 }
 
-type organizationRecord struct {
-	ID            int64  `gorm:"primaryKey"`
-	Name          string `gorm:"type:varchar(255)"`
-	OSSCustomerID string `gorm:"column:oss_customer_id"`
-}
-
-func (organizationRecord) TableName() string {
-	return "organizations"
+// skipLocked returns the clause that lets concurrent processors claim
+// disjoint batches. SQLite, used in tests and local runs, has a single writer
+// and no row locks.
+func skipLocked(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "FOR UPDATE SKIP LOCKED"
+	}
+	return ""
 }
 
-func (p *Processor) handleDeployInstance(ctx context.Context, event Event) error {
-	inst, err := p.loadInstance(ctx, event.InstanceID)
-	if err != nil {
-		return p.markEventFailed(ctx, event, fmt.Errorf("load instance: %w", err))
-	}
-	if inst == nil {
-		return p.markEventFailed(ctx, event, fmt.Errorf("instance not found"))
-	}
-	if inst.OrgID != event.OrgID {
-		return p.markEventFailed(ctx, event, fmt.Errorf("instance org mismatch"))
-	}
+func (p *Processor) processEvent(ctx context.Context, event Event) error {
+	ctx = instance.WithActor(ctx, instance.Actor{Type: instance.ActorOutbox, ID: strconv.FormatInt(event.ID, 10)})
 
-	if inst.Status == instance.StatusActive {
-		return p.markEventCompleted(ctx, event.ID)
+	handler, ok := p.handlers.Handler(event.EventType)
+	if !ok {
+		return p.markEventFailed(ctx, event, fmt.Errorf("unsupported event type: %s", event.EventType))
 	}
 
-	if err := p.markInstanceProvisioning(ctx, inst.ID); err != nil {
-		return p.markEventFailed(ctx, event, err)
-	}
+	handlerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopHeartbeat := p.holdLease(handlerCtx, event, cancel)
+	err := handler.Handle(handlerCtx, event)
+	stopHeartbeat()
 
-	org, err := p.loadOrganization(ctx, event.OrgID)
 	if err != nil {
-		return p.markEventFailed(ctx, event, fmt.Errorf("load organization: %w", err))
-	}
-	if org == nil {
-		return p.markEventFailed(ctx, event, fmt.Errorf("organization not found"))
-	}
-
-	if err := p.ensureCustomer(ctx, org); err != nil {
-		return p.markEventFailed(ctx, event, err)
-	}
-
-	if err := p.ensureSubscription(ctx, inst, org); err != nil {
 		return p.markEventFailed(ctx, event, err)
 	}
-
-	// Activate subscription BEFORE deployment to ensure billing is active
-	// before the instance starts running
-	if inst.SubscriptionID != "" {
-		if err := p.ossClient.ActivateSubscription(ctx, inst.SubscriptionID); err != nil {
-			return p.markEventFailed(ctx, event, fmt.Errorf("activate subscription: %w", err))
-		}
-		p.logger.Info("subscription_activated",
-			zap.String("subscription_id", inst.SubscriptionID),
-			zap.Int64("org_id", inst.OrgID),
-		)
-	}
-
-	// Deploy instance - if this fails, we need to rollback the subscription
-	if err := p.deployUC.Execute(ctx, inst.OrgID, inst.DesiredVersion); err != nil {
-		// Rollback: cancel subscription immediately since deployment failed
-		p.rollbackSubscription(ctx, inst.SubscriptionID)
-		return p.markEventFailed(ctx, event, fmt.Errorf("deployment failed: %w", err))
-	}
-
-	return p.markEventCompleted(ctx, event.ID)
+	return p.markEventCompleted(ctx, event)
 }
 
-func (p *Processor) loadInstance(ctx context.Context, instanceID int64) (*instance.Instance, error) {
-	var inst instance.Instance
-	if err := p.db.WithContext(ctx).First(&inst, "id = ?", instanceID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
+// holdLease renews the lease of an event until the returned stop function
+// is called. Once the sweeper has reclaimed the event the handler is
+// cancelled, since another processor may already be running it.
+func (p *Processor) holdLease(ctx context.Context, event Event, cancel context.CancelFunc) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(p.heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				renewed, err := p.renewLease(ctx, event)
+				if err != nil {
+					p.logger.Warn("outbox_lease_renew_failed", zap.Int64("event_id", event.ID), zap.Error(err))
+					continue
+				}
+				if !renewed {
+					p.logger.Warn("outbox_lease_lost", zap.Int64("event_id", event.ID))
+					cancel()
+					return
+				}
+			}
 		}
-		return nil, err
-	}
-	return &inst, nil
-}
+	}()
 
-func (p *Processor) loadOrganization(ctx context.Context, orgID int64) (*organizationRecord, error) {
-	var org organizationRecord
-	if err := p.db.WithContext(ctx).First(&org, "id = ?", orgID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
+	return func() {
+		close(done)
+		wg.Wait()
 	}
-	return &org, nil
 }
 
-func (p *Processor) ensureCustomer(ctx context.Context, org *organizationRecord) error {
-	if org.OSSCustomerID != "" {
-		return nil
-	}
-
-	externalID := fmt.Sprintf("org_%d", org.ID)
-	orgEmail := fmt.Sprintf("org_%d@railzway.com", org.ID)
-
-	customer, err := p.ossClient.EnsureCustomer(ctx, org.Name, orgEmail, externalID)
-	if err != nil {
-		return fmt.Errorf("ensure oss customer: %w", err)
-	}
-
+func (p *Processor) renewLease(ctx context.Context, event Event) (bool, error) {
 	now := time.Now().UTC()
-	if err := p.db.WithContext(ctx).Model(&organizationRecord{}).
-		Where("id = ?", org.ID).
+	result := p.leased(p.db.WithContext(ctx), event).
 		Updates(map[string]any{
-			"oss_customer_id": customer.ID,
-			"updated_at":      now,
-		}).Error; err != nil {
-		return fmt.Errorf("update organization oss customer id: %w", err)
-	}
-
-	org.OSSCustomerID = customer.ID
-	return nil
+			"locked_at":  now,
+			"updated_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
 }
 
-func (p *Processor) ensureSubscription(ctx context.Context, inst *instance.Instance, org *organizationRecord) error {
-	if inst.SubscriptionID != "" {
-		subscription, err := p.ossClient.GetSubscription(ctx, inst.SubscriptionID)
-		if err != nil {
-			return fmt.Errorf("load subscription: %w", err)
-		}
-		if subscription != nil && shouldReplaceSubscription(subscription.Status) {
-			p.logger.Warn("subscription_inactive_replacing",
-				zap.String("subscription_id", inst.SubscriptionID),
-				zap.String("status", subscription.Status),
-				zap.Int64("org_id", inst.OrgID),
-			)
-			inst.SubscriptionID = ""
-		} else {
-			return nil
-		}
-	}
-
-	priceID := strings.TrimSpace(inst.PriceID)
-	if priceID == "" {
-		return fmt.Errorf("missing price_id in instance - price must be selected during provisioning")
-	}
-
-	items := []railzwayclient.CreateSubscriptionItemRequest{
-		{
-			PriceID:  priceID,
-			Quantity: 1,
-		},
-	}
-
-	subscription, err := p.ossClient.CreateSubscription(ctx, org.OSSCustomerID, "monthly", items)
-	if err != nil {
-		return fmt.Errorf("create subscription: %w", err)
-	}
-
-	now := time.Now().UTC()
-	updates := map[string]any{
-		"subscription_id": subscription.ID,
-		"updated_at":      now,
-	}
-	if inst.PriceID == "" && priceID != "" {
-		updates["price_id"] = priceID
-	}
-
-	if err := p.db.WithContext(ctx).Model(&instance.Instance{}).
-		Where("id = ?", inst.ID).
-		Updates(updates).Error; err != nil {
-		return fmt.Errorf("update subscription id: %w", err)
-	}
-
-	inst.SubscriptionID = subscription.ID
-	if inst.PriceID == "" && priceID != "" {
-		inst.PriceID = priceID
-	}
-	return nil
+// leased scopes an update to the lease the processor holds on event. Every
+// claim counts an attempt, so the attempts column fences off a processor
+// whose lease was reclaimed and claimed again.
+func (p *Processor) leased(db *gorm.DB, event Event) *gorm.DB {
+	return db.Model(&Event{}).
+		Where("id = ? AND status = ? AND attempts = ?", event.ID, outboxevent.StatusProcessing, event.Attempts)
 }
 
-func shouldReplaceSubscription(status string) bool {
-	switch strings.ToUpper(strings.TrimSpace(status)) {
-	case "CANCELED", "CANCELLED", "ENDED":
-		return true
-	default:
-		return false
-	}
-}
-
-func (p *Processor) markInstanceProvisioning(ctx context.Context, instanceID int64) error {
-	allowed := []instance.InstanceStatus{instance.StatusInit, instance.StatusProvisionFailed}
-	return p.markInstanceStatus(ctx, instanceID, allowed, instance.StatusProvisioning, "")
-}
-
-func (p *Processor) markInstanceProvisionFailed(ctx context.Context, instanceID int64, errMsg string) error {
-	allowed := []instance.InstanceStatus{instance.StatusInit, instance.StatusProvisioning}
-	return p.markInstanceStatus(ctx, instanceID, allowed, instance.StatusProvisionFailed, errMsg)
-}
-
-func (p *Processor) markInstanceStatus(ctx context.Context, instanceID int64, allowed []instance.InstanceStatus, next instance.InstanceStatus, errMsg string) error {
-	now := time.Now().UTC()
-	updates := map[string]any{
-		"status":     next,
-		"updated_at": now,
-	}
-	if errMsg == "" {
-		updates["last_error"] = nil
-	} else {
-		updates["last_error"] = errMsg
-	}
-
-	result := p.db.WithContext(ctx).Model(&instance.Instance{}).
-		Where("id = ? AND status IN ?", instanceID, allowed).
-		Updates(updates)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected > 0 {
-		return nil
-	}
+// reclaimExpiredLeases fails the events whose lease was not renewed in time,
+// typically because their processor died mid-handler, so they are retried
+// with backoff instead of staying processing forever.
+func (p *Processor) reclaimExpiredLeases(ctx context.Context) error {
+	cutoff := time.Now().UTC().Add(-p.leaseDuration)
 
-	currentStatus, err := p.getInstanceStatus(ctx, instanceID)
-	if err != nil {
+	var events []Event
+	if err := p.db.WithContext(ctx).
+		Where("status = ? AND locked_at < ?", outboxevent.StatusProcessing, cutoff).
+		Order("id").
+		Find(&events).Error; err != nil {
 		return err
 	}
-	if currentStatus == next {
-		return nil
-	}
 
-	return fmt.Errorf("invalid state transition from %s to %s", currentStatus, next)
-}
-
-func (p *Processor) getInstanceStatus(ctx context.Context, instanceID int64) (instance.InstanceStatus, error) {
-	var status string
-	if err := p.db.WithContext(ctx).Model(&instance.Instance{}).
-		Select("status").
-		Where("id = ?", instanceID).
-		Scan(&status).Error; err != nil {
-		return "", err
+	for _, event := range events {
+		p.logger.Warn("outbox_lease_expired",
+			zap.Int64("event_id", event.ID),
+			zap.String("event_type", string(event.EventType)),
+			zap.Timep("locked_at", event.LockedAt),
+		)
+		if err := p.recordFailure(ctx, event, fmt.Sprintf("lease expired after %s", p.leaseDuration)); err != nil {
+			return fmt.Errorf("reclaim event %d: %w", event.ID, err)
+		}
 	}
-	return instance.InstanceStatus(status), nil
+	return nil
 }
 
-func (p *Processor) markEventCompleted(ctx context.Context, eventID int64) error {
+func (p *Processor) markEventCompleted(ctx context.Context, event Event) error {
 	now := time.Now().UTC()
-	return p.db.WithContext(ctx).Model(&Event{}).
-		Where("id = ? AND status = ?", eventID, StatusProcessing).
+	return p.leased(p.db.WithContext(ctx), event).
 		Updates(map[string]any{
-			"status":       StatusCompleted,
+			"status":       outboxevent.StatusCompleted,
 			"processed_at": now,
 			"updated_at":   now,
 			"last_error":   nil,
@@ -383,26 +430,45 @@ func (p *Processor) markEventFailed(ctx context.Context, event Event, err error)
 	if err == nil {
 		return nil
 	}
-
-	if event.InstanceID != 0 {
-		_ = p.markInstanceProvisionFailed(ctx, event.InstanceID, err.Error())
+	if updateErr := p.recordFailure(ctx, event, err.Error()); updateErr != nil {
+		return fmt.Errorf("mark event failed: %w (original error: %v)", updateErr, err)
 	}
+	return err
+}
 
+// recordFailure schedules a retry, or moves the event to the dead-letter
+// queue once it used up its attempts.
+func (p *Processor) recordFailure(ctx context.Context, event Event, errMsg string) error {
 	now := time.Now().UTC()
-	nextAttempt := now.Add(backoffDuration(event.Attempts))
+	updates := map[string]any{
+		"status":     outboxevent.StatusFailed,
+		"last_error": errMsg,
+		"updated_at": now,
+	}
+	dead := event.Attempts >= p.maxAttempts
+	if dead {
+		updates["status"] = outboxevent.StatusDead
+		updates["next_attempt_at"] = nil
+	} else {
+		updates["next_attempt_at"] = now.Add(backoffDuration(event.Attempts))
+	}
 
-	updateErr := p.db.WithContext(ctx).Model(&Event{}).
-		Where("id = ?", event.ID).
-		Updates(map[string]any{
-			"status":          StatusFailed,
-			"last_error":      err.Error(),
-			"next_attempt_at": nextAttempt,
-			"updated_at":      now,
-		}).Error
-	if updateErr != nil {
-		return fmt.Errorf("mark event failed: %w (original error: %v)", updateErr, err)
+	result := p.leased(p.db.WithContext(ctx), event).Updates(updates)
+	if result.Error != nil {
+		return result.Error
 	}
-	return err
+
+	if dead && result.RowsAffected > 0 {
+		deadEventsTotal.WithLabelValues(string(event.EventType)).Inc()
+		p.logger.Error("outbox_event_dead",
+			zap.Int64("event_id", event.ID),
+			zap.String("event_type", string(event.EventType)),
+			zap.Int64("instance_id", event.InstanceID),
+			zap.Int("attempts", event.Attempts),
+			zap.String("error", errMsg),
+		)
+	}
+	return nil
 }
 
 func backoffDuration(attempt int) time.Duration {
@@ -423,27 +489,3 @@ func backoffDuration(attempt int) time.Duration {
 	}
 	return d
 }
-
-// rollbackSubscription cancels a subscription immediately when deployment fails.
-// This prevents users from being charged for instances that failed to deploy.
-func (p *Processor) rollbackSubscription(ctx context.Context, subscriptionID string) {
-	if subscriptionID == "" {
-		return
-	}
-
-	p.logger.Info("rolling_back_subscription",
-		zap.String("subscription_id", subscriptionID),
-	)
-
-	// Cancel immediately (not at period end) since deployment failed
-	if err := p.ossClient.CancelSubscription(ctx, subscriptionID, false); err != nil {
-		p.logger.Error("failed_to_rollback_subscription",
-			zap.String("subscription_id", subscriptionID),
-			zap.Error(err),
-		)
-	} else {
-		p.logger.Info("subscription_rolled_back",
-			zap.String("subscription_id", subscriptionID),
-		)
-	}
-}
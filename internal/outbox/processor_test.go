@@ -0,0 +1,126 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/rollout"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newTestProcessor(t *testing.T) (*Processor, *Registry, *gorm.DB) {
+	t.Helper()
+
+	gdb, err := db.NewTest()
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, gdb.AutoMigrate(&Event{}, &rollout.Rollout{}))
+
+	registry := NewRegistry()
+	return NewProcessor(gdb, registry, &config.Config{}, zap.NewNop()), registry, gdb
+}
+
+// drain runs due events until none is left and every worker is idle.
+func (p *Processor) drain(ctx context.Context) error {
+	defer p.workers.Wait()
+	for {
+		if err := p.fill(ctx); err != nil {
+			return err
+		}
+		if p.inFlight.Load() == 0 {
+			return nil
+		}
+		<-p.freed
+	}
+}
+
+func seedEvent(t *testing.T, gdb *gorm.DB, eventType outboxevent.Type, payload any) Event {
+	t.Helper()
+
+	event := Event{EventType: eventType, OrgID: 1, InstanceID: 2, Status: outboxevent.StatusPending}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		require.NoError(t, err)
+		event.Payload = raw
+	}
+	require.NoError(t, gdb.Create(&event).Error)
+	return event
+}
+
+func reloadEvent(t *testing.T, gdb *gorm.DB, id int64) Event {
+	t.Helper()
+
+	var event Event
+	require.NoError(t, gdb.First(&event, id).Error)
+	return event
+}
+
+func TestProcessor_DispatchesToRegisteredHandler(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+
+	type resize struct {
+		Replicas int `json:"replicas"`
+	}
+	var got resize
+	registry.Register("resize_instance", HandlerFunc(func(ctx context.Context, event Event) error {
+		return event.DecodePayload(&got)
+	}))
+	event := seedEvent(t, gdb, "resize_instance", resize{Replicas: 3})
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	assert.Equal(t, 3, got.Replicas)
+	processed := reloadEvent(t, gdb, event.ID)
+	assert.Equal(t, outboxevent.StatusCompleted, processed.Status)
+	assert.Equal(t, 1, processed.Attempts)
+	assert.JSONEq(t, `{"replicas":3}`, string(processed.Payload))
+}
+
+func TestProcessor_HandlerErrorSchedulesRetry(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		return errors.New("nomad unavailable")
+	}))
+	event := seedEvent(t, gdb, outboxevent.TypeStopInstance, nil)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	processed := reloadEvent(t, gdb, event.ID)
+	assert.Equal(t, outboxevent.StatusFailed, processed.Status)
+	assert.Equal(t, "nomad unavailable", processed.LastError)
+	assert.NotNil(t, processed.NextAttemptAt)
+	assert.Nil(t, processed.Payload)
+}
+
+func TestProcessor_FailsUnregisteredEventType(t *testing.T) {
+	processor, _, gdb := newTestProcessor(t)
+	event := seedEvent(t, gdb, "unknown_event", nil)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	processed := reloadEvent(t, gdb, event.ID)
+	assert.Equal(t, outboxevent.StatusFailed, processed.Status)
+	assert.Contains(t, processed.LastError, "unsupported event type")
+}
+
+func TestTierChangeHandler_RequiresPayload(t *testing.T) {
+	handler := tierChangeHandler(func(ctx context.Context, eventID, instanceID int64, tier instance.Tier) error {
+		t.Fatal("tier change ran without a tier")
+		return nil
+	})
+
+	err := handler.Handle(context.Background(), Event{ID: 7, EventType: outboxevent.TypeUpgradeInstance})
+	assert.ErrorContains(t, err, "event 7 has no payload")
+}
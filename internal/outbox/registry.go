@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+)
+
+// Handler carries out the side effect of an event. Events are delivered at
+// least once, so handlers must tolerate running again after a partial or
+// unrecorded success. A nil error completes the event; an error fails it and
+// schedules a retry with backoff.
+type Handler interface {
+	Handle(ctx context.Context, event Event) error
+}
+
+// HandlerFunc adapts a function to a Handler.
+type HandlerFunc func(ctx context.Context, event Event) error
+
+func (f HandlerFunc) Handle(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Registry maps event types to their handlers. Handlers are registered while
+// the application is wired, before the processor starts polling.
+type Registry struct {
+	handlers map[outboxevent.Type]Handler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[outboxevent.Type]Handler)}
+}
+
+// Register sets the handler of an event type, replacing any previous one.
+func (r *Registry) Register(eventType outboxevent.Type, handler Handler) {
+	r.handlers[eventType] = handler
+}
+
+// Handler returns the handler of an event type.
+func (r *Registry) Handler(eventType outboxevent.Type) (Handler, bool) {
+	handler, ok := r.handlers[eventType]
+	return handler, ok
+}
@@ -0,0 +1,247 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/rollout"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestProcessor_KeepsPerInstanceOrderAcrossWorkers(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+	processor.concurrency = 3
+
+	var mu sync.Mutex
+	order := make(map[int64][]int64)
+	running := make(map[int64]bool)
+	var inFlight, maxInFlight atomic.Int32
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		mu.Lock()
+		assert.False(t, running[event.InstanceID], "instance %d already has an event in flight", event.InstanceID)
+		running[event.InstanceID] = true
+		mu.Unlock()
+
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if n <= seen || maxInFlight.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		running[event.InstanceID] = false
+		order[event.InstanceID] = append(order[event.InstanceID], event.ID)
+		return nil
+	}))
+
+	want := make(map[int64][]int64)
+	for i := 0; i < 4; i++ {
+		for _, instanceID := range []int64{10, 20, 30} {
+			event := Event{EventType: outboxevent.TypeStopInstance, OrgID: 1, InstanceID: instanceID, Status: outboxevent.StatusPending}
+			require.NoError(t, gdb.Create(&event).Error)
+			want[instanceID] = append(want[instanceID], event.ID)
+		}
+	}
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	assert.Equal(t, want, order)
+	assert.Greater(t, maxInFlight.Load(), int32(1), "instances are handled concurrently")
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3), "no more than concurrency events run at once")
+}
+
+func TestProcessor_DrainsMoreThanOneBatch(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+	processor.batchSize = 2
+
+	var handled atomic.Int32
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		handled.Add(1)
+		return nil
+	}))
+	for i := 0; i < 5; i++ {
+		seedEvent(t, gdb, outboxevent.TypeStopInstance, nil)
+	}
+
+	require.NoError(t, processor.drain(context.Background()))
+	assert.Equal(t, int32(5), handled.Load())
+}
+
+func TestProcessor_SlowEventHoldsUpOnlyItsInstance(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+	processor.concurrency = 2
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var others []int64
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		if event.InstanceID == 10 {
+			select {
+			case <-release:
+			case <-time.After(2 * time.Second):
+				t.Error("the events of instance 20 waited for the slow event of instance 10")
+			}
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		others = append(others, event.ID)
+		if len(others) == 3 {
+			close(release)
+		}
+		return nil
+	}))
+	slow := Event{EventType: outboxevent.TypeStopInstance, OrgID: 1, InstanceID: 10, Status: outboxevent.StatusPending}
+	require.NoError(t, gdb.Create(&slow).Error)
+	for i := 0; i < 3; i++ {
+		event := Event{EventType: outboxevent.TypeStopInstance, OrgID: 1, InstanceID: 20, Status: outboxevent.StatusPending}
+		require.NoError(t, gdb.Create(&event).Error)
+	}
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	assert.Len(t, others, 3)
+	assert.Equal(t, outboxevent.StatusCompleted, reloadEvent(t, gdb, slow.ID).Status)
+}
+
+func TestProcessor_FailedEventHoldsBackItsInstance(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+
+	var mu sync.Mutex
+	var handled []int64
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		mu.Lock()
+		handled = append(handled, event.ID)
+		mu.Unlock()
+		if event.InstanceID == 10 {
+			return errors.New("nomad unavailable")
+		}
+		return nil
+	}))
+	seed := func(instanceID int64) Event {
+		event := Event{EventType: outboxevent.TypeStopInstance, OrgID: 1, InstanceID: instanceID, Status: outboxevent.StatusPending}
+		require.NoError(t, gdb.Create(&event).Error)
+		return event
+	}
+	head := seed(10)
+	blocked := seed(10)
+	other := seed(20)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	assert.ElementsMatch(t, []int64{head.ID, other.ID}, handled)
+	assert.Equal(t, outboxevent.StatusFailed, reloadEvent(t, gdb, head.ID).Status)
+	assert.Equal(t, outboxevent.StatusPending, reloadEvent(t, gdb, blocked.ID).Status)
+	assert.Equal(t, outboxevent.StatusCompleted, reloadEvent(t, gdb, other.ID).Status)
+}
+
+func TestProcessor_CancelsSupersededDeploys(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+
+	var handled []outboxevent.Type
+	record := HandlerFunc(func(ctx context.Context, event Event) error {
+		handled = append(handled, event.EventType)
+		return nil
+	})
+	registry.Register(outboxevent.TypeDeployInstance, record)
+	registry.Register(outboxevent.TypeStopInstance, record)
+
+	superseded := seedEvent(t, gdb, outboxevent.TypeDeployInstance, nil)
+	beforeStop := seedEvent(t, gdb, outboxevent.TypeDeployInstance, nil)
+	stop := seedEvent(t, gdb, outboxevent.TypeStopInstance, nil)
+	latest := seedEvent(t, gdb, outboxevent.TypeDeployInstance, nil)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	assert.Equal(t, []outboxevent.Type{outboxevent.TypeDeployInstance, outboxevent.TypeStopInstance, outboxevent.TypeDeployInstance}, handled)
+	cancelled := reloadEvent(t, gdb, superseded.ID)
+	assert.Equal(t, outboxevent.StatusCancelled, cancelled.Status)
+	assert.Equal(t, "superseded by a newer deploy", cancelled.LastError)
+	assert.Equal(t, outboxevent.StatusCompleted, reloadEvent(t, gdb, beforeStop.ID).Status)
+	assert.Equal(t, outboxevent.StatusCompleted, reloadEvent(t, gdb, stop.ID).Status)
+	assert.Equal(t, outboxevent.StatusCompleted, reloadEvent(t, gdb, latest.ID).Status)
+}
+
+func TestProcessor_KeepsDeploysRequestingAVersion(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+
+	var handled []int64
+	registry.Register(outboxevent.TypeDeployInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		handled = append(handled, event.ID)
+		return nil
+	}))
+
+	requested := seedEvent(t, gdb, outboxevent.TypeDeployInstance, deployment.DeployRequest{Version: "v2.0.0"})
+	desired := seedEvent(t, gdb, outboxevent.TypeDeployInstance, nil)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	assert.Equal(t, []int64{requested.ID, desired.ID}, handled)
+	assert.Equal(t, outboxevent.StatusCompleted, reloadEvent(t, gdb, requested.ID).Status)
+}
+
+func TestProcessor_KeepsDeploysBeforeAPausedRollout(t *testing.T) {
+	processor, registry, gdb := newTestProcessor(t)
+
+	var handled []int64
+	registry.Register(outboxevent.TypeDeployInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		handled = append(handled, event.ID)
+		return nil
+	}))
+
+	paused := rollout.Rollout{TargetVersion: "v2.0.0", Status: rollout.StatusPaused}
+	require.NoError(t, gdb.Create(&paused).Error)
+	requested := seedEvent(t, gdb, outboxevent.TypeDeployInstance, nil)
+	parked := seedEvent(t, gdb, outboxevent.TypeDeployInstance, nil)
+	require.NoError(t, gdb.Model(&Event{}).Where("id = ?", parked.ID).
+		Updates(map[string]any{"rollout_id": paused.ID, "rollout_wave": 0}).Error)
+
+	require.NoError(t, processor.drain(context.Background()))
+
+	assert.Equal(t, []int64{requested.ID}, handled)
+	assert.Equal(t, outboxevent.StatusCompleted, reloadEvent(t, gdb, requested.ID).Status)
+	assert.Equal(t, outboxevent.StatusPending, reloadEvent(t, gdb, parked.ID).Status)
+}
+
+func TestProcessor_WakesOnNotify(t *testing.T) {
+	gdb := testhelper.SetupMigratedPostgres(t)
+
+	handled := make(chan int64, 1)
+	registry := NewRegistry()
+	registry.Register(outboxevent.TypeStopInstance, HandlerFunc(func(ctx context.Context, event Event) error {
+		handled <- event.ID
+		return nil
+	}))
+	// The poll interval is far longer than the test, so only a
+	// notification can get the event handled in time.
+	processor := NewProcessor(gdb, registry, &config.Config{OutboxPollIntervalSeconds: 3600}, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go processor.Run(ctx)
+
+	event := Event{EventType: outboxevent.TypeStopInstance, OrgID: 1, InstanceID: 2, Status: outboxevent.StatusPending}
+	require.NoError(t, gdb.Create(&event).Error)
+
+	select {
+	case id := <-handled:
+		assert.Equal(t, event.ID, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not handled after its insert was notified")
+	}
+}
@@ -0,0 +1,59 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"go.uber.org/zap"
+)
+
+// EntitlementReconciler re-deploys instances when the entitlements of their
+// product change in Railzway OSS.
+type EntitlementReconciler struct {
+	entitlements *deployment.EntitlementUseCase
+	logger       *zap.Logger
+	interval     time.Duration
+}
+
+func NewEntitlementReconciler(entitlements *deployment.EntitlementUseCase, cfg *config.Config, logger *zap.Logger) *EntitlementReconciler {
+	return &EntitlementReconciler{
+		entitlements: entitlements,
+		logger:       logger.Named("entitlement.reconciler"),
+		interval:     time.Duration(cfg.EntitlementSyncIntervalSeconds) * time.Second,
+	}
+}
+
+func (r *EntitlementReconciler) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		r.logger.Info("entitlement_sync_disabled")
+		return
+	}
+	ctx = instance.WithActor(ctx, instance.Actor{Type: instance.ActorReconciler, ID: "entitlement"})
+
+	r.reconcile(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+func (r *EntitlementReconciler) reconcile(ctx context.Context) {
+	enqueued, err := r.entitlements.Sync(ctx)
+	if err != nil {
+		r.logger.Error("entitlement_sync_failed", zap.Error(err))
+	}
+	if enqueued > 0 {
+		r.logger.Info("entitlement_redeploy_enqueued", zap.Int64("count", enqueued))
+	}
+}
@@ -2,6 +2,7 @@ package reconciler
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"time"
 
@@ -12,15 +13,17 @@ import (
 
 type InstanceReconciler struct {
 	repo        instance.Repository
+	upgrades    instance.UpgradeRepository
 	provisioner provisioning.Provisioner
 	logger      *zap.Logger
 	interval    time.Duration
 	batchSize   int
 }
 
-func NewInstanceReconciler(repo instance.Repository, provisioner provisioning.Provisioner, logger *zap.Logger) *InstanceReconciler {
+func NewInstanceReconciler(repo instance.Repository, upgrades instance.UpgradeRepository, provisioner provisioning.Provisioner, logger *zap.Logger) *InstanceReconciler {
 	return &InstanceReconciler{
 		repo:        repo,
+		upgrades:    upgrades,
 		provisioner: provisioner,
 		logger:      logger.Named("instance.reconciler"),
 		interval:    10 * time.Second,
@@ -29,6 +32,8 @@ func NewInstanceReconciler(repo instance.Repository, provisioner provisioning.Pr
 }
 
 func (r *InstanceReconciler) Run(ctx context.Context) {
+	ctx = instance.WithActor(ctx, instance.Actor{Type: instance.ActorReconciler, ID: "instance"})
+
 	if err := r.reconcile(ctx); err != nil {
 		r.logger.Error("reconcile_initial_failed", zap.Error(err))
 	}
@@ -68,7 +73,18 @@ func (r *InstanceReconciler) reconcileInstance(ctx context.Context, inst *instan
 	if inst == nil {
 		return
 	}
-	raw, err := r.provisioner.GetStatus(ctx, inst.OrgID)
+
+	// Instances with a blue/green upgrade in flight are owned by the upgrade reconciler.
+	up, err := r.upgrades.FindActiveByInstanceID(ctx, inst.ID)
+	if err != nil {
+		r.logger.Warn("reconcile_upgrade_lookup_failed", zap.Error(err), zap.Int64("org_id", inst.OrgID))
+		return
+	}
+	if up != nil {
+		return
+	}
+
+	raw, err := r.provisioner.GetStatus(ctx, provisioning.Workload{OrgID: inst.OrgID, JobID: inst.JobID(), Provisioner: inst.Provisioner})
 	if err != nil {
 		r.logger.Warn("reconcile_status_failed",
 			zap.Error(err),
@@ -81,15 +97,26 @@ func (r *InstanceReconciler) reconcileInstance(ctx context.Context, inst *instan
 	switch strings.ToLower(strings.TrimSpace(raw)) {
 	case "running":
 		inst.MarkActive(inst.DesiredVersion)
-		if err := r.repo.Save(ctx, inst); err != nil {
+		if err := r.repo.Save(ctx, inst); err != nil && !r.dropStale(err, inst) {
 			r.logger.Warn("reconcile_mark_active_failed", zap.Error(err), zap.Int64("org_id", inst.OrgID))
 		}
 	case "failed", "lost", "complete":
 		inst.MarkProvisionFailed("provisioner_status:" + raw)
-		if err := r.repo.Save(ctx, inst); err != nil {
+		if err := r.repo.Save(ctx, inst); err != nil && !r.dropStale(err, inst) {
 			r.logger.Warn("reconcile_mark_failed_failed", zap.Error(err), zap.Int64("org_id", inst.OrgID))
 		}
 	default:
 		return
 	}
 }
+
+// dropStale reports whether err is a lost compare-and-swap. The instance was
+// changed while its job status was fetched, so the observation is discarded
+// and made again on the next pass.
+func (r *InstanceReconciler) dropStale(err error, inst *instance.Instance) bool {
+	if !errors.Is(err, instance.ErrConflict) {
+		return false
+	}
+	r.logger.Debug("reconcile_stale_write_dropped", zap.Int64("org_id", inst.OrgID), zap.Int64("instance_id", inst.ID))
+	return true
+}
@@ -3,6 +3,7 @@ package reconciler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,20 +11,23 @@ import (
 	"time"
 
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
 	"go.uber.org/zap"
 )
 
 type LifecycleReconciler struct {
 	repo      instance.Repository
+	upgrades  instance.UpgradeRepository
 	logger    *zap.Logger
 	interval  time.Duration
 	batchSize int
 	client    *http.Client
 }
 
-func NewLifecycleReconciler(repo instance.Repository, logger *zap.Logger) *LifecycleReconciler {
+func NewLifecycleReconciler(repo instance.Repository, upgrades instance.UpgradeRepository, logger *zap.Logger) *LifecycleReconciler {
 	return &LifecycleReconciler{
 		repo:      repo,
+		upgrades:  upgrades,
 		logger:    logger.Named("lifecycle.reconciler"),
 		interval:  15 * time.Second,
 		batchSize: 50,
@@ -34,6 +38,8 @@ func NewLifecycleReconciler(repo instance.Repository, logger *zap.Logger) *Lifec
 }
 
 func (r *LifecycleReconciler) Run(ctx context.Context) {
+	ctx = instance.WithActor(ctx, instance.Actor{Type: instance.ActorReconciler, ID: "lifecycle"})
+
 	if err := r.reconcile(ctx); err != nil {
 		r.logger.Error("reconcile_initial_failed", zap.Error(err))
 	}
@@ -62,6 +68,36 @@ func (r *LifecycleReconciler) reconcile(ctx context.Context) error {
 	for _, inst := range items {
 		r.reconcileInstance(ctx, inst)
 	}
+	return r.reconcileStandbys(ctx)
+}
+
+// reconcileStandbys probes /ready on standby jobs of in-flight upgrades so the
+// upgrade reconciler knows when traffic can be switched.
+func (r *LifecycleReconciler) reconcileStandbys(ctx context.Context) error {
+	upgrades, err := r.upgrades.ListActive(ctx, r.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, up := range upgrades {
+		if up.Phase != instance.UpgradePhaseWarming {
+			continue
+		}
+		inst, err := r.repo.FindByID(ctx, up.InstanceID)
+		if err != nil || inst == nil {
+			continue
+		}
+
+		readiness, readyErr := r.checkReadiness(ctx, inst.LaunchURL, up.ToJobID)
+		errMsg := ""
+		if readyErr != nil {
+			errMsg = readyErr.Error()
+		}
+		up.SetReadiness(readiness, errMsg)
+		if err := r.upgrades.SaveReadiness(ctx, up); err != nil {
+			r.logger.Warn("standby_readiness_save_failed", zap.Error(err), zap.Int64("org_id", up.OrgID))
+		}
+	}
 	return nil
 }
 
@@ -70,14 +106,12 @@ func (r *LifecycleReconciler) reconcileInstance(ctx context.Context, inst *insta
 		return
 	}
 
-	readiness, readyErr := r.checkReadiness(ctx, inst.LaunchURL)
-	now := time.Now().UTC()
-	inst.Readiness = readiness
-	inst.ReadinessCheckedAt = &now
-	inst.ReadinessError = ""
+	readiness, readyErr := r.checkReadiness(ctx, inst.LaunchURL, "")
+	errMsg := ""
 	if readyErr != nil {
-		inst.ReadinessError = readyErr.Error()
+		errMsg = readyErr.Error()
 	}
+	inst.ObserveReadiness(readiness, errMsg, time.Now().UTC())
 
 	desired := r.computeLifecycle(inst)
 	if desired != "" && desired != inst.LifecycleState {
@@ -93,6 +127,11 @@ func (r *LifecycleReconciler) reconcileInstance(ctx context.Context, inst *insta
 	}
 
 	if err := r.repo.Save(ctx, inst); err != nil {
+		if errors.Is(err, instance.ErrConflict) {
+			// The probe result is stale; the next pass observes the new state.
+			r.logger.Debug("lifecycle_reconcile_stale_write_dropped", zap.Int64("org_id", inst.OrgID), zap.Int64("instance_id", inst.ID))
+			return
+		}
 		r.logger.Warn("lifecycle_reconcile_save_failed", zap.Error(err), zap.Int64("org_id", inst.OrgID))
 	}
 }
@@ -128,13 +167,15 @@ func (r *LifecycleReconciler) computeLifecycle(inst *instance.Instance) instance
 	return inst.LifecycleState
 }
 
-func (r *LifecycleReconciler) checkReadiness(ctx context.Context, launchURL string) (instance.ReadinessStatus, error) {
+// checkReadiness probes the instance behind launchURL. A non-empty standbyJobID
+// targets that standby job instead of the one serving traffic.
+func (r *LifecycleReconciler) checkReadiness(ctx context.Context, launchURL string, standbyJobID string) (instance.ReadinessStatus, error) {
 	base, err := baseURL(launchURL)
 	if err != nil {
 		return instance.ReadinessUnknown, err
 	}
 
-	healthOK, err := r.checkEndpoint(ctx, base, "/health")
+	healthOK, err := r.checkEndpoint(ctx, base, "/health", standbyJobID)
 	if err != nil || !healthOK {
 		if err == nil {
 			err = fmt.Errorf("health check failed")
@@ -142,7 +183,7 @@ func (r *LifecycleReconciler) checkReadiness(ctx context.Context, launchURL stri
 		return instance.ReadinessNotReady, err
 	}
 
-	readyOK, readyErr := r.checkReadyEndpoint(ctx, base)
+	readyOK, readyErr := r.checkReadyEndpoint(ctx, base, standbyJobID)
 	if readyErr != nil {
 		if errorsIsNotFound(readyErr) {
 			// Fallback: if /ready is missing, treat health as ready for now.
@@ -156,9 +197,9 @@ func (r *LifecycleReconciler) checkReadiness(ctx context.Context, launchURL stri
 	return instance.ReadinessReady, nil
 }
 
-func (r *LifecycleReconciler) checkEndpoint(ctx context.Context, base string, path string) (bool, error) {
+func (r *LifecycleReconciler) checkEndpoint(ctx context.Context, base string, path string, standbyJobID string) (bool, error) {
 	target := strings.TrimRight(base, "/") + path
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	req, err := newProbeRequest(ctx, target, standbyJobID)
 	if err != nil {
 		return false, err
 	}
@@ -173,9 +214,9 @@ func (r *LifecycleReconciler) checkEndpoint(ctx context.Context, base string, pa
 	return true, nil
 }
 
-func (r *LifecycleReconciler) checkReadyEndpoint(ctx context.Context, base string) (bool, error) {
+func (r *LifecycleReconciler) checkReadyEndpoint(ctx context.Context, base string, standbyJobID string) (bool, error) {
 	target := strings.TrimRight(base, "/") + "/ready"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	req, err := newProbeRequest(ctx, target, standbyJobID)
 	if err != nil {
 		return false, err
 	}
@@ -212,6 +253,17 @@ func (r *LifecycleReconciler) checkReadyEndpoint(ctx context.Context, base strin
 	return true, nil
 }
 
+func newProbeRequest(ctx context.Context, target string, standbyJobID string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if standbyJobID != "" {
+		req.Header.Set(nomad.StandbyHeader, standbyJobID)
+	}
+	return req, nil
+}
+
 func baseURL(raw string) (string, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
@@ -0,0 +1,83 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"go.uber.org/zap"
+)
+
+// RollbackReconciler reverts instances that failed on a new version to their
+// last known-good version.
+type RollbackReconciler struct {
+	repo      instance.Repository
+	rollbacks *deployment.RollbackUseCase
+	logger    *zap.Logger
+	interval  time.Duration
+	batchSize int
+}
+
+func NewRollbackReconciler(repo instance.Repository, rollbacks *deployment.RollbackUseCase, logger *zap.Logger) *RollbackReconciler {
+	return &RollbackReconciler{
+		repo:      repo,
+		rollbacks: rollbacks,
+		logger:    logger.Named("rollback.reconciler"),
+		interval:  30 * time.Second,
+		batchSize: 100,
+	}
+}
+
+func (r *RollbackReconciler) Run(ctx context.Context) {
+	ctx = instance.WithActor(ctx, instance.Actor{Type: instance.ActorReconciler, ID: "rollback"})
+
+	if err := r.reconcile(ctx); err != nil {
+		r.logger.Error("reconcile_initial_failed", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.reconcile(ctx); err != nil {
+				r.logger.Error("reconcile_failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (r *RollbackReconciler) reconcile(ctx context.Context) error {
+	statuses := []instance.InstanceStatus{
+		instance.StatusProvisionFailed,
+		instance.StatusActive,
+		instance.StatusRunning,
+	}
+	items, err := r.repo.ListByStatus(ctx, statuses, r.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range items {
+		rb, err := r.rollbacks.Evaluate(ctx, inst)
+		if err != nil {
+			r.logger.Warn("rollback_failed", zap.Error(err), zap.Int64("org_id", inst.OrgID), zap.Int64("instance_id", inst.ID))
+			continue
+		}
+		if rb != nil {
+			r.logger.Warn("instance_rolled_back",
+				zap.Int64("org_id", rb.OrgID),
+				zap.Int64("instance_id", rb.InstanceID),
+				zap.String("from_version", rb.FromVersion),
+				zap.String("to_version", rb.ToVersion),
+				zap.String("trigger", string(rb.Trigger)),
+				zap.String("reason", rb.Reason),
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/rollout"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"go.uber.org/zap"
+)
+
+// RolloutReconciler evaluates the active wave of running rollouts, halting
+// them on failures and releasing the next wave once thresholds are met.
+type RolloutReconciler struct {
+	rollouts *deployment.RolloutUseCase
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+func NewRolloutReconciler(rollouts *deployment.RolloutUseCase, logger *zap.Logger) *RolloutReconciler {
+	return &RolloutReconciler{
+		rollouts: rollouts,
+		logger:   logger.Named("rollout.reconciler"),
+		interval: 15 * time.Second,
+	}
+}
+
+func (r *RolloutReconciler) Run(ctx context.Context) {
+	ctx = instance.WithActor(ctx, instance.Actor{Type: instance.ActorReconciler, ID: "rollout"})
+
+	if err := r.reconcile(ctx); err != nil {
+		r.logger.Error("reconcile_initial_failed", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.reconcile(ctx); err != nil {
+				r.logger.Error("reconcile_failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (r *RolloutReconciler) reconcile(ctx context.Context) error {
+	items, err := r.rollouts.ListRunning(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		status, wave := item.Status, item.CurrentWave
+		if err := r.rollouts.Advance(ctx, item); err != nil {
+			r.logger.Warn("rollout_advance_failed",
+				zap.Error(err),
+				zap.Int64("rollout_id", item.ID),
+				zap.Int("wave", wave),
+			)
+			continue
+		}
+		if item.Status != status || item.CurrentWave != wave {
+			fields := []zap.Field{
+				zap.Int64("rollout_id", item.ID),
+				zap.String("target_version", item.TargetVersion),
+				zap.String("status", string(item.Status)),
+				zap.Int("wave", item.CurrentWave),
+			}
+			if item.Status == rollout.StatusHalted {
+				r.logger.Warn("rollout_halted", append(fields, zap.String("reason", item.HaltReason))...)
+				continue
+			}
+			r.logger.Info("rollout_progressed", fields...)
+		}
+	}
+	return nil
+}
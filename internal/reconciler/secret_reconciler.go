@@ -0,0 +1,54 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"go.uber.org/zap"
+)
+
+// SecretReconciler disables the credentials replaced by a rotation once their
+// grace period ended.
+type SecretReconciler struct {
+	operations *deployment.OperationUseCase
+	logger     *zap.Logger
+	interval   time.Duration
+}
+
+func NewSecretReconciler(operations *deployment.OperationUseCase, logger *zap.Logger) *SecretReconciler {
+	return &SecretReconciler{
+		operations: operations,
+		logger:     logger.Named("secret.reconciler"),
+		interval:   time.Minute,
+	}
+}
+
+func (r *SecretReconciler) Run(ctx context.Context) {
+	ctx = instance.WithActor(ctx, instance.Actor{Type: instance.ActorReconciler, ID: "secret"})
+
+	r.reconcile(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+func (r *SecretReconciler) reconcile(ctx context.Context) {
+	enqueued, err := r.operations.ExpireSecrets(ctx)
+	if err != nil {
+		r.logger.Error("secret_expiry_failed", zap.Error(err))
+	}
+	if enqueued > 0 {
+		r.logger.Info("secret_expiry_enqueued", zap.Int64("count", enqueued))
+	}
+}
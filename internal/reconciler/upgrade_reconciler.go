@@ -0,0 +1,81 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/usecase/deployment"
+	"go.uber.org/zap"
+)
+
+// UpgradeReconciler drives in-flight blue/green upgrades through their phases.
+type UpgradeReconciler struct {
+	upgrades  instance.UpgradeRepository
+	blueGreen *deployment.BlueGreenUseCase
+	logger    *zap.Logger
+	interval  time.Duration
+	batchSize int
+}
+
+func NewUpgradeReconciler(upgrades instance.UpgradeRepository, blueGreen *deployment.BlueGreenUseCase, logger *zap.Logger) *UpgradeReconciler {
+	return &UpgradeReconciler{
+		upgrades:  upgrades,
+		blueGreen: blueGreen,
+		logger:    logger.Named("upgrade.reconciler"),
+		interval:  10 * time.Second,
+		batchSize: 50,
+	}
+}
+
+func (r *UpgradeReconciler) Run(ctx context.Context) {
+	ctx = instance.WithActor(ctx, instance.Actor{Type: instance.ActorReconciler, ID: "upgrade"})
+
+	if err := r.reconcile(ctx); err != nil {
+		r.logger.Error("reconcile_initial_failed", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.reconcile(ctx); err != nil {
+				r.logger.Error("reconcile_failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (r *UpgradeReconciler) reconcile(ctx context.Context) error {
+	items, err := r.upgrades.ListActive(ctx, r.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, up := range items {
+		phase := up.Phase
+		if err := r.blueGreen.Advance(ctx, up); err != nil {
+			r.logger.Warn("upgrade_advance_failed",
+				zap.Error(err),
+				zap.Int64("org_id", up.OrgID),
+				zap.Int64("upgrade_id", up.ID),
+				zap.String("phase", string(phase)),
+			)
+			continue
+		}
+		if up.Phase != phase {
+			r.logger.Info("upgrade_phase_changed",
+				zap.Int64("org_id", up.OrgID),
+				zap.Int64("upgrade_id", up.ID),
+				zap.String("from", string(phase)),
+				zap.String("to", string(up.Phase)),
+				zap.String("last_error", up.LastError),
+			)
+		}
+	}
+	return nil
+}
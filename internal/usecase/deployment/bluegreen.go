@@ -0,0 +1,319 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/internal/organization"
+)
+
+// orgResolver resolves the organization details used in deployment configs.
+type orgResolver interface {
+	GetSlug(ctx context.Context, orgID int64) (*organization.Organization, error)
+}
+
+// BlueGreenUseCase upgrades serving instances without downtime.
+// A standby job running the target version is deployed next to the serving job,
+// traffic is switched once the standby reports ready, and the old job is
+// terminated after a drain period. See docs/zero-downtime-upgrade.md.
+// Tier changes are only billed once the standby on the new tier takes traffic.
+type BlueGreenUseCase struct {
+	repo          instance.Repository
+	upgrades      instance.UpgradeRepository
+	provisioner   provisioning.Provisioner
+	profiles      *ProfileResolver
+	billingEngine billing.Engine
+	priceResolver billing.PriceResolver
+	orgs          orgResolver
+	runtimeCfg    RuntimeConfig
+	cfg           *config.Config
+}
+
+func NewBlueGreenUseCase(
+	repo instance.Repository,
+	upgrades instance.UpgradeRepository,
+	provisioner provisioning.Provisioner,
+	profiles *ProfileResolver,
+	billingEngine billing.Engine,
+	priceResolver billing.PriceResolver,
+	orgService *organization.Service,
+	runtimeCfg RuntimeConfig,
+	cfg *config.Config,
+) *BlueGreenUseCase {
+	return &BlueGreenUseCase{
+		repo:          repo,
+		upgrades:      upgrades,
+		provisioner:   provisioner,
+		profiles:      profiles,
+		billingEngine: billingEngine,
+		priceResolver: priceResolver,
+		orgs:          orgService,
+		runtimeCfg:    runtimeCfg,
+		cfg:           cfg,
+	}
+}
+
+// Start deploys a standby job for the target version and tier.
+// The serving job is left untouched until the standby is ready.
+func (uc *BlueGreenUseCase) Start(ctx context.Context, inst *instance.Instance, version string, tier instance.Tier) (*instance.Upgrade, error) {
+	existing, err := uc.upgrades.FindActiveByInstanceID(ctx, inst.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.ToVersion == version && existing.ToTier == tier {
+			return existing, nil
+		}
+		return nil, instance.ErrUpgradeInProgress
+	}
+
+	org, err := uc.orgs.GetSlug(ctx, inst.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve org slug: %w", err)
+	}
+
+	now := time.Now().UTC()
+	up := instance.NewUpgrade(inst, version, tier, now.Add(uc.warmupTimeout()))
+
+	deployCfg, err := buildDeploymentConfig(ctx, uc.cfg, uc.runtimeCfg, uc.profiles, org, inst, version, tier)
+	if err != nil {
+		return nil, err
+	}
+	deployCfg.JobID = up.ToJobID
+	deployCfg.Standby = true
+
+	// Record the upgrade first so a crash after Deploy still leaves a row
+	// for the reconciler to finish or roll back.
+	if err := uc.upgrades.Create(ctx, up); err != nil {
+		return nil, err
+	}
+
+	if err := uc.provisioner.Deploy(ctx, deployCfg); err != nil {
+		up.MarkRolledBack("standby_deploy_failed: " + err.Error())
+		_ = uc.upgrades.Save(ctx, up)
+		return nil, fmt.Errorf("failed to deploy standby: %w", err)
+	}
+
+	err = saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.DesiredVersion = version
+		i.UpdatedAt = now
+	})
+	if err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+// Advance moves an upgrade one phase forward. It is safe to call repeatedly.
+func (uc *BlueGreenUseCase) Advance(ctx context.Context, up *instance.Upgrade) error {
+	if !up.IsActive() {
+		return nil
+	}
+
+	inst, err := uc.repo.FindByID(ctx, up.InstanceID)
+	if err != nil {
+		return err
+	}
+	if inst == nil {
+		return uc.rollback(ctx, nil, up, "instance_missing")
+	}
+	if inst.Status == instance.StatusStopped || inst.Status == instance.StatusTerminated {
+		return uc.Cancel(ctx, inst, "instance_"+string(inst.Status))
+	}
+
+	switch up.Phase {
+	case instance.UpgradePhaseDeploying:
+		return uc.awaitStandby(ctx, inst, up)
+	case instance.UpgradePhaseWarming:
+		return uc.awaitReady(ctx, inst, up)
+	case instance.UpgradePhaseSwitching:
+		return uc.switchTraffic(ctx, inst, up)
+	case instance.UpgradePhaseDraining:
+		return uc.finishDrain(ctx, up)
+	default:
+		return fmt.Errorf("unknown upgrade phase %s", up.Phase)
+	}
+}
+
+// Cancel abandons the active upgrade of an instance, if any.
+// Once traffic has moved to the new job, the old job is terminated instead.
+func (uc *BlueGreenUseCase) Cancel(ctx context.Context, inst *instance.Instance, reason string) error {
+	up, err := uc.upgrades.FindActiveByInstanceID(ctx, inst.ID)
+	if err != nil || up == nil {
+		return err
+	}
+	if up.Phase == instance.UpgradePhaseDraining {
+		if err := uc.provisioner.Stop(ctx, upgradeWorkload(up, up.FromJobID)); err != nil {
+			return fmt.Errorf("failed to stop old job: %w", err)
+		}
+		up.MarkCompleted()
+		return uc.upgrades.Save(ctx, up)
+	}
+	return uc.rollback(ctx, inst, up, reason)
+}
+
+func (uc *BlueGreenUseCase) awaitStandby(ctx context.Context, inst *instance.Instance, up *instance.Upgrade) error {
+	raw, err := uc.provisioner.GetStatus(ctx, upgradeWorkload(up, up.ToJobID))
+	if err != nil {
+		return fmt.Errorf("failed to get standby status: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "running":
+		up.MarkWarming()
+		return uc.upgrades.Save(ctx, up)
+	case "failed", "lost", "complete":
+		return uc.rollback(ctx, inst, up, "standby_status:"+raw)
+	}
+
+	if up.DeadlineExceeded(time.Now().UTC()) {
+		return uc.rollback(ctx, inst, up, "standby_deploy_timeout")
+	}
+	return nil
+}
+
+func (uc *BlueGreenUseCase) awaitReady(ctx context.Context, inst *instance.Instance, up *instance.Upgrade) error {
+	if up.Readiness == instance.ReadinessReady {
+		up.MarkSwitching()
+		if err := uc.upgrades.Save(ctx, up); err != nil {
+			return err
+		}
+		return uc.switchTraffic(ctx, inst, up)
+	}
+
+	if up.DeadlineExceeded(time.Now().UTC()) {
+		reason := "standby_not_ready"
+		if up.ReadinessError != "" {
+			reason += ": " + up.ReadinessError
+		}
+		return uc.rollback(ctx, inst, up, reason)
+	}
+	return nil
+}
+
+// switchTraffic routes production traffic to the standby job and removes the
+// old job from the route. Both steps re-register jobs with the same config and
+// are idempotent, so a failed switch is simply retried.
+func (uc *BlueGreenUseCase) switchTraffic(ctx context.Context, inst *instance.Instance, up *instance.Upgrade) error {
+	org, err := uc.orgs.GetSlug(ctx, inst.OrgID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve org slug: %w", err)
+	}
+
+	// Attach the new job first so the host is never left without a route.
+	newCfg, err := buildDeploymentConfig(ctx, uc.cfg, uc.runtimeCfg, uc.profiles, org, inst, up.ToVersion, up.ToTier)
+	if err != nil {
+		return err
+	}
+	newCfg.JobID = up.ToJobID
+	if err := uc.provisioner.Deploy(ctx, newCfg); err != nil {
+		return fmt.Errorf("failed to route traffic to standby: %w", err)
+	}
+
+	oldCfg, err := buildDeploymentConfig(ctx, uc.cfg, uc.runtimeCfg, uc.profiles, org, inst, up.FromVersion, up.FromTier)
+	if err != nil {
+		return err
+	}
+	oldCfg.JobID = up.FromJobID
+	oldCfg.Standby = true
+	if err := uc.provisioner.Deploy(ctx, oldCfg); err != nil {
+		return fmt.Errorf("failed to detach old job: %w", err)
+	}
+
+	err = saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.PromoteStandby(up)
+		i.TierProfileRevision = newCfg.TierProfileRevision
+		i.EntitlementsHash = newCfg.EntitlementsHash
+	})
+	if err != nil {
+		return err
+	}
+
+	// The new tier serves traffic from here on. A failed plan change keeps the
+	// upgrade switching, so the whole switch is retried.
+	if err := uc.changePlan(ctx, inst, up); err != nil {
+		return err
+	}
+
+	up.MarkDraining(time.Now().UTC().Add(uc.drainPeriod()))
+	return uc.upgrades.Save(ctx, up)
+}
+
+func (uc *BlueGreenUseCase) finishDrain(ctx context.Context, up *instance.Upgrade) error {
+	if up.DrainUntil != nil && time.Now().UTC().Before(*up.DrainUntil) {
+		return nil
+	}
+	if err := uc.provisioner.Stop(ctx, upgradeWorkload(up, up.FromJobID)); err != nil {
+		return fmt.Errorf("failed to stop old job: %w", err)
+	}
+	up.MarkCompleted()
+	return uc.upgrades.Save(ctx, up)
+}
+
+// rollback discards the standby job. The old job never stopped serving, so
+// rolling back is only a matter of removing the standby.
+func (uc *BlueGreenUseCase) rollback(ctx context.Context, inst *instance.Instance, up *instance.Upgrade, reason string) error {
+	if err := uc.provisioner.Stop(ctx, upgradeWorkload(up, up.ToJobID)); err != nil {
+		return fmt.Errorf("failed to stop standby: %w", err)
+	}
+
+	up.MarkRolledBack(reason)
+	if err := uc.upgrades.Save(ctx, up); err != nil {
+		return err
+	}
+
+	if inst == nil {
+		return nil
+	}
+	return saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.DesiredVersion = up.FromVersion
+		i.Tier = up.FromTier
+		if i.Status == instance.StatusUpgrading {
+			i.Status = instance.StatusActive
+		}
+		i.LastError = fmt.Sprintf("upgrade to %s rolled back: %s", up.ToVersion, reason)
+		i.UpdatedAt = time.Now().UTC()
+	})
+}
+
+// changePlan moves the subscription to the price of the upgraded tier.
+func (uc *BlueGreenUseCase) changePlan(ctx context.Context, inst *instance.Instance, up *instance.Upgrade) error {
+	if up.FromTier == up.ToTier || inst.SubscriptionID == "" {
+		return nil
+	}
+	priceID, err := uc.priceResolver.ResolvePriceID(ctx, string(up.ToTier))
+	if err != nil {
+		return fmt.Errorf("billing config missing for tier %s", up.ToTier)
+	}
+	params := billing.ChangePlanParams{
+		SubscriptionID:    inst.SubscriptionID,
+		NewPriceID:        priceID,
+		ProrationBehavior: billing.CreateProration,
+		EffectiveDate:     "immediate",
+		IdempotencyKey:    fmt.Sprintf("upgrade:%d:change-plan:%s", up.ID, priceID),
+	}
+	if err := uc.billingEngine.ChangePlan(ctx, params); err != nil {
+		return fmt.Errorf("traffic switched but billing failed: %w", err)
+	}
+	return nil
+}
+
+func (uc *BlueGreenUseCase) warmupTimeout() time.Duration {
+	if uc.cfg.UpgradeWarmupTimeoutSeconds <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(uc.cfg.UpgradeWarmupTimeoutSeconds) * time.Second
+}
+
+func (uc *BlueGreenUseCase) drainPeriod() time.Duration {
+	if uc.cfg.UpgradeDrainSeconds < 0 {
+		return 0
+	}
+	return time.Duration(uc.cfg.UpgradeDrainSeconds) * time.Second
+}
@@ -0,0 +1,292 @@
+package deployment
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/organization"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUpgradeRepository is a simple in-memory upgrade repository for testing
+type mockUpgradeRepository struct {
+	upgrades map[int64]*instance.Upgrade
+	nextID   int64
+}
+
+func newMockUpgradeRepository() *mockUpgradeRepository {
+	return &mockUpgradeRepository{upgrades: make(map[int64]*instance.Upgrade)}
+}
+
+func (m *mockUpgradeRepository) Create(ctx context.Context, up *instance.Upgrade) error {
+	if existing, _ := m.FindActiveByInstanceID(ctx, up.InstanceID); existing != nil {
+		return instance.ErrUpgradeInProgress
+	}
+	m.nextID++
+	up.ID = m.nextID
+	m.upgrades[up.ID] = up
+	return nil
+}
+
+func (m *mockUpgradeRepository) Save(ctx context.Context, up *instance.Upgrade) error {
+	m.upgrades[up.ID] = up
+	return nil
+}
+
+func (m *mockUpgradeRepository) SaveReadiness(ctx context.Context, up *instance.Upgrade) error {
+	stored, ok := m.upgrades[up.ID]
+	if !ok || stored.Phase != instance.UpgradePhaseWarming {
+		return nil
+	}
+	stored.Readiness, stored.ReadinessCheckedAt, stored.ReadinessError = up.Readiness, up.ReadinessCheckedAt, up.ReadinessError
+	return nil
+}
+
+func (m *mockUpgradeRepository) FindActiveByInstanceID(ctx context.Context, instanceID int64) (*instance.Upgrade, error) {
+	for _, up := range m.upgrades {
+		if up.InstanceID == instanceID && up.IsActive() {
+			return up, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockUpgradeRepository) ListActive(ctx context.Context, limit int) ([]*instance.Upgrade, error) {
+	var result []*instance.Upgrade
+	for _, up := range m.upgrades {
+		if up.IsActive() {
+			result = append(result, up)
+		}
+	}
+	return result, nil
+}
+
+// recordingBilling records plan changes and the keys of the other mutating
+// calls, and resolves every tier to "price_<tier>".
+type recordingBilling struct {
+	planChanges []billing.ChangePlanParams
+	keys        []string
+}
+
+func (b *recordingBilling) PauseSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error {
+	b.keys = append(b.keys, idempotencyKey)
+	return nil
+}
+
+func (b *recordingBilling) ResumeSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error {
+	b.keys = append(b.keys, idempotencyKey)
+	return nil
+}
+
+func (b *recordingBilling) CancelSubscription(ctx context.Context, subscriptionID, idempotencyKey string) error {
+	b.keys = append(b.keys, idempotencyKey)
+	return nil
+}
+
+func (b *recordingBilling) GetSubscriptionStatus(ctx context.Context, subscriptionID string) (string, error) {
+	return "active", nil
+}
+
+func (b *recordingBilling) ChangePlan(ctx context.Context, params billing.ChangePlanParams) error {
+	b.planChanges = append(b.planChanges, params)
+	return nil
+}
+
+func (b *recordingBilling) ResolvePriceID(ctx context.Context, tier string) (string, error) {
+	return "price_" + tier, nil
+}
+
+type staticOrgResolver struct{}
+
+func (staticOrgResolver) GetSlug(ctx context.Context, orgID int64) (*organization.Organization, error) {
+	return &organization.Organization{ID: orgID, Slug: "acme", Name: "Acme"}, nil
+}
+
+func newTestBlueGreen(t *testing.T) (*BlueGreenUseCase, *mockInstanceRepository, *mockUpgradeRepository, *testhelper.MockProvisioner) {
+	uc, repo, upgrades, provisioner, _ := newTestBlueGreenWithBilling(t)
+	return uc, repo, upgrades, provisioner
+}
+
+func newTestBlueGreenWithBilling(t *testing.T) (*BlueGreenUseCase, *mockInstanceRepository, *mockUpgradeRepository, *testhelper.MockProvisioner, *recordingBilling) {
+	t.Helper()
+	repo := newMockInstanceRepository()
+	upgrades := newMockUpgradeRepository()
+	provisioner := &testhelper.MockProvisioner{Statuses: map[string]string{}}
+	cfg := &config.Config{
+		InstanceSecretEncryptionKey: base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		UpgradeWarmupTimeoutSeconds: 60,
+		UpgradeDrainSeconds:         0,
+	}
+	billingEngine := &recordingBilling{}
+	uc := &BlueGreenUseCase{
+		repo:          repo,
+		upgrades:      upgrades,
+		provisioner:   provisioner,
+		billingEngine: billingEngine,
+		priceResolver: billingEngine,
+		orgs:          staticOrgResolver{},
+		cfg:           cfg,
+	}
+	return uc, repo, upgrades, provisioner, billingEngine
+}
+
+func servingInstance(repo *mockInstanceRepository) *instance.Instance {
+	inst := &instance.Instance{
+		ID:             1,
+		OrgID:          42,
+		Tier:           instance.TierStarter,
+		ComputeEngine:  instance.EngineHetzner,
+		CurrentVersion: "v1.0.0",
+		DesiredVersion: "v1.0.0",
+		Status:         instance.StatusActive,
+		Role:           instance.RolePrimary,
+	}
+	repo.instances[inst.ID] = inst
+	return inst
+}
+
+func TestBlueGreen_FullUpgrade(t *testing.T) {
+	ctx := context.Background()
+	uc, repo, upgrades, provisioner := newTestBlueGreen(t)
+	inst := servingInstance(repo)
+
+	up, err := uc.Start(ctx, inst, "v1.1.0", inst.Tier)
+	require.NoError(t, err)
+
+	// Standby is deployed next to the serving job without public traffic.
+	require.Len(t, provisioner.DeployCalls, 1)
+	assert.Equal(t, "railzway-org-42-blue", provisioner.DeployCalls[0].JobID)
+	assert.True(t, provisioner.DeployCalls[0].Standby)
+	assert.Equal(t, "v1.1.0", provisioner.DeployCalls[0].Version)
+	assert.Equal(t, "v1.0.0", inst.CurrentVersion)
+
+	// A second start for the same target is a no-op.
+	again, err := uc.Start(ctx, inst, "v1.1.0", inst.Tier)
+	require.NoError(t, err)
+	assert.Equal(t, up.ID, again.ID)
+	_, err = uc.Start(ctx, inst, "v1.2.0", inst.Tier)
+	assert.ErrorIs(t, err, instance.ErrUpgradeInProgress)
+
+	// Standby allocation running -> warming.
+	require.NoError(t, uc.Advance(ctx, up))
+	assert.Equal(t, instance.UpgradePhaseWarming, up.Phase)
+
+	// Not ready yet: nothing happens.
+	require.NoError(t, uc.Advance(ctx, up))
+	assert.Equal(t, instance.UpgradePhaseWarming, up.Phase)
+
+	// Ready -> traffic switched, old job detached.
+	up.SetReadiness(instance.ReadinessReady, "")
+	require.NoError(t, uc.Advance(ctx, up))
+	assert.Equal(t, instance.UpgradePhaseDraining, up.Phase)
+	require.Len(t, provisioner.DeployCalls, 3)
+	assert.Equal(t, "railzway-org-42-blue", provisioner.DeployCalls[1].JobID)
+	assert.False(t, provisioner.DeployCalls[1].Standby)
+	assert.Equal(t, "railzway-org-42", provisioner.DeployCalls[2].JobID)
+	assert.True(t, provisioner.DeployCalls[2].Standby)
+	assert.Equal(t, "v1.0.0", provisioner.DeployCalls[2].Version)
+
+	promoted := repo.instances[1]
+	assert.Equal(t, "railzway-org-42-blue", promoted.NomadJobID)
+	assert.Equal(t, "v1.1.0", promoted.CurrentVersion)
+
+	// Drain elapsed -> old job terminated.
+	require.NoError(t, uc.Advance(ctx, up))
+	assert.Equal(t, instance.UpgradePhaseCompleted, up.Phase)
+	require.Len(t, provisioner.StopCalls, 1)
+	assert.Equal(t, "railzway-org-42", provisioner.StopCalls[0].JobID)
+
+	active, _ := upgrades.ListActive(ctx, 10)
+	assert.Empty(t, active)
+}
+
+func TestBlueGreen_RollsBackFailedStandby(t *testing.T) {
+	ctx := context.Background()
+	uc, repo, _, provisioner := newTestBlueGreen(t)
+	inst := servingInstance(repo)
+
+	up, err := uc.Start(ctx, inst, "v1.1.0", inst.Tier)
+	require.NoError(t, err)
+
+	provisioner.Statuses[up.ToJobID] = "failed"
+	require.NoError(t, uc.Advance(ctx, up))
+
+	assert.Equal(t, instance.UpgradePhaseRolledBack, up.Phase)
+	require.Len(t, provisioner.StopCalls, 1)
+	assert.Equal(t, "railzway-org-42-blue", provisioner.StopCalls[0].JobID)
+
+	// The serving job is untouched.
+	assert.Equal(t, "", inst.NomadJobID)
+	assert.Equal(t, "v1.0.0", inst.CurrentVersion)
+	assert.Equal(t, "v1.0.0", inst.DesiredVersion)
+	assert.Contains(t, inst.LastError, "rolled back")
+}
+
+func TestBlueGreen_RollsBackWhenNeverReady(t *testing.T) {
+	ctx := context.Background()
+	uc, repo, _, provisioner := newTestBlueGreen(t)
+	inst := servingInstance(repo)
+
+	up, err := uc.Start(ctx, inst, "v1.1.0", inst.Tier)
+	require.NoError(t, err)
+	require.NoError(t, uc.Advance(ctx, up))
+
+	past := time.Now().Add(-time.Second)
+	up.DeadlineAt = &past
+	up.SetReadiness(instance.ReadinessNotReady, "ready=false")
+	require.NoError(t, uc.Advance(ctx, up))
+
+	assert.Equal(t, instance.UpgradePhaseRolledBack, up.Phase)
+	assert.Equal(t, "standby_not_ready: ready=false", up.LastError)
+	assert.Len(t, provisioner.StopCalls, 1)
+}
+
+func TestBlueGreen_TierUpgradeBilledOnSwitch(t *testing.T) {
+	ctx := context.Background()
+	uc, repo, _, _, billingEngine := newTestBlueGreenWithBilling(t)
+	inst := servingInstance(repo)
+	inst.SubscriptionID = "sub_1"
+
+	up, err := uc.Start(ctx, inst, inst.DesiredVersion, instance.TierPro)
+	require.NoError(t, err)
+	inst.MarkUpgrading(instance.TierPro)
+
+	require.NoError(t, uc.Advance(ctx, up))
+	assert.Empty(t, billingEngine.planChanges, "plan must not change before the standby serves")
+
+	up.SetReadiness(instance.ReadinessReady, "")
+	require.NoError(t, uc.Advance(ctx, up))
+	assert.Equal(t, instance.UpgradePhaseDraining, up.Phase)
+	require.Len(t, billingEngine.planChanges, 1)
+	assert.Equal(t, "price_PRO", billingEngine.planChanges[0].NewPriceID)
+	assert.Equal(t, fmt.Sprintf("upgrade:%d:change-plan:price_PRO", up.ID), billingEngine.planChanges[0].IdempotencyKey)
+	assert.Equal(t, instance.TierPro, repo.instances[1].Tier)
+}
+
+func TestBlueGreen_RollsBackTierUpgrade(t *testing.T) {
+	ctx := context.Background()
+	uc, repo, _, provisioner, billingEngine := newTestBlueGreenWithBilling(t)
+	inst := servingInstance(repo)
+	inst.SubscriptionID = "sub_1"
+
+	up, err := uc.Start(ctx, inst, inst.DesiredVersion, instance.TierPro)
+	require.NoError(t, err)
+	assert.Equal(t, instance.TierPro, provisioner.DeployCalls[0].Tier)
+	inst.MarkUpgrading(instance.TierPro)
+
+	provisioner.Statuses[up.ToJobID] = "failed"
+	require.NoError(t, uc.Advance(ctx, up))
+
+	assert.Equal(t, instance.UpgradePhaseRolledBack, up.Phase)
+	assert.Equal(t, instance.TierStarter, inst.Tier)
+	assert.Equal(t, instance.StatusActive, inst.Status)
+	assert.Empty(t, billingEngine.planChanges)
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 type DeployUseCase struct {
 	repo          instance.Repository
 	provisioner   provisioning.Provisioner
+	profiles      *ProfileResolver
 	dbProvisioner provisioning.DatabaseProvisioner
 	dbConfig      provisioning.DBConfig // Default config connection params (host/port)
 	runtimeCfg    RuntimeConfig
@@ -26,6 +28,8 @@ type DeployUseCase struct {
 	billingEngine billing.Engine
 	cfg           *config.Config // OAuth and other config
 	authClient    *authclient.Client
+	blueGreen     *BlueGreenUseCase
+	rotations     instance.SecretRotationRepository
 }
 
 type RuntimeConfig struct {
@@ -37,6 +41,7 @@ type RuntimeConfig struct {
 func NewDeployUseCase(
 	repo instance.Repository,
 	provisioner provisioning.Provisioner,
+	profiles *ProfileResolver,
 	dbProvisioner provisioning.DatabaseProvisioner,
 	dbConfig provisioning.DBConfig,
 	runtimeCfg RuntimeConfig,
@@ -44,10 +49,13 @@ func NewDeployUseCase(
 	billingEngine billing.Engine,
 	cfg *config.Config,
 	authClient *authclient.Client,
+	blueGreen *BlueGreenUseCase,
+	rotations instance.SecretRotationRepository,
 ) *DeployUseCase {
 	return &DeployUseCase{
 		repo:          repo,
 		provisioner:   provisioner,
+		profiles:      profiles,
 		dbProvisioner: dbProvisioner,
 		dbConfig:      dbConfig,
 		runtimeCfg:    runtimeCfg,
@@ -55,10 +63,12 @@ func NewDeployUseCase(
 		billingEngine: billingEngine,
 		cfg:           cfg,
 		authClient:    authClient,
+		blueGreen:     blueGreen,
+		rotations:     rotations,
 	}
 }
 
-// Execute deploys or updates an instance for an organization.
+// Execute deploys or updates the primary instance of an organization.
 func (uc *DeployUseCase) Execute(ctx context.Context, orgID int64, version string) error {
 	// 1. Load Instance
 	inst, err := uc.repo.FindByOrgID(ctx, orgID)
@@ -68,8 +78,125 @@ func (uc *DeployUseCase) Execute(ctx context.Context, orgID int64, version strin
 	if inst == nil {
 		return fmt.Errorf("instance not found for org %d", orgID)
 	}
+	return uc.deploy(ctx, inst, version)
+}
+
+// ExecuteInstance deploys or updates a single instance.
+func (uc *DeployUseCase) ExecuteInstance(ctx context.Context, instanceID int64, version string) error {
+	inst, err := uc.repo.FindByID(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	if inst == nil {
+		return fmt.Errorf("instance %d not found", instanceID)
+	}
+	return uc.deploy(ctx, inst, version)
+}
+
+// NeedsRedeploy reports whether a serving instance runs another version than
+// desired or was deployed with an outdated tier profile or entitlements.
+func (uc *DeployUseCase) NeedsRedeploy(ctx context.Context, inst *instance.Instance) (bool, error) {
+	if inst.CurrentVersion != inst.DesiredVersion {
+		return true, nil
+	}
+	return uc.profiles.Outdated(ctx, inst)
+}
+
+func (uc *DeployUseCase) deploy(ctx context.Context, inst *instance.Instance, version string) error {
+	// 1. Check Subscription Status
+	if err := uc.checkSubscription(ctx, inst); err != nil {
+		return err
+	}
+
+	// Serving instances are never redeployed in place: a new version, tier
+	// profile or entitlements are rolled out next to the running one and traffic is switched
+	// once ready.
+	stale, err := uc.profiles.Outdated(ctx, inst)
+	if err != nil {
+		return err
+	}
+	if inst.IsServing() && (inst.CurrentVersion != version || stale) && uc.blueGreen != nil {
+		if _, err := uc.blueGreen.Start(ctx, inst, version, inst.Tier); err != nil {
+			return fmt.Errorf("blue/green upgrade failed: %w", err)
+		}
+		return nil
+	}
+
+	// 2. Provision Database (Idempotent)
+	if err := uc.provisionDatabase(ctx, inst); err != nil {
+		return err
+	}
+
+	// 3. Deploy Workload
+	return uc.deployWorkload(ctx, inst, version)
+}
+
+// ProvisionDatabase creates the database of an instance, or syncs the
+// password of an existing one.
+func (uc *DeployUseCase) ProvisionDatabase(ctx context.Context, instanceID int64) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	return uc.provisionDatabase(ctx, inst)
+}
+
+// DeployWorkload deploys the workload of an instance whose database is
+// provisioned, without going through a blue/green upgrade.
+func (uc *DeployUseCase) DeployWorkload(ctx context.Context, instanceID int64, version string) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	if inst.DBUser == "" {
+		return fmt.Errorf("instance %d has no database yet", inst.ID)
+	}
+	if err := uc.checkSubscription(ctx, inst); err != nil {
+		return err
+	}
+	return uc.deployWorkload(ctx, inst, version)
+}
 
-	// 2. Check Subscription Status
+// DeprovisionDatabase drops the database of an instance and forgets its
+// credentials, so the next deploy provisions a new one.
+func (uc *DeployUseCase) DeprovisionDatabase(ctx context.Context, instanceID int64) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	if inst.DBUser == "" {
+		return nil
+	}
+
+	dbCfg := provisioning.DBConfig{
+		Host: inst.DBHost,
+		Port: inst.DBPort,
+		Name: inst.DBName,
+		User: inst.DBUser,
+	}
+	if err := uc.dbProvisioner.Deprovision(ctx, dbCfg); err != nil {
+		return fmt.Errorf("db deprovisioning failed: %w", err)
+	}
+	return saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.DBHost, i.DBPort = "", 0
+		i.DBName, i.DBUser, i.DBPassword = "", "", ""
+		i.DBLoginUser, i.PreviousDBLoginUser, i.PreviousDBLoginExpiresAt = "", "", nil
+	})
+}
+
+// RemoveWorkload stops the workload of an instance.
+func (uc *DeployUseCase) RemoveWorkload(ctx context.Context, instanceID int64) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	if err := uc.provisioner.Stop(ctx, workloadOf(inst)); err != nil {
+		return fmt.Errorf("failed to stop instance: %w", err)
+	}
+	return nil
+}
+
+func (uc *DeployUseCase) checkSubscription(ctx context.Context, inst *instance.Instance) error {
 	if inst.SubscriptionID != "" {
 		status, err := uc.billingEngine.GetSubscriptionStatus(ctx, inst.SubscriptionID)
 		if err != nil {
@@ -85,40 +212,70 @@ func (uc *DeployUseCase) Execute(ctx context.Context, orgID int64, version strin
 		// NOTE: Assuming all paid tiers MUST have a subscription ID.
 		return fmt.Errorf("subscription required for tier %s", inst.Tier)
 	}
+	return nil
+}
 
-	// 3. Provision Database (Idempotent)
+func (uc *DeployUseCase) provisionDatabase(ctx context.Context, inst *instance.Instance) error {
 	if inst.DBUser == "" {
 		// First time provisioning
-		inst.DBUser = fmt.Sprintf("railzway_user_%d", inst.OrgID)
-		inst.DBName = fmt.Sprintf("railzway_org_%d", inst.OrgID)
-		inst.DBHost = uc.dbConfig.Host
-		inst.DBPort = uc.dbConfig.Port
+		name, user := inst.DatabaseNames()
 
 		// Generate random password
 		password, err := generatePassword()
 		if err != nil {
 			return fmt.Errorf("failed to generate db password: %w", err)
 		}
-		inst.DBPassword = password
+
+		// Saved before the database exists, so a retry reuses the
+		// credentials and a compensation knows what to drop.
+		if err := saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+			i.DBName, i.DBUser, i.DBPassword = name, user, password
+			i.DBHost, i.DBPort = uc.dbConfig.Host, uc.dbConfig.Port
+		}); err != nil {
+			return err
+		}
 	}
 
 	// Always ensure DB exists/user password is synced
-	if err := uc.dbProvisioner.Provision(ctx, inst.OrgID, inst.DBPassword); err != nil {
+	if err := uc.dbProvisioner.Provision(ctx, databaseConfig(inst)); err != nil {
 		return fmt.Errorf("db provisioning failed: %w", err)
 	}
+	return nil
+}
+
+// databaseConfig describes the database of an instance with the limits of its
+// tier, which every provisioning applies again.
+func databaseConfig(inst *instance.Instance) provisioning.DBConfig {
+	limits := inst.DatabaseLimits()
+	return provisioning.DBConfig{
+		Host:             inst.DBHost,
+		Port:             inst.DBPort,
+		Name:             inst.DBName,
+		User:             inst.DBUser,
+		Password:         inst.DBPassword,
+		LoginUser:        inst.DBLoginUser,
+		ConnectionLimit:  limits.ConnectionLimit,
+		StatementTimeout: limits.StatementTimeout,
+	}
+}
 
+func (uc *DeployUseCase) deployWorkload(ctx context.Context, inst *instance.Instance, version string) error {
 	// 3. Prepare Config
 	org, err := uc.orgService.GetSlug(ctx, inst.OrgID)
 	if err != nil {
 		return fmt.Errorf("failed to resolve org slug: %w", err)
 	}
 
-	launchURL := resolveLaunchURL(uc.cfg, org.Slug, inst.LaunchURL)
+	launchURL := resolveLaunchURL(uc.cfg, inst.HostLabel(org.Slug), inst.LaunchURL)
 	if launchURL == "" {
 		return fmt.Errorf("launch url is required")
 	}
 
-	client, err := uc.ensureOAuthClient(ctx, inst.OrgID, launchURL)
+	redirectURIs, err := uc.redirectURIs(ctx, inst, launchURL)
+	if err != nil {
+		return err
+	}
+	client, err := uc.ensureOAuthClient(ctx, inst.OrgID, redirectURIs)
 	if err != nil {
 		return err
 	}
@@ -131,50 +288,33 @@ func (uc *DeployUseCase) Execute(ctx context.Context, orgID int64, version strin
 		return fmt.Errorf("auth client secret missing for org %d", inst.OrgID)
 	}
 
-	paymentSecret, err := resolvePaymentProviderSecret(uc.cfg, inst)
+	deployCfg, err := buildDeploymentConfig(ctx, uc.cfg, uc.runtimeCfg, uc.profiles, org, inst, version, inst.Tier)
 	if err != nil {
 		return err
 	}
 
-	deployCfg := provisioning.DeploymentConfig{
-		OrgID:         org.ID,
-		OrgSlug:       org.Slug,
-		OrgName:       org.Name,
-		Version:       version,
-		Tier:          inst.Tier,
-		ComputeEngine: inst.ComputeEngine,
-		DBConfig: provisioning.DBConfig{
-			Host:     inst.DBHost,
-			Port:     inst.DBPort,
-			Name:     inst.DBName,
-			User:     inst.DBUser,
-			Password: inst.DBPassword,
-		},
-		// Runtime Configuration
-		RateLimitRedisAddr:     uc.runtimeCfg.RateLimitRedisAddr,
-		RateLimitRedisPassword: uc.runtimeCfg.RateLimitRedisPassword,
-		RateLimitRedisDB:       uc.runtimeCfg.RateLimitRedisDB,
-
-		// OAuth Configuration
-		OAuth2URI:                   uc.cfg.OAuth2URI,
-		OAuth2ClientID:              inst.OAuthClientID,
-		OAuth2ClientSecret:          inst.OAuthClientSecret,
-		PaymentProviderConfigSecret: paymentSecret,
-	}
-
 	// 3. Provision
-	if err := uc.provisioner.Deploy(ctx, &deployCfg); err != nil {
+	if err := uc.provisioner.Deploy(ctx, deployCfg); err != nil {
 		return fmt.Errorf("deployment failed: %w", err)
 	}
 
 	// 4. Update State
-	inst.DesiredVersion = version
-	inst.Status = instance.StatusProvisioning
-	inst.UpdatedAt = time.Now().UTC()
 	// Note: Status will transition to Active once health checks pass.
 	// This is handled by a separate monitoring process or webhook from Nomad.
-
-	return uc.repo.Save(ctx, inst)
+	deployed := *inst
+	return saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.DBHost, i.DBPort = deployed.DBHost, deployed.DBPort
+		i.DBName, i.DBUser, i.DBPassword = deployed.DBName, deployed.DBUser, deployed.DBPassword
+		i.LaunchURL = deployed.LaunchURL
+		i.OAuthClientID = deployed.OAuthClientID
+		i.OAuthClientSecret = deployed.OAuthClientSecret
+		i.PaymentProviderConfigSecret = deployed.PaymentProviderConfigSecret
+		i.DesiredVersion = version
+		i.TierProfileRevision = deployCfg.TierProfileRevision
+		i.EntitlementsHash = deployCfg.EntitlementsHash
+		i.Status = instance.StatusProvisioning
+		i.UpdatedAt = time.Now().UTC()
+	})
 }
 
 // generateJWTSecret generates a deterministic but unique JWT secret for each organization.
@@ -207,13 +347,32 @@ func resolveLaunchURL(cfg *config.Config, orgSlug string, current string) string
 	return fmt.Sprintf("%s://%s/login/railzway_com", scheme, host)
 }
 
-func (uc *DeployUseCase) ensureOAuthClient(ctx context.Context, orgID int64, launchURL string) (*authclient.OAuthClient, error) {
+// redirectURIs collects the launch URLs of every live instance of the org.
+// The OAuth client is shared per org, so each environment must stay registered.
+func (uc *DeployUseCase) redirectURIs(ctx context.Context, inst *instance.Instance, launchURL string) ([]string, error) {
+	siblings, err := uc.repo.ListByOrgID(ctx, inst.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list org instances: %w", err)
+	}
+
+	uris := []string{launchURL}
+	for _, sibling := range siblings {
+		url := strings.TrimSpace(sibling.LaunchURL)
+		if sibling.ID == inst.ID || sibling.Status == instance.StatusTerminated || url == "" || slices.Contains(uris, url) {
+			continue
+		}
+		uris = append(uris, url)
+	}
+	return uris, nil
+}
+
+func (uc *DeployUseCase) ensureOAuthClient(ctx context.Context, orgID int64, redirectURIs []string) (*authclient.OAuthClient, error) {
 	if uc.authClient == nil {
 		return nil, fmt.Errorf("auth client not configured")
 	}
 	creds, err := uc.authClient.EnsureOAuthClient(ctx, authclient.EnsureOAuthClientRequest{
 		ExternalOrgID: orgID,
-		RedirectURIs:  []string{launchURL},
+		RedirectURIs:  redirectURIs,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("ensure oauth client: %w", err)
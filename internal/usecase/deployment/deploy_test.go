@@ -23,21 +23,40 @@ func newMockInstanceRepository() *mockInstanceRepository {
 	}
 }
 
-func (m *mockInstanceRepository) FindByOrgID(ctx context.Context, orgID int64) (*instance.Instance, error) {
-	inst, ok := m.instances[orgID]
+func (m *mockInstanceRepository) FindByID(ctx context.Context, id int64) (*instance.Instance, error) {
+	inst, ok := m.instances[id]
 	if !ok {
 		return nil, nil
 	}
 	return inst, nil
 }
 
+func (m *mockInstanceRepository) FindByOrgID(ctx context.Context, orgID int64) (*instance.Instance, error) {
+	for _, inst := range m.instances {
+		if inst.OrgID == orgID && inst.IsDefaultEnvironment() {
+			return inst, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockInstanceRepository) ListByOrgID(ctx context.Context, orgID int64) ([]*instance.Instance, error) {
+	var result []*instance.Instance
+	for _, inst := range m.instances {
+		if inst.OrgID == orgID {
+			result = append(result, inst)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockInstanceRepository) Save(ctx context.Context, inst *instance.Instance) error {
-	m.instances[inst.OrgID] = inst
+	m.instances[inst.ID] = inst
 	return nil
 }
 
-func (m *mockInstanceRepository) UpdateStatus(ctx context.Context, orgID int64, status instance.InstanceStatus) error {
-	inst, ok := m.instances[orgID]
+func (m *mockInstanceRepository) UpdateStatus(ctx context.Context, id int64, status instance.InstanceStatus) error {
+	inst, ok := m.instances[id]
 	if !ok {
 		return nil
 	}
@@ -112,3 +131,49 @@ func TestDeployUseCase_Execute_InstanceNotFound(t *testing.T) {
 
 // Note: Full integration tests with org service require actual database
 // These unit tests focus on the deployment logic without external dependencies
+
+func TestDeployUseCase_ProvisionDatabase_SavesCredentialsFirst(t *testing.T) {
+	mockRepo := newMockInstanceRepository()
+	mockRepo.instances[1] = &instance.Instance{ID: 1, OrgID: 7, Environment: "production"}
+	dbProvisioner := &testhelper.MockDatabaseProvisioner{ShouldFail: true}
+	uc := newTestDeployUseCase(mockRepo, &testhelper.MockProvisioner{}, dbProvisioner, nil, &config.Config{})
+
+	err := uc.ProvisionDatabase(context.Background(), 1)
+	assert.ErrorContains(t, err, "db provisioning failed")
+
+	// A retry reuses the saved credentials instead of generating new ones.
+	saved := *mockRepo.instances[1]
+	assert.NotEmpty(t, saved.DBUser)
+	assert.NotEmpty(t, saved.DBPassword)
+	assert.Equal(t, "localhost", saved.DBHost)
+
+	dbProvisioner.ShouldFail = false
+	assert.NoError(t, uc.ProvisionDatabase(context.Background(), 1))
+	assert.Equal(t, saved.DBPassword, mockRepo.instances[1].DBPassword)
+	if assert.Len(t, dbProvisioner.ProvisionCalls, 1) {
+		assert.Equal(t, saved.DBUser, dbProvisioner.ProvisionCalls[0].User)
+	}
+}
+
+func TestDeployUseCase_DeprovisionDatabase_ForgetsCredentials(t *testing.T) {
+	mockRepo := newMockInstanceRepository()
+	mockRepo.instances[1] = &instance.Instance{
+		ID:         1,
+		DBHost:     "localhost",
+		DBPort:     5432,
+		DBName:     "railzway_org_7",
+		DBUser:     "railzway_org_7",
+		DBPassword: "secret",
+	}
+	dbProvisioner := &testhelper.MockDatabaseProvisioner{}
+	uc := newTestDeployUseCase(mockRepo, &testhelper.MockProvisioner{}, dbProvisioner, nil, &config.Config{})
+
+	assert.NoError(t, uc.DeprovisionDatabase(context.Background(), 1))
+	assert.NoError(t, uc.DeprovisionDatabase(context.Background(), 1))
+
+	if assert.Len(t, dbProvisioner.DeprovisionCalls, 1) {
+		assert.Equal(t, "railzway_org_7", dbProvisioner.DeprovisionCalls[0].Name)
+	}
+	assert.Empty(t, mockRepo.instances[1].DBUser)
+	assert.Empty(t, mockRepo.instances[1].DBPassword)
+}
@@ -0,0 +1,121 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/tierprofile"
+	"github.com/railzwaylabs/railzway-cloud/internal/organization"
+)
+
+// buildDeploymentConfig assembles the provisioner config for an already provisioned instance.
+// It may generate the payment provider secret, so callers must persist inst afterwards.
+func buildDeploymentConfig(ctx context.Context, cfg *config.Config, runtimeCfg RuntimeConfig, profiles *ProfileResolver, org *organization.Organization, inst *instance.Instance, version string, tier instance.Tier) (*provisioning.DeploymentConfig, error) {
+	paymentSecret, err := resolvePaymentProviderSecret(inst)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := profiles.Resolve(ctx, inst, tier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provisioning.DeploymentConfig{
+		Provisioner:   inst.Provisioner,
+		OrgID:         org.ID,
+		OrgSlug:       inst.HostLabel(org.Slug),
+		OrgName:       org.Name,
+		Version:       version,
+		Tier:          tier,
+		ComputeEngine: inst.ComputeEngine,
+		DBConfig: provisioning.DBConfig{
+			Host:     inst.DBHost,
+			Port:     inst.DBPort,
+			Name:     inst.DBName,
+			User:     inst.DatabaseLogin(),
+			Password: inst.DBPassword,
+		},
+		RateLimitRedisAddr:     runtimeCfg.RateLimitRedisAddr,
+		RateLimitRedisPassword: runtimeCfg.RateLimitRedisPassword,
+		RateLimitRedisDB:       runtimeCfg.RateLimitRedisDB,
+
+		OAuth2URI:                   cfg.OAuth2URI,
+		OAuth2ClientID:              coalesce(inst.OAuthClientID, cfg.TenantOAuth2ClientID),
+		OAuth2ClientSecret:          coalesce(inst.OAuthClientSecret, cfg.TenantOAuth2ClientSecret),
+		PaymentProviderConfigSecret: paymentSecret,
+
+		PreviousPaymentProviderConfigSecret: inst.PreviousPaymentProviderSecretAt(time.Now().UTC()),
+
+		JobID: inst.JobID(),
+
+		TierProfile:         &profile.Spec,
+		TierProfileRevision: profile.Revision,
+		EntitlementsHash:    profile.EntitlementsHash,
+	}, nil
+}
+
+// resolveTierProfile returns the current catalog profile of a tier, or the
+// built-in one when the catalog has none or is not configured.
+func resolveTierProfile(ctx context.Context, profiles tierprofile.Repository, tier instance.Tier) (*tierprofile.Profile, error) {
+	if profiles == nil {
+		return tierprofile.Builtin(tier), nil
+	}
+	profile, err := profiles.Current(ctx, tier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tier profile: %w", err)
+	}
+	if profile == nil {
+		return tierprofile.Builtin(tier), nil
+	}
+	return profile, nil
+}
+
+// workloadOf returns the workload currently serving the instance.
+func workloadOf(inst *instance.Instance) provisioning.Workload {
+	return provisioning.Workload{OrgID: inst.OrgID, JobID: inst.JobID(), Provisioner: inst.Provisioner}
+}
+
+// upgradeWorkload returns one of the two jobs of a blue/green upgrade.
+func upgradeWorkload(up *instance.Upgrade, jobID string) provisioning.Workload {
+	return provisioning.Workload{OrgID: up.OrgID, JobID: jobID, Provisioner: up.Provisioner}
+}
+
+// findInstance loads an instance by ID and fails when it does not exist.
+func findInstance(ctx context.Context, repo instance.Repository, instanceID int64) (*instance.Instance, error) {
+	inst, err := repo.FindByID(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if inst == nil {
+		return nil, fmt.Errorf("instance not found")
+	}
+	return inst, nil
+}
+
+// maxConflictRetries bounds how often a save is retried after losing a
+// compare-and-swap race.
+const maxConflictRetries = 3
+
+// saveInstance applies mutate to inst and saves it. When another writer
+// changed the instance first, it reloads the instance, reapplies mutate and
+// tries again, so mutate must only set the fields this caller owns.
+func saveInstance(ctx context.Context, repo instance.Repository, inst *instance.Instance, mutate func(*instance.Instance)) error {
+	for attempt := 0; ; attempt++ {
+		mutate(inst)
+		err := repo.Save(ctx, inst)
+		if !errors.Is(err, instance.ErrConflict) || attempt >= maxConflictRetries {
+			return err
+		}
+
+		fresh, err := findInstance(ctx, repo, inst.ID)
+		if err != nil {
+			return err
+		}
+		*inst = *fresh
+	}
+}
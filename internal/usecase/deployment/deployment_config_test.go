@@ -0,0 +1,62 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conflictingRepository loses the first saves to a concurrent writer that
+// sets the instance's last error.
+type conflictingRepository struct {
+	*mockInstanceRepository
+	conflicts int
+	saves     int
+}
+
+func (m *conflictingRepository) Save(ctx context.Context, inst *instance.Instance) error {
+	m.saves++
+	if m.conflicts > 0 {
+		m.conflicts--
+		stored := *m.instances[inst.ID]
+		stored.LastError = "set concurrently"
+		stored.ResourceVersion++
+		m.instances[inst.ID] = &stored
+		return instance.ErrConflict
+	}
+	return m.mockInstanceRepository.Save(ctx, inst)
+}
+
+func (m *conflictingRepository) FindByID(ctx context.Context, id int64) (*instance.Instance, error) {
+	inst, err := m.mockInstanceRepository.FindByID(ctx, id)
+	if inst == nil || err != nil {
+		return inst, err
+	}
+	fresh := *inst
+	return &fresh, nil
+}
+
+func TestSaveInstance_RetriesOnConflict(t *testing.T) {
+	repo := &conflictingRepository{mockInstanceRepository: newMockInstanceRepository(), conflicts: 2}
+	repo.instances[1] = &instance.Instance{ID: 1, Status: instance.StatusActive}
+
+	inst := &instance.Instance{ID: 1, Status: instance.StatusActive}
+	err := saveInstance(context.Background(), repo, inst, (*instance.Instance).MarkStopped)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, repo.saves)
+	assert.Equal(t, instance.StatusStopped, repo.instances[1].Status)
+	assert.Equal(t, "set concurrently", repo.instances[1].LastError, "the concurrent write is kept")
+}
+
+func TestSaveInstance_GivesUp(t *testing.T) {
+	repo := &conflictingRepository{mockInstanceRepository: newMockInstanceRepository(), conflicts: 10}
+	repo.instances[1] = &instance.Instance{ID: 1, Status: instance.StatusActive}
+
+	err := saveInstance(context.Background(), repo, &instance.Instance{ID: 1}, (*instance.Instance).MarkStopped)
+	assert.ErrorIs(t, err, instance.ErrConflict)
+	assert.Equal(t, maxConflictRetries+1, repo.saves)
+}
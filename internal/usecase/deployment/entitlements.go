@@ -0,0 +1,86 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	"gorm.io/gorm"
+)
+
+// EntitlementUseCase re-deploys instances whose product entitlements changed
+// in Railzway OSS since they were deployed.
+type EntitlementUseCase struct {
+	db           *gorm.DB
+	entitlements billing.EntitlementResolver
+}
+
+func NewEntitlementUseCase(db *gorm.DB, entitlements billing.EntitlementResolver) *EntitlementUseCase {
+	return &EntitlementUseCase{
+		db:           db,
+		entitlements: entitlements,
+	}
+}
+
+// Sync resolves the entitlements of every price billed to a serving instance
+// and enqueues a deploy for the instances deployed with other entitlements.
+// Instances with a deploy already queued pick the entitlements up from it.
+// Prices that fail to resolve are skipped and reported in the error.
+func (uc *EntitlementUseCase) Sync(ctx context.Context) (int64, error) {
+	serving := []instance.InstanceStatus{instance.StatusActive, instance.StatusRunning}
+
+	var priceIDs []string
+	if err := uc.db.WithContext(ctx).Model(&instance.Instance{}).
+		Distinct("price_id").
+		Where("status IN ? AND price_id <> ''", serving).
+		Pluck("price_id", &priceIDs).Error; err != nil {
+		return 0, err
+	}
+
+	var enqueued int64
+	var errs []error
+	for _, priceID := range priceIDs {
+		raw, err := uc.entitlements.ResolveEntitlements(ctx, priceID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("price %s: %w", priceID, err))
+			continue
+		}
+		hash := nomad.Entitlements(raw).Fingerprint()
+
+		now := time.Now().UTC()
+		insert := uc.db.WithContext(ctx).Exec(
+			`INSERT INTO outbox_events (event_type, org_id, instance_id, status, attempts, created_at, updated_at)
+			 SELECT ?, i.org_id, i.id, ?, 0, ?, ?
+			 FROM instances i
+			 WHERE i.price_id = ?
+			   AND i.status IN ?
+			   AND i.entitlements_hash <> ?
+			   AND NOT EXISTS (
+			     SELECT 1 FROM outbox_events e
+			     WHERE e.instance_id = i.id
+			       AND e.event_type = ?
+			       AND e.status IN (?, ?)
+			   )`,
+			outboxevent.TypeDeployInstance,
+			outboxevent.StatusPending,
+			now,
+			now,
+			priceID,
+			serving,
+			hash,
+			outboxevent.TypeDeployInstance,
+			outboxevent.StatusPending,
+			outboxevent.StatusProcessing,
+		)
+		if insert.Error != nil {
+			return enqueued, insert.Error
+		}
+		enqueued += insert.RowsAffected
+	}
+	return enqueued, errors.Join(errs...)
+}
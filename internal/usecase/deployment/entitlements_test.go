@@ -0,0 +1,40 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntitlementUseCase_SyncRedeploysChangedPrices(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := context.Background()
+
+	entitlements := staticEntitlements{"price_hobby": {"billing.customers.max": float64(1000)}}
+	hash := nomad.Entitlements(entitlements["price_hobby"]).Fingerprint()
+
+	changed := seedServingInstance(t, conn, seedOrg(t, conn, "changed"), "v1.0.0")
+	current := seedServingInstance(t, conn, seedOrg(t, conn, "current"), "v1.0.0")
+	queued := seedServingInstance(t, conn, seedOrg(t, conn, "queued"), "v1.0.0")
+	unbilled := seedServingInstance(t, conn, seedOrg(t, conn, "unbilled"), "v1.0.0")
+	for _, inst := range []*instance.Instance{changed, current, queued} {
+		require.NoError(t, conn.Model(&instance.Instance{}).Where("id = ?", inst.ID).Update("price_id", "price_hobby").Error)
+	}
+	require.NoError(t, conn.Model(&instance.Instance{}).Where("id = ?", current.ID).Update("entitlements_hash", hash).Error)
+	seedDeployEvent(t, conn, queued, outboxevent.StatusPending, 0)
+
+	enqueued, err := NewEntitlementUseCase(conn, entitlements).Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), enqueued)
+
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, changed.ID, outboxevent.StatusPending))
+	assert.Equal(t, int64(0), countDeployEvents(t, conn, current.ID, outboxevent.StatusPending))
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, queued.ID, outboxevent.StatusPending))
+	assert.Equal(t, int64(0), countDeployEvents(t, conn, unbilled.ID, outboxevent.StatusPending))
+}
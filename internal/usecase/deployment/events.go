@@ -0,0 +1,45 @@
+package deployment
+
+import (
+	"context"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db/pagination"
+)
+
+// EventUseCase serves the audit timeline of instance state changes and the
+// progress of their deploys.
+type EventUseCase struct {
+	repo   instance.Repository
+	events instance.EventRepository
+	sagas  instance.DeploySagaRepository
+}
+
+func NewEventUseCase(repo instance.Repository, events instance.EventRepository, sagas instance.DeploySagaRepository) *EventUseCase {
+	return &EventUseCase{
+		repo:   repo,
+		events: events,
+		sagas:  sagas,
+	}
+}
+
+// ListByOrgID returns the events of the primary instance of an organization.
+// It returns nil without error when the organization has no instance.
+func (uc *EventUseCase) ListByOrgID(ctx context.Context, orgID int64, page pagination.Pagination) ([]*instance.Event, *pagination.PageInfo, error) {
+	inst, err := uc.repo.FindByOrgID(ctx, orgID)
+	if err != nil || inst == nil {
+		return nil, nil, err
+	}
+	return uc.events.ListByInstanceID(ctx, inst.ID, page)
+}
+
+// ListByInstanceID returns the events of an instance, newest first.
+func (uc *EventUseCase) ListByInstanceID(ctx context.Context, instanceID int64, page pagination.Pagination) ([]*instance.Event, *pagination.PageInfo, error) {
+	return uc.events.ListByInstanceID(ctx, instanceID, page)
+}
+
+// LatestDeploy returns the step progress of the latest deploy of an
+// instance, or nil when it was never deployed through a saga.
+func (uc *EventUseCase) LatestDeploy(ctx context.Context, instanceID int64) (*instance.DeploySaga, error) {
+	return uc.sagas.FindLatestByInstanceID(ctx, instanceID)
+}
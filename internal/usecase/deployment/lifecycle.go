@@ -2,6 +2,7 @@ package deployment
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"github.com/railzwaylabs/railzway-cloud/internal/config"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
 	"github.com/railzwaylabs/railzway-cloud/internal/organization"
 )
@@ -18,18 +20,24 @@ import (
 type LifecycleUseCase struct {
 	repo          instance.Repository
 	provisioner   provisioning.Provisioner
+	profiles      *ProfileResolver
 	billingEngine billing.Engine
 	orgService    *organization.Service
+	runtimeCfg    RuntimeConfig
 	cfg           *config.Config
+	blueGreen     *BlueGreenUseCase
 }
 
-func NewLifecycleUseCase(r instance.Repository, p provisioning.Provisioner, b billing.Engine, orgService *organization.Service, cfg *config.Config) *LifecycleUseCase {
+func NewLifecycleUseCase(r instance.Repository, p provisioning.Provisioner, profiles *ProfileResolver, b billing.Engine, orgService *organization.Service, runtimeCfg RuntimeConfig, cfg *config.Config, blueGreen *BlueGreenUseCase) *LifecycleUseCase {
 	return &LifecycleUseCase{
 		repo:          r,
 		provisioner:   p,
+		profiles:      profiles,
 		billingEngine: b,
 		orgService:    orgService,
+		runtimeCfg:    runtimeCfg,
 		cfg:           cfg,
+		blueGreen:     blueGreen,
 	}
 }
 
@@ -41,22 +49,74 @@ func (uc *LifecycleUseCase) Stop(ctx context.Context, orgID int64) error {
 	if inst == nil {
 		return fmt.Errorf("instance not found")
 	}
+	return uc.stop(ctx, 0, inst)
+}
+
+// StopInstance stops a single instance of an organization for the
+// stop_instance outbox event eventID.
+func (uc *LifecycleUseCase) StopInstance(ctx context.Context, eventID, instanceID int64) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	return uc.stop(ctx, eventID, inst)
+}
 
+func (uc *LifecycleUseCase) stop(ctx context.Context, eventID int64, inst *instance.Instance) error {
 	// 1. Stop Infrastructure
-	if err := uc.provisioner.Stop(ctx, orgID); err != nil {
+	if err := uc.blueGreen.Cancel(ctx, inst, "instance_stopped"); err != nil {
+		return fmt.Errorf("failed to cancel upgrade: %w", err)
+	}
+	if err := uc.provisioner.Stop(ctx, workloadOf(inst)); err != nil {
 		return fmt.Errorf("failed to stop instance: %w", err)
 	}
 
 	// 2. Pause Billing
-	if inst.SubscriptionID != "" {
-		if err := uc.billingEngine.PauseSubscription(ctx, inst.SubscriptionID); err != nil {
+	// The subscription belongs to the primary instance; other environments
+	// stop without touching billing.
+	if inst.SubscriptionID != "" && inst.IsDefaultEnvironment() {
+		key := outboxevent.IdempotencyKey(eventID, "pause-subscription:"+inst.SubscriptionID)
+		if err := uc.billingEngine.PauseSubscription(ctx, inst.SubscriptionID, key); err != nil {
 			fmt.Printf("warning: failed to pause subscription %s: %v\n", inst.SubscriptionID, err)
 		}
 	}
 
 	// 3. Update State
-	inst.MarkStopped()
-	return uc.repo.Save(ctx, inst)
+	return saveInstance(ctx, uc.repo, inst, (*instance.Instance).MarkStopped)
+}
+
+// TerminateInstance stops an instance for good, for the terminate_instance
+// outbox event eventID. Terminating the default environment cancels the
+// organization's subscription; its database is kept.
+func (uc *LifecycleUseCase) TerminateInstance(ctx context.Context, eventID, instanceID int64) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	if inst.Status == instance.StatusTerminated {
+		return nil
+	}
+
+	// 1. Stop Infrastructure
+	if err := uc.blueGreen.Cancel(ctx, inst, "instance_terminated"); err != nil {
+		return fmt.Errorf("failed to cancel upgrade: %w", err)
+	}
+	if err := uc.provisioner.Stop(ctx, workloadOf(inst)); err != nil {
+		return fmt.Errorf("failed to stop instance: %w", err)
+	}
+
+	// 2. Cancel Billing
+	// Unlike a paused subscription, a canceled one must not keep billing,
+	// so a failure is retried rather than ignored.
+	if inst.SubscriptionID != "" && inst.IsDefaultEnvironment() {
+		key := outboxevent.IdempotencyKey(eventID, "cancel-subscription:"+inst.SubscriptionID)
+		if err := uc.billingEngine.CancelSubscription(ctx, inst.SubscriptionID, key); err != nil {
+			return fmt.Errorf("failed to cancel subscription: %w", err)
+		}
+	}
+
+	// 3. Update State
+	return saveInstance(ctx, uc.repo, inst, (*instance.Instance).MarkTerminated)
 }
 
 func (uc *LifecycleUseCase) Pause(ctx context.Context, orgID int64) error {
@@ -71,7 +131,20 @@ func (uc *LifecycleUseCase) Start(ctx context.Context, orgID int64) error {
 	if inst == nil {
 		return fmt.Errorf("instance not found")
 	}
+	return uc.start(ctx, 0, inst)
+}
+
+// StartInstance starts a single stopped instance of an organization for the
+// start_instance outbox event eventID.
+func (uc *LifecycleUseCase) StartInstance(ctx context.Context, eventID, instanceID int64) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	return uc.start(ctx, eventID, inst)
+}
 
+func (uc *LifecycleUseCase) start(ctx context.Context, eventID int64, inst *instance.Instance) error {
 	if inst.Status != instance.StatusStopped {
 		return fmt.Errorf("instance is not stopped")
 	}
@@ -82,40 +155,28 @@ func (uc *LifecycleUseCase) Start(ctx context.Context, orgID int64) error {
 		return fmt.Errorf("failed to resolve org slug: %w", err)
 	}
 
-	paymentSecret, err := resolvePaymentProviderSecret(uc.cfg, inst)
+	deployCfg, err := buildDeploymentConfig(ctx, uc.cfg, uc.runtimeCfg, uc.profiles, org, inst, inst.DesiredVersion, inst.Tier)
 	if err != nil {
 		return err
 	}
-
-	deployCfg := provisioning.DeploymentConfig{
-		OrgID:                       org.ID,
-		OrgSlug:                     org.Slug,
-		OrgName:                     org.Name,
-		Version:                     inst.DesiredVersion,
-		Tier:                        inst.Tier,
-		ComputeEngine:               inst.ComputeEngine,
-		OAuth2URI:                   uc.cfg.OAuth2URI,
-		OAuth2ClientID:              coalesce(inst.OAuthClientID, uc.cfg.TenantOAuth2ClientID),
-		OAuth2ClientSecret:          coalesce(inst.OAuthClientSecret, uc.cfg.TenantOAuth2ClientSecret),
-		PaymentProviderConfigSecret: paymentSecret,
-	}
-	if err := uc.provisioner.Deploy(ctx, &deployCfg); err != nil {
+	if err := uc.provisioner.Deploy(ctx, deployCfg); err != nil {
 		return fmt.Errorf("failed to start instance: %w", err)
 	}
 
 	// 2. Resume Billing
-	if inst.SubscriptionID != "" {
-		if err := uc.billingEngine.ResumeSubscription(ctx, inst.SubscriptionID); err != nil {
+	if inst.SubscriptionID != "" && inst.IsDefaultEnvironment() {
+		key := outboxevent.IdempotencyKey(eventID, "resume-subscription:"+inst.SubscriptionID)
+		if err := uc.billingEngine.ResumeSubscription(ctx, inst.SubscriptionID, key); err != nil {
 			fmt.Printf("warning: failed to resume subscription %s: %v\n", inst.SubscriptionID, err)
 		}
 	}
 
 	// 3. Update State
-	inst.MarkRunning(inst.CurrentVersion)
-	// Manual time update if repo doesn't
-	inst.UpdatedAt = time.Now().UTC()
-
-	return uc.repo.Save(ctx, inst)
+	return saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.MarkRunning(i.CurrentVersion)
+		i.TierProfileRevision = deployCfg.TierProfileRevision
+		i.EntitlementsHash = deployCfg.EntitlementsHash
+	})
 }
 
 func (uc *LifecycleUseCase) GetStatus(ctx context.Context, orgID int64) (*instance.Instance, error) {
@@ -126,7 +187,36 @@ func (uc *LifecycleUseCase) GetStatus(ctx context.Context, orgID int64) (*instan
 	if inst == nil {
 		return nil, nil
 	}
+	return uc.refreshStatus(ctx, inst), nil
+}
+
+// GetInstanceStatus returns a single instance of an organization, refreshing a
+// pending provisioning status. Instances of other organizations are reported
+// as missing and never refreshed.
+func (uc *LifecycleUseCase) GetInstanceStatus(ctx context.Context, orgID int64, instanceID int64) (*instance.Instance, error) {
+	inst, err := uc.repo.FindByID(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if inst == nil || inst.OrgID != orgID {
+		return nil, nil
+	}
+	return uc.refreshStatus(ctx, inst), nil
+}
+
+// ListInstances returns every instance of an organization.
+func (uc *LifecycleUseCase) ListInstances(ctx context.Context, orgID int64) ([]*instance.Instance, error) {
+	insts, err := uc.repo.ListByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, inst := range insts {
+		uc.refreshStatus(ctx, inst)
+	}
+	return insts, nil
+}
 
+func (uc *LifecycleUseCase) refreshStatus(ctx context.Context, inst *instance.Instance) *instance.Instance {
 	if uc.provisioner != nil && inst.Status == instance.StatusProvisioning {
 		if next, ok := uc.resolveProvisioningStatus(ctx, inst); ok {
 			if inst.Status != next {
@@ -135,18 +225,23 @@ func (uc *LifecycleUseCase) GetStatus(ctx context.Context, orgID int64) (*instan
 					inst.CurrentVersion = inst.DesiredVersion
 				}
 				inst.UpdatedAt = time.Now().UTC()
-				if err := uc.repo.Save(ctx, inst); err != nil {
+				if err := uc.repo.Save(ctx, inst); errors.Is(err, instance.ErrConflict) {
+					// Someone else moved the instance on; report their state.
+					if fresh, err := uc.repo.FindByID(ctx, inst.ID); err == nil && fresh != nil {
+						*inst = *fresh
+					}
+				} else if err != nil {
 					fmt.Printf("warning: failed to save instance state: %v\n", err)
 				}
 			}
 		}
 	}
 
-	return inst, nil
+	return inst
 }
 
 func (uc *LifecycleUseCase) resolveProvisioningStatus(ctx context.Context, inst *instance.Instance) (instance.InstanceStatus, bool) {
-	raw, err := uc.provisioner.GetStatus(ctx, inst.OrgID)
+	raw, err := uc.provisioner.GetStatus(ctx, workloadOf(inst))
 	if err != nil {
 		return "", false
 	}
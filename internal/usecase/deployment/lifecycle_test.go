@@ -0,0 +1,47 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleUseCase_GetInstanceStatus_ChecksOrgBeforeRefresh(t *testing.T) {
+	ctx := context.Background()
+	repo := newMockInstanceRepository()
+	provisioner := &testhelper.MockProvisioner{Statuses: map[string]string{"railzway-org-42": "failed"}}
+	uc := &LifecycleUseCase{repo: repo, provisioner: provisioner}
+
+	repo.instances[1] = &instance.Instance{ID: 1, OrgID: 42, Status: instance.StatusProvisioning}
+
+	// Another organization neither sees nor refreshes the instance.
+	inst, err := uc.GetInstanceStatus(ctx, 7, 1)
+	require.NoError(t, err)
+	assert.Nil(t, inst)
+	assert.Equal(t, instance.StatusProvisioning, repo.instances[1].Status)
+
+	inst, err = uc.GetInstanceStatus(ctx, 42, 1)
+	require.NoError(t, err)
+	require.NotNil(t, inst)
+	assert.Equal(t, instance.StatusProvisionFailed, inst.Status)
+}
+
+func TestLifecycleUseCase_KeysBillingCallsByEvent(t *testing.T) {
+	ctx := context.Background()
+	blueGreen, repo, _, provisioner, billingEngine := newTestBlueGreenWithBilling(t)
+	uc := &LifecycleUseCase{repo: repo, provisioner: provisioner, billingEngine: billingEngine, blueGreen: blueGreen}
+
+	repo.instances[1] = &instance.Instance{ID: 1, OrgID: 42, Status: instance.StatusActive, SubscriptionID: "sub_1"}
+
+	require.NoError(t, uc.StopInstance(ctx, 5, 1))
+	require.NoError(t, uc.TerminateInstance(ctx, 6, 1))
+
+	assert.Equal(t, []string{
+		"outbox:5:pause-subscription:sub_1",
+		"outbox:6:cancel-subscription:sub_1",
+	}, billingEngine.keys)
+}
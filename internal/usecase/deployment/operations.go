@@ -0,0 +1,334 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"gorm.io/gorm"
+)
+
+// DeployRequest is the payload of deploy operations. Events queued without
+// one, such as those of rollouts, deploy the desired version of the instance.
+type DeployRequest struct {
+	Version string `json:"version,omitempty"`
+}
+
+// TierChange is the payload of upgrade and downgrade operations.
+type TierChange struct {
+	Tier instance.Tier `json:"tier"`
+}
+
+// SecretRotation is the payload of rotate_secrets operations. Events queued
+// without one rotate the database password.
+type SecretRotation struct {
+	Secrets   []instance.SecretKind `json:"secrets"`
+	ActorType instance.ActorType    `json:"actor_type,omitempty"` // Who requested the rotation
+	ActorID   string                `json:"actor_id,omitempty"`
+}
+
+// actor returns who requested the rotation, or the actor of ctx for
+// rotations that did not record one.
+func (r SecretRotation) actor(ctx context.Context) instance.Actor {
+	if r.ActorType == "" {
+		return instance.ActorFromContext(ctx)
+	}
+	return instance.Actor{Type: r.ActorType, ID: r.ActorID}
+}
+
+// FleetSecretRotation selects the instances of a fleet-wide rotation.
+type FleetSecretRotation struct {
+	Secrets []instance.SecretKind
+	Tier    instance.Tier // Empty means every tier
+}
+
+type FleetSecretRotationResult struct {
+	EnqueuedCount int64 `json:"enqueued_count"`
+	SkippedCount  int64 `json:"skipped_count"` // Already queued, or none of the secrets can be rotated now
+}
+
+// OperationUseCase validates instance operations against the current state
+// and enqueues them as outbox events, so Nomad and billing are only called
+// once the request is durable. The handlers re-check the state when the
+// event runs.
+type OperationUseCase struct {
+	db   *gorm.DB
+	repo instance.Repository
+}
+
+func NewOperationUseCase(db *gorm.DB, repo instance.Repository) *OperationUseCase {
+	return &OperationUseCase{
+		db:   db,
+		repo: repo,
+	}
+}
+
+// Deploy enqueues deploying an instance. A serving instance moves to version
+// through a blue/green upgrade; an empty version deploys the desired one.
+func (uc *OperationUseCase) Deploy(ctx context.Context, instanceID int64, version string) (int64, error) {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	switch inst.Status {
+	case instance.StatusTerminated:
+		return 0, fmt.Errorf("instance is terminated: %w", instance.ErrInvalidState)
+	case instance.StatusUpgrading:
+		return 0, instance.ErrUpgradeInProgress
+	}
+	var payload any
+	if version != "" {
+		payload = DeployRequest{Version: version}
+	}
+	return uc.enqueue(ctx, inst, outboxevent.TypeDeployInstance, payload)
+}
+
+// Stop enqueues stopping an instance and pausing its billing.
+func (uc *OperationUseCase) Stop(ctx context.Context, instanceID int64) (int64, error) {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	if inst.Status == instance.StatusTerminated {
+		return 0, fmt.Errorf("instance is terminated: %w", instance.ErrInvalidState)
+	}
+	return uc.enqueue(ctx, inst, outboxevent.TypeStopInstance, nil)
+}
+
+// Start enqueues starting a stopped instance and resuming its billing.
+func (uc *OperationUseCase) Start(ctx context.Context, instanceID int64) (int64, error) {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	if inst.Status != instance.StatusStopped {
+		return 0, fmt.Errorf("instance is not stopped: %w", instance.ErrInvalidState)
+	}
+	return uc.enqueue(ctx, inst, outboxevent.TypeStartInstance, nil)
+}
+
+// Upgrade enqueues moving an instance to a higher tier.
+func (uc *OperationUseCase) Upgrade(ctx context.Context, instanceID int64, targetTier instance.Tier) (int64, error) {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkTierChange(inst, targetTier); err != nil {
+		return 0, err
+	}
+	if !inst.CanUpgrade(targetTier) {
+		return 0, instance.ErrInvalidTierUpgrade
+	}
+	return uc.enqueue(ctx, inst, outboxevent.TypeUpgradeInstance, TierChange{Tier: targetTier})
+}
+
+// Downgrade enqueues scheduling an instance's move to a lower tier.
+func (uc *OperationUseCase) Downgrade(ctx context.Context, instanceID int64, targetTier instance.Tier) (int64, error) {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkTierChange(inst, targetTier); err != nil {
+		return 0, err
+	}
+	if !inst.CanDowngrade(targetTier) {
+		return 0, fmt.Errorf("cannot downgrade to %s: %w", targetTier, instance.ErrInvalidTierUpgrade)
+	}
+	return uc.enqueue(ctx, inst, outboxevent.TypeDowngradeInstance, TierChange{Tier: targetTier})
+}
+
+// Terminate enqueues terminating an instance. The default environment can
+// only be terminated last, since its subscription covers the others.
+func (uc *OperationUseCase) Terminate(ctx context.Context, instanceID int64) (int64, error) {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	if inst.Status == instance.StatusTerminated {
+		return 0, fmt.Errorf("instance is already terminated: %w", instance.ErrInvalidState)
+	}
+	if inst.IsDefaultEnvironment() {
+		siblings, err := uc.repo.ListByOrgID(ctx, inst.OrgID)
+		if err != nil {
+			return 0, err
+		}
+		for _, sibling := range siblings {
+			if sibling.ID != inst.ID && sibling.Status != instance.StatusTerminated {
+				return 0, fmt.Errorf("environment %s must be terminated first: %w", sibling.Environment, instance.ErrInvalidState)
+			}
+		}
+	}
+	return uc.enqueue(ctx, inst, outboxevent.TypeTerminateInstance, nil)
+}
+
+// RotateSecrets enqueues replacing credentials of an instance.
+func (uc *OperationUseCase) RotateSecrets(ctx context.Context, instanceID int64, secrets []instance.SecretKind) (int64, error) {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkRotatable(inst, secrets, time.Now().UTC()); err != nil {
+		return 0, err
+	}
+	actor := instance.ActorFromContext(ctx)
+	return uc.enqueue(ctx, inst, outboxevent.TypeRotateSecrets, SecretRotation{
+		Secrets:   secrets,
+		ActorType: actor.Type,
+		ActorID:   actor.ID,
+	})
+}
+
+// RotateFleetSecrets enqueues replacing credentials of every provisioned
+// instance. Each instance rotates the requested secrets that apply to it;
+// instances with a rotation already queued are skipped.
+func (uc *OperationUseCase) RotateFleetSecrets(ctx context.Context, params FleetSecretRotation) (*FleetSecretRotationResult, error) {
+	query := uc.db.WithContext(ctx).Model(&rotationCandidate{}).
+		Select(`instances.id, instances.org_id, instances.status, instances.db_user, instances.oauth_client_id,
+		        instances.previous_db_login_user, instances.previous_db_login_expires_at,
+		        instances.previous_payment_provider_secret_expires_at,
+		        EXISTS (
+		          SELECT 1 FROM outbox_events e
+		          WHERE e.instance_id = instances.id
+		            AND e.event_type = ?
+		            AND e.status IN (?, ?, ?)
+		        ) AS queued`,
+			outboxevent.TypeRotateSecrets, outboxevent.StatusPending, outboxevent.StatusProcessing, outboxevent.StatusFailed).
+		Where("instances.status IN ?", []instance.InstanceStatus{instance.StatusActive, instance.StatusRunning, instance.StatusStopped}).
+		Order("instances.id ASC")
+	if params.Tier != "" {
+		query = query.Where("instances.tier = ?", params.Tier)
+	}
+	var candidates []rotationCandidate
+	if err := query.Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("list instances to rotate: %w", err)
+	}
+
+	actor := instance.ActorFromContext(ctx)
+	now := time.Now().UTC()
+	result := &FleetSecretRotationResult{}
+	for _, candidate := range candidates {
+		inst := candidate.instance()
+		var secrets []instance.SecretKind
+		for _, kind := range params.Secrets {
+			if checkSecretRotatable(inst, kind, now) == nil {
+				secrets = append(secrets, kind)
+			}
+		}
+		if candidate.Queued || checkRotatable(inst, nil, now) != nil || len(secrets) == 0 {
+			result.SkippedCount++
+			continue
+		}
+		if _, err := uc.enqueue(ctx, inst, outboxevent.TypeRotateSecrets, SecretRotation{
+			Secrets:   secrets,
+			ActorType: actor.Type,
+			ActorID:   actor.ID,
+		}); err != nil {
+			return result, err
+		}
+		result.EnqueuedCount++
+	}
+	return result, nil
+}
+
+// ExpireSecrets enqueues disabling the database logins whose grace period
+// ended. Instances with an expiry already queued are skipped.
+func (uc *OperationUseCase) ExpireSecrets(ctx context.Context) (int64, error) {
+	var due []rotationCandidate
+	if err := uc.db.WithContext(ctx).Model(&rotationCandidate{}).
+		Select("instances.id, instances.org_id").
+		Where("instances.previous_db_login_user <> '' AND instances.previous_db_login_expires_at <= ?", time.Now().UTC()).
+		Where(`NOT EXISTS (
+		         SELECT 1 FROM outbox_events e
+		         WHERE e.instance_id = instances.id
+		           AND e.event_type = ?
+		           AND e.status IN (?, ?, ?)
+		       )`, outboxevent.TypeExpireSecrets, outboxevent.StatusPending, outboxevent.StatusProcessing, outboxevent.StatusFailed).
+		Order("instances.id ASC").
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("list expired secrets: %w", err)
+	}
+
+	var enqueued int64
+	for _, candidate := range due {
+		if _, err := uc.enqueue(ctx, candidate.instance(), outboxevent.TypeExpireSecrets, nil); err != nil {
+			return enqueued, err
+		}
+		enqueued++
+	}
+	return enqueued, nil
+}
+
+// rotationCandidate is the part of an instance a fleet-wide rotation checks.
+// Credentials are not loaded.
+type rotationCandidate struct {
+	ID                                     int64      `gorm:"column:id"`
+	OrgID                                  int64      `gorm:"column:org_id"`
+	Status                                 string     `gorm:"column:status"`
+	DBUser                                 string     `gorm:"column:db_user"`
+	OAuthClientID                          string     `gorm:"column:oauth_client_id"`
+	PreviousDBLoginUser                    string     `gorm:"column:previous_db_login_user"`
+	PreviousDBLoginExpiresAt               *time.Time `gorm:"column:previous_db_login_expires_at"`
+	PreviousPaymentProviderSecretExpiresAt *time.Time `gorm:"column:previous_payment_provider_secret_expires_at"`
+	Queued                                 bool       `gorm:"column:queued"`
+}
+
+func (rotationCandidate) TableName() string {
+	return "instances"
+}
+
+func (c rotationCandidate) instance() *instance.Instance {
+	return &instance.Instance{
+		ID:            c.ID,
+		OrgID:         c.OrgID,
+		Status:        instance.InstanceStatus(c.Status),
+		DBUser:        c.DBUser,
+		OAuthClientID: c.OAuthClientID,
+
+		PreviousDBLoginUser:                    c.PreviousDBLoginUser,
+		PreviousDBLoginExpiresAt:               c.PreviousDBLoginExpiresAt,
+		PreviousPaymentProviderSecretExpiresAt: c.PreviousPaymentProviderSecretExpiresAt,
+	}
+}
+
+// enqueue inserts a pending outbox event for the operation and returns its ID.
+func (uc *OperationUseCase) enqueue(ctx context.Context, inst *instance.Instance, op outboxevent.Type, payload any) (int64, error) {
+	var raw any
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return 0, fmt.Errorf("encode %s payload: %w", op, err)
+		}
+		raw = string(b)
+	}
+
+	var id int64
+	now := time.Now().UTC()
+	if err := uc.db.WithContext(ctx).Raw(
+		`INSERT INTO outbox_events (event_type, org_id, instance_id, status, attempts, payload, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, 0, ?, ?, ?)
+		 RETURNING id`,
+		op,
+		inst.OrgID,
+		inst.ID,
+		outboxevent.StatusPending,
+		raw,
+		now,
+		now,
+	).Scan(&id).Error; err != nil {
+		return 0, fmt.Errorf("enqueue %s: %w", op, err)
+	}
+	return id, nil
+}
+
+func checkTierChange(inst *instance.Instance, targetTier instance.Tier) error {
+	if _, ok := instance.TierRank[targetTier]; !ok {
+		return fmt.Errorf("unknown tier %s: %w", targetTier, instance.ErrInvalidTierUpgrade)
+	}
+	if inst.Tier == targetTier {
+		return fmt.Errorf("already on tier %s: %w", targetTier, instance.ErrInvalidTierUpgrade)
+	}
+	return nil
+}
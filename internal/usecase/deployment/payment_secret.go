@@ -4,35 +4,20 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/railzwaylabs/railzway-cloud/internal/config"
-	"github.com/railzwaylabs/railzway-cloud/internal/cryptoutils"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
 )
 
-func resolvePaymentProviderSecret(cfg *config.Config, inst *instance.Instance) (string, error) {
-	key := strings.TrimSpace(cfg.InstanceSecretEncryptionKey)
-	if key == "" {
-		return "", fmt.Errorf("INSTANCE_SECRET_ENCRYPTION_KEY is required")
-	}
-
-	if strings.TrimSpace(inst.PaymentProviderConfigSecretEncrypted) == "" {
+// resolvePaymentProviderSecret returns the payment provider secret of an
+// instance, generating it on first use. The repository encrypts it at rest.
+func resolvePaymentProviderSecret(inst *instance.Instance) (string, error) {
+	if strings.TrimSpace(inst.PaymentProviderConfigSecret) == "" {
 		secret, err := generatePaymentProviderSecret()
 		if err != nil {
 			return "", fmt.Errorf("generate payment provider secret: %w", err)
 		}
-		encrypted, err := cryptoutils.Encrypt(secret, key)
-		if err != nil {
-			return "", fmt.Errorf("encrypt payment provider secret: %w", err)
-		}
-		inst.PaymentProviderConfigSecretEncrypted = encrypted
-		return secret, nil
-	}
-
-	secret, err := cryptoutils.Decrypt(inst.PaymentProviderConfigSecretEncrypted, key)
-	if err != nil {
-		return "", fmt.Errorf("decrypt payment provider secret: %w", err)
+		inst.PaymentProviderConfigSecret = secret
 	}
-	return secret, nil
+	return inst.PaymentProviderConfigSecret, nil
 }
 
 func generatePaymentProviderSecret() (string, error) {
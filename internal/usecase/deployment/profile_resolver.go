@@ -0,0 +1,75 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/tierprofile"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+// ProfileResolver resolves the profile a workload is deployed with: the
+// current catalog revision of its tier, with quotas and isolation overridden
+// by the entitlements of the subscribed product. A nil resolver yields the
+// built-in profiles.
+type ProfileResolver struct {
+	profiles     tierprofile.Repository
+	entitlements billing.EntitlementResolver
+}
+
+// WorkloadProfile is a resolved profile and what it was resolved from.
+type WorkloadProfile struct {
+	Spec             nomad.TierProfile
+	Revision         int    // Tier profile catalog revision
+	EntitlementsHash string // Fingerprint of the applied entitlements, empty when none
+}
+
+func NewProfileResolver(profiles tierprofile.Repository, entitlements billing.EntitlementResolver) *ProfileResolver {
+	return &ProfileResolver{
+		profiles:     profiles,
+		entitlements: entitlements,
+	}
+}
+
+// Resolve returns the profile of inst deployed at tier. Entitlements come
+// from the instance's price, so they only apply while the instance stays on
+// its billed tier; a tier change gets them on the next deploy after billing
+// moved to the new price.
+func (r *ProfileResolver) Resolve(ctx context.Context, inst *instance.Instance, tier instance.Tier) (*WorkloadProfile, error) {
+	if r == nil {
+		return &WorkloadProfile{Spec: nomad.DefaultTierProfile(nomad.Tier(tier)), Revision: tierprofile.BuiltinRevision}, nil
+	}
+
+	profile, err := resolveTierProfile(ctx, r.profiles, tier)
+	if err != nil {
+		return nil, err
+	}
+	resolved := &WorkloadProfile{Spec: profile.Spec, Revision: profile.Revision}
+
+	if r.entitlements == nil || inst.PriceID == "" || tier != inst.Tier {
+		return resolved, nil
+	}
+	raw, err := r.entitlements.ResolveEntitlements(ctx, inst.PriceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve entitlements: %w", err)
+	}
+	entitlements := nomad.Entitlements(raw)
+	resolved.Spec, err = profile.Spec.WithEntitlements(entitlements)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entitlements for price %s: %w", inst.PriceID, err)
+	}
+	resolved.EntitlementsHash = entitlements.Fingerprint()
+	return resolved, nil
+}
+
+// Outdated reports whether the instance was deployed with another tier
+// profile revision or other entitlements than it would get now.
+func (r *ProfileResolver) Outdated(ctx context.Context, inst *instance.Instance) (bool, error) {
+	resolved, err := r.Resolve(ctx, inst, inst.Tier)
+	if err != nil {
+		return false, err
+	}
+	return resolved.Revision != inst.TierProfileRevision || resolved.EntitlementsHash != inst.EntitlementsHash, nil
+}
@@ -0,0 +1,55 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticEntitlements resolves entitlements from a map keyed by price ID.
+type staticEntitlements map[string]map[string]any
+
+func (s staticEntitlements) ResolveEntitlements(ctx context.Context, priceID string) (map[string]any, error) {
+	return s[priceID], nil
+}
+
+func TestProfileResolver_AppliesEntitlements(t *testing.T) {
+	entitlements := staticEntitlements{"price_hobby": {
+		"billing.customers.max": float64(1000),
+		"infra.isolation_level": nomad.IsolationDedicatedNamespace,
+	}}
+	resolver := NewProfileResolver(nil, entitlements)
+	inst := &instance.Instance{Tier: instance.TierStarter, PriceID: "price_hobby"}
+
+	profile, err := resolver.Resolve(context.Background(), inst, instance.TierStarter)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", profile.Spec.Quotas["QUOTA_ORG_CUSTOMER"])
+	assert.Equal(t, nomad.IsolationDedicatedNamespace, profile.Spec.Placement.Isolation)
+	assert.Equal(t, nomad.Entitlements(entitlements["price_hobby"]).Fingerprint(), profile.EntitlementsHash)
+
+	outdated, err := resolver.Outdated(context.Background(), inst)
+	require.NoError(t, err)
+	assert.True(t, outdated)
+	inst.EntitlementsHash = profile.EntitlementsHash
+	outdated, err = resolver.Outdated(context.Background(), inst)
+	require.NoError(t, err)
+	assert.False(t, outdated)
+
+	// The price's entitlements do not apply to another tier.
+	profile, err = resolver.Resolve(context.Background(), inst, instance.TierPro)
+	require.NoError(t, err)
+	assert.Empty(t, profile.EntitlementsHash)
+	assert.Empty(t, profile.Spec.Placement.Isolation)
+}
+
+func TestProfileResolver_RejectsInvalidEntitlements(t *testing.T) {
+	resolver := NewProfileResolver(nil, staticEntitlements{"price_bad": {"infra.isolation_level": "private"}})
+	inst := &instance.Instance{Tier: instance.TierStarter, PriceID: "price_bad"}
+
+	_, err := resolver.Resolve(context.Background(), inst, instance.TierStarter)
+	assert.ErrorContains(t, err, "invalid entitlements for price price_bad")
+}
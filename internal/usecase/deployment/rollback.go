@@ -0,0 +1,220 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/version"
+	"gorm.io/gorm"
+)
+
+// versionFlagger marks versions that keep failing in the fleet.
+type versionFlagger interface {
+	MarkSuspect(ctx context.Context, appName, version, reason string) error
+	ClearSuspect(ctx context.Context, appName, version string) error
+	ListSuspect(ctx context.Context, appName string) ([]version.ApplicationVersion, error)
+}
+
+// RollbackUseCase reverts unhealthy instances to their last known-good version.
+// The revert is recorded and a deploy event is enqueued in the same transaction,
+// so the outbox processor redeploys the good version.
+type RollbackUseCase struct {
+	db       *gorm.DB
+	upgrades instance.UpgradeRepository
+	versions versionFlagger
+	cfg      *config.Config
+}
+
+func NewRollbackUseCase(db *gorm.DB, upgrades instance.UpgradeRepository, versionReg *version.Registry, cfg *config.Config) *RollbackUseCase {
+	uc := &RollbackUseCase{
+		db:       db,
+		upgrades: upgrades,
+		cfg:      cfg,
+	}
+	if versionReg != nil {
+		uc.versions = versionReg
+	}
+	return uc
+}
+
+// Evaluate rolls inst back when it failed on a new version. It returns the
+// recorded rollback, or nil when the instance is healthy or not eligible.
+func (uc *RollbackUseCase) Evaluate(ctx context.Context, inst *instance.Instance) (*instance.Rollback, error) {
+	if !uc.cfg.AutoRollbackEnabled {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	trigger, failedVersion, ok := inst.NeedsRollback(now, uc.notReadyGrace())
+	if !ok {
+		return nil, nil
+	}
+
+	// Blue/green upgrades roll themselves back while the old job still serves.
+	active, err := uc.upgrades.FindActiveByInstanceID(ctx, inst.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, nil
+	}
+
+	prev := *inst
+	rb := inst.RollBack(trigger, failedVersion, rollbackReason(inst, trigger), now)
+
+	applied := false
+	err = uc.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only revert if nothing else wrote the instance in the meantime; the
+		// next pass re-evaluates it from fresh state.
+		update := tx.Model(&instance.Instance{}).
+			Where("id = ? AND resource_version = ?", inst.ID, prev.ResourceVersion).
+			Updates(map[string]any{
+				"desired_version":  inst.DesiredVersion,
+				"last_error":       inst.LastError,
+				"not_ready_since":  nil,
+				"updated_at":       now,
+				"resource_version": gorm.Expr("resource_version + 1"),
+			})
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return nil
+		}
+
+		inst.ResourceVersion++
+
+		if err := tx.Create(rb).Error; err != nil {
+			return fmt.Errorf("record rollback: %w", err)
+		}
+		if event := instance.NewEvent(&prev, inst, instance.ActorFromContext(ctx), now); event != nil {
+			if err := tx.Create(event).Error; err != nil {
+				return fmt.Errorf("record instance event: %w", err)
+			}
+		}
+
+		// A failed deploy event runs again against the new desired version,
+		// so only enqueue when no deploy is still queued for the instance.
+		// Dead events never run again unless an operator replays them.
+		if err := tx.Exec(
+			`INSERT INTO outbox_events (event_type, org_id, instance_id, status, attempts, created_at, updated_at)
+			 SELECT ?, ?, ?, ?, 0, ?, ?
+			 WHERE NOT EXISTS (
+			   SELECT 1 FROM outbox_events e
+			   WHERE e.instance_id = ?
+			     AND e.event_type = ?
+			     AND e.status IN (?, ?, ?)
+			 )`,
+			outboxevent.TypeDeployInstance,
+			inst.OrgID,
+			inst.ID,
+			outboxevent.StatusPending,
+			now,
+			now,
+			inst.ID,
+			outboxevent.TypeDeployInstance,
+			outboxevent.StatusPending,
+			outboxevent.StatusProcessing,
+			outboxevent.StatusFailed,
+		).Error; err != nil {
+			return fmt.Errorf("enqueue rollback deploy: %w", err)
+		}
+
+		applied = true
+		return nil
+	})
+	if err != nil || !applied {
+		return nil, err
+	}
+
+	if err := uc.flagSuspect(ctx, failedVersion, now); err != nil {
+		return rb, err
+	}
+	return rb, nil
+}
+
+// flagSuspect marks a version suspect once enough distinct instances rolled
+// back from it within the configured window.
+func (uc *RollbackUseCase) flagSuspect(ctx context.Context, failedVersion string, now time.Time) error {
+	if uc.versions == nil || uc.cfg.RollbackSuspectThreshold <= 0 {
+		return nil
+	}
+
+	window := time.Duration(uc.cfg.RollbackSuspectWindowSeconds) * time.Second
+	var count int64
+	if err := uc.db.WithContext(ctx).Model(&instance.Rollback{}).
+		Where("from_version = ? AND created_at >= ?", failedVersion, now.Add(-window)).
+		Distinct("instance_id").
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count < int64(uc.cfg.RollbackSuspectThreshold) {
+		return nil
+	}
+
+	reason := fmt.Sprintf("%d instances rolled back within %s", count, window)
+	return uc.versions.MarkSuspect(ctx, "railzway", failedVersion, reason)
+}
+
+// ListSuspectVersions returns the versions refused as rollout targets after
+// automatic rollbacks.
+func (uc *RollbackUseCase) ListSuspectVersions(ctx context.Context) ([]version.ApplicationVersion, error) {
+	if uc.versions == nil {
+		return nil, nil
+	}
+	return uc.versions.ListSuspect(ctx, "railzway")
+}
+
+// ClearSuspectVersion lets a suspect version be rolled out again, e.g. once
+// the rollbacks turned out to be caused by something else.
+func (uc *RollbackUseCase) ClearSuspectVersion(ctx context.Context, v string) error {
+	if uc.versions == nil {
+		return fmt.Errorf("%w: %s", version.ErrVersionNotFound, v)
+	}
+	return uc.versions.ClearSuspect(ctx, "railzway", v)
+}
+
+// ListByInstance returns the most recent rollbacks of an instance.
+func (uc *RollbackUseCase) ListByInstance(ctx context.Context, instanceID int64, limit int) ([]instance.Rollback, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var items []instance.Rollback
+	if err := uc.db.WithContext(ctx).
+		Where("instance_id = ?", instanceID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (uc *RollbackUseCase) notReadyGrace() time.Duration {
+	if uc.cfg.RollbackNotReadyGraceSeconds <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(uc.cfg.RollbackNotReadyGraceSeconds) * time.Second
+}
+
+func rollbackReason(inst *instance.Instance, trigger instance.RollbackTrigger) string {
+	switch trigger {
+	case instance.RollbackProvisionFailed:
+		if inst.LastError != "" {
+			return "provision failed: " + inst.LastError
+		}
+		return "provision failed"
+	case instance.RollbackNotReady:
+		reason := fmt.Sprintf("not ready since %s", inst.NotReadySince.Format(time.RFC3339))
+		if inst.ReadinessError != "" {
+			reason += ": " + inst.ReadinessError
+		}
+		return reason
+	default:
+		return string(trigger)
+	}
+}
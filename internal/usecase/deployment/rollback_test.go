@@ -0,0 +1,90 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestRollback(conn *gorm.DB) *RollbackUseCase {
+	return NewRollbackUseCase(conn, newMockUpgradeRepository(), nil, &config.Config{AutoRollbackEnabled: true})
+}
+
+// seedFailedDeploy inserts an instance whose deploy of v2.0.0 failed.
+func seedFailedDeploy(t *testing.T, conn *gorm.DB, slug string) *instance.Instance {
+	t.Helper()
+	inst := seedServingInstance(t, conn, seedOrg(t, conn, slug), "v1.0.0")
+	require.NoError(t, conn.Model(&instance.Instance{}).Where("id = ?", inst.ID).Updates(map[string]any{
+		"desired_version": "v2.0.0",
+		"status":          instance.StatusProvisionFailed,
+		"last_error":      "image pull failed",
+	}).Error)
+	require.NoError(t, conn.First(inst, "id = ?", inst.ID).Error)
+	return inst
+}
+
+func TestRollbackUseCase_EvaluateRedeploysAfterExhaustedEvent(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := context.Background()
+	uc := newTestRollback(conn)
+
+	inst := seedFailedDeploy(t, conn, "exhausted")
+	seedDeployEvent(t, conn, inst, outboxevent.StatusDead, MaxDeployAttempts)
+
+	rb, err := uc.Evaluate(ctx, inst)
+	require.NoError(t, err)
+	require.NotNil(t, rb)
+	assert.Equal(t, "v2.0.0", rb.FromVersion)
+	assert.Equal(t, "v1.0.0", rb.ToVersion)
+
+	var desired string
+	require.NoError(t, conn.Model(&instance.Instance{}).Where("id = ?", inst.ID).Pluck("desired_version", &desired).Error)
+	assert.Equal(t, "v1.0.0", desired)
+
+	// The exhausted event never runs again, so a fresh deploy is queued.
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, inst.ID, outboxevent.StatusPending))
+
+	var rollbacks int64
+	require.NoError(t, conn.Model(&instance.Rollback{}).Where("instance_id = ?", inst.ID).Count(&rollbacks).Error)
+	assert.Equal(t, int64(1), rollbacks)
+}
+
+func TestRollbackUseCase_EvaluateReusesRetryableEvent(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := context.Background()
+	uc := newTestRollback(conn)
+
+	inst := seedFailedDeploy(t, conn, "retryable")
+	seedDeployEvent(t, conn, inst, outboxevent.StatusFailed, 1)
+
+	rb, err := uc.Evaluate(ctx, inst)
+	require.NoError(t, err)
+	require.NotNil(t, rb)
+
+	// The failed event still has retries left and deploys the reverted version.
+	assert.Equal(t, int64(0), countDeployEvents(t, conn, inst.ID, outboxevent.StatusPending))
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, inst.ID, outboxevent.StatusFailed))
+}
+
+func TestRollbackUseCase_EvaluateSkipsStaleInstance(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := context.Background()
+	uc := newTestRollback(conn)
+
+	inst := seedFailedDeploy(t, conn, "stale")
+	// Someone else wrote the instance after it was loaded.
+	require.NoError(t, conn.Model(&instance.Instance{}).Where("id = ?", inst.ID).
+		Update("resource_version", gorm.Expr("resource_version + 1")).Error)
+
+	rb, err := uc.Evaluate(ctx, inst)
+	require.NoError(t, err)
+	assert.Nil(t, rb)
+	assert.Equal(t, int64(0), countDeployEvents(t, conn, inst.ID, outboxevent.StatusPending))
+}
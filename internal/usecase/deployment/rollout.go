@@ -2,16 +2,24 @@ package deployment
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/railzwaylabs/railzway-cloud/internal/config"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/rollout"
 	"github.com/railzwaylabs/railzway-cloud/internal/version"
+	"github.com/railzwaylabs/railzway-cloud/pkg/db"
 	"gorm.io/gorm"
 )
 
+// RolloutUseCase moves the fleet to a new version in waves. Deploy events for
+// every wave are enqueued up front; the outbox processor only picks up events
+// of the rollout's active wave while the rollout is running.
 type RolloutUseCase struct {
 	db         *gorm.DB
 	repo       instance.Repository
@@ -20,16 +28,38 @@ type RolloutUseCase struct {
 }
 
 type RolloutResult struct {
+	RolloutID     int64
 	TargetVersion string
 	UpdatedCount  int64
 	EnqueuedCount int64
 }
 
-const (
-	deployEventType  = "deploy_instance"
-	statusPending   = "pending"
-	statusProcessing = "processing"
-)
+// CreateRolloutParams describes a staged rollout. Waves default to the
+// configured percentage plan when empty.
+type CreateRolloutParams struct {
+	Version string
+	Waves   []rollout.Wave
+}
+
+// RolloutView is a rollout with per-wave progress.
+type RolloutView struct {
+	Rollout  *rollout.Rollout `json:"rollout"`
+	Waves    []WaveView       `json:"waves"`
+	Failures []rollout.Target `json:"failures"`
+}
+
+type WaveView struct {
+	Index int               `json:"index"`
+	Wave  rollout.Wave      `json:"wave"`
+	Stats rollout.WaveStats `json:"stats"`
+}
+
+// MaxDeployAttempts is how often the outbox processor runs a deploy event.
+// A failed event is only waiting for its retry; once the attempts are used
+// up the event is dead.
+const MaxDeployAttempts = 10
+
+var activeRolloutStatuses = []rollout.Status{rollout.StatusRunning, rollout.StatusPaused, rollout.StatusHalted}
 
 func NewRolloutUseCase(db *gorm.DB, repo instance.Repository, versionReg *version.Registry, cfg *config.Config) *RolloutUseCase {
 	return &RolloutUseCase{
@@ -40,69 +70,531 @@ func NewRolloutUseCase(db *gorm.DB, repo instance.Repository, versionReg *versio
 	}
 }
 
+// Rollout moves the whole fleet in a single wave that never halts.
+// Kept for the original POST /admin/rollout endpoint.
 func (uc *RolloutUseCase) Rollout(ctx context.Context, version string) (*RolloutResult, error) {
+	waves := []rollout.Wave{{Name: "fleet", Percent: 100, MaxFailurePercent: 100}}
+	result, err := uc.create(ctx, version, waves)
+	if errors.Is(err, rollout.ErrNothingToRollOut) {
+		target, _ := uc.resolveTargetVersion(ctx, version)
+		return &RolloutResult{TargetVersion: target}, nil
+	}
+	return result, err
+}
+
+// Create starts a staged rollout and activates its first wave.
+func (uc *RolloutUseCase) Create(ctx context.Context, params CreateRolloutParams) (*rollout.Rollout, error) {
+	waves := params.Waves
+	if len(waves) == 0 {
+		defaults, err := uc.DefaultWaves()
+		if err != nil {
+			return nil, err
+		}
+		waves = defaults
+	}
+
+	result, err := uc.create(ctx, params.Version, waves)
+	if err != nil {
+		return nil, err
+	}
+	return uc.find(ctx, result.RolloutID)
+}
+
+// DefaultWave returns a wave with the configured thresholds.
+func (uc *RolloutUseCase) DefaultWave() rollout.Wave {
+	return rollout.Wave{
+		MinSuccessPercent: uc.cfg.RolloutMinSuccessPercent,
+		MaxFailurePercent: uc.cfg.RolloutMaxFailurePercent,
+		BakeSeconds:       uc.cfg.RolloutWaveBakeSeconds,
+		TimeoutSeconds:    uc.cfg.RolloutWaveTimeoutSeconds,
+	}
+}
+
+// DefaultWaves builds the wave plan from ROLLOUT_DEFAULT_WAVES.
+func (uc *RolloutUseCase) DefaultWaves() ([]rollout.Wave, error) {
+	var waves []rollout.Wave
+	for _, part := range strings.Split(uc.cfg.RolloutDefaultWaves, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		percent, err := strconv.Atoi(strings.TrimSuffix(part, "%"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid percentage %q", rollout.ErrInvalidWavePlan, part)
+		}
+		wave := uc.DefaultWave()
+		wave.Name = fmt.Sprintf("%d%%", percent)
+		wave.Percent = percent
+		waves = append(waves, wave)
+	}
+	return waves, nil
+}
+
+func (uc *RolloutUseCase) create(ctx context.Context, version string, waves []rollout.Wave) (*RolloutResult, error) {
 	target, err := uc.resolveTargetVersion(ctx, version)
 	if err != nil {
 		return nil, err
 	}
+	if err := rollout.ValidateWaves(waves); err != nil {
+		return nil, err
+	}
 
 	now := time.Now().UTC()
-	var updatedRows int64
-	var enqueuedRows int64
+	result := &RolloutResult{TargetVersion: target}
 
 	err = uc.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		update := tx.Model(&instance.Instance{}).
+		var active int64
+		if err := tx.Model(&rollout.Rollout{}).Where("status IN ?", activeRolloutStatuses).Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return rollout.ErrRolloutInProgress
+		}
+
+		var rows []struct {
+			ID             int64
+			OrgID          int64
+			Tier           instance.Tier
+			CurrentVersion string
+		}
+		if err := tx.Model(&instance.Instance{}).
+			Select("id, org_id, tier, COALESCE(current_version, '') AS current_version").
 			Where("status <> ?", instance.StatusTerminated).
-			Where("desired_version <> ?", target).
-			Updates(map[string]any{
-				"desired_version": target,
-				"updated_at":      now,
+			Where("current_version IS NULL OR current_version <> ?", target).
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return rollout.ErrNothingToRollOut
+		}
+
+		r := &rollout.Rollout{
+			TargetVersion: target,
+			Status:        rollout.StatusRunning,
+			Waves:         waves,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := tx.Create(r).Error; err != nil {
+			if db.IsDuplicateKeyErr(err) {
+				return rollout.ErrRolloutInProgress
+			}
+			return err
+		}
+		result.RolloutID = r.ID
+
+		candidates := make([]rollout.Candidate, 0, len(rows))
+		for _, row := range rows {
+			candidates = append(candidates, rollout.Candidate{
+				InstanceID:  row.ID,
+				OrgID:       row.OrgID,
+				Tier:        row.Tier,
+				FromVersion: row.CurrentVersion,
 			})
-		if update.Error != nil {
-			return update.Error
 		}
-		updatedRows = update.RowsAffected
+		targets := rollout.AssignWaves(r.ID, waves, candidates, now)
+		if err := tx.CreateInBatches(targets, 500).Error; err != nil {
+			return err
+		}
 
 		insert := tx.Exec(
-			`INSERT INTO outbox_events (event_type, org_id, instance_id, status, attempts, created_at, updated_at)
-			 SELECT ?, i.org_id, i.id, ?, 0, ?, ?
-			 FROM instances i
-			 WHERE i.status <> ?
-			   AND i.desired_version = ?
-			   AND (i.current_version IS NULL OR i.current_version <> i.desired_version OR i.status <> ?)
+			`INSERT INTO outbox_events (event_type, org_id, instance_id, status, attempts, rollout_id, rollout_wave, created_at, updated_at)
+			 SELECT ?, t.org_id, t.instance_id, ?, 0, t.rollout_id, t.wave, ?, ?
+			 FROM rollout_targets t
+			 WHERE t.rollout_id = ?
 			   AND NOT EXISTS (
 			     SELECT 1 FROM outbox_events e
-			     WHERE e.instance_id = i.id
+			     WHERE e.instance_id = t.instance_id
 			       AND e.event_type = ?
 			       AND e.status IN (?, ?)
-			   )`,
-			deployEventType,
-			statusPending,
+			   )
+			 ORDER BY t.wave ASC`,
+			outboxevent.TypeDeployInstance,
+			outboxevent.StatusPending,
 			now,
 			now,
-			instance.StatusTerminated,
-			target,
-			instance.StatusActive,
-			deployEventType,
-			statusPending,
-			statusProcessing,
+			r.ID,
+			outboxevent.TypeDeployInstance,
+			outboxevent.StatusPending,
+			outboxevent.StatusProcessing,
 		)
 		if insert.Error != nil {
 			return insert.Error
 		}
-		enqueuedRows = insert.RowsAffected
+		result.EnqueuedCount = insert.RowsAffected
+
+		updated, err := uc.startWave(tx, r, 0, now)
+		if err != nil {
+			return err
+		}
+		result.UpdatedCount = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// startWave points the instances of a wave at the target version so the
+// deploy events released for that wave roll them forward.
+func (uc *RolloutUseCase) startWave(tx *gorm.DB, r *rollout.Rollout, wave int, now time.Time) (int64, error) {
+	r.StartWave(wave, now)
 
+	pending := tx.Model(&rollout.Target{}).
+		Select("instance_id").
+		Where("rollout_id = ? AND wave = ? AND status = ?", r.ID, wave, rollout.TargetPending)
+	update := tx.Model(&instance.Instance{}).
+		Where("id IN (?)", pending).
+		Updates(map[string]any{
+			"desired_version":  r.TargetVersion,
+			"updated_at":       now,
+			"resource_version": gorm.Expr("resource_version + 1"),
+		})
+	if update.Error != nil {
+		return 0, update.Error
+	}
+	if err := recordVersionEvents(tx, pending, now); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Model(&rollout.Target{}).
+		Where("rollout_id = ? AND wave = ? AND status = ?", r.ID, wave, rollout.TargetPending).
+		Updates(map[string]any{
+			"status":     rollout.TargetDeploying,
+			"updated_at": now,
+		}).Error; err != nil {
+		return 0, err
+	}
+
+	return update.RowsAffected, uc.saveState(tx, r, rollout.StatusRunning)
+}
+
+// Advance evaluates the active wave of a running rollout and halts, waits,
+// moves to the next wave or completes the rollout.
+func (uc *RolloutUseCase) Advance(ctx context.Context, r *rollout.Rollout) error {
+	if r.Status != rollout.StatusRunning {
 		return nil
+	}
+
+	now := time.Now().UTC()
+	stats, err := uc.refreshTargets(ctx, r, now)
+	if err != nil {
+		return err
+	}
+
+	decision, reason := rollout.Evaluate(r.ActiveWave(), stats)
+	switch decision {
+	case rollout.DecisionHalt:
+		r.Halt(fmt.Sprintf("wave %d: %s", r.CurrentWave, reason))
+		return uc.saveState(uc.db.WithContext(ctx), r, rollout.StatusRunning)
+	case rollout.DecisionAdvance:
+		// Empty waves have nothing to soak.
+		if stats.Total > 0 && !r.Baked(now) {
+			return uc.saveState(uc.db.WithContext(ctx), r, rollout.StatusRunning)
+		}
+		if r.IsLastWave() {
+			r.Complete(now)
+			return uc.saveState(uc.db.WithContext(ctx), r, rollout.StatusRunning)
+		}
+		return uc.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			_, err := uc.startWave(tx, r, r.CurrentWave+1, now)
+			return err
+		})
+	default:
+		return nil
+	}
+}
+
+// refreshTargets classifies the targets of the active wave from the current
+// instance state and returns the wave statistics.
+func (uc *RolloutUseCase) refreshTargets(ctx context.Context, r *rollout.Rollout, now time.Time) (rollout.WaveStats, error) {
+	var stats rollout.WaveStats
+	dbCtx := uc.db.WithContext(ctx)
+
+	var targets []rollout.Target
+	if err := dbCtx.Where("rollout_id = ? AND wave = ?", r.ID, r.CurrentWave).Find(&targets).Error; err != nil {
+		return stats, err
+	}
+
+	ids := make([]int64, 0, len(targets))
+	for _, t := range targets {
+		if !t.Status.IsFinal() {
+			ids = append(ids, t.InstanceID)
+		}
+	}
+
+	instances := make(map[int64]*instance.Instance, len(ids))
+	failedEvents := make(map[int64]bool)
+	if len(ids) > 0 {
+		var rows []instance.Instance
+		if err := dbCtx.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return stats, err
+		}
+		for i := range rows {
+			instances[rows[i].ID] = &rows[i]
+		}
+
+		var failedIDs []int64
+		if err := dbCtx.Table("outbox_events").
+			Where("rollout_id = ? AND rollout_wave = ? AND status = ?", r.ID, r.CurrentWave, outboxevent.StatusDead).
+			Pluck("instance_id", &failedIDs).Error; err != nil {
+			return stats, err
+		}
+		for _, id := range failedIDs {
+			failedEvents[id] = true
+		}
+	}
+
+	timedOut := r.WaveTimedOut(now)
+	for _, t := range targets {
+		if !t.Status.IsFinal() {
+			next, lastErr := rollout.Classify(instances[t.InstanceID], r.TargetVersion, failedEvents[t.InstanceID], timedOut)
+			if next != t.Status || lastErr != t.LastError {
+				if err := dbCtx.Model(&rollout.Target{}).
+					Where("rollout_id = ? AND instance_id = ?", t.RolloutID, t.InstanceID).
+					Updates(map[string]any{
+						"status":     next,
+						"last_error": lastErr,
+						"updated_at": now,
+					}).Error; err != nil {
+					return stats, err
+				}
+				t.Status = next
+			}
+		}
+		stats.Add(t.Status)
+	}
+	return stats, nil
+}
+
+// Pause stops releasing deploy events. Deploys already in flight finish.
+func (uc *RolloutUseCase) Pause(ctx context.Context, id int64) (*rollout.Rollout, error) {
+	r, err := uc.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Pause(); err != nil {
+		return nil, err
+	}
+	return r, uc.saveState(uc.db.WithContext(ctx), r, rollout.StatusRunning)
+}
+
+// Resume continues a paused or halted rollout. Resuming a halted rollout
+// accepts the failures of the active wave: failed targets are skipped and no
+// longer count against the thresholds.
+func (uc *RolloutUseCase) Resume(ctx context.Context, id int64) (*rollout.Rollout, error) {
+	r, err := uc.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	prev := r.Status
+	if err := r.Resume(); err != nil {
+		return nil, err
+	}
+
+	err = uc.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if prev == rollout.StatusHalted {
+			if err := tx.Model(&rollout.Target{}).
+				Where("rollout_id = ? AND wave = ? AND status = ?", r.ID, r.CurrentWave, rollout.TargetFailed).
+				Updates(map[string]any{
+					"status":     rollout.TargetSkipped,
+					"updated_at": r.UpdatedAt,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return uc.saveState(tx, r, prev)
 	})
 	if err != nil {
 		return nil, err
 	}
+	return r, nil
+}
+
+// Abort stops the rollout for good. Deploy events that have not run yet are
+// cancelled and their instances keep their previous version.
+func (uc *RolloutUseCase) Abort(ctx context.Context, id int64) (*rollout.Rollout, error) {
+	r, err := uc.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	prev := r.Status
+	if err := r.Abort(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	err = uc.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var reverted []int64
+		if err := tx.Raw(
+			`UPDATE instances i
+			 SET desired_version = t.from_version, updated_at = ?, resource_version = i.resource_version + 1
+			 FROM rollout_targets t, outbox_events e
+			 WHERE t.rollout_id = ?
+			   AND t.instance_id = i.id
+			   AND t.from_version <> ''
+			   AND e.rollout_id = t.rollout_id
+			   AND e.instance_id = t.instance_id
+			   AND e.status IN (?, ?, ?)
+			   AND i.desired_version = ?
+			 RETURNING i.id`,
+			now,
+			r.ID,
+			outboxevent.StatusPending,
+			outboxevent.StatusFailed,
+			outboxevent.StatusDead,
+			r.TargetVersion,
+		).Scan(&reverted).Error; err != nil {
+			return err
+		}
+		if len(reverted) > 0 {
+			if err := recordVersionEvents(tx, reverted, now); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Table("outbox_events").
+			Where("rollout_id = ? AND status IN ?", r.ID, []outboxevent.Status{outboxevent.StatusPending, outboxevent.StatusFailed, outboxevent.StatusDead}).
+			Updates(map[string]any{
+				"status":     outboxevent.StatusCancelled,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
 
-	return &RolloutResult{
-		TargetVersion: target,
-		UpdatedCount:  updatedRows,
-		EnqueuedCount: enqueuedRows,
-	}, nil
+		if err := tx.Model(&rollout.Target{}).
+			Where("rollout_id = ? AND status IN ?", r.ID, []rollout.TargetStatus{rollout.TargetPending, rollout.TargetDeploying, rollout.TargetFailed}).
+			Updates(map[string]any{
+				"status":     rollout.TargetCancelled,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		return uc.saveState(tx, r, prev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Get returns a rollout with per-wave statistics and its failed targets.
+func (uc *RolloutUseCase) Get(ctx context.Context, id int64) (*RolloutView, error) {
+	r, err := uc.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		Wave   int
+		Status rollout.TargetStatus
+		Count  int
+	}
+	if err := uc.db.WithContext(ctx).Model(&rollout.Target{}).
+		Select("wave, status, COUNT(*) AS count").
+		Where("rollout_id = ?", id).
+		Group("wave, status").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	view := &RolloutView{Rollout: r, Waves: make([]WaveView, len(r.Waves))}
+	for i, w := range r.Waves {
+		view.Waves[i] = WaveView{Index: i, Wave: w}
+	}
+	for _, c := range counts {
+		if c.Wave < 0 || c.Wave >= len(view.Waves) {
+			continue
+		}
+		for n := 0; n < c.Count; n++ {
+			view.Waves[c.Wave].Stats.Add(c.Status)
+		}
+	}
+
+	if err := uc.db.WithContext(ctx).
+		Where("rollout_id = ? AND status = ?", id, rollout.TargetFailed).
+		Order("updated_at DESC").
+		Limit(100).
+		Find(&view.Failures).Error; err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// List returns the most recent rollouts.
+func (uc *RolloutUseCase) List(ctx context.Context, limit int) ([]rollout.Rollout, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var items []rollout.Rollout
+	if err := uc.db.WithContext(ctx).Order("id DESC").Limit(limit).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListRunning returns rollouts the controller should advance.
+func (uc *RolloutUseCase) ListRunning(ctx context.Context) ([]*rollout.Rollout, error) {
+	var items []*rollout.Rollout
+	if err := uc.db.WithContext(ctx).
+		Where("status = ?", rollout.StatusRunning).
+		Order("id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (uc *RolloutUseCase) find(ctx context.Context, id int64) (*rollout.Rollout, error) {
+	var r rollout.Rollout
+	if err := uc.db.WithContext(ctx).First(&r, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, rollout.ErrNotFound
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+// saveState persists the mutable rollout fields if the rollout is still in
+// the expected status, so the controller never overwrites an admin action.
+func (uc *RolloutUseCase) saveState(tx *gorm.DB, r *rollout.Rollout, expected rollout.Status) error {
+	result := tx.Model(&rollout.Rollout{}).
+		Where("id = ? AND status = ?", r.ID, expected).
+		Updates(map[string]any{
+			"status":          r.Status,
+			"current_wave":    r.CurrentWave,
+			"wave_started_at": r.WaveStartedAt,
+			"wave_passed_at":  r.WavePassedAt,
+			"halt_reason":     r.HaltReason,
+			"completed_at":    r.CompletedAt,
+			"updated_at":      r.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: rollout %d is no longer %s", rollout.ErrInvalidTransition, r.ID, expected)
+	}
+	return nil
+}
+
+// recordVersionEvents appends an instance event for instances whose desired
+// version was just changed in bulk. ids is a slice or a subquery of instance IDs.
+func recordVersionEvents(tx *gorm.DB, ids any, now time.Time) error {
+	actor := instance.ActorFromContext(tx.Statement.Context)
+	return tx.Exec(
+		`INSERT INTO instance_events (instance_id, org_id, actor_type, actor_id, old_status, new_status, old_lifecycle_state, lifecycle_state, desired_version, current_version, error, created_at)
+		 SELECT i.id, i.org_id, ?, ?, i.status, i.status, i.lifecycle_state, i.lifecycle_state, i.desired_version, COALESCE(i.current_version, ''), '', ?
+		 FROM instances i
+		 WHERE i.id IN (?)`,
+		actor.Type,
+		actor.ID,
+		now,
+		ids,
+	).Error
 }
 
 func (uc *RolloutUseCase) resolveTargetVersion(ctx context.Context, raw string) (string, error) {
@@ -128,6 +620,13 @@ func (uc *RolloutUseCase) resolveTargetVersion(ctx context.Context, raw string)
 		if !ok {
 			return "", fmt.Errorf("version %s not found or not available", target)
 		}
+		suspect, err := uc.versionReg.IsSuspect(ctx, "railzway", target)
+		if err != nil {
+			return "", err
+		}
+		if suspect {
+			return "", fmt.Errorf("version %s is marked suspect after automatic rollbacks", target)
+		}
 	}
 	return target, nil
 }
@@ -0,0 +1,166 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/rollout"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedOrg inserts an owner and an organization and returns the org ID.
+func seedOrg(t *testing.T, conn *gorm.DB, slug string) int64 {
+	t.Helper()
+	var userID, orgID int64
+	require.NoError(t, conn.Raw(
+		`INSERT INTO users (email, auth_id) VALUES (?, ?) RETURNING id`,
+		slug+"@example.com", "auth-"+slug,
+	).Scan(&userID).Error)
+	require.NoError(t, conn.Raw(
+		`INSERT INTO organizations (owner_id, name, slug) VALUES (?, ?, ?) RETURNING id`,
+		userID, slug, slug,
+	).Scan(&orgID).Error)
+	return orgID
+}
+
+// seedServingInstance inserts an active, ready instance running version.
+func seedServingInstance(t *testing.T, conn *gorm.DB, orgID int64, version string) *instance.Instance {
+	t.Helper()
+	inst := instance.NewInstance(orgID, instance.TierStarter, instance.EngineHetzner, version)
+	inst.CurrentVersion = version
+	inst.LastGoodVersion = version
+	inst.Status = instance.StatusActive
+	inst.Readiness = instance.ReadinessReady
+	inst.Provisioner = "nomad"
+	require.NoError(t, conn.Create(inst).Error)
+	return inst
+}
+
+func seedDeployEvent(t *testing.T, conn *gorm.DB, inst *instance.Instance, status outboxevent.Status, attempts int) int64 {
+	t.Helper()
+	var id int64
+	require.NoError(t, conn.Raw(
+		`INSERT INTO outbox_events (event_type, org_id, instance_id, status, attempts) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		outboxevent.TypeDeployInstance, inst.OrgID, inst.ID, status, attempts,
+	).Scan(&id).Error)
+	return id
+}
+
+func countDeployEvents(t *testing.T, conn *gorm.DB, instanceID int64, status outboxevent.Status) int64 {
+	t.Helper()
+	var count int64
+	require.NoError(t, conn.Table("outbox_events").
+		Where("instance_id = ? AND event_type = ? AND status = ?", instanceID, outboxevent.TypeDeployInstance, status).
+		Count(&count).Error)
+	return count
+}
+
+func newTestRollout(conn *gorm.DB) *RolloutUseCase {
+	return NewRolloutUseCase(conn, nil, nil, &config.Config{})
+}
+
+func fleetWave(maxFailurePercent int) []rollout.Wave {
+	return []rollout.Wave{{Name: "fleet", Percent: 100, MinSuccessPercent: 100, MaxFailurePercent: maxFailurePercent}}
+}
+
+func TestRolloutUseCase_CreateSkipsQueuedDeploys(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := context.Background()
+	uc := newTestRollout(conn)
+
+	queued := seedServingInstance(t, conn, seedOrg(t, conn, "queued"), "v1.0.0")
+	fresh := seedServingInstance(t, conn, seedOrg(t, conn, "fresh"), "v1.0.0")
+	seedDeployEvent(t, conn, queued, outboxevent.StatusPending, 0)
+
+	r, err := uc.Create(ctx, CreateRolloutParams{Version: "v2.0.0", Waves: fleetWave(100)})
+	require.NoError(t, err)
+	assert.Equal(t, rollout.StatusRunning, r.Status)
+
+	// Both instances are targeted and point at the new version...
+	var targets int64
+	require.NoError(t, conn.Model(&rollout.Target{}).Where("rollout_id = ?", r.ID).Count(&targets).Error)
+	assert.Equal(t, int64(2), targets)
+	for _, id := range []int64{queued.ID, fresh.ID} {
+		var desired string
+		require.NoError(t, conn.Model(&instance.Instance{}).Where("id = ?", id).Pluck("desired_version", &desired).Error)
+		assert.Equal(t, "v2.0.0", desired)
+	}
+
+	// ...but the instance with a deploy already queued gets no second one.
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, queued.ID, outboxevent.StatusPending))
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, fresh.ID, outboxevent.StatusPending))
+
+	// Only one rollout may hold the fleet.
+	_, err = uc.Create(ctx, CreateRolloutParams{Version: "v2.0.0", Waves: fleetWave(100)})
+	assert.ErrorIs(t, err, rollout.ErrRolloutInProgress)
+}
+
+func TestRolloutUseCase_AdvanceWaitsForRetries(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := context.Background()
+	uc := newTestRollout(conn)
+
+	flaky := seedServingInstance(t, conn, seedOrg(t, conn, "flaky"), "v1.0.0")
+	seedServingInstance(t, conn, seedOrg(t, conn, "steady"), "v1.0.0")
+
+	r, err := uc.Create(ctx, CreateRolloutParams{Version: "v2.0.0", Waves: fleetWave(0)})
+	require.NoError(t, err)
+
+	// A failed event with attempts left is only waiting for its retry.
+	require.NoError(t, conn.Table("outbox_events").
+		Where("rollout_id = ? AND instance_id = ?", r.ID, flaky.ID).
+		Updates(map[string]any{"status": outboxevent.StatusFailed, "attempts": 1}).Error)
+	require.NoError(t, uc.Advance(ctx, r))
+	r, err = uc.find(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rollout.StatusRunning, r.Status)
+
+	// Once the retries are used up the target fails and the wave halts.
+	require.NoError(t, conn.Table("outbox_events").
+		Where("rollout_id = ? AND instance_id = ?", r.ID, flaky.ID).
+		Updates(map[string]any{"status": outboxevent.StatusDead, "attempts": MaxDeployAttempts}).Error)
+	require.NoError(t, uc.Advance(ctx, r))
+	r, err = uc.find(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rollout.StatusHalted, r.Status)
+
+	var target rollout.Target
+	require.NoError(t, conn.Where("rollout_id = ? AND instance_id = ?", r.ID, flaky.ID).First(&target).Error)
+	assert.Equal(t, rollout.TargetFailed, target.Status)
+}
+
+func TestRolloutUseCase_AbortRevertsUndeployedInstances(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := context.Background()
+	uc := newTestRollout(conn)
+
+	inst := seedServingInstance(t, conn, seedOrg(t, conn, "aborted"), "v1.0.0")
+
+	r, err := uc.Create(ctx, CreateRolloutParams{Version: "v2.0.0", Waves: fleetWave(100)})
+	require.NoError(t, err)
+
+	r, err = uc.Abort(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rollout.StatusAborted, r.Status)
+
+	var desired string
+	require.NoError(t, conn.Model(&instance.Instance{}).Where("id = ?", inst.ID).Pluck("desired_version", &desired).Error)
+	assert.Equal(t, "v1.0.0", desired)
+	assert.Equal(t, int64(0), countDeployEvents(t, conn, inst.ID, outboxevent.StatusPending))
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, inst.ID, outboxevent.StatusCancelled))
+
+	var target rollout.Target
+	require.NoError(t, conn.Where("rollout_id = ? AND instance_id = ?", r.ID, inst.ID).First(&target).Error)
+	assert.Equal(t, rollout.TargetCancelled, target.Status)
+
+	// The revert is part of the instance history.
+	var events int64
+	require.NoError(t, conn.Model(&instance.Event{}).Where("instance_id = ? AND desired_version = ?", inst.ID, "v1.0.0").Count(&events).Error)
+	assert.Equal(t, int64(1), events)
+}
@@ -0,0 +1,274 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
+	"github.com/railzwaylabs/railzway-cloud/pkg/authclient"
+)
+
+// RotateSecrets replaces credentials of a provisioned instance, as requested
+// by the rotate_secrets outbox event eventID, and redeploys its workload in
+// place. The redeploy is a rolling update, so the workload keeps serving
+// while its allocations pick the new credentials up. Stopped instances pick
+// them up when started.
+//
+// A new database password is set on the login role the workload is not
+// using, before the redeploy moves the workload to it. The replaced login
+// keeps its password until the grace period ends; ExpireSecrets disables it
+// after that.
+//
+// Every rotated credential is recorded once stored, so a retried event only
+// rotates what it did not rotate yet and then finishes the redeploy.
+func (uc *DeployUseCase) RotateSecrets(ctx context.Context, eventID, instanceID int64, rotation SecretRotation) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+
+	kinds := rotation.Secrets
+	if len(kinds) == 0 {
+		// Queued before other credentials could be rotated.
+		kinds = []instance.SecretKind{instance.SecretDBPassword}
+	}
+	records, err := uc.rotations.ListByEventID(ctx, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to load rotation history: %w", err)
+	}
+	var pending []instance.SecretKind
+	for _, kind := range kinds {
+		if !slices.ContainsFunc(records, func(r *instance.SecretRotation) bool { return r.Secret == kind }) {
+			pending = append(pending, kind)
+		}
+	}
+	now := time.Now().UTC()
+	if err := checkRotatable(inst, pending, now); err != nil {
+		return err
+	}
+
+	// 1. Store each new credential before the old one stops working, so a
+	// failed attempt never leaves the instance with a credential nobody knows.
+	actor := rotation.actor(ctx)
+	for _, kind := range pending {
+		graceUntil, err := uc.rotateSecret(ctx, inst, kind, now)
+		if err != nil {
+			return err
+		}
+		record := &instance.SecretRotation{
+			InstanceID: inst.ID,
+			OrgID:      inst.OrgID,
+			EventID:    eventID,
+			Secret:     kind,
+			Status:     instance.SecretRotationRotated,
+			ActorType:  actor.Type,
+			ActorID:    actor.ID,
+			GraceUntil: graceUntil,
+			CreatedAt:  now,
+		}
+		if err := uc.rotations.Create(ctx, record); err != nil {
+			return fmt.Errorf("failed to record %s rotation: %w", kind, err)
+		}
+		records = append(records, record)
+	}
+
+	// 2. Provision the new database login next to the current one
+	if slices.Contains(kinds, instance.SecretDBPassword) {
+		if err := uc.dbProvisioner.Provision(ctx, databaseConfig(inst)); err != nil {
+			return fmt.Errorf("db provisioning failed: %w", err)
+		}
+	}
+
+	// 3. Redeploy with the new credentials
+	if inst.Status != instance.StatusStopped {
+		if err := uc.redeployWithSecrets(ctx, inst); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		if record.Status == instance.SecretRotationCompleted {
+			continue
+		}
+		record.Complete(time.Now().UTC())
+		if err := uc.rotations.Save(ctx, record); err != nil {
+			return fmt.Errorf("failed to record %s rotation: %w", record.Secret, err)
+		}
+	}
+	return nil
+}
+
+// rotateSecret stores a new credential of one kind and returns until when
+// the previous one stays valid, if at all.
+func (uc *DeployUseCase) rotateSecret(ctx context.Context, inst *instance.Instance, kind instance.SecretKind, now time.Time) (*time.Time, error) {
+	grace := uc.secretGrace()
+	graceUntil := now.Add(grace)
+
+	switch kind {
+	case instance.SecretDBPassword:
+		password, err := generatePassword()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate db password: %w", err)
+		}
+		rotated := *inst
+		if err := rotated.RotateDBLogin(password, grace, now); err != nil {
+			return nil, err
+		}
+		return &graceUntil, saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+			i.DBLoginUser = rotated.DBLoginUser
+			i.DBPassword = rotated.DBPassword
+			i.PreviousDBLoginUser = rotated.PreviousDBLoginUser
+			i.PreviousDBLoginExpiresAt = rotated.PreviousDBLoginExpiresAt
+		})
+
+	case instance.SecretOAuthClientSecret:
+		if uc.authClient == nil {
+			return nil, fmt.Errorf("auth client not configured")
+		}
+		client, err := uc.authClient.RotateOAuthClientSecret(ctx, authclient.RotateOAuthClientSecretRequest{
+			ClientID:    inst.OAuthClientID,
+			GracePeriod: grace,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rotate oauth client secret: %w", err)
+		}
+		return &graceUntil, storeIssuedSecret(ctx, uc.repo, inst, func(i *instance.Instance) {
+			i.OAuthClientSecret = client.ClientSecret
+		})
+
+	case instance.SecretPaymentProviderSecret:
+		secret, err := generatePaymentProviderSecret()
+		if err != nil {
+			return nil, fmt.Errorf("generate payment provider secret: %w", err)
+		}
+		rotated := *inst
+		if err := rotated.RotatePaymentProviderSecret(secret, grace, now); err != nil {
+			return nil, err
+		}
+		return &graceUntil, saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+			i.PaymentProviderConfigSecret = rotated.PaymentProviderConfigSecret
+			i.PreviousPaymentProviderSecret = rotated.PreviousPaymentProviderSecret
+			i.PreviousPaymentProviderSecretExpiresAt = rotated.PreviousPaymentProviderSecretExpiresAt
+		})
+	}
+	return nil, fmt.Errorf("%w: %s", instance.ErrUnknownSecret, kind)
+}
+
+// storeIssuedSecret saves a credential another service already issued. That
+// service cannot hand it out again, and a retried rotation would issue
+// another one and invalidate this one, so lost conflicts are retried until
+// the credential is stored and a cancelled context does not stop the save.
+func storeIssuedSecret(ctx context.Context, repo instance.Repository, inst *instance.Instance, mutate func(*instance.Instance)) error {
+	ctx = context.WithoutCancel(ctx)
+	for {
+		err := saveInstance(ctx, repo, inst, mutate)
+		if !errors.Is(err, instance.ErrConflict) {
+			return err
+		}
+	}
+}
+
+// redeployWithSecrets deploys the running version of an instance again, with
+// the credentials currently stored.
+func (uc *DeployUseCase) redeployWithSecrets(ctx context.Context, inst *instance.Instance) error {
+	org, err := uc.orgService.GetSlug(ctx, inst.OrgID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve org slug: %w", err)
+	}
+	// A pending version change is left to the deploy that rolls it out.
+	version := coalesce(inst.CurrentVersion, inst.DesiredVersion)
+	deployCfg, err := buildDeploymentConfig(ctx, uc.cfg, uc.runtimeCfg, uc.profiles, org, inst, version, inst.Tier)
+	if err != nil {
+		return err
+	}
+	if err := uc.provisioner.Deploy(ctx, deployCfg); err != nil {
+		return fmt.Errorf("failed to redeploy instance: %w", err)
+	}
+
+	paymentSecret := inst.PaymentProviderConfigSecret
+	return saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.PaymentProviderConfigSecret = paymentSecret
+		i.TierProfileRevision = deployCfg.TierProfileRevision
+		i.EntitlementsHash = deployCfg.EntitlementsHash
+	})
+}
+
+// ExpireSecrets disables the database login replaced by the last password
+// rotation once its grace period ended, as requested by an expire_secrets
+// outbox event.
+func (uc *DeployUseCase) ExpireSecrets(ctx context.Context, instanceID int64) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	if !inst.PreviousDBLoginExpired(time.Now().UTC()) {
+		return nil
+	}
+
+	dbCfg := provisioning.DBConfig{
+		Host:      inst.DBHost,
+		Port:      inst.DBPort,
+		Name:      inst.DBName,
+		User:      inst.DBUser,
+		LoginUser: inst.PreviousDBLoginUser,
+	}
+	if err := uc.dbProvisioner.DisableLogin(ctx, dbCfg); err != nil {
+		return fmt.Errorf("failed to disable previous db login: %w", err)
+	}
+	return saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.PreviousDBLoginUser = ""
+		i.PreviousDBLoginExpiresAt = nil
+	})
+}
+
+// ListSecretRotations returns the most recent credential rotations of an
+// instance.
+func (uc *DeployUseCase) ListSecretRotations(ctx context.Context, instanceID int64, limit int) ([]*instance.SecretRotation, error) {
+	return uc.rotations.ListByInstanceID(ctx, instanceID, limit)
+}
+
+func (uc *DeployUseCase) secretGrace() time.Duration {
+	if uc.cfg == nil || uc.cfg.SecretRotationGraceSeconds <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(uc.cfg.SecretRotationGraceSeconds) * time.Second
+}
+
+// checkRotatable rejects instances whose secrets cannot be rotated now. A
+// blue/green upgrade would bring up its standby with the old password.
+func checkRotatable(inst *instance.Instance, kinds []instance.SecretKind, now time.Time) error {
+	switch {
+	case inst.DBUser == "":
+		return fmt.Errorf("instance %d has no database yet: %w", inst.ID, instance.ErrInvalidState)
+	case inst.Status == instance.StatusTerminated:
+		return fmt.Errorf("instance %d is terminated: %w", inst.ID, instance.ErrInvalidState)
+	case inst.Status == instance.StatusUpgrading:
+		return instance.ErrUpgradeInProgress
+	}
+	for _, kind := range kinds {
+		if err := checkSecretRotatable(inst, kind, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSecretRotatable rejects credentials of an instance that cannot be
+// rotated now.
+func checkSecretRotatable(inst *instance.Instance, kind instance.SecretKind, now time.Time) error {
+	switch kind {
+	case instance.SecretDBPassword:
+		return inst.CanRotateDBLogin(now)
+	case instance.SecretOAuthClientSecret:
+		if inst.OAuthClientID == "" {
+			return fmt.Errorf("instance %d uses the shared oauth client: %w", inst.ID, instance.ErrInvalidState)
+		}
+	case instance.SecretPaymentProviderSecret:
+		return inst.CanRotatePaymentProviderSecret(now)
+	}
+	return nil
+}
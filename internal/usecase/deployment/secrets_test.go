@@ -0,0 +1,199 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/config"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/pkg/authclient"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRotationRepository keeps the rotation history in memory.
+type mockRotationRepository struct {
+	items []*instance.SecretRotation
+}
+
+func (m *mockRotationRepository) Create(ctx context.Context, rotation *instance.SecretRotation) error {
+	rotation.ID = int64(len(m.items) + 1)
+	m.items = append(m.items, rotation)
+	return nil
+}
+
+func (m *mockRotationRepository) Save(ctx context.Context, rotation *instance.SecretRotation) error {
+	return nil
+}
+
+func (m *mockRotationRepository) ListByEventID(ctx context.Context, eventID int64) ([]*instance.SecretRotation, error) {
+	var items []*instance.SecretRotation
+	for _, item := range m.items {
+		if item.EventID == eventID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (m *mockRotationRepository) ListByInstanceID(ctx context.Context, instanceID int64, limit int) ([]*instance.SecretRotation, error) {
+	var items []*instance.SecretRotation
+	for _, item := range m.items {
+		if item.InstanceID == instanceID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func newStoppedInstance() *instance.Instance {
+	return &instance.Instance{
+		ID:                          1,
+		OrgID:                       7,
+		Environment:                 "production",
+		Status:                      instance.StatusStopped,
+		DBHost:                      "localhost",
+		DBName:                      "railzway_org_7",
+		DBUser:                      "railzway_org_7",
+		DBPassword:                  "old-password",
+		PaymentProviderConfigSecret: "old-payment-secret",
+	}
+}
+
+func TestDeployUseCase_RotateSecrets_RecordsEachSecret(t *testing.T) {
+	mockRepo := newMockInstanceRepository()
+	mockRepo.instances[1] = newStoppedInstance()
+	dbProvisioner := &testhelper.MockDatabaseProvisioner{}
+	rotations := &mockRotationRepository{}
+	uc := newTestDeployUseCase(mockRepo, &testhelper.MockProvisioner{}, dbProvisioner, nil, &config.Config{SecretRotationGraceSeconds: 3600})
+	uc.rotations = rotations
+
+	err := uc.RotateSecrets(context.Background(), 42, 1, SecretRotation{
+		Secrets:   []instance.SecretKind{instance.SecretDBPassword, instance.SecretPaymentProviderSecret},
+		ActorType: instance.ActorUser,
+		ActorID:   "9",
+	})
+	require.NoError(t, err)
+
+	rotated := mockRepo.instances[1]
+	assert.NotEqual(t, "old-password", rotated.DBPassword)
+	assert.Equal(t, "railzway_org_7_a", rotated.DatabaseLogin())
+	assert.Equal(t, "railzway_org_7", rotated.PreviousDBLoginUser)
+	assert.False(t, rotated.PreviousDBLoginExpired(time.Now().UTC()))
+	if assert.Len(t, dbProvisioner.ProvisionCalls, 1) {
+		assert.Equal(t, rotated.DBPassword, dbProvisioner.ProvisionCalls[0].Password)
+		assert.Equal(t, "railzway_org_7_a", dbProvisioner.ProvisionCalls[0].LoginUser)
+		assert.Equal(t, instance.DatabaseLimitsPerTier[instance.TierFreeTrial].ConnectionLimit, dbProvisioner.ProvisionCalls[0].ConnectionLimit)
+	}
+	assert.NotEqual(t, "old-payment-secret", rotated.PaymentProviderConfigSecret)
+	assert.Equal(t, "old-payment-secret", rotated.PreviousPaymentProviderSecretAt(time.Now().UTC()))
+
+	require.Len(t, rotations.items, 2)
+	for _, record := range rotations.items {
+		assert.Equal(t, int64(42), record.EventID)
+		assert.Equal(t, instance.SecretRotationCompleted, record.Status)
+		assert.Equal(t, instance.ActorUser, record.ActorType)
+		assert.Equal(t, "9", record.ActorID)
+	}
+	assert.NotNil(t, rotations.items[0].GraceUntil)
+	assert.NotNil(t, rotations.items[1].GraceUntil)
+
+	// The previous login keeps working until the grace period ends.
+	err = uc.RotateSecrets(context.Background(), 43, 1, SecretRotation{Secrets: []instance.SecretKind{instance.SecretDBPassword}})
+	assert.ErrorIs(t, err, instance.ErrInvalidState)
+}
+
+func TestDeployUseCase_ExpireSecrets_DisablesPreviousLogin(t *testing.T) {
+	mockRepo := newMockInstanceRepository()
+	inst := newStoppedInstance()
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	inst.DBLoginUser = "railzway_org_7_b"
+	inst.PreviousDBLoginUser = "railzway_org_7_a"
+	inst.PreviousDBLoginExpiresAt = &expiresAt
+	mockRepo.instances[1] = inst
+	dbProvisioner := &testhelper.MockDatabaseProvisioner{}
+	uc := newTestDeployUseCase(mockRepo, &testhelper.MockProvisioner{}, dbProvisioner, nil, &config.Config{})
+
+	// Nothing happens during the grace period.
+	require.NoError(t, uc.ExpireSecrets(context.Background(), 1))
+	assert.Empty(t, dbProvisioner.DisableLoginCalls)
+
+	expiresAt = time.Now().UTC().Add(-time.Minute)
+	require.NoError(t, uc.ExpireSecrets(context.Background(), 1))
+	if assert.Len(t, dbProvisioner.DisableLoginCalls, 1) {
+		assert.Equal(t, "railzway_org_7", dbProvisioner.DisableLoginCalls[0].User)
+		assert.Equal(t, "railzway_org_7_a", dbProvisioner.DisableLoginCalls[0].LoginUser)
+	}
+	expired := mockRepo.instances[1]
+	assert.Empty(t, expired.PreviousDBLoginUser)
+	assert.Nil(t, expired.PreviousDBLoginExpiresAt)
+	assert.Equal(t, "railzway_org_7_b", expired.DatabaseLogin())
+	assert.NoError(t, expired.CanRotateDBLogin(time.Now().UTC()))
+}
+
+func TestDeployUseCase_RotateSecrets_RetryKeepsRotatedSecrets(t *testing.T) {
+	mockRepo := newMockInstanceRepository()
+	mockRepo.instances[1] = newStoppedInstance()
+	dbProvisioner := &testhelper.MockDatabaseProvisioner{ShouldFail: true}
+	rotations := &mockRotationRepository{}
+	uc := newTestDeployUseCase(mockRepo, &testhelper.MockProvisioner{}, dbProvisioner, nil, &config.Config{})
+	uc.rotations = rotations
+	rotation := SecretRotation{Secrets: []instance.SecretKind{instance.SecretDBPassword, instance.SecretPaymentProviderSecret}}
+
+	err := uc.RotateSecrets(context.Background(), 42, 1, rotation)
+	assert.ErrorContains(t, err, "db provisioning failed")
+	stored := *mockRepo.instances[1]
+
+	// The retry applies the stored credentials instead of rotating again,
+	// which the payment provider secret's grace period would refuse.
+	dbProvisioner.ShouldFail = false
+	require.NoError(t, uc.RotateSecrets(context.Background(), 42, 1, rotation))
+	assert.Equal(t, stored.DBPassword, mockRepo.instances[1].DBPassword)
+	assert.Equal(t, stored.PaymentProviderConfigSecret, mockRepo.instances[1].PaymentProviderConfigSecret)
+	if assert.Len(t, dbProvisioner.ProvisionCalls, 1) {
+		assert.Equal(t, stored.DBPassword, dbProvisioner.ProvisionCalls[0].Password)
+	}
+	assert.Len(t, rotations.items, 2)
+
+	// Another rotation of the payment provider secret waits for the grace period.
+	err = uc.RotateSecrets(context.Background(), 43, 1, SecretRotation{Secrets: []instance.SecretKind{instance.SecretPaymentProviderSecret}})
+	assert.ErrorIs(t, err, instance.ErrInvalidState)
+}
+
+func TestDeployUseCase_RotateSecrets_StoresIssuedOAuthSecret(t *testing.T) {
+	auth := testhelper.NewMockAuthServer(t)
+	authClient := authclient.New(authclient.Config{
+		BaseURL:      auth.URL(),
+		TenantSlug:   "railzway",
+		ClientID:     "cloud",
+		ClientSecret: "secret",
+	})
+	repo := &conflictingRepository{mockInstanceRepository: newMockInstanceRepository(), conflicts: 2 * (maxConflictRetries + 1)}
+	inst := newStoppedInstance()
+	inst.OAuthClientID = "tenant-client"
+	inst.OAuthClientSecret = "old-client-secret"
+	repo.instances[1] = inst
+	uc := newTestDeployUseCase(repo, &testhelper.MockProvisioner{}, &testhelper.MockDatabaseProvisioner{}, authClient, &config.Config{})
+	uc.rotations = &mockRotationRepository{}
+
+	// Concurrent writes outlast saveInstance's retries; the issued secret is
+	// stored anyway instead of being rotated again by a retry.
+	err := uc.RotateSecrets(context.Background(), 42, 1, SecretRotation{Secrets: []instance.SecretKind{instance.SecretOAuthClientSecret}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, auth.RotateRequests)
+	assert.Equal(t, "test-client-secret-1", repo.instances[1].OAuthClientSecret)
+	assert.Equal(t, "set concurrently", repo.instances[1].LastError)
+}
+
+func TestCheckRotatable_RequiresOwnOAuthClient(t *testing.T) {
+	inst := newStoppedInstance()
+	now := time.Now().UTC()
+
+	err := checkRotatable(inst, []instance.SecretKind{instance.SecretOAuthClientSecret}, now)
+	assert.ErrorIs(t, err, instance.ErrInvalidState)
+
+	inst.OAuthClientID = "tenant-client"
+	assert.NoError(t, checkRotatable(inst, []instance.SecretKind{instance.SecretOAuthClientSecret}, now))
+}
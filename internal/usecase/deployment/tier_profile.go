@@ -0,0 +1,145 @@
+package deployment
+
+import (
+	"context"
+	"time"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/tierprofile"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	"gorm.io/gorm"
+)
+
+// TierProfileUseCase edits the tier profile catalog and re-deploys instances
+// running an outdated profile.
+type TierProfileUseCase struct {
+	db       *gorm.DB
+	profiles tierprofile.Repository
+}
+
+// SaveTierProfileParams describes a new revision of a tier profile.
+type SaveTierProfileParams struct {
+	Tier    instance.Tier
+	Spec    nomad.TierProfile
+	Comment string
+	// BaseRevision is the revision the edit started from. Saving fails with
+	// ErrRevisionConflict when another revision was saved in between.
+	BaseRevision int
+}
+
+func NewTierProfileUseCase(db *gorm.DB, profiles tierprofile.Repository) *TierProfileUseCase {
+	return &TierProfileUseCase{
+		db:       db,
+		profiles: profiles,
+	}
+}
+
+// List returns the current profile of every tier, falling back to the
+// built-in profile for tiers missing from the catalog.
+func (uc *TierProfileUseCase) List(ctx context.Context) ([]*tierprofile.Profile, error) {
+	current, err := uc.profiles.ListCurrent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byTier := make(map[instance.Tier]*tierprofile.Profile, len(current))
+	for _, p := range current {
+		byTier[p.Tier] = p
+	}
+
+	items := make([]*tierprofile.Profile, 0, len(tierprofile.Tiers))
+	for _, tier := range tierprofile.Tiers {
+		if p, ok := byTier[tier]; ok {
+			items = append(items, p)
+		} else {
+			items = append(items, tierprofile.Builtin(tier))
+		}
+	}
+	return items, nil
+}
+
+// Get returns the current profile of a tier.
+func (uc *TierProfileUseCase) Get(ctx context.Context, tier instance.Tier) (*tierprofile.Profile, error) {
+	if err := tierprofile.ValidateTier(tier); err != nil {
+		return nil, err
+	}
+	return resolveTierProfile(ctx, uc.profiles, tier)
+}
+
+// History returns the revisions of a tier, newest first.
+func (uc *TierProfileUseCase) History(ctx context.Context, tier instance.Tier, limit int) ([]*tierprofile.Profile, error) {
+	if err := tierprofile.ValidateTier(tier); err != nil {
+		return nil, err
+	}
+	return uc.profiles.History(ctx, tier, limit)
+}
+
+// Save validates the spec and appends it as the next revision of the tier.
+// Instances pick it up on their next deploy or through Redeploy.
+func (uc *TierProfileUseCase) Save(ctx context.Context, params SaveTierProfileParams) (*tierprofile.Profile, error) {
+	current, err := uc.Get(ctx, params.Tier)
+	if err != nil {
+		return nil, err
+	}
+	if params.BaseRevision != current.Revision {
+		return nil, tierprofile.ErrRevisionConflict
+	}
+
+	profile := &tierprofile.Profile{
+		Tier:      params.Tier,
+		Revision:  current.Revision + 1,
+		Spec:      params.Spec,
+		Comment:   params.Comment,
+		CreatedBy: string(instance.ActorFromContext(ctx).Type),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.profiles.Create(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Redeploy enqueues a deploy for every serving instance of the tier that was
+// deployed with another revision than the current one. The deploy rolls the
+// new profile out as a blue/green upgrade. Instances with a deploy already
+// queued pick the profile up from that deploy.
+func (uc *TierProfileUseCase) Redeploy(ctx context.Context, tier instance.Tier) (int64, error) {
+	current, err := uc.Get(ctx, tier)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	insert := uc.db.WithContext(ctx).Exec(
+		`INSERT INTO outbox_events (event_type, org_id, instance_id, status, attempts, created_at, updated_at)
+		 SELECT ?, i.org_id, i.id, ?, 0, ?, ?
+		 FROM instances i
+		 WHERE i.tier = ?
+		   AND i.status IN (?, ?)
+		   AND i.tier_profile_revision <> ?
+		   AND NOT EXISTS (
+		     SELECT 1 FROM outbox_events e
+		     WHERE e.instance_id = i.id
+		       AND e.event_type = ?
+		       AND e.status IN (?, ?)
+		   )`,
+		outboxevent.TypeDeployInstance,
+		outboxevent.StatusPending,
+		now,
+		now,
+		tier,
+		instance.StatusActive,
+		instance.StatusRunning,
+		current.Revision,
+		outboxevent.TypeDeployInstance,
+		outboxevent.StatusPending,
+		outboxevent.StatusProcessing,
+	)
+	if insert.Error != nil {
+		return 0, insert.Error
+	}
+	return insert.RowsAffected, nil
+}
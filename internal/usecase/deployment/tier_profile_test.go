@@ -0,0 +1,65 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/internal/adapter/repository/postgres"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/tierprofile"
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTierProfileUseCase_SaveAppendsRevision(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := instance.WithActor(context.Background(), instance.Actor{Type: instance.ActorAdmin})
+	uc := NewTierProfileUseCase(conn, postgres.NewTierProfileRepository(conn))
+
+	spec := nomad.DefaultTierProfile(nomad.TierStarter)
+	spec.Resources.MemoryMB = 1536
+
+	saved, err := uc.Save(ctx, SaveTierProfileParams{Tier: instance.TierStarter, Spec: spec, BaseRevision: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, saved.Revision)
+	assert.Equal(t, "admin", saved.CreatedBy)
+
+	current, err := uc.Get(ctx, instance.TierStarter)
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.Revision)
+	assert.Equal(t, 1536, current.Spec.Resources.MemoryMB)
+
+	// An edit based on the replaced revision loses.
+	_, err = uc.Save(ctx, SaveTierProfileParams{Tier: instance.TierStarter, Spec: spec, BaseRevision: 1})
+	assert.ErrorIs(t, err, tierprofile.ErrRevisionConflict)
+
+	delete(spec.Quotas, "QUOTA_ORG_SUBSCRIPTION")
+	_, err = uc.Save(ctx, SaveTierProfileParams{Tier: instance.TierStarter, Spec: spec, BaseRevision: 2})
+	assert.ErrorIs(t, err, tierprofile.ErrInvalidProfile)
+
+	_, err = uc.Save(ctx, SaveTierProfileParams{Tier: "GOLD", Spec: spec})
+	assert.ErrorIs(t, err, tierprofile.ErrUnknownTier)
+}
+
+func TestTierProfileUseCase_RedeployOutdatedInstances(t *testing.T) {
+	conn := testhelper.SetupMigratedPostgres(t)
+	ctx := context.Background()
+	uc := NewTierProfileUseCase(conn, postgres.NewTierProfileRepository(conn))
+
+	outdated := seedServingInstance(t, conn, seedOrg(t, conn, "outdated"), "v1.0.0")
+	current := seedServingInstance(t, conn, seedOrg(t, conn, "current"), "v1.0.0")
+	require.NoError(t, conn.Model(&instance.Instance{}).Where("id = ?", current.ID).Update("tier_profile_revision", 1).Error)
+	queued := seedServingInstance(t, conn, seedOrg(t, conn, "queued"), "v1.0.0")
+	seedDeployEvent(t, conn, queued, outboxevent.StatusPending, 0)
+
+	enqueued, err := uc.Redeploy(ctx, instance.TierStarter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), enqueued)
+
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, outdated.ID, outboxevent.StatusPending))
+	assert.Equal(t, int64(0), countDeployEvents(t, conn, current.ID, outboxevent.StatusPending))
+	assert.Equal(t, int64(1), countDeployEvents(t, conn, queued.ID, outboxevent.StatusPending))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/railzwaylabs/railzway-cloud/internal/config"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/billing"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/instance"
+	"github.com/railzwaylabs/railzway-cloud/internal/domain/outboxevent"
 	"github.com/railzwaylabs/railzway-cloud/internal/domain/provisioning"
 	"github.com/railzwaylabs/railzway-cloud/internal/organization"
 )
@@ -14,20 +15,26 @@ import (
 type UpgradeUseCase struct {
 	repo          instance.Repository
 	provisioner   provisioning.Provisioner
+	profiles      *ProfileResolver
 	billingEngine billing.Engine
 	priceResolver billing.PriceResolver
 	orgService    *organization.Service
+	runtimeCfg    RuntimeConfig
 	cfg           *config.Config
+	blueGreen     *BlueGreenUseCase
 }
 
-func NewUpgradeUseCase(r instance.Repository, p provisioning.Provisioner, b billing.Engine, pr billing.PriceResolver, orgService *organization.Service, cfg *config.Config) *UpgradeUseCase {
+func NewUpgradeUseCase(r instance.Repository, p provisioning.Provisioner, profiles *ProfileResolver, b billing.Engine, pr billing.PriceResolver, orgService *organization.Service, runtimeCfg RuntimeConfig, cfg *config.Config, blueGreen *BlueGreenUseCase) *UpgradeUseCase {
 	return &UpgradeUseCase{
 		repo:          r,
 		provisioner:   p,
+		profiles:      profiles,
 		billingEngine: b,
 		priceResolver: pr,
 		orgService:    orgService,
+		runtimeCfg:    runtimeCfg,
 		cfg:           cfg,
+		blueGreen:     blueGreen,
 	}
 }
 
@@ -39,7 +46,20 @@ func (uc *UpgradeUseCase) Upgrade(ctx context.Context, orgID int64, targetTier i
 	if inst == nil {
 		return fmt.Errorf("instance not found")
 	}
+	return uc.upgrade(ctx, 0, inst, targetTier)
+}
 
+// UpgradeInstance changes the tier of a single instance of an organization for
+// the upgrade_instance outbox event eventID.
+func (uc *UpgradeUseCase) UpgradeInstance(ctx context.Context, eventID, instanceID int64, targetTier instance.Tier) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	return uc.upgrade(ctx, eventID, inst, targetTier)
+}
+
+func (uc *UpgradeUseCase) upgrade(ctx context.Context, eventID int64, inst *instance.Instance, targetTier instance.Tier) error {
 	if inst.Tier == targetTier {
 		return fmt.Errorf("already on tier %s", targetTier)
 	}
@@ -55,29 +75,27 @@ func (uc *UpgradeUseCase) Upgrade(ctx context.Context, orgID int64, targetTier i
 	}
 
 	// 2. Deploy Infra
+	// A serving instance keeps taking traffic on the old tier until the
+	// standby with the new resources is ready. The plan is changed when
+	// traffic switches, so a rolled back upgrade is never billed.
+	if inst.IsServing() {
+		if _, err := uc.blueGreen.Start(ctx, inst, inst.DesiredVersion, targetTier); err != nil {
+			return fmt.Errorf("failed to upgrade infra: %w", err)
+		}
+		return saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+			i.MarkUpgrading(targetTier)
+		})
+	}
+
 	org, err := uc.orgService.GetSlug(ctx, inst.OrgID)
 	if err != nil {
 		return fmt.Errorf("failed to resolve org slug: %w", err)
 	}
-
-	paymentSecret, err := resolvePaymentProviderSecret(uc.cfg, inst)
+	deployCfg, err := buildDeploymentConfig(ctx, uc.cfg, uc.runtimeCfg, uc.profiles, org, inst, inst.DesiredVersion, targetTier)
 	if err != nil {
 		return err
 	}
-
-	deployCfg := provisioning.DeploymentConfig{
-		OrgID:                       org.ID,
-		OrgSlug:                     org.Slug,
-		OrgName:                     org.Name,
-		Version:                     inst.DesiredVersion,
-		Tier:                        targetTier,
-		ComputeEngine:               inst.ComputeEngine,
-		OAuth2URI:                   uc.cfg.OAuth2URI,
-		OAuth2ClientID:              coalesce(inst.OAuthClientID, uc.cfg.TenantOAuth2ClientID),
-		OAuth2ClientSecret:          coalesce(inst.OAuthClientSecret, uc.cfg.TenantOAuth2ClientSecret),
-		PaymentProviderConfigSecret: paymentSecret,
-	}
-	if err := uc.provisioner.Deploy(ctx, &deployCfg); err != nil {
+	if err := uc.provisioner.Deploy(ctx, deployCfg); err != nil {
 		return fmt.Errorf("failed to upgrade infra: %w", err)
 	}
 
@@ -88,6 +106,7 @@ func (uc *UpgradeUseCase) Upgrade(ctx context.Context, orgID int64, targetTier i
 			NewPriceID:        priceID,
 			ProrationBehavior: billing.CreateProration,
 			EffectiveDate:     "immediate",
+			IdempotencyKey:    outboxevent.IdempotencyKey(eventID, "change-plan:"+priceID),
 		}
 		if err := uc.billingEngine.ChangePlan(ctx, params); err != nil {
 			return fmt.Errorf("infra upgraded but billing failed: %w", err)
@@ -95,8 +114,11 @@ func (uc *UpgradeUseCase) Upgrade(ctx context.Context, orgID int64, targetTier i
 	}
 
 	// 4. Update State
-	inst.MarkUpgrading(targetTier)
-	return uc.repo.Save(ctx, inst)
+	return saveInstance(ctx, uc.repo, inst, func(i *instance.Instance) {
+		i.MarkUpgrading(targetTier)
+		i.TierProfileRevision = deployCfg.TierProfileRevision
+		i.EntitlementsHash = deployCfg.EntitlementsHash
+	})
 }
 
 func (uc *UpgradeUseCase) Downgrade(ctx context.Context, orgID int64, targetTier instance.Tier) error {
@@ -107,7 +129,20 @@ func (uc *UpgradeUseCase) Downgrade(ctx context.Context, orgID int64, targetTier
 	if inst == nil {
 		return fmt.Errorf("instance not found")
 	}
+	return uc.downgrade(ctx, 0, inst, targetTier)
+}
+
+// DowngradeInstance changes the tier of a single instance of an organization for
+// the downgrade_instance outbox event eventID.
+func (uc *UpgradeUseCase) DowngradeInstance(ctx context.Context, eventID, instanceID int64, targetTier instance.Tier) error {
+	inst, err := findInstance(ctx, uc.repo, instanceID)
+	if err != nil {
+		return err
+	}
+	return uc.downgrade(ctx, eventID, inst, targetTier)
+}
 
+func (uc *UpgradeUseCase) downgrade(ctx context.Context, eventID int64, inst *instance.Instance, targetTier instance.Tier) error {
 	if inst.Tier == targetTier {
 		return fmt.Errorf("already on tier %s", targetTier)
 	}
@@ -129,6 +164,7 @@ func (uc *UpgradeUseCase) Downgrade(ctx context.Context, orgID int64, targetTier
 			NewPriceID:        priceID,
 			ProrationBehavior: billing.None,
 			CancelAtPeriodEnd: true,
+			IdempotencyKey:    outboxevent.IdempotencyKey(eventID, "change-plan:"+priceID),
 		}
 		if err := uc.billingEngine.ChangePlan(ctx, params); err != nil {
 			return fmt.Errorf("failed to schedule billing change: %w", err)
@@ -136,6 +172,5 @@ func (uc *UpgradeUseCase) Downgrade(ctx context.Context, orgID int64, targetTier
 	}
 
 	// 3. Update State
-	inst.ScheduleDowngrade()
-	return uc.repo.Save(ctx, inst)
+	return saveInstance(ctx, uc.repo, inst, (*instance.Instance).ScheduleDowngrade)
 }
@@ -2,6 +2,7 @@ package version
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -20,6 +21,9 @@ type ApplicationVersion struct {
 	ChangelogURL    *string   `gorm:"type:text"`
 	ReleaseNotes    *string   `gorm:"type:text"`
 	BreakingChanges bool      `gorm:"default:false"`
+	Suspect         bool      `gorm:"default:false"` // Flagged by automatic rollbacks
+	SuspectReason   *string   `gorm:"type:text"`
+	SuspectedAt     *time.Time
 	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 	UpdatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 }
@@ -38,6 +42,8 @@ const (
 	StatusEOL        = "eol"
 )
 
+var ErrVersionNotFound = errors.New("version not found")
+
 // Registry manages application versions.
 type Registry struct {
 	db *gorm.DB
@@ -189,6 +195,81 @@ func (r *Registry) UpdateVersionStatus(ctx context.Context, appName, version, st
 	return nil
 }
 
+// MarkSuspect flags a version that made several instances unhealthy.
+// Suspect versions are refused as rollout targets until cleared.
+func (r *Registry) MarkSuspect(ctx context.Context, appName, version, reason string) error {
+	now := time.Now().UTC()
+	err := r.db.WithContext(ctx).
+		Model(&ApplicationVersion{}).
+		Where("application_name = ? AND version = ? AND suspect = ?", appName, version, false).
+		Updates(map[string]any{
+			"suspect":        true,
+			"suspect_reason": reason,
+			"suspected_at":   now,
+			"updated_at":     now,
+		}).Error
+
+	if err != nil {
+		return fmt.Errorf("failed to mark version suspect: %w", err)
+	}
+
+	return nil
+}
+
+// ClearSuspect removes the suspect flag from a version so it can be rolled
+// out again. It returns ErrVersionNotFound for unknown versions.
+func (r *Registry) ClearSuspect(ctx context.Context, appName, version string) error {
+	result := r.db.WithContext(ctx).
+		Model(&ApplicationVersion{}).
+		Where("application_name = ? AND version = ?", appName, version).
+		Updates(map[string]any{
+			"suspect":        false,
+			"suspect_reason": nil,
+			"suspected_at":   nil,
+			"updated_at":     time.Now().UTC(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to clear version suspect: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+	}
+
+	return nil
+}
+
+// ListSuspect returns the suspect versions of an app, most recently flagged first.
+func (r *Registry) ListSuspect(ctx context.Context, appName string) ([]ApplicationVersion, error) {
+	var versions []ApplicationVersion
+
+	err := r.db.WithContext(ctx).
+		Where("application_name = ? AND suspect = ?", appName, true).
+		Order("suspected_at DESC").
+		Find(&versions).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspect versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// IsSuspect reports whether a version has been flagged as suspect.
+func (r *Registry) IsSuspect(ctx context.Context, appName, version string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&ApplicationVersion{}).
+		Where("application_name = ? AND version = ? AND suspect = ?", appName, version, true).
+		Count(&count).Error
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
 // GetVersionStats returns statistics about version usage across instances for a specific app.
 func (r *Registry) GetVersionStats(ctx context.Context, appName string) (map[string]int64, error) {
 	// Note: Currently instances table doesn't have application_name column.
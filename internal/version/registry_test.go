@@ -124,6 +124,23 @@ func TestRegistry_Integration(t *testing.T) {
 		}
 		assert.True(t, found, "STARTER tier should see STARTER version")
 	})
+
+	t.Run("SuspectVersions", func(t *testing.T) {
+		require.NoError(t, reg.MarkSuspect(ctx, "railzway", "v1.1.0", "3 instances rolled back"))
+
+		suspect, err := reg.ListSuspect(ctx, "railzway")
+		require.NoError(t, err)
+		require.Len(t, suspect, 1)
+		assert.Equal(t, "v1.1.0", suspect[0].Version)
+
+		require.NoError(t, reg.ClearSuspect(ctx, "railzway", "v1.1.0"))
+		isSuspect, err := reg.IsSuspect(ctx, "railzway", "v1.1.0")
+		require.NoError(t, err)
+		assert.False(t, isSuspect)
+
+		err = reg.ClearSuspect(ctx, "railzway", "v9.9.9")
+		assert.ErrorIs(t, err, version.ErrVersionNotFound)
+	})
 }
 
 func stringPtr(s string) *string {
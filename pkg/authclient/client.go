@@ -114,6 +114,74 @@ func (c *Client) EnsureOAuthClient(ctx context.Context, req EnsureOAuthClientReq
 	return &out, nil
 }
 
+type RotateOAuthClientSecretRequest struct {
+	ClientID    string
+	GracePeriod time.Duration // How long the previous secret keeps working
+}
+
+type rotateOAuthClientSecretPayload struct {
+	PreviousSecretTTLSeconds int64 `json:"previous_secret_ttl_seconds"`
+}
+
+// RotateOAuthClientSecret issues a new secret for an OAuth client. The auth
+// service keeps accepting the previous secret for the grace period.
+func (c *Client) RotateOAuthClientSecret(ctx context.Context, req RotateOAuthClientSecretRequest) (*OAuthClient, error) {
+	if c == nil {
+		return nil, fmt.Errorf("auth client not configured")
+	}
+	base := strings.TrimRight(c.cfg.BaseURL, "/")
+	if base == "" {
+		return nil, fmt.Errorf("auth service url missing")
+	}
+	if strings.TrimSpace(c.cfg.TenantSlug) == "" {
+		return nil, fmt.Errorf("auth service tenant missing")
+	}
+	if strings.TrimSpace(req.ClientID) == "" {
+		return nil, fmt.Errorf("oauth client id missing")
+	}
+
+	body, err := json.Marshal(rotateOAuthClientSecretPayload{
+		PreviousSecretTTLSeconds: int64(req.GracePeriod / time.Second),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode rotate secret payload: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/admin/oauth/clients/%s/rotate-secret", base, url.PathEscape(req.ClientID))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build rotate secret request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Tenant-ID", c.cfg.TenantSlug)
+
+	token, err := c.clientCredentialsToken(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("rotate secret request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("auth client error (%d): %s", resp.StatusCode, string(raw))
+	}
+
+	var out OAuthClient
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode rotate secret response: %w", err)
+	}
+	if strings.TrimSpace(out.ClientSecret) == "" {
+		return nil, fmt.Errorf("rotate secret response missing client_secret")
+	}
+	return &out, nil
+}
+
 type tokenResponse struct {
 	AccessToken      string `json:"access_token"`
 	TokenType        string `json:"token_type"`
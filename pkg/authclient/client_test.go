@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -268,6 +269,60 @@ func TestClient_EnsureOAuthClient_MissingClientID(t *testing.T) {
 	assert.Contains(t, err.Error(), "auth client response missing client_id")
 }
 
+func TestClient_RotateOAuthClientSecret_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			json.NewEncoder(w).Encode(tokenResponse{
+				AccessToken: "test-token",
+			})
+		case "/admin/oauth/clients/tenant-client/rotate-secret":
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "test-tenant", r.Header.Get("X-Tenant-ID"))
+			assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+			var payload rotateOAuthClientSecretPayload
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			assert.Equal(t, int64(3600), payload.PreviousSecretTTLSeconds)
+
+			json.NewEncoder(w).Encode(OAuthClient{
+				ClientID:     "tenant-client",
+				ClientSecret: "rotated-secret",
+			})
+		default:
+			t.Fatalf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		BaseURL:      server.URL,
+		TenantSlug:   "test-tenant",
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+	})
+
+	result, err := client.RotateOAuthClientSecret(context.Background(), RotateOAuthClientSecretRequest{
+		ClientID:    "tenant-client",
+		GracePeriod: time.Hour,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-secret", result.ClientSecret)
+}
+
+func TestClient_RotateOAuthClientSecret_MissingClientID(t *testing.T) {
+	client := New(Config{
+		BaseURL:    "http://localhost",
+		TenantSlug: "test-tenant",
+	})
+
+	_, err := client.RotateOAuthClientSecret(context.Background(), RotateOAuthClientSecretRequest{})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "oauth client id missing")
+}
+
 func TestClient_ClientCredentialsToken_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "/token", r.URL.Path)
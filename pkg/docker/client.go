@@ -0,0 +1,157 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+// Labels set on every tenant container.
+const (
+	LabelManagedBy = "railzway.com/managed-by"
+	LabelOrgID     = "railzway.com/org-id"
+)
+
+// Workload statuses, matching the vocabulary of the Nomad client.
+const (
+	StatusRunning  = "running"
+	StatusPending  = "pending"
+	StatusFailed   = "failed"
+	StatusNotFound = "not_found"
+)
+
+const containerPort = 8080
+
+// Options configures how tenant containers are run locally.
+type Options struct {
+	Network    string   // Docker network shared with Traefik, if any
+	ExtraHosts []string // e.g. host.docker.internal:host-gateway
+	// DBHost replaces the tenant database host inside containers, since
+	// localhost on the control plane is not reachable from a container.
+	DBHost string
+	// Host ports published for the tenant HTTP port are taken from this range.
+	PortRangeStart int
+	PortRangeEnd   int
+}
+
+// Client runs tenant workloads as single containers, for development and CI.
+type Client struct {
+	runtime Runtime
+	opts    Options
+}
+
+func NewClient(runtime Runtime, opts Options) *Client {
+	if opts.PortRangeStart <= 0 {
+		opts.PortRangeStart = 20000
+	}
+	if opts.PortRangeEnd < opts.PortRangeStart {
+		opts.PortRangeEnd = opts.PortRangeStart + 999
+	}
+	return &Client{runtime: runtime, opts: opts}
+}
+
+// DeployInstance (re)creates the container of a workload with the tier env of
+// the Nomad job. A redeploy keeps the host port of the previous container.
+func (c *Client) DeployInstance(ctx context.Context, cfg nomad.JobConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid job config: %w", err)
+	}
+	if c.opts.DBHost != "" {
+		cfg.DBConfig.Host = c.opts.DBHost
+	}
+
+	name := cfg.JobName()
+	hostPort, err := c.hostPort(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]string{
+		LabelManagedBy: "railzway-cloud",
+		LabelOrgID:     strconv.FormatInt(cfg.OrgID, 10),
+	}
+	for _, tag := range nomad.RouterTags(cfg) {
+		key, value, _ := strings.Cut(tag, "=")
+		labels[key] = value
+	}
+
+	return c.runtime.Run(ctx, ContainerSpec{
+		Name:       name,
+		Image:      nomad.Image(cfg.Version),
+		Env:        nomad.EnvVars(cfg),
+		Labels:     labels,
+		Ports:      []PortBinding{{HostPort: hostPort, ContainerPort: containerPort}},
+		Network:    c.opts.Network,
+		ExtraHosts: c.opts.ExtraHosts,
+	})
+}
+
+// StopInstance removes the container of a workload.
+func (c *Client) StopInstance(ctx context.Context, name string) error {
+	return c.runtime.Remove(ctx, name)
+}
+
+// GetInstanceStatus maps the container state onto the provisioner vocabulary.
+func (c *Client) GetInstanceStatus(ctx context.Context, name string) (string, error) {
+	state, err := c.runtime.Inspect(ctx, name)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return StatusNotFound, nil
+		}
+		return "", err
+	}
+
+	switch state.State {
+	case StateRunning:
+		return StatusRunning, nil
+	case StateExited, StateDead:
+		return StatusFailed, nil
+	default:
+		return StatusPending, nil
+	}
+}
+
+// HostPort returns the host port published for a workload's HTTP port.
+func (c *Client) HostPort(ctx context.Context, name string) (int, error) {
+	state, err := c.runtime.Inspect(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range state.Ports {
+		if p.ContainerPort == containerPort {
+			return p.HostPort, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+// hostPort reuses the port of an existing container, or picks the lowest port
+// of the range not published by another tenant container.
+func (c *Client) hostPort(ctx context.Context, name string) (int, error) {
+	if port, err := c.HostPort(ctx, name); err == nil {
+		return port, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return 0, err
+	}
+
+	containers, err := c.runtime.List(ctx, LabelManagedBy)
+	if err != nil {
+		return 0, err
+	}
+	used := make(map[int]bool)
+	for _, ct := range containers {
+		for _, p := range ct.Ports {
+			used[p.HostPort] = true
+		}
+	}
+	for port := c.opts.PortRangeStart; port <= c.opts.PortRangeEnd; port++ {
+		if !used[port] {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free host port in %d-%d", c.opts.PortRangeStart, c.opts.PortRangeEnd)
+}
@@ -0,0 +1,179 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+// EngineRuntime runs containers through the Docker Engine API.
+type EngineRuntime struct {
+	client *dockerclient.Client
+}
+
+// NewEngineRuntime connects to the engine configured by DOCKER_HOST and
+// friends, defaulting to the local socket.
+func NewEngineRuntime() (*EngineRuntime, error) {
+	c, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	return &EngineRuntime{client: c}, nil
+}
+
+func (e *EngineRuntime) Run(ctx context.Context, spec ContainerSpec) error {
+	if err := e.Remove(ctx, spec.Name); err != nil {
+		return err
+	}
+
+	cfg := &container.Config{
+		Image:        spec.Image,
+		Env:          envList(spec.Env),
+		Labels:       spec.Labels,
+		ExposedPorts: nat.PortSet{},
+	}
+	hostCfg := &container.HostConfig{
+		PortBindings:  nat.PortMap{},
+		ExtraHosts:    spec.ExtraHosts,
+		RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 3},
+	}
+	for _, p := range spec.Ports {
+		port := nat.Port(fmt.Sprintf("%d/tcp", p.ContainerPort))
+		cfg.ExposedPorts[port] = struct{}{}
+		hostCfg.PortBindings[port] = []nat.PortBinding{{HostPort: strconv.Itoa(p.HostPort)}}
+	}
+	if spec.Network != "" {
+		hostCfg.NetworkMode = container.NetworkMode(spec.Network)
+	}
+
+	created, err := e.client.ContainerCreate(ctx, cfg, hostCfg, nil, nil, spec.Name)
+	if dockerclient.IsErrNotFound(err) {
+		// The image is not present locally yet.
+		if err := e.pull(ctx, spec.Image); err != nil {
+			return err
+		}
+		created, err = e.client.ContainerCreate(ctx, cfg, hostCfg, nil, nil, spec.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("create container: %w", err)
+	}
+
+	if err := e.client.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("start container: %w", err)
+	}
+	return nil
+}
+
+func (e *EngineRuntime) Remove(ctx context.Context, name string) error {
+	err := e.client.ContainerRemove(ctx, name, container.RemoveOptions{Force: true})
+	if err != nil && !dockerclient.IsErrNotFound(err) {
+		return fmt.Errorf("remove container: %w", err)
+	}
+	return nil
+}
+
+func (e *EngineRuntime) Inspect(ctx context.Context, name string) (*ContainerState, error) {
+	resp, err := e.client.ContainerInspect(ctx, name)
+	if err != nil {
+		if dockerclient.IsErrNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	state := &ContainerState{Name: strings.TrimPrefix(resp.Name, "/")}
+	if resp.State != nil {
+		state.State = resp.State.Status
+		state.ExitCode = resp.State.ExitCode
+	}
+	if resp.Config != nil {
+		state.Image = resp.Config.Image
+		state.Env = envMap(resp.Config.Env)
+		state.Labels = resp.Config.Labels
+	}
+	if resp.HostConfig != nil {
+		state.Ports = portBindings(resp.HostConfig.PortBindings)
+	}
+	return state, nil
+}
+
+func (e *EngineRuntime) List(ctx context.Context, label string) ([]ContainerState, error) {
+	items, err := e.client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", label)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ContainerState, 0, len(items))
+	for _, item := range items {
+		state := ContainerState{
+			Image:  item.Image,
+			State:  string(item.State),
+			Labels: item.Labels,
+		}
+		if len(item.Names) > 0 {
+			state.Name = strings.TrimPrefix(item.Names[0], "/")
+		}
+		for _, p := range item.Ports {
+			if p.PublicPort != 0 {
+				state.Ports = append(state.Ports, PortBinding{HostPort: int(p.PublicPort), ContainerPort: int(p.PrivatePort)})
+			}
+		}
+		out = append(out, state)
+	}
+	return out, nil
+}
+
+func (e *EngineRuntime) pull(ctx context.Context, ref string) error {
+	rc, err := e.client.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("pull image %s: %w", ref, err)
+	}
+	defer rc.Close()
+	// The pull only completes once the progress stream is drained.
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
+
+func envList(env map[string]string) []string {
+	out := make([]string, 0, len(env))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		out = append(out, key+"="+env[key])
+	}
+	return out
+}
+
+func envMap(env []string) map[string]string {
+	out := make(map[string]string, len(env))
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		out[key] = value
+	}
+	return out
+}
+
+func portBindings(ports nat.PortMap) []PortBinding {
+	var out []PortBinding
+	for port, bindings := range ports {
+		for _, b := range bindings {
+			hostPort, err := strconv.Atoi(b.HostPort)
+			if err != nil {
+				continue
+			}
+			out = append(out, PortBinding{HostPort: hostPort, ContainerPort: port.Int()})
+		}
+	}
+	return out
+}
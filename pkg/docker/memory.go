@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// MemoryRuntime is an in-memory Runtime. Containers start in StateRunning;
+// tests move them to other states with SetState.
+type MemoryRuntime struct {
+	mu         sync.Mutex
+	containers map[string]*ContainerState
+}
+
+func NewMemoryRuntime() *MemoryRuntime {
+	return &MemoryRuntime{containers: make(map[string]*ContainerState)}
+}
+
+func (m *MemoryRuntime) Run(ctx context.Context, spec ContainerSpec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.containers[spec.Name] = &ContainerState{
+		Name:   spec.Name,
+		Image:  spec.Image,
+		State:  StateRunning,
+		Env:    maps.Clone(spec.Env),
+		Labels: maps.Clone(spec.Labels),
+		Ports:  slices.Clone(spec.Ports),
+	}
+	return nil
+}
+
+func (m *MemoryRuntime) Remove(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.containers, name)
+	return nil
+}
+
+func (m *MemoryRuntime) Inspect(ctx context.Context, name string) (*ContainerState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.containers[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	out := *c
+	return &out, nil
+}
+
+func (m *MemoryRuntime) List(ctx context.Context, label string) ([]ContainerState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []ContainerState
+	for _, name := range slices.Sorted(maps.Keys(m.containers)) {
+		c := m.containers[name]
+		if _, ok := c.Labels[label]; ok {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+// SetState changes the state of a container, e.g. to simulate a crash.
+func (m *MemoryRuntime) SetState(name string, state string, exitCode int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.containers[name]; ok {
+		c.State = state
+		c.ExitCode = exitCode
+	}
+}
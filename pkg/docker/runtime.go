@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by a Runtime when the named container does not exist.
+var ErrNotFound = errors.New("container not found")
+
+// Container states reported by a Runtime, as named by the Docker Engine API.
+const (
+	StateCreated    = "created"
+	StateRunning    = "running"
+	StateRestarting = "restarting"
+	StatePaused     = "paused"
+	StateExited     = "exited"
+	StateDead       = "dead"
+)
+
+// PortBinding publishes a container port on the host.
+type PortBinding struct {
+	HostPort      int
+	ContainerPort int
+}
+
+// ContainerSpec describes a container to run.
+type ContainerSpec struct {
+	Name       string
+	Image      string
+	Env        map[string]string
+	Labels     map[string]string
+	Ports      []PortBinding
+	Network    string   // Network to attach to; empty uses the default bridge
+	ExtraHosts []string // host:ip entries, e.g. host.docker.internal:host-gateway
+}
+
+// ContainerState is the observed state of a container.
+type ContainerState struct {
+	Name     string
+	Image    string
+	State    string
+	ExitCode int
+	Env      map[string]string
+	Labels   map[string]string
+	Ports    []PortBinding
+}
+
+// Runtime runs containers. EngineRuntime talks to a Docker Engine; MemoryRuntime
+// keeps containers in memory for tests.
+type Runtime interface {
+	// Run starts a container, replacing any existing container with the same name.
+	Run(ctx context.Context, spec ContainerSpec) error
+	// Remove force-removes a container. Missing containers are not an error.
+	Remove(ctx context.Context, name string) error
+	// Inspect returns the state of a container, or ErrNotFound.
+	Inspect(ctx context.Context, name string) (*ContainerState, error)
+	// List returns every container carrying the given label.
+	List(ctx context.Context, label string) ([]ContainerState, error)
+}
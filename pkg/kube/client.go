@@ -0,0 +1,193 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+type Client struct {
+	clientset kubernetes.Interface
+	namespace string
+	opts      Options
+}
+
+// NewClient wraps an existing clientset. Tests pass a fake clientset.
+func NewClient(clientset kubernetes.Interface, namespace string, opts Options) *Client {
+	return &Client{clientset: clientset, namespace: namespace, opts: opts}
+}
+
+// NewClientFromConfig connects using the kubeconfig at path, or the in-cluster
+// config when path is empty.
+func NewClientFromConfig(path string, namespace string, opts Options) (*Client, error) {
+	restCfg, err := clientcmd.BuildConfigFromFlags("", path)
+	if err != nil {
+		return nil, fmt.Errorf("load kubernetes config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(clientset, namespace, opts), nil
+}
+
+// DeployInstance creates or updates the Secret, Deployment, Service and Ingress of a workload.
+func (c *Client) DeployInstance(ctx context.Context, cfg nomad.JobConfig) error {
+	manifests, err := GenerateManifests(cfg, c.namespace, c.opts)
+	if err != nil {
+		return err
+	}
+
+	// The Secret goes first so new pods never start without their credentials.
+	if err := c.applySecret(ctx, manifests.Secret); err != nil {
+		return fmt.Errorf("apply secret: %w", err)
+	}
+	if err := c.applyDeployment(ctx, manifests.Deployment); err != nil {
+		return fmt.Errorf("apply deployment: %w", err)
+	}
+	if err := c.applyService(ctx, manifests.Service); err != nil {
+		return fmt.Errorf("apply service: %w", err)
+	}
+	if err := c.applyIngress(ctx, manifests.Ingress); err != nil {
+		return fmt.Errorf("apply ingress: %w", err)
+	}
+	return nil
+}
+
+// StopInstance deletes every resource of a workload. Missing resources are ignored.
+func (c *Client) StopInstance(ctx context.Context, name string) error {
+	deletes := []func() error{
+		func() error {
+			return c.clientset.NetworkingV1().Ingresses(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+		func() error {
+			return c.clientset.CoreV1().Services(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+		func() error {
+			return c.clientset.AppsV1().Deployments(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+		func() error {
+			return c.clientset.CoreV1().Secrets(c.namespace).Delete(ctx, secretName(name), metav1.DeleteOptions{})
+		},
+	}
+	for _, del := range deletes {
+		if err := del(); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetInstanceStatus reports the rollout state of a workload's Deployment.
+func (c *Client) GetInstanceStatus(ctx context.Context, name string) (string, error) {
+	deployment, err := c.clientset.AppsV1().Deployments(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return StatusNotFound, nil
+		}
+		return "", err
+	}
+	return deploymentStatus(deployment), nil
+}
+
+func deploymentStatus(d *appsv1.Deployment) string {
+	for _, cond := range d.Status.Conditions {
+		switch {
+		case cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded":
+			return StatusFailed
+		case cond.Type == appsv1.DeploymentReplicaFailure && cond.Status == corev1.ConditionTrue:
+			return StatusFailed
+		}
+	}
+
+	// The controller has not seen the latest spec yet.
+	if d.Status.ObservedGeneration < d.Generation {
+		return StatusPending
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.UpdatedReplicas >= replicas &&
+		d.Status.AvailableReplicas >= replicas &&
+		d.Status.Replicas == d.Status.UpdatedReplicas {
+		return StatusRunning
+	}
+	return StatusPending
+}
+
+func (c *Client) applySecret(ctx context.Context, desired *corev1.Secret) error {
+	secrets := c.clientset.CoreV1().Secrets(c.namespace)
+	existing, err := secrets.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	_, err = secrets.Update(ctx, desired, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *Client) applyDeployment(ctx context.Context, desired *appsv1.Deployment) error {
+	deployments := c.clientset.AppsV1().Deployments(c.namespace)
+	existing, err := deployments.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = deployments.Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	_, err = deployments.Update(ctx, desired, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *Client) applyService(ctx context.Context, desired *corev1.Service) error {
+	services := c.clientset.CoreV1().Services(c.namespace)
+	existing, err := services.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = services.Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// The cluster IP is immutable once allocated.
+	desired.ResourceVersion = existing.ResourceVersion
+	desired.Spec.ClusterIP = existing.Spec.ClusterIP
+	desired.Spec.ClusterIPs = existing.Spec.ClusterIPs
+	_, err = services.Update(ctx, desired, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *Client) applyIngress(ctx context.Context, desired *networkingv1.Ingress) error {
+	ingresses := c.clientset.NetworkingV1().Ingresses(c.namespace)
+	existing, err := ingresses.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = ingresses.Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	_, err = ingresses.Update(ctx, desired, metav1.UpdateOptions{})
+	return err
+}
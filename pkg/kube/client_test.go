@@ -0,0 +1,167 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testJobConfig() nomad.JobConfig {
+	return nomad.JobConfig{
+		OrgID:         42,
+		OrgSlug:       "acme",
+		Tier:          nomad.TierStarter,
+		ComputeEngine: nomad.EngineGCP,
+		Version:       "v1.0.0",
+		DBConfig:      nomad.DBConfig{Host: "db", Port: 5432, Name: "acme", User: "acme", Password: "old"},
+	}
+}
+
+func TestClient_DeployUpdatesAndStops(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewClientset()
+	client := NewClient(clientset, "tenants", Options{})
+
+	cfg := testJobConfig()
+	if err := client.DeployInstance(ctx, cfg); err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+
+	svc, err := clientset.CoreV1().Services("tenants").Get(ctx, "railzway-org-42", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get service: %v", err)
+	}
+	svc.Spec.ClusterIP = "10.0.0.7"
+	if _, err := clientset.CoreV1().Services("tenants").Update(ctx, svc, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update service: %v", err)
+	}
+
+	before, _ := clientset.AppsV1().Deployments("tenants").Get(ctx, "railzway-org-42", metav1.GetOptions{})
+
+	// Redeploying with a new password updates the secret and rolls the pods.
+	cfg.DBConfig.Password = "new"
+	cfg.Version = "v1.1.0"
+	if err := client.DeployInstance(ctx, cfg); err != nil {
+		t.Fatalf("redeploy: %v", err)
+	}
+
+	secret, err := clientset.CoreV1().Secrets("tenants").Get(ctx, "railzway-org-42-env", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if secret.StringData["DB_PASSWORD"] != "new" {
+		t.Errorf("expected updated password, got %s", secret.StringData["DB_PASSWORD"])
+	}
+
+	after, _ := clientset.AppsV1().Deployments("tenants").Get(ctx, "railzway-org-42", metav1.GetOptions{})
+	if after.Spec.Template.Spec.Containers[0].Image != "ghcr.io/smallbiznis/railzway:v1.1.0" {
+		t.Errorf("expected updated image, got %s", after.Spec.Template.Spec.Containers[0].Image)
+	}
+	if before.Spec.Template.Annotations[AnnotationConfigHash] == after.Spec.Template.Annotations[AnnotationConfigHash] {
+		t.Error("expected config hash to change with the secret")
+	}
+
+	svc, _ = clientset.CoreV1().Services("tenants").Get(ctx, "railzway-org-42", metav1.GetOptions{})
+	if svc.Spec.ClusterIP != "10.0.0.7" {
+		t.Errorf("expected cluster IP to be preserved, got %q", svc.Spec.ClusterIP)
+	}
+
+	if err := client.StopInstance(ctx, "railzway-org-42"); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	// Stopping twice is a no-op.
+	if err := client.StopInstance(ctx, "railzway-org-42"); err != nil {
+		t.Fatalf("second stop: %v", err)
+	}
+
+	status, err := client.GetInstanceStatus(ctx, "railzway-org-42")
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if status != StatusNotFound {
+		t.Errorf("expected not_found after stop, got %s", status)
+	}
+	if _, err := clientset.CoreV1().Secrets("tenants").Get(ctx, "railzway-org-42-env", metav1.GetOptions{}); err == nil {
+		t.Error("expected secret to be deleted")
+	}
+}
+
+func TestClient_GetInstanceStatus(t *testing.T) {
+	replicas := int32(1)
+	tests := []struct {
+		name   string
+		status appsv1.DeploymentStatus
+		gen    int64
+		want   string
+	}{
+		{
+			name: "rolling out",
+			gen:  2,
+			status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           2,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  1,
+			},
+			want: StatusPending,
+		},
+		{
+			name:   "not observed yet",
+			gen:    3,
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			want:   StatusPending,
+		},
+		{
+			name:   "available",
+			gen:    2,
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			want:   StatusRunning,
+		},
+		{
+			name: "progress deadline exceeded",
+			gen:  2,
+			status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+				},
+			},
+			want: StatusFailed,
+		},
+		{
+			name: "replica failure",
+			gen:  2,
+			status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentReplicaFailure, Status: corev1.ConditionTrue, Reason: "FailedCreate"},
+				},
+			},
+			want: StatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "railzway-org-42", Namespace: "tenants", Generation: tt.gen},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     tt.status,
+			}
+			client := NewClient(fake.NewClientset(deployment), "tenants", Options{})
+
+			got, err := client.GetInstanceStatus(context.Background(), "railzway-org-42")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
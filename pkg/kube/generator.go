@@ -0,0 +1,207 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	containerName = "railzway"
+	containerPort = 8080
+	servicePort   = 80
+)
+
+// Manifests are the resources making up one tenant workload.
+type Manifests struct {
+	Deployment *appsv1.Deployment
+	Service    *corev1.Service
+	Ingress    *networkingv1.Ingress
+	Secret     *corev1.Secret
+}
+
+// GenerateManifests renders the Kubernetes resources of a workload. Resources
+// and environment come from the same tier tables as the Nomad job, and the
+// workload name matches the Nomad job name.
+func GenerateManifests(cfg nomad.JobConfig, namespace string, opts Options) (*Manifests, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid job config: %w", err)
+	}
+
+	name := cfg.JobName()
+	labels := map[string]string{
+		LabelApp:       "railzway",
+		LabelInstance:  name,
+		LabelManagedBy: "railzway-cloud",
+		LabelOrgID:     strconv.FormatInt(cfg.OrgID, 10),
+	}
+	meta := func(resourceName string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      resourceName,
+			Namespace: namespace,
+			Labels:    maps.Clone(labels),
+		}
+	}
+
+	profile := cfg.TierProfile()
+	plainEnv, secretEnv := SplitSecretEnv(nomad.EnvVars(cfg))
+
+	secret := &corev1.Secret{
+		ObjectMeta: meta(secretName(name)),
+		Type:       corev1.SecretTypeOpaque,
+		StringData: secretEnv,
+	}
+
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(int64(profile.Resources.CPU), resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(int64(profile.Resources.MemoryMB)*1024*1024, resource.BinarySI),
+	}
+
+	podSpec := corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name:  containerName,
+				Image: nomad.Image(cfg.Version),
+				Ports: []corev1.ContainerPort{
+					{Name: "http", ContainerPort: containerPort},
+				},
+				Env: envList(plainEnv),
+				EnvFrom: []corev1.EnvFromSource{
+					{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name}}},
+				},
+				Resources: corev1.ResourceRequirements{
+					Requests: resources,
+					Limits:   resources,
+				},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{
+						HTTPGet: &corev1.HTTPGetAction{Path: "/health", Port: intstr.FromString("http")},
+					},
+					PeriodSeconds:  10,
+					TimeoutSeconds: 2,
+				},
+			},
+		},
+	}
+	// Skip placement in development to allow running on a local cluster.
+	if !nomad.SkipPlacement(cfg.Version) {
+		podSpec.NodeSelector = map[string]string{
+			NodeLabelTier:    profile.Placement.NodeTier,
+			NodeLabelCompute: string(cfg.ComputeEngine),
+		}
+		if profile.Placement.Isolation != "" {
+			podSpec.NodeSelector[NodeLabelIsolation] = profile.Placement.Isolation
+		}
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: meta(name),
+		Spec: appsv1.DeploymentSpec{
+			Replicas:                int32Ptr(1),
+			ProgressDeadlineSeconds: int32Ptr(600), // Same as the Nomad progress deadline
+			Selector:                &metav1.LabelSelector{MatchLabels: map[string]string{LabelInstance: name}},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      maps.Clone(labels),
+					Annotations: map[string]string{AnnotationConfigHash: configHash(secretEnv)},
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: meta(name),
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{LabelInstance: name},
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: servicePort, TargetPort: intstr.FromString("http")},
+			},
+		},
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: meta(name),
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{ingressRule(nomad.PublicHost(cfg.OrgSlug), name)},
+		},
+	}
+	if opts.IngressClass != "" {
+		ingress.Spec.IngressClassName = &opts.IngressClass
+	}
+	// A standby workload only receives requests carrying StandbyHeader with its name.
+	if cfg.Standby {
+		ingress.Annotations = map[string]string{
+			"nginx.ingress.kubernetes.io/canary":                 "true",
+			"nginx.ingress.kubernetes.io/canary-by-header":       nomad.StandbyHeader,
+			"nginx.ingress.kubernetes.io/canary-by-header-value": name,
+		}
+	}
+
+	return &Manifests{
+		Deployment: deployment,
+		Service:    service,
+		Ingress:    ingress,
+		Secret:     secret,
+	}, nil
+}
+
+func ingressRule(host string, serviceName string) networkingv1.IngressRule {
+	pathType := networkingv1.PathTypePrefix
+	return networkingv1.IngressRule{
+		Host: host,
+		IngressRuleValue: networkingv1.IngressRuleValue{
+			HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{
+					{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: serviceName,
+								Port: networkingv1.ServiceBackendPort{Number: servicePort},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// envList returns env vars sorted by name so unchanged configs render identical pod specs.
+func envList(env map[string]string) []corev1.EnvVar {
+	out := make([]corev1.EnvVar, 0, len(env))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		out = append(out, corev1.EnvVar{Name: key, Value: env[key]})
+	}
+	return out
+}
+
+func configHash(data map[string]string) string {
+	h := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		fmt.Fprintf(h, "%s=%s\n", key, data[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func secretName(name string) string {
+	return name + "-env"
+}
+
+func int32Ptr(i int32) *int32 { return &i }
@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+func TestGenerateManifests_TierResources(t *testing.T) {
+	cfg := nomad.JobConfig{
+		OrgID:         123,
+		OrgSlug:       "acme",
+		Tier:          nomad.TierFreeTrial,
+		ComputeEngine: nomad.EngineHetzner,
+		Version:       "v1.0.0",
+		DBConfig:      nomad.DBConfig{Host: "db", Port: 5432, Name: "acme", User: "acme", Password: "s3cret"},
+	}
+
+	m, err := GenerateManifests(cfg, "tenants", Options{IngressClass: "nginx"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.Deployment.Name != "railzway-org-123" || m.Deployment.Namespace != "tenants" {
+		t.Errorf("unexpected deployment %s/%s", m.Deployment.Namespace, m.Deployment.Name)
+	}
+
+	container := m.Deployment.Spec.Template.Spec.Containers[0]
+	if container.Image != "ghcr.io/smallbiznis/railzway:v1.0.0" {
+		t.Errorf("unexpected image %s", container.Image)
+	}
+	if got := container.Resources.Limits.Cpu().MilliValue(); got != 250 {
+		t.Errorf("expected 250m CPU, got %dm", got)
+	}
+	if got := container.Resources.Limits.Memory().Value(); got != 512*1024*1024 {
+		t.Errorf("expected 512Mi memory, got %d", got)
+	}
+
+	selector := m.Deployment.Spec.Template.Spec.NodeSelector
+	if selector[NodeLabelTier] != "free-trial" || selector[NodeLabelCompute] != "hetzner" {
+		t.Errorf("unexpected node selector %v", selector)
+	}
+
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if env["USAGE_INGEST_ORG_RATE"] != "5" {
+		t.Errorf("expected USAGE_INGEST_ORG_RATE 5, got %s", env["USAGE_INGEST_ORG_RATE"])
+	}
+	if env["QUOTA_ORG_USAGE_MONTHLY"] != "10000" {
+		t.Errorf("expected QUOTA_ORG_USAGE_MONTHLY 10000, got %s", env["QUOTA_ORG_USAGE_MONTHLY"])
+	}
+	if _, ok := env["DB_PASSWORD"]; ok {
+		t.Error("DB_PASSWORD must not be set on the pod spec")
+	}
+	if m.Secret.StringData["DB_PASSWORD"] != "s3cret" {
+		t.Errorf("expected DB_PASSWORD in secret, got %v", m.Secret.StringData)
+	}
+	if container.EnvFrom[0].SecretRef.Name != m.Secret.Name {
+		t.Errorf("expected container to load secret %s", m.Secret.Name)
+	}
+
+	rule := m.Ingress.Spec.Rules[0]
+	if rule.Host != "acme.railzway.com" {
+		t.Errorf("expected host acme.railzway.com, got %s", rule.Host)
+	}
+	if rule.HTTP.Paths[0].Backend.Service.Name != m.Service.Name {
+		t.Errorf("ingress must route to service %s", m.Service.Name)
+	}
+	if *m.Ingress.Spec.IngressClassName != "nginx" {
+		t.Errorf("expected ingress class nginx, got %s", *m.Ingress.Spec.IngressClassName)
+	}
+}
+
+func TestGenerateManifests_StandbyRouting(t *testing.T) {
+	cfg := nomad.JobConfig{
+		OrgID:         321,
+		OrgSlug:       "acme",
+		Tier:          nomad.TierStarter,
+		ComputeEngine: nomad.EngineHetzner,
+		Version:       "v1.1.0",
+		JobID:         "railzway-org-321-blue",
+		Standby:       true,
+	}
+
+	m, err := GenerateManifests(cfg, "tenants", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header"] != nomad.StandbyHeader {
+		t.Errorf("standby ingress must match on %s, got %v", nomad.StandbyHeader, m.Ingress.Annotations)
+	}
+	if m.Ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header-value"] != "railzway-org-321-blue" {
+		t.Errorf("unexpected header value %v", m.Ingress.Annotations)
+	}
+
+	cfg.Standby = false
+	m, err = GenerateManifests(cfg, "tenants", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Ingress.Annotations) != 0 {
+		t.Errorf("promoted workload must serve public traffic, got %v", m.Ingress.Annotations)
+	}
+}
+
+func TestGenerateManifests_Invalid(t *testing.T) {
+	_, err := GenerateManifests(nomad.JobConfig{OrgID: 1, Version: "v1"}, "tenants", Options{})
+	if err == nil {
+		t.Fatal("expected error for missing tier")
+	}
+}
@@ -0,0 +1,52 @@
+package kube
+
+import (
+	"slices"
+
+	"github.com/railzwaylabs/railzway-cloud/pkg/nomad"
+)
+
+// Workload statuses, matching the vocabulary of the Nomad client.
+const (
+	StatusRunning  = "running"
+	StatusPending  = "pending"
+	StatusFailed   = "failed"
+	StatusNotFound = "not_found"
+)
+
+// Labels and annotations set on every tenant resource.
+const (
+	LabelApp       = "app.kubernetes.io/name"
+	LabelInstance  = "app.kubernetes.io/instance"
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	LabelOrgID     = "railzway.com/org-id"
+
+	// Node labels used for tier and compute placement, like the Nomad node meta.
+	NodeLabelTier      = "railzway.com/tier"
+	NodeLabelCompute   = "railzway.com/compute"
+	NodeLabelIsolation = "railzway.com/isolation"
+
+	// AnnotationConfigHash changes whenever the Secret does, so pods roll on
+	// credential changes.
+	AnnotationConfigHash = "railzway.com/config-hash"
+)
+
+// Options holds cluster-wide settings applied to every generated manifest.
+type Options struct {
+	IngressClass string
+}
+
+// SplitSecretEnv separates credentials, injected from the workload Secret,
+// from plain environment variables.
+func SplitSecretEnv(env map[string]string) (plain map[string]string, secret map[string]string) {
+	plain = make(map[string]string, len(env))
+	secret = make(map[string]string)
+	for key, value := range env {
+		if slices.Contains(nomad.SecretEnvKeys, key) {
+			secret[key] = value
+			continue
+		}
+		plain[key] = value
+	}
+	return plain, secret
+}
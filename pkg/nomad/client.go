@@ -1,7 +1,6 @@
 package nomad
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/hashicorp/nomad/api"
@@ -31,14 +30,12 @@ func (c *Client) DeployInstance(cfg JobConfig) error {
 	return err
 }
 
-func (c *Client) StopInstance(orgID int64) error {
-	jobName := fmt.Sprintf("railzway-org-%d", orgID)
+func (c *Client) StopInstance(jobName string) error {
 	_, _, err := c.client.Jobs().Deregister(jobName, true, nil)
 	return err
 }
 
-func (c *Client) GetInstanceStatus(orgID int64) (string, error) {
-	jobName := fmt.Sprintf("railzway-org-%d", orgID)
+func (c *Client) GetInstanceStatus(jobName string) (string, error) {
 	allocs, _, err := c.client.Jobs().Allocations(jobName, false, nil)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
@@ -0,0 +1,118 @@
+package nomad
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Entitlements are the product entitlements from Railzway OSS product
+// metadata, keyed like "billing.customers.max".
+type Entitlements map[string]any
+
+// EntitlementIsolationLevel selects the nodes a workload is placed on.
+const EntitlementIsolationLevel = "infra.isolation_level"
+
+// Isolation levels a product may grant.
+const (
+	IsolationShared             = "shared"
+	IsolationDedicatedNamespace = "dedicated-namespace"
+	IsolationIsolated           = "isolated"
+)
+
+// entitlementQuotas maps numeric entitlements onto the quota env vars of the
+// tenant. Entitlements without an env var are not enforced by the tenant.
+var entitlementQuotas = map[string]string{
+	"billing.customers.max":        "QUOTA_ORG_CUSTOMER",
+	"billing.subscriptions.max":    "QUOTA_ORG_SUBSCRIPTION",
+	"billing.usage_events.monthly": "QUOTA_ORG_USAGE_MONTHLY",
+	"infra.retention_days":         "WEBHOOK_RETENTION_DAYS",
+}
+
+// Fingerprint identifies the entitlements so deploys can tell when they
+// changed. It is empty when there are none.
+func (e Entitlements) Fingerprint() string {
+	if len(e) == 0 {
+		return ""
+	}
+	// Map keys are marshalled in sorted order.
+	b, err := json.Marshal(map[string]any(e))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// WithEntitlements returns a copy of the profile with its quotas and
+// isolation level overridden by the product entitlements.
+func (p TierProfile) WithEntitlements(e Entitlements) (TierProfile, error) {
+	out := p
+	out.Quotas = maps.Clone(p.Quotas)
+
+	for _, key := range slices.Sorted(maps.Keys(entitlementQuotas)) {
+		value, ok := e[key]
+		if !ok {
+			continue
+		}
+		n, err := entitlementInt(value)
+		if err != nil {
+			return p, fmt.Errorf("entitlement %s: %w", key, err)
+		}
+		if out.Quotas == nil {
+			out.Quotas = make(map[string]string, len(entitlementQuotas))
+		}
+		out.Quotas[entitlementQuotas[key]] = strconv.FormatInt(n, 10)
+	}
+
+	if value, ok := e[EntitlementIsolationLevel]; ok {
+		level, ok := value.(string)
+		if !ok || !validIsolation(level) {
+			return p, fmt.Errorf("entitlement %s: unsupported isolation level %v", EntitlementIsolationLevel, value)
+		}
+		out.Placement.Isolation = level
+	}
+	return out, nil
+}
+
+// entitlementInt accepts JSON numbers and numeric strings. -1 means unlimited.
+func entitlementInt(value any) (int64, error) {
+	var n int64
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		n = int64(v)
+	case int:
+		n = int64(v)
+	case int64:
+		n = v
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer", v)
+		}
+		n = parsed
+	default:
+		return 0, fmt.Errorf("unsupported value %v", value)
+	}
+	if n < -1 {
+		return 0, fmt.Errorf("%d must be at least -1", n)
+	}
+	return n, nil
+}
+
+func validIsolation(level string) bool {
+	switch level {
+	case IsolationShared, IsolationDedicatedNamespace, IsolationIsolated:
+		return true
+	default:
+		return false
+	}
+}
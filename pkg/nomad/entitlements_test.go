@@ -0,0 +1,98 @@
+package nomad
+
+import "testing"
+
+func TestWithEntitlements_OverridesQuotasAndIsolation(t *testing.T) {
+	base := DefaultTierProfile(TierStarter)
+
+	profile, err := base.WithEntitlements(Entitlements{
+		"billing.customers.max":        float64(1000),
+		"billing.usage_events.monthly": "2000000",
+		"infra.retention_days":         float64(-1),
+		"infra.isolation_level":        IsolationDedicatedNamespace,
+		"support.level":                "community",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"QUOTA_ORG_CUSTOMER":      "1000",
+		"QUOTA_ORG_USAGE_MONTHLY": "2000000",
+		"WEBHOOK_RETENTION_DAYS":  "-1",
+		"QUOTA_ORG_SUBSCRIPTION":  base.Quotas["QUOTA_ORG_SUBSCRIPTION"],
+	}
+	for key, value := range want {
+		if profile.Quotas[key] != value {
+			t.Errorf("expected %s=%s, got %s", key, value, profile.Quotas[key])
+		}
+	}
+	if profile.Placement.Isolation != IsolationDedicatedNamespace {
+		t.Errorf("expected dedicated-namespace isolation, got %q", profile.Placement.Isolation)
+	}
+	if err := profile.Validate(); err != nil {
+		t.Errorf("expected valid profile, got %v", err)
+	}
+
+	// The base profile is not affected.
+	if base.Quotas["QUOTA_ORG_CUSTOMER"] == "1000" || base.Placement.Isolation != "" {
+		t.Error("expected base profile to be unchanged")
+	}
+}
+
+func TestWithEntitlements_InvalidValues(t *testing.T) {
+	for _, e := range []Entitlements{
+		{"billing.customers.max": 2.5},
+		{"billing.customers.max": "many"},
+		{"billing.subscriptions.max": float64(-2)},
+		{"infra.retention_days": true},
+		{"infra.isolation_level": "private"},
+		{"infra.isolation_level": float64(1)},
+	} {
+		if _, err := DefaultTierProfile(TierPro).WithEntitlements(e); err == nil {
+			t.Errorf("expected error for %v", e)
+		}
+	}
+}
+
+func TestEntitlementsFingerprint(t *testing.T) {
+	if fp := Entitlements(nil).Fingerprint(); fp != "" {
+		t.Errorf("expected empty fingerprint without entitlements, got %q", fp)
+	}
+
+	a := Entitlements{"billing.customers.max": float64(3), "infra.isolation_level": "shared"}
+	b := Entitlements{"infra.isolation_level": "shared", "billing.customers.max": float64(3)}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected fingerprint to ignore key order")
+	}
+	c := Entitlements{"billing.customers.max": float64(4), "infra.isolation_level": "shared"}
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Error("expected fingerprint to change with a value")
+	}
+}
+
+func TestGenerateJob_IsolationConstraint(t *testing.T) {
+	profile := DefaultTierProfile(TierPro)
+	profile.Placement.Isolation = IsolationIsolated
+
+	job, err := GenerateJob(JobConfig{
+		OrgID:         7,
+		Tier:          TierPro,
+		ComputeEngine: EngineHetzner,
+		Version:       "v1.0.0",
+		Profile:       &profile,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := false
+	for _, c := range job.TaskGroups[0].Constraints {
+		if c.LTarget == "${node.meta.isolation}" && c.RTarget == IsolationIsolated {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected isolation constraint")
+	}
+}
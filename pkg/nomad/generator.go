@@ -1,8 +1,12 @@
 package nomad
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,21 +20,24 @@ func GenerateJob(cfg JobConfig) (*api.Job, error) {
 		return nil, fmt.Errorf("invalid job config: %w", err)
 	}
 
-	jobName := fmt.Sprintf("railzway-org-%d", cfg.OrgID)
+	jobName := cfg.JobName()
 	jobType := "service"
 	region := "global"
 
-	// 1. Allocate Resources
-	cpu, memoryMB, priority, tierLabel := allocateResources(cfg.Tier)
+	// 1. Resolve the tier profile (resources, placement, update strategy, quotas)
+	profile := cfg.TierProfile()
+	cpu := profile.Resources.CPU
+	memoryMB := profile.Resources.MemoryMB
+	priority := profile.Resources.Priority
 
-	// 2. Get Quotas
-	quotaCfg := getTierQuotaConfig(cfg.Tier)
+	// 2. Determine Update Strategy
+	updateStanza := updateStrategy(profile.Update)
 
-	// 3. Determine Update Strategy
-	updateStanza := getUpdateStrategy(cfg.Tier)
-
-	// 4. Build Environment Variables
-	envVars := buildEnvVars(cfg, quotaCfg)
+	// 3. Build Environment Variables, without the credentials
+	envVars := buildEnvVars(cfg, profile.Quotas)
+	for _, key := range SecretEnvKeys {
+		delete(envVars, key)
+	}
 
 	// Task Group
 	taskGroup := &api.TaskGroup{
@@ -54,7 +61,7 @@ func GenerateJob(cfg JobConfig) (*api.Job, error) {
 		Constraints: []*api.Constraint{
 			{
 				LTarget: "${node.meta.tier}",
-				RTarget: tierLabel,
+				RTarget: profile.Placement.NodeTier,
 				Operand: "=",
 			},
 			{
@@ -65,8 +72,16 @@ func GenerateJob(cfg JobConfig) (*api.Job, error) {
 		},
 	}
 
+	if profile.Placement.Isolation != "" {
+		taskGroup.Constraints = append(taskGroup.Constraints, &api.Constraint{
+			LTarget: "${node.meta.isolation}",
+			RTarget: profile.Placement.Isolation,
+			Operand: "=",
+		})
+	}
+
 	// Skip constraints in development environment to allow running on local dev nomad agent
-	if cfg.Version == "development" || strings.ToLower(os.Getenv("APP_ENV")) == "development" {
+	if SkipPlacement(cfg.Version) {
 		taskGroup.Constraints = nil
 	}
 
@@ -75,7 +90,7 @@ func GenerateJob(cfg JobConfig) (*api.Job, error) {
 		Name:   "railzway",
 		Driver: "docker",
 		Config: map[string]interface{}{
-			"image": fmt.Sprintf("ghcr.io/smallbiznis/railzway:%s", cfg.Version),
+			"image": Image(cfg.Version),
 			"ports": []string{"http"},
 		},
 		Env: envVars,
@@ -85,54 +100,31 @@ func GenerateJob(cfg JobConfig) (*api.Job, error) {
 		},
 	}
 
-	consulPrefix := fmt.Sprintf("railzway-cloud/orgs/%d", cfg.OrgID)
-	consulTemplate := fmt.Sprintf(`{{ with key "%s/db/host" }}DB_HOST={{ . }}
-{{ end }}{{ with key "%s/db/port" }}DB_PORT={{ . }}
-{{ end }}{{ with key "%s/db/name" }}DB_NAME={{ . }}
-{{ end }}{{ with key "%s/db/user" }}DB_USER={{ . }}
-{{ end }}{{ with key "%s/db/password" }}DB_PASSWORD={{ . }}
-{{ end }}{{ with key "%s/oauth/client_id" }}OAUTH2_CLIENT_ID={{ . }}
-{{ end }}{{ with key "%s/oauth/client_secret" }}OAUTH2_CLIENT_SECRET={{ . }}
-{{ end }}{{ with key "%s/oauth/client_id" }}AUTH_RAILZWAY_COM_CLIENT_ID={{ . }}
-{{ end }}{{ with key "%s/oauth/client_secret" }}AUTH_RAILZWAY_COM_CLIENT_SECRET={{ . }}
-{{ end }}{{ with key "%s/payment_provider_config_secret" }}PAYMENT_PROVIDER_CONFIG_SECRET={{ . }}
-{{ end }}`,
-		consulPrefix,
-		consulPrefix,
-		consulPrefix,
-		consulPrefix,
-		consulPrefix,
-		consulPrefix,
-		consulPrefix,
-		consulPrefix,
-		consulPrefix,
-		consulPrefix,
-	)
-
-	task.Templates = []*api.Template{
-		{
-			EmbeddedTmpl: stringToPtr(consulTemplate),
-			DestPath:     stringToPtr("local/consul.env"),
-			Envvars:      boolToPtr(true),
-		},
+	// Credentials stay out of the job spec; a template reads them from the
+	// secret store when the allocation starts. Changing them in the store
+	// leaves running allocations alone: the deploy that wrote them changes
+	// MetaSecretsHash, so the job rolls out under its update strategy.
+	if cfg.SecretsTemplate != "" {
+		task.Templates = []*api.Template{
+			{
+				EmbeddedTmpl: stringToPtr(cfg.SecretsTemplate),
+				DestPath:     stringToPtr("secrets/tenant.env"),
+				Envvars:      boolToPtr(true),
+				ChangeMode:   stringToPtr("noop"),
+			},
+		}
+		task.Meta = map[string]string{MetaSecretsHash: secretsHash(SecretEnvVars(cfg))}
 	}
-
-	// Service (for Consul & Traefik)
-	host := "railzway.com"
-	if os.Getenv("APP_ROOT_DOMAIN") != "" {
-		host = strings.ToLower(os.Getenv("APP_ROOT_DOMAIN"))
+	if cfg.SecretsFromVault {
+		task.Vault = &api.Vault{ChangeMode: stringToPtr("noop")}
 	}
 
-	host = strings.TrimSpace(fmt.Sprintf("%s.%s", cfg.OrgSlug, host))
-	serviceName := fmt.Sprintf("railzway-org-%d", cfg.OrgID)
+	// Service (for Consul & Traefik)
+	host := PublicHost(cfg.OrgSlug)
 	service := &api.Service{
-		Name:      serviceName,
+		Name:      jobName,
 		PortLabel: "http",
-		Tags: []string{
-			"traefik.enable=true",
-			fmt.Sprintf("traefik.http.routers.org-%d.rule=Host(`%s`)", cfg.OrgID, host),
-			fmt.Sprintf("traefik.http.routers.org-%d.entrypoints=web", cfg.OrgID), // Assume 'web' is port 80
-		},
+		Tags:      routerTags(cfg, jobName, host),
 		Checks: []api.ServiceCheck{
 			{
 				Type:     "http",
@@ -160,180 +152,113 @@ func GenerateJob(cfg JobConfig) (*api.Job, error) {
 	return job, nil
 }
 
-// Helpers
-func intToPtr(i int) *int                      { return &i }
-func boolToPtr(b bool) *bool                   { return &b }
-func stringToPtr(s string) *string             { return &s }
-func timeToPtr(d time.Duration) *time.Duration { return &d }
+// StandbyHeader routes a request to a standby workload while it is warming up.
+// Its value is the job ID. Every provisioner matches on it.
+const StandbyHeader = "X-Railzway-Job"
 
-type tierQuotaConfig struct {
-	IngestOrgRate       string
-	IngestOrgBurst      string
-	IngestEndpointRate  string
-	IngestEndpointBurst string
-
-	QuotaOrgUser       string
-	QuotaOrgDashboard  string
-	QuotaOrgDataSource string
-	QuotaOrgAPIKey     string
-	QuotaUserOrg       string
-	QuotaUsageMonthly  string
-
-	// New Quotas
-	QuotaOrgCustomer     string
-	QuotaOrgSubscription string
-	WebhookRetentionDays string
+// RouterTags returns the Traefik tags of a job. Other provisioners reuse them
+// as container labels so the same router rules apply.
+func RouterTags(cfg JobConfig) []string {
+	return routerTags(cfg, cfg.JobName(), PublicHost(cfg.OrgSlug))
 }
 
-func getTierQuotaConfig(tier Tier) tierQuotaConfig {
-	switch tier {
-	case TierFreeTrial:
-		return tierQuotaConfig{
-			IngestOrgRate:       "5",
-			IngestOrgBurst:      "10",
-			IngestEndpointRate:  "5",
-			IngestEndpointBurst: "10",
-			QuotaOrgUser:        "2",
-			QuotaOrgDashboard:   "5",
-			QuotaOrgDataSource:  "2",
-			QuotaOrgAPIKey:      "2",
-			QuotaUserOrg:        "1",
-			QuotaUsageMonthly:   "10000",
-
-			QuotaOrgCustomer:     "10",
-			QuotaOrgSubscription: "5",
-			WebhookRetentionDays: "7",
-		}
-	case TierStarter:
-		return tierQuotaConfig{
-			IngestOrgRate:       "10",
-			IngestOrgBurst:      "20",
-			IngestEndpointRate:  "10",
-			IngestEndpointBurst: "20",
-			QuotaOrgUser:        "5",
-			QuotaOrgDashboard:   "20",
-			QuotaOrgDataSource:  "5",
-			QuotaOrgAPIKey:      "5",
-			QuotaUserOrg:        "2",
-			QuotaUsageMonthly:   "100000",
-
-			QuotaOrgCustomer:     "100",
-			QuotaOrgSubscription: "10",
-			WebhookRetentionDays: "30",
-		}
-	case TierPro:
-		return tierQuotaConfig{
-			IngestOrgRate:       "25",
-			IngestOrgBurst:      "50",
-			IngestEndpointRate:  "15",
-			IngestEndpointBurst: "30",
-			QuotaOrgUser:        "10",
-			QuotaOrgDashboard:   "100",
-			QuotaOrgDataSource:  "10",
-			QuotaOrgAPIKey:      "10",
-			QuotaUserOrg:        "10",
-			QuotaUsageMonthly:   "1000000",
-		}
-	case TierTeam:
-		return tierQuotaConfig{
-			IngestOrgRate:       "100",
-			IngestOrgBurst:      "200",
-			IngestEndpointRate:  "50",
-			IngestEndpointBurst: "100",
-			QuotaOrgUser:        "50",
-			QuotaOrgDashboard:   "500",
-			QuotaOrgDataSource:  "50",
-			QuotaOrgAPIKey:      "50",
-			QuotaUserOrg:        "20",
-			QuotaUsageMonthly:   "10000000",
-
-			QuotaOrgCustomer:     "5000",
-			QuotaOrgSubscription: "200",
-			WebhookRetentionDays: "180",
-		}
-	case TierEnterprise:
-		return tierQuotaConfig{
-			IngestOrgRate:       "500",
-			IngestOrgBurst:      "1000",
-			IngestEndpointRate:  "200",
-			IngestEndpointBurst: "500",
-			QuotaOrgUser:        "100",
-			QuotaOrgDashboard:   "1000",
-			QuotaOrgDataSource:  "100",
-			QuotaOrgAPIKey:      "100",
-			QuotaUserOrg:        "50",
-			QuotaUsageMonthly:   "100000000",
-
-			QuotaOrgCustomer:     "-1",
-			QuotaOrgSubscription: "-1",
-			WebhookRetentionDays: "365",
-		}
-	default:
-		// Fallback to Pro settings if unknown (safe default)
-		return tierQuotaConfig{
-			IngestOrgRate:       "25",
-			IngestOrgBurst:      "50",
-			IngestEndpointRate:  "15",
-			IngestEndpointBurst: "30",
-			QuotaOrgUser:        "10",
-			QuotaOrgDashboard:   "100",
-			QuotaOrgDataSource:  "10",
-			QuotaOrgAPIKey:      "10",
-			QuotaUserOrg:        "10",
-			QuotaUsageMonthly:   "1000000",
-
-			QuotaOrgCustomer:     "1000",
-			QuotaOrgSubscription: "50",
-			WebhookRetentionDays: "90",
-		}
+// routerTags builds the Traefik tags for the job. Each job gets its own router
+// so the old and new job can serve the same host while traffic is switched.
+// A standby job only matches requests carrying StandbyHeader with its job name.
+func routerTags(cfg JobConfig, jobName string, host string) []string {
+	router := "org-" + strings.TrimPrefix(jobName, "railzway-org-")
+	rule := fmt.Sprintf("Host(`%s`)", host)
+	tags := []string{"traefik.enable=true"}
+	if cfg.Standby {
+		router += "-standby"
+		rule = fmt.Sprintf("%s && Header(`%s`, `%s`)", rule, StandbyHeader, jobName)
+		tags = append(tags, fmt.Sprintf("traefik.http.routers.%s.priority=1000", router))
 	}
+	return append(tags,
+		fmt.Sprintf("traefik.http.routers.%s.rule=%s", router, rule),
+		fmt.Sprintf("traefik.http.routers.%s.entrypoints=web", router), // Assume 'web' is port 80
+	)
 }
 
-func allocateResources(tier Tier) (cpu int, memoryMB int, priority int, tierLabel string) {
-	// Deterministic allocation of resources based on Tier
-	switch tier {
-	case TierFreeTrial:
-		return 250, 512, 40, "free-trial"
-	case TierStarter:
-		return 500, 1024, 60, "starter"
-	case TierPro:
-		return 1000, 2048, 75, "pro"
-	case TierTeam:
-		return 2000, 4096, 90, "team"
-	case TierEnterprise:
-		return 4000, 8192, 100, "enterprise"
-	default:
-		// Safe default (Pro)
-		return 1000, 2048, 75, "pro"
+// Image returns the container image of a Railzway version.
+func Image(version string) string {
+	return fmt.Sprintf("ghcr.io/smallbiznis/railzway:%s", version)
+}
+
+// PublicHost returns the public hostname of an org, under APP_ROOT_DOMAIN.
+func PublicHost(orgSlug string) string {
+	host := "railzway.com"
+	if os.Getenv("APP_ROOT_DOMAIN") != "" {
+		host = strings.ToLower(os.Getenv("APP_ROOT_DOMAIN"))
 	}
+	return strings.TrimSpace(fmt.Sprintf("%s.%s", orgSlug, host))
+}
+
+// EnvVars returns the environment injected into the Railzway container,
+// including the tier quotas.
+func EnvVars(cfg JobConfig) map[string]string {
+	return buildEnvVars(cfg, cfg.TierProfile().Quotas)
 }
 
-func getUpdateStrategy(tier Tier) *api.UpdateStrategy {
-	updateStanza := &api.UpdateStrategy{
-		MaxParallel:      intToPtr(1),
-		MinHealthyTime:   timeToPtr(10 * 1000 * 1000000),      // 10s
-		HealthyDeadline:  timeToPtr(5 * 60 * 1000 * 1000000),  // 5m
-		ProgressDeadline: timeToPtr(10 * 60 * 1000 * 1000000), // 10m
-		AutoRevert:       boolToPtr(true),
-		Canary:           intToPtr(0),
+// MetaSecretsHash is the task meta fingerprinting the credentials in the
+// secret store. It changes whenever they do, so their allocations are
+// replaced.
+const MetaSecretsHash = "secrets_hash"
+
+// SecretEnvKeys are the environment variables that carry credentials.
+var SecretEnvKeys = []string{
+	"DB_PASSWORD",
+	"DATABASE_URL",
+	"OAUTH2_CLIENT_SECRET",
+	"AUTH_RAILZWAY_COM_CLIENT_SECRET",
+	"PAYMENT_PROVIDER_CONFIG_SECRET",
+	"PAYMENT_PROVIDER_CONFIG_PREVIOUS_SECRET",
+	"RATE_LIMIT_REDIS_PASSWORD",
+}
+
+// SecretEnvVars returns the credentials of the environment, keyed by
+// variable, for the secret store.
+func SecretEnvVars(cfg JobConfig) map[string]string {
+	env := EnvVars(cfg)
+	secrets := make(map[string]string, len(SecretEnvKeys))
+	for _, key := range SecretEnvKeys {
+		secrets[key] = env[key]
 	}
+	return secrets
+}
 
-	if tier == TierTeam || tier == TierEnterprise {
-		// Stricter update strategy for Team/Enterprise
-		updateStanza.Canary = intToPtr(1)
-		updateStanza.AutoRevert = boolToPtr(true)
-	} else if tier == TierPro {
-		// Moderate update strategy
-		updateStanza.AutoRevert = boolToPtr(true)
-	} else {
-		// Simple rolling update for Free Trial/Starter
-		updateStanza.AutoRevert = boolToPtr(false)
+func secretsHash(secrets map[string]string) string {
+	h := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(secrets)) {
+		fmt.Fprintf(h, "%s=%s\n", key, secrets[key])
 	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
 
-	return updateStanza
+// SkipPlacement reports whether tier and compute placement should be ignored,
+// so workloads can run on a local development agent.
+func SkipPlacement(version string) bool {
+	return version == "development" || strings.ToLower(os.Getenv("APP_ENV")) == "development"
 }
 
-func buildEnvVars(cfg JobConfig, quotaCfg tierQuotaConfig) map[string]string {
+// Helpers
+func intToPtr(i int) *int                      { return &i }
+func boolToPtr(b bool) *bool                   { return &b }
+func stringToPtr(s string) *string             { return &s }
+func timeToPtr(d time.Duration) *time.Duration { return &d }
+
+func updateStrategy(p UpdateProfile) *api.UpdateStrategy {
+	return &api.UpdateStrategy{
+		MaxParallel:      intToPtr(p.MaxParallel),
+		MinHealthyTime:   timeToPtr(time.Duration(p.MinHealthySeconds) * time.Second),
+		HealthyDeadline:  timeToPtr(time.Duration(p.HealthyDeadlineSeconds) * time.Second),
+		ProgressDeadline: timeToPtr(time.Duration(p.ProgressDeadlineSeconds) * time.Second),
+		AutoRevert:       boolToPtr(p.AutoRevert),
+		Canary:           intToPtr(p.Canary),
+	}
+}
+
+func buildEnvVars(cfg JobConfig, quotas map[string]string) map[string]string {
 	env := map[string]string{
 		"APP_MODE":              "cloud",
 		"DEFAULT_ORG":           fmt.Sprintf("%d", cfg.OrgID),
@@ -358,6 +283,9 @@ func buildEnvVars(cfg JobConfig, quotaCfg tierQuotaConfig) map[string]string {
 		"OAUTH2_CLIENT_SECRET":           cfg.OAuth2ClientSecret,
 		"PAYMENT_PROVIDER_CONFIG_SECRET": cfg.PaymentProviderConfigSecret,
 
+		// Rotated payment provider secret, kept during its grace period
+		"PAYMENT_PROVIDER_CONFIG_PREVIOUS_SECRET": cfg.PreviousPaymentProviderConfigSecret,
+
 		"AUTH_RAILZWAY_COM_NAME":          "Railzway.com",
 		"AUTH_RAILZWAY_COM_ENABLED":       "true",
 		"AUTH_RAILZWAY_COM_ALLOW_SIGN_UP": "false",
@@ -378,28 +306,17 @@ func buildEnvVars(cfg JobConfig, quotaCfg tierQuotaConfig) map[string]string {
 		"RATE_LIMIT_REDIS_DB":       fmt.Sprintf("%d", cfg.RateLimitRedisDB),
 
 		// Usage Ingest
-		"USAGE_INGEST_ORG_RATE":                quotaCfg.IngestOrgRate,
-		"USAGE_INGEST_ORG_BURST":               quotaCfg.IngestOrgBurst,
-		"USAGE_INGEST_ENDPOINT_RATE":           quotaCfg.IngestEndpointRate,
-		"USAGE_INGEST_ENDPOINT_BURST":          quotaCfg.IngestEndpointBurst,
 		"USAGE_INGEST_CONCURRENCY_TTL_SECONDS": "3",
 
 		// Usage Quotas
-		"QUOTA_ENABLED":           "true",
-		"QUOTA_ORG_USER":          quotaCfg.QuotaOrgUser,
-		"QUOTA_ORG_DASHBOARD":     quotaCfg.QuotaOrgDashboard,
-		"QUOTA_ORG_DATA_SOURCE":   quotaCfg.QuotaOrgDataSource,
-		"QUOTA_ORG_API_KEY":       quotaCfg.QuotaOrgAPIKey,
-		"QUOTA_USER_ORG":          quotaCfg.QuotaUserOrg,
-		"QUOTA_GLOBAL_USER":       "-1",
-		"QUOTA_GLOBAL_ORG":        "1",
-		"QUOTA_ORG_USAGE_MONTHLY": quotaCfg.QuotaUsageMonthly,
-
-		"QUOTA_ORG_CUSTOMER":     quotaCfg.QuotaOrgCustomer,
-		"QUOTA_ORG_SUBSCRIPTION": quotaCfg.QuotaOrgSubscription,
-
-		// Privacy
-		"WEBHOOK_RETENTION_DAYS": quotaCfg.WebhookRetentionDays,
+		"QUOTA_ENABLED":     "true",
+		"QUOTA_GLOBAL_USER": "-1",
+		"QUOTA_GLOBAL_ORG":  "1",
+	}
+
+	// Usage ingest limits, quotas and retention come from the tier profile.
+	for key, value := range quotas {
+		env[key] = value
 	}
 	return env
 }
@@ -1,6 +1,7 @@
 package nomad
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -182,8 +183,11 @@ func TestGenerateJob_AuthFederation(t *testing.T) {
 	if env["AUTH_RAILZWAY_COM_CLIENT_ID"] != "client-id-xyz" {
 		t.Errorf("expected CLIENT_ID client-id-xyz, got %s", env["AUTH_RAILZWAY_COM_CLIENT_ID"])
 	}
-	if env["AUTH_RAILZWAY_COM_CLIENT_SECRET"] != "client-secret-abc" {
-		t.Errorf("expected CLIENT_SECRET client-secret-abc, got %s", env["AUTH_RAILZWAY_COM_CLIENT_SECRET"])
+	if _, ok := env["AUTH_RAILZWAY_COM_CLIENT_SECRET"]; ok {
+		t.Error("expected CLIENT_SECRET to stay out of the job spec")
+	}
+	if secret := SecretEnvVars(cfg)["AUTH_RAILZWAY_COM_CLIENT_SECRET"]; secret != "client-secret-abc" {
+		t.Errorf("expected CLIENT_SECRET client-secret-abc for the secret store, got %s", secret)
 	}
 
 	// Verify Metadata
@@ -194,3 +198,106 @@ func TestGenerateJob_AuthFederation(t *testing.T) {
 		t.Errorf("expected AUTH_RAILZWAY_COM_NAME Railzway.com, got %s", env["AUTH_RAILZWAY_COM_NAME"])
 	}
 }
+
+func TestGenerateJob_SecretsOnlyThroughTemplate(t *testing.T) {
+	cfg := JobConfig{
+		OrgID:                       555,
+		Tier:                        TierStarter,
+		ComputeEngine:               EngineGCP,
+		Version:                     "v1.0.0",
+		DBConfig:                    DBConfig{Host: "db", Port: 5432, Name: "org_555", User: "user_555", Password: "db-secret"},
+		OAuth2ClientSecret:          "oauth-secret",
+		PaymentProviderConfigSecret: "payment-secret",
+		SecretsTemplate:             `DB_PASSWORD={{ key "railzway-cloud/workloads/railzway-org-555/DB_PASSWORD" }}`,
+		SecretsFromVault:            true,
+	}
+
+	job, err := GenerateJob(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	task := job.TaskGroups[0].Tasks[0]
+	for key, value := range task.Env {
+		for _, secret := range []string{"db-secret", "oauth-secret", "payment-secret"} {
+			if strings.Contains(value, secret) {
+				t.Errorf("env %s leaks a credential into the job spec", key)
+			}
+		}
+	}
+	if task.Env["DB_USER"] != "user_555" {
+		t.Errorf("expected DB_USER user_555, got %s", task.Env["DB_USER"])
+	}
+
+	if len(task.Templates) != 1 || *task.Templates[0].EmbeddedTmpl != cfg.SecretsTemplate || !*task.Templates[0].Envvars {
+		t.Fatalf("expected the secrets template exported as env, got %+v", task.Templates)
+	}
+	if *task.Templates[0].ChangeMode != "noop" {
+		t.Errorf("expected the template to leave running allocations alone, got %s", *task.Templates[0].ChangeMode)
+	}
+	if task.Vault == nil {
+		t.Error("expected a Vault block for a Vault template")
+	}
+
+	// A rotated credential changes the job spec, so the deploy rolls it out.
+	hash := task.Meta[MetaSecretsHash]
+	cfg.DBConfig.Password = "rotated-secret"
+	rotated, err := GenerateJob(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next := rotated.TaskGroups[0].Tasks[0].Meta[MetaSecretsHash]; hash == "" || next == hash {
+		t.Errorf("expected %s to change with the credentials, got %q and %q", MetaSecretsHash, hash, next)
+	}
+}
+
+func TestGenerateJob_StandbyRouting(t *testing.T) {
+	cfg := JobConfig{
+		OrgID:         321,
+		OrgSlug:       "acme",
+		Tier:          TierStarter,
+		ComputeEngine: EngineHetzner,
+		Version:       "v1.1.0",
+		JobID:         "railzway-org-321-blue",
+		Standby:       true,
+	}
+
+	job, err := GenerateJob(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *job.ID != "railzway-org-321-blue" {
+		t.Errorf("expected ID 'railzway-org-321-blue', got %s", *job.ID)
+	}
+
+	service := job.TaskGroups[0].Services[0]
+	if service.Name != "railzway-org-321-blue" {
+		t.Errorf("expected service name 'railzway-org-321-blue', got %s", service.Name)
+	}
+
+	wantRule := "traefik.http.routers.org-321-blue-standby.rule=Host(`acme.railzway.com`) && Header(`X-Railzway-Job`, `railzway-org-321-blue`)"
+	found := false
+	for _, tag := range service.Tags {
+		if tag == wantRule {
+			found = true
+		}
+		if tag == "traefik.http.routers.org-321-blue.rule=Host(`acme.railzway.com`)" {
+			t.Errorf("standby job must not register the public route")
+		}
+	}
+	if !found {
+		t.Errorf("expected standby rule %q in tags %v", wantRule, service.Tags)
+	}
+
+	// Promoting the job drops the header match.
+	cfg.Standby = false
+	job, err = GenerateJob(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tags := job.TaskGroups[0].Services[0].Tags
+	if tags[1] != "traefik.http.routers.org-321-blue.rule=Host(`acme.railzway.com`)" {
+		t.Errorf("expected public rule for promoted job, got %s", tags[1])
+	}
+}
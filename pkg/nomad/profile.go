@@ -0,0 +1,251 @@
+package nomad
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// QuotaKeys lists the quota environment variables every tier profile must define.
+var QuotaKeys = []string{
+	"USAGE_INGEST_ORG_RATE",
+	"USAGE_INGEST_ORG_BURST",
+	"USAGE_INGEST_ENDPOINT_RATE",
+	"USAGE_INGEST_ENDPOINT_BURST",
+	"QUOTA_ORG_USER",
+	"QUOTA_ORG_DASHBOARD",
+	"QUOTA_ORG_DATA_SOURCE",
+	"QUOTA_ORG_API_KEY",
+	"QUOTA_USER_ORG",
+	"QUOTA_ORG_USAGE_MONTHLY",
+	"QUOTA_ORG_CUSTOMER",
+	"QUOTA_ORG_SUBSCRIPTION",
+	"WEBHOOK_RETENTION_DAYS",
+}
+
+// TierProfile describes how workloads of a tier are sized, placed, updated
+// and limited. Profiles are kept in the tier profile catalog; the built-in
+// ones are only used when the catalog has no profile for a tier.
+type TierProfile struct {
+	Resources ResourceProfile  `json:"resources"`
+	Placement PlacementProfile `json:"placement"`
+	Update    UpdateProfile    `json:"update"`
+	// Quotas maps each of QuotaKeys to its value. -1 means unlimited.
+	Quotas map[string]string `json:"quotas"`
+}
+
+type ResourceProfile struct {
+	CPU      int `json:"cpu"` // MHz, or millicores outside Nomad
+	MemoryMB int `json:"memory_mb"`
+	Priority int `json:"priority"`
+}
+
+type PlacementProfile struct {
+	// NodeTier must match the tier meta of the nodes running the workload.
+	NodeTier string `json:"node_tier"`
+	// Isolation, when set, must match the isolation meta of the nodes. It is
+	// usually granted by the product entitlements rather than the tier.
+	Isolation string `json:"isolation,omitempty"`
+}
+
+type UpdateProfile struct {
+	MaxParallel             int  `json:"max_parallel"`
+	Canary                  int  `json:"canary"`
+	AutoRevert              bool `json:"auto_revert"`
+	MinHealthySeconds       int  `json:"min_healthy_seconds"`
+	HealthyDeadlineSeconds  int  `json:"healthy_deadline_seconds"`
+	ProgressDeadlineSeconds int  `json:"progress_deadline_seconds"`
+}
+
+// Validate checks that the profile is complete and defines every quota key.
+func (p TierProfile) Validate() error {
+	var errs []error
+	if p.Resources.CPU <= 0 {
+		errs = append(errs, errors.New("resources.cpu must be positive"))
+	}
+	if p.Resources.MemoryMB <= 0 {
+		errs = append(errs, errors.New("resources.memory_mb must be positive"))
+	}
+	if p.Resources.Priority < 1 || p.Resources.Priority > 100 {
+		errs = append(errs, errors.New("resources.priority must be between 1 and 100"))
+	}
+	if p.Placement.NodeTier == "" {
+		errs = append(errs, errors.New("placement.node_tier is required"))
+	}
+	if p.Placement.Isolation != "" && !validIsolation(p.Placement.Isolation) {
+		errs = append(errs, fmt.Errorf("placement.isolation %q is not supported", p.Placement.Isolation))
+	}
+	if p.Update.MaxParallel < 1 {
+		errs = append(errs, errors.New("update.max_parallel must be at least 1"))
+	}
+	if p.Update.Canary < 0 {
+		errs = append(errs, errors.New("update.canary must not be negative"))
+	}
+	if p.Update.MinHealthySeconds <= 0 || p.Update.HealthyDeadlineSeconds <= p.Update.MinHealthySeconds {
+		errs = append(errs, errors.New("update.healthy_deadline_seconds must exceed update.min_healthy_seconds"))
+	}
+	if p.Update.ProgressDeadlineSeconds <= p.Update.HealthyDeadlineSeconds {
+		errs = append(errs, errors.New("update.progress_deadline_seconds must exceed update.healthy_deadline_seconds"))
+	}
+
+	for _, key := range QuotaKeys {
+		value, ok := p.Quotas[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("quota %s is not defined", key))
+			continue
+		}
+		if n, err := strconv.Atoi(value); err != nil || n < -1 {
+			errs = append(errs, fmt.Errorf("quota %s must be an integer of at least -1, got %q", key, value))
+		}
+	}
+	for key := range p.Quotas {
+		if !slices.Contains(QuotaKeys, key) {
+			errs = append(errs, fmt.Errorf("unknown quota %s", key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DefaultTierProfile returns the built-in profile of a tier. Unknown tiers get
+// the Pro profile.
+func DefaultTierProfile(tier Tier) TierProfile {
+	base, ok := defaultTierProfiles[tier]
+	if !ok {
+		base = defaultTierProfiles[TierPro]
+	}
+	base.Quotas = maps.Clone(base.Quotas)
+	return base
+}
+
+// Built-in profiles. The catalog is seeded with the same values.
+var defaultTierProfiles = map[Tier]TierProfile{
+	TierFreeTrial: {
+		Resources: ResourceProfile{CPU: 250, MemoryMB: 512, Priority: 40},
+		Placement: PlacementProfile{NodeTier: "free-trial"},
+		Update:    simpleRollingUpdate,
+		Quotas: map[string]string{
+			"USAGE_INGEST_ORG_RATE":       "5",
+			"USAGE_INGEST_ORG_BURST":      "10",
+			"USAGE_INGEST_ENDPOINT_RATE":  "5",
+			"USAGE_INGEST_ENDPOINT_BURST": "10",
+			"QUOTA_ORG_USER":              "2",
+			"QUOTA_ORG_DASHBOARD":         "5",
+			"QUOTA_ORG_DATA_SOURCE":       "2",
+			"QUOTA_ORG_API_KEY":           "2",
+			"QUOTA_USER_ORG":              "1",
+			"QUOTA_ORG_USAGE_MONTHLY":     "10000",
+			"QUOTA_ORG_CUSTOMER":          "10",
+			"QUOTA_ORG_SUBSCRIPTION":      "5",
+			"WEBHOOK_RETENTION_DAYS":      "7",
+		},
+	},
+	TierStarter: {
+		Resources: ResourceProfile{CPU: 500, MemoryMB: 1024, Priority: 60},
+		Placement: PlacementProfile{NodeTier: "starter"},
+		Update:    simpleRollingUpdate,
+		Quotas: map[string]string{
+			"USAGE_INGEST_ORG_RATE":       "10",
+			"USAGE_INGEST_ORG_BURST":      "20",
+			"USAGE_INGEST_ENDPOINT_RATE":  "10",
+			"USAGE_INGEST_ENDPOINT_BURST": "20",
+			"QUOTA_ORG_USER":              "5",
+			"QUOTA_ORG_DASHBOARD":         "20",
+			"QUOTA_ORG_DATA_SOURCE":       "5",
+			"QUOTA_ORG_API_KEY":           "5",
+			"QUOTA_USER_ORG":              "2",
+			"QUOTA_ORG_USAGE_MONTHLY":     "100000",
+			"QUOTA_ORG_CUSTOMER":          "100",
+			"QUOTA_ORG_SUBSCRIPTION":      "10",
+			"WEBHOOK_RETENTION_DAYS":      "30",
+		},
+	},
+	TierPro: {
+		Resources: ResourceProfile{CPU: 1000, MemoryMB: 2048, Priority: 75},
+		Placement: PlacementProfile{NodeTier: "pro"},
+		Update:    autoRevertUpdate,
+		Quotas: map[string]string{
+			"USAGE_INGEST_ORG_RATE":       "25",
+			"USAGE_INGEST_ORG_BURST":      "50",
+			"USAGE_INGEST_ENDPOINT_RATE":  "15",
+			"USAGE_INGEST_ENDPOINT_BURST": "30",
+			"QUOTA_ORG_USER":              "10",
+			"QUOTA_ORG_DASHBOARD":         "100",
+			"QUOTA_ORG_DATA_SOURCE":       "10",
+			"QUOTA_ORG_API_KEY":           "10",
+			"QUOTA_USER_ORG":              "10",
+			"QUOTA_ORG_USAGE_MONTHLY":     "1000000",
+			"QUOTA_ORG_CUSTOMER":          "1000",
+			"QUOTA_ORG_SUBSCRIPTION":      "50",
+			"WEBHOOK_RETENTION_DAYS":      "90",
+		},
+	},
+	TierTeam: {
+		Resources: ResourceProfile{CPU: 2000, MemoryMB: 4096, Priority: 90},
+		Placement: PlacementProfile{NodeTier: "team"},
+		Update:    canaryUpdate,
+		Quotas: map[string]string{
+			"USAGE_INGEST_ORG_RATE":       "100",
+			"USAGE_INGEST_ORG_BURST":      "200",
+			"USAGE_INGEST_ENDPOINT_RATE":  "50",
+			"USAGE_INGEST_ENDPOINT_BURST": "100",
+			"QUOTA_ORG_USER":              "50",
+			"QUOTA_ORG_DASHBOARD":         "500",
+			"QUOTA_ORG_DATA_SOURCE":       "50",
+			"QUOTA_ORG_API_KEY":           "50",
+			"QUOTA_USER_ORG":              "20",
+			"QUOTA_ORG_USAGE_MONTHLY":     "10000000",
+			"QUOTA_ORG_CUSTOMER":          "5000",
+			"QUOTA_ORG_SUBSCRIPTION":      "200",
+			"WEBHOOK_RETENTION_DAYS":      "180",
+		},
+	},
+	TierEnterprise: {
+		Resources: ResourceProfile{CPU: 4000, MemoryMB: 8192, Priority: 100},
+		Placement: PlacementProfile{NodeTier: "enterprise"},
+		Update:    canaryUpdate,
+		Quotas: map[string]string{
+			"USAGE_INGEST_ORG_RATE":       "500",
+			"USAGE_INGEST_ORG_BURST":      "1000",
+			"USAGE_INGEST_ENDPOINT_RATE":  "200",
+			"USAGE_INGEST_ENDPOINT_BURST": "500",
+			"QUOTA_ORG_USER":              "100",
+			"QUOTA_ORG_DASHBOARD":         "1000",
+			"QUOTA_ORG_DATA_SOURCE":       "100",
+			"QUOTA_ORG_API_KEY":           "100",
+			"QUOTA_USER_ORG":              "50",
+			"QUOTA_ORG_USAGE_MONTHLY":     "100000000",
+			"QUOTA_ORG_CUSTOMER":          "-1",
+			"QUOTA_ORG_SUBSCRIPTION":      "-1",
+			"WEBHOOK_RETENTION_DAYS":      "365",
+		},
+	},
+}
+
+var (
+	// Simple rolling update for Free Trial/Starter
+	simpleRollingUpdate = UpdateProfile{
+		MaxParallel:             1,
+		MinHealthySeconds:       10,
+		HealthyDeadlineSeconds:  300,
+		ProgressDeadlineSeconds: 600,
+	}
+	// Moderate update strategy
+	autoRevertUpdate = UpdateProfile{
+		MaxParallel:             1,
+		AutoRevert:              true,
+		MinHealthySeconds:       10,
+		HealthyDeadlineSeconds:  300,
+		ProgressDeadlineSeconds: 600,
+	}
+	// Stricter update strategy for Team/Enterprise
+	canaryUpdate = UpdateProfile{
+		MaxParallel:             1,
+		Canary:                  1,
+		AutoRevert:              true,
+		MinHealthySeconds:       10,
+		HealthyDeadlineSeconds:  300,
+		ProgressDeadlineSeconds: 600,
+	}
+)
@@ -0,0 +1,76 @@
+package nomad
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultTierProfiles_DefineEveryKey(t *testing.T) {
+	for _, tier := range []Tier{TierFreeTrial, TierStarter, TierPro, TierTeam, TierEnterprise} {
+		if err := DefaultTierProfile(tier).Validate(); err != nil {
+			t.Errorf("built-in profile of %s is invalid: %v", tier, err)
+		}
+	}
+}
+
+func TestTierProfileValidate_MissingQuota(t *testing.T) {
+	profile := DefaultTierProfile(TierPro)
+	delete(profile.Quotas, "QUOTA_ORG_CUSTOMER")
+	profile.Quotas["QUOTA_ORG_WIDGETS"] = "3"
+
+	err := profile.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if !strings.Contains(err.Error(), "quota QUOTA_ORG_CUSTOMER is not defined") {
+		t.Errorf("expected missing quota error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "unknown quota QUOTA_ORG_WIDGETS") {
+		t.Errorf("expected unknown quota error, got %v", err)
+	}
+
+	// The built-in profile is not affected by changes to a returned copy.
+	if _, ok := DefaultTierProfile(TierPro).Quotas["QUOTA_ORG_CUSTOMER"]; !ok {
+		t.Error("expected built-in profile to keep QUOTA_ORG_CUSTOMER")
+	}
+}
+
+func TestGenerateJob_ProfileOverride(t *testing.T) {
+	profile := DefaultTierProfile(TierStarter)
+	profile.Resources.CPU = 750
+	profile.Placement.NodeTier = "starter-arm"
+	profile.Update.Canary = 1
+	profile.Quotas["QUOTA_ORG_USER"] = "8"
+
+	cfg := JobConfig{
+		OrgID:         7,
+		Tier:          TierStarter,
+		ComputeEngine: EngineHetzner,
+		Version:       "v1.0.0",
+		Profile:       &profile,
+	}
+
+	job, err := GenerateJob(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	taskGroup := job.TaskGroups[0]
+	if *taskGroup.Tasks[0].Resources.CPU != 750 {
+		t.Errorf("expected CPU 750, got %d", *taskGroup.Tasks[0].Resources.CPU)
+	}
+	if taskGroup.Constraints[0].RTarget != "starter-arm" {
+		t.Errorf("expected tier constraint starter-arm, got %s", taskGroup.Constraints[0].RTarget)
+	}
+	if *taskGroup.Update.Canary != 1 {
+		t.Errorf("expected Canary 1, got %d", *taskGroup.Update.Canary)
+	}
+	if env := taskGroup.Tasks[0].Env; env["QUOTA_ORG_USER"] != "8" {
+		t.Errorf("expected QUOTA_ORG_USER 8, got %s", env["QUOTA_ORG_USER"])
+	}
+
+	delete(profile.Quotas, "WEBHOOK_RETENTION_DAYS")
+	if _, err := GenerateJob(cfg); err == nil {
+		t.Error("expected incomplete profile to be rejected")
+	}
+}
@@ -1,6 +1,9 @@
 package nomad
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Tier represents the pricing tier of the organization.
 type Tier string
@@ -42,6 +45,24 @@ type JobConfig struct {
 	OAuth2ClientSecret          string
 	OAuth2CallbackURL           string
 	PaymentProviderConfigSecret string
+	// PreviousPaymentProviderConfigSecret still opens what the tenant
+	// encrypted before the last rotation. Empty once its grace period ended.
+	PreviousPaymentProviderConfigSecret string
+
+	// JobID overrides the default job name (railzway-org-<id>), used by blue/green upgrades.
+	JobID string
+	// Standby removes the job from the public route; it is only reachable with the X-Railzway-Job header.
+	Standby bool
+
+	// Profile overrides the built-in profile of Tier, usually with the
+	// current revision from the tier profile catalog.
+	Profile *TierProfile
+
+	// SecretsTemplate renders SecretEnvKeys from the secret store. The job
+	// spec never carries them.
+	SecretsTemplate string
+	// SecretsFromVault gives the task a Vault token for the template.
+	SecretsFromVault bool
 }
 
 type DBConfig struct {
@@ -72,5 +93,26 @@ func (c JobConfig) Validate() error {
 	default:
 		return errors.New("invalid or unsupported compute engine")
 	}
+	if c.Profile != nil {
+		if err := c.Profile.Validate(); err != nil {
+			return fmt.Errorf("invalid tier profile: %w", err)
+		}
+	}
 	return nil
 }
+
+// TierProfile returns the profile the job is generated from.
+func (c JobConfig) TierProfile() TierProfile {
+	if c.Profile != nil {
+		return *c.Profile
+	}
+	return DefaultTierProfile(c.Tier)
+}
+
+// JobName returns the Nomad job name for the configuration.
+func (c JobConfig) JobName() string {
+	if c.JobID != "" {
+		return c.JobID
+	}
+	return fmt.Sprintf("railzway-org-%d", c.OrgID)
+}
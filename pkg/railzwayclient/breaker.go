@@ -1,9 +1,18 @@
 package railzwayclient
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/sony/gobreaker"
 )
 
+// ErrCircuitOpen is returned without contacting OSS while the circuit
+// breaker is open or its half-open probe quota is used up. It matches
+// ErrUnavailable.
+var ErrCircuitOpen = fmt.Errorf("railzway client: circuit breaker open: %w", ErrUnavailable)
+
 type CircuitBreaker interface {
 	Execute(fn func() error) error
 }
@@ -38,12 +47,16 @@ func (g *gobreakerWrapper) Execute(fn func() error) error {
 	_, err := g.cb.Execute(func() (any, error) {
 		return nil, fn()
 	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		circuitBreakerRejections.Inc()
+		return ErrCircuitOpen
+	}
 	return err
 }
 
 func NewGobreaker(cfg Config) CircuitBreaker {
 	settings := gobreaker.Settings{
-		Name: "valora-client",
+		Name: "railzway-client",
 
 		MaxRequests: uint32(cfg.CBHalfOpenMaxSuccess),
 
@@ -58,7 +71,11 @@ func NewGobreaker(cfg Config) CircuitBreaker {
 		},
 
 		IsSuccessful: func(err error) bool {
-			return err == nil
+			return !breakerFailure(err)
+		},
+
+		OnStateChange: func(_ string, _, to gobreaker.State) {
+			circuitBreakerState.Set(float64(to))
 		},
 	}
 
@@ -73,3 +90,17 @@ func NewCircuitBreaker(cfg Config) CircuitBreaker {
 	}
 	return NewGobreaker(cfg)
 }
+
+// breakerFailure reports whether err says OSS is unhealthy: a server error
+// or a transport failure. Client errors, throttling and requests the caller
+// gave up on are answers, not outages.
+func breakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return errors.Is(apiErr, ErrUnavailable)
+	}
+	return true
+}
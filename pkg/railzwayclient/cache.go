@@ -1,6 +1,7 @@
 package railzwayclient
 
 import (
+	"strings"
 	"sync"
 	"time"
 )
@@ -68,3 +69,19 @@ func (c *Cache) Set(key string, value any) {
 		expiresAt: time.Now().Add(c.ttl),
 	}
 }
+
+// DeletePrefix removes every entry whose key starts with prefix.
+func (c *Cache) DeletePrefix(prefix string) {
+	if c.items == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+		}
+	}
+}
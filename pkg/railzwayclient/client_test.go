@@ -0,0 +1,214 @@
+package railzwayclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer counts the requests it serves and answers with handler.
+func testServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, n int32)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, hits.Add(1))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func testConfig(baseURL string) Config {
+	return Config{
+		BaseURL:    baseURL,
+		APIKey:     "test-key",
+		Timeout:    5 * time.Second,
+		RetryCount: 3,
+		RetryDelay: time.Millisecond,
+		CacheTTL:   time.Minute,
+		CacheSize:  100,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestDoRequest_RetriesIdempotentRequests(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		if n < 3 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "unavailable"})
+			return
+		}
+		writeJSON(w, http.StatusOK, ResponseWrapper[Customer]{Data: Customer{ID: "cus_1"}})
+	})
+	client := New(testConfig(server.URL))
+
+	customer, err := client.GetCustomer(context.Background(), "cus_1")
+	require.NoError(t, err)
+	assert.Equal(t, "cus_1", customer.ID)
+	assert.Equal(t, int32(3), hits.Load())
+}
+
+func TestDoRequest_DoesNotRetryUnsafeRequests(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "unavailable"})
+	})
+	client := New(testConfig(server.URL))
+
+	_, err := client.CreateProduct(context.Background(), CreateProductRequest{Code: "hobby"})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestDoRequest_DoesNotRetryClientErrors(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid"})
+	})
+	client := New(testConfig(server.URL))
+
+	_, err := client.GetCustomer(context.Background(), "cus_1")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestDoRequest_BackoffStopsOnContextCancel(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "bad gateway"})
+	})
+	cfg := testConfig(server.URL)
+	cfg.RetryDelay = time.Hour
+	client := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetCustomer(ctx, "cus_1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestDoRequest_CachesReferenceAndPricingReads(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		switch r.URL.Path {
+		case "/api/currencies":
+			writeJSON(w, http.StatusOK, []Currency{{Code: "USD"}})
+		case "/api/prices":
+			if r.Method == http.MethodPost {
+				writeJSON(w, http.StatusCreated, Price{ID: "price_2"})
+				return
+			}
+			writeJSON(w, http.StatusOK, ResponseWrapper[[]Price]{Data: []Price{{ID: "price_1"}}})
+		default:
+			writeJSON(w, http.StatusOK, ResponseWrapper[Customer]{Data: Customer{ID: "cus_1"}})
+		}
+	})
+	client := New(testConfig(server.URL))
+	ctx := context.Background()
+
+	for range 2 {
+		currencies, err := client.ListCurrencies(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "USD", currencies[0].Code)
+	}
+	assert.Equal(t, int32(1), hits.Load())
+
+	// Customers are not cached.
+	for range 2 {
+		_, err := client.GetCustomer(ctx, "cus_1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), hits.Load())
+
+	// Creating a price invalidates cached price reads.
+	_, err := client.ListPrices(ctx, nil)
+	require.NoError(t, err)
+	_, err = client.CreatePrice(ctx, map[string]string{"code": "hobby"})
+	require.NoError(t, err)
+	_, err = client.ListPrices(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(6), hits.Load())
+}
+
+func TestDoRequest_CircuitBreakerOpens(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "boom"})
+	})
+	cfg := testConfig(server.URL)
+	cfg.RetryCount = 0
+	cfg.CircuitBreakerEnabled = true
+	cfg.CBMinRequests = 2
+	cfg.CBFailureThreshold = 2
+	cfg.CBRecoveryTime = time.Minute
+	cfg.CBSamplingDuration = time.Minute
+	cfg.CBHalfOpenMaxSuccess = 1
+	client := New(cfg)
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := client.GetCustomer(ctx, "cus_1")
+		require.Error(t, err)
+	}
+	_, err := client.GetCustomer(ctx, "cus_1")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestDoRequest_ClientErrorsDoNotTripBreaker(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+	cfg := testConfig(server.URL)
+	cfg.CircuitBreakerEnabled = true
+	cfg.CBMinRequests = 1
+	cfg.CBFailureThreshold = 1
+	cfg.CBRecoveryTime = time.Minute
+	client := New(cfg)
+
+	for range 3 {
+		_, err := client.GetProduct(context.Background(), "prod_1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	assert.Equal(t, int32(3), hits.Load())
+}
+
+func TestDoRequest_RateLimitWaitRespectsContext(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		writeJSON(w, http.StatusOK, ResponseWrapper[Customer]{Data: Customer{ID: "cus_1"}})
+	})
+	cfg := testConfig(server.URL)
+	cfg.RateLimit = 1
+	cfg.RateBurst = 1
+	client := New(cfg)
+
+	_, err := client.GetCustomer(context.Background(), "cus_1")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.GetCustomer(ctx, "cus_1")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestRetryPolicy_BackoffIsJitteredAndCapped(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second}
+	for attempt := range 10 {
+		want := min(time.Second<<attempt, maxRetryDelay)
+		delay := policy.Backoff(attempt)
+		assert.GreaterOrEqual(t, delay, want/2)
+		assert.LessOrEqual(t, delay, want)
+	}
+	assert.LessOrEqual(t, policy.Backoff(100), maxRetryDelay)
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
-	"strconv"
 )
 
 type Customer struct {
@@ -23,20 +23,10 @@ type ListCustomersParams struct {
 	Currency    string
 	CreatedFrom string
 	CreatedTo   string
-	PageToken   string
-	PageSize    int
+	PageParams
 }
 
-type customerListResponse struct {
-	Items         []Customer `json:"items"`
-	NextPageToken string     `json:"next_page_token"`
-}
-
-// ListCustomers lists customers with optional filtering
-func (c *Client) ListCustomers(ctx context.Context, params ListCustomersParams) ([]Customer, error) {
-	path := "/api/customers"
-
-	// Build query string
+func (params ListCustomersParams) query() url.Values {
 	query := url.Values{}
 	if params.Name != "" {
 		query.Set("name", params.Name)
@@ -56,23 +46,22 @@ func (c *Client) ListCustomers(ctx context.Context, params ListCustomersParams)
 	if params.CreatedTo != "" {
 		query.Set("created_to", params.CreatedTo)
 	}
-	if params.PageToken != "" {
-		query.Set("page_token", params.PageToken)
-	}
-	if params.PageSize > 0 {
-		query.Set("page_size", strconv.Itoa(params.PageSize))
-	}
-
-	if len(query) > 0 {
-		path += "?" + query.Encode()
-	}
+	params.PageParams.apply(query)
+	return query
+}
 
-	var resp customerListResponse
-	err := c.doRequest(ctx, http.MethodGet, path, nil, &resp)
+// ListCustomers lists one page of customers with optional filtering
+func (c *Client) ListCustomers(ctx context.Context, params ListCustomersParams) ([]Customer, error) {
+	page, err := listPage[Customer](ctx, c, "/api/customers", params.query())
 	if err != nil {
 		return nil, err
 	}
-	return resp.Items, nil
+	return page.Items, nil
+}
+
+// AllCustomers iterates over the customers matching params, page by page
+func (c *Client) AllCustomers(ctx context.Context, params ListCustomersParams) iter.Seq2[Customer, error] {
+	return paginate[Customer](ctx, c, "/api/customers", params.query())
 }
 
 // GetCustomer retrieves a customer by ID
@@ -89,12 +78,13 @@ func (c *Client) GetCustomer(ctx context.Context, id string) (*Customer, error)
 // EnsureCustomer ensures a Customer exists in Railzway OSS.
 // Idempotent by externalID.
 // If customer exists → return it.
-// If not → create it.
+// If not → create it, sending idempotencyKey with the create request.
 func (c *Client) EnsureCustomer(
 	ctx context.Context,
 	name string,
 	email string,
 	externalID string,
+	idempotencyKey string,
 ) (*Customer, error) {
 
 	// 1. Try to find existing customer by external_id
@@ -119,7 +109,11 @@ func (c *Client) EnsureCustomer(
 	}
 
 	var resp ResponseWrapper[Customer]
-	err = c.doRequest(ctx, http.MethodPost, "/api/customers", createReq, &resp)
+	err = c.doKeyedRequest(ctx, http.MethodPost, "/api/customers", idempotencyKey, createReq, &resp)
+	if errors.Is(err, ErrConflict) {
+		// A concurrent call created it first.
+		return c.getCustomerByExternalID(ctx, externalID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -127,13 +121,10 @@ func (c *Client) EnsureCustomer(
 	return &resp.Data, nil
 }
 
-// ErrNotFound is returned when a resource is not found in OSS.
-var ErrNotFound = errors.New("resource not found")
-
 func (c *Client) getCustomerByExternalID(ctx context.Context, externalID string) (*Customer, error) {
 	customers, err := c.ListCustomers(ctx, ListCustomersParams{
 		ExternalID: externalID,
-		PageSize:   1,
+		PageParams: PageParams{PageSize: 1},
 	})
 	if err != nil {
 		return nil, err
@@ -4,54 +4,151 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ResponseWrapper[T any] struct {
 	Data T `json:"data"`
 }
 
+// cachedResources are the reference and pricing collections whose GET
+// responses are cached. They change rarely and only through this client or
+// the OSS admin, so a short TTL keeps them fresh enough.
+var cachedResources = []string{
+	"/api/countries",
+	"/api/currencies",
+	"/api/timezones",
+	"/api/prices",
+	"/api/price_amounts",
+	"/api/price_tiers",
+	"/api/pricings",
+}
+
+// doRequest sends a request without an idempotency key; see doKeyedRequest.
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, out interface{}) error {
-	url := c.cfg.BaseURL + path
+	return c.doKeyedRequest(ctx, method, path, "", body, out)
+}
 
-	var reqBody io.Reader
+// doKeyedRequest sends a request through the client pipeline: cache lookup
+// for cacheable GETs, then per attempt the rate limiter, the circuit breaker
+// and the HTTP call. Only idempotent methods and requests carrying an
+// idempotency key are retried.
+func (c *Client) doKeyedRequest(ctx context.Context, method, path, idempotencyKey string, body interface{}, out interface{}) error {
+	var payload []byte
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
 			return err
 		}
-		reqBody = bytes.NewBuffer(b)
+		payload = b
+	}
+
+	res := resource(path)
+	start := time.Now()
+	defer func() {
+		requestDuration.WithLabelValues(method, res).Observe(time.Since(start).Seconds())
+	}()
+
+	collection, cacheable := cachedCollection(path)
+	if cacheable && method == http.MethodGet {
+		if cached, ok := c.cache.Get(path); ok {
+			cacheLookupsTotal.WithLabelValues(res, "hit").Inc()
+			return decode(cached.([]byte), out)
+		}
+		cacheLookupsTotal.WithLabelValues(res, "miss").Inc()
+	}
+
+	// Idempotent methods need no key; a keyed POST is safe to repeat.
+	if idempotent(method) {
+		idempotencyKey = ""
 	}
+	keyed := idempotencyKey != ""
 
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	var respBody []byte
+	attempt := 0
+	err := c.retry.Do(ctx, idempotent(method) || keyed, func() error {
+		if attempt > 0 {
+			retriesTotal.WithLabelValues(method, res).Inc()
+		}
+		attempt++
+
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		return c.breaker.Execute(func() error {
+			b, err := c.send(ctx, method, path, payload, idempotencyKey)
+			respBody = b
+			return err
+		})
+	})
 	if err != nil {
 		return err
 	}
 
+	if cacheable {
+		if method == http.MethodGet {
+			c.cache.Set(path, respBody)
+		} else {
+			c.cache.DeletePrefix(collection)
+		}
+	}
+	return decode(respBody, out)
+}
+
+// send performs a single HTTP attempt and returns the response body.
+func (c *Client) send(ctx context.Context, method, path string, payload []byte, idempotencyKey string) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
 	req.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
 	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return err
+		requestsTotal.WithLabelValues(method, resource(path), "error").Inc()
+		return nil, err
 	}
 	defer resp.Body.Close()
+	requestsTotal.WithLabelValues(method, resource(path), strconv.Itoa(resp.StatusCode)).Inc()
 
+	bodyBytes, err := io.ReadAll(resp.Body)
 	if resp.StatusCode >= 400 {
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("api error: %s (failed to read body: %v)", resp.Status, err)
-		}
-		return fmt.Errorf("api error: %s: %s", resp.Status, string(bodyBytes))
+		// A truncated error body still carries the status.
+		return nil, newAPIError(resp, bodyBytes)
+	}
+	if err != nil {
+		return nil, err
 	}
+	return bodyBytes, nil
+}
 
-	if out != nil {
-		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
-			return err
-		}
+func decode(body []byte, out interface{}) error {
+	if out == nil || len(body) == 0 {
+		return nil
 	}
+	return json.Unmarshal(body, out)
+}
 
-	return nil
+// cachedCollection returns the cached collection path belongs to.
+func cachedCollection(path string) (string, bool) {
+	for _, collection := range cachedResources {
+		if path == collection || strings.HasPrefix(path, collection+"/") || strings.HasPrefix(path, collection+"?") {
+			return collection, true
+		}
+	}
+	return "", false
 }
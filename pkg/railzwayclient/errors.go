@@ -1,17 +1,128 @@
 package railzwayclient
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Error classes of OSS responses, for use with errors.Is.
+var (
+	ErrNotFound    = errors.New("resource not found")
+	ErrConflict    = errors.New("resource conflict")
+	ErrRateLimited = errors.New("rate limited")
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// APIError is an error response from Railzway OSS.
 type APIError struct {
-	Status  int    `json:"status"`
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+
+	// RetryAfter is the delay the server asked for before retrying, zero
+	// when it did not send Retry-After.
+	RetryAfter time.Duration `json:"-"`
 }
 
 func (e *APIError) Error() string {
-	return fmt.Sprintf("valora api error (%d): %s", e.Status, e.Message)
+	msg := e.Message
+	if msg == "" {
+		msg = e.Code
+	}
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("railzway api error (%d): %s (request id %s)", e.Status, msg, e.RequestID)
+	}
+	return fmt.Sprintf("railzway api error (%d): %s", e.Status, msg)
 }
 
+// Is matches the error class sentinels, so callers can write
+// errors.Is(err, railzwayclient.ErrNotFound).
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrConflict:
+		return e.Status == http.StatusConflict
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.Status >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// newAPIError decodes an error response. OSS answers with either
+// {"code", "message"}, {"error": "<code>"} or {"error": {"code", "message"}};
+// bodies in none of those shapes become the message.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		Status:     resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+
+	var payload struct {
+		Code      string          `json:"code"`
+		Message   string          `json:"message"`
+		RequestID string          `json:"request_id"`
+		Error     json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		apiErr.Message = strings.TrimSpace(string(body))
+		return apiErr
+	}
+	apiErr.Code = payload.Code
+	apiErr.Message = payload.Message
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = payload.RequestID
+	}
 
+	if len(payload.Error) > 0 {
+		var code string
+		var nested struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(payload.Error, &code) == nil {
+			apiErr.Code = coalesce(apiErr.Code, code)
+		} else if json.Unmarshal(payload.Error, &nested) == nil {
+			apiErr.Code = coalesce(apiErr.Code, nested.Code)
+			apiErr.Message = coalesce(apiErr.Message, nested.Message)
+		}
+	}
+	return apiErr
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+func coalesce(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package railzwayclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoRequest_DecodesAPIErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     any
+		sentinel error
+		want     APIError
+	}{
+		{
+			name:     "code and message",
+			status:   http.StatusConflict,
+			body:     map[string]string{"code": "subscription_active", "message": "already active", "request_id": "req_body"},
+			sentinel: ErrConflict,
+			want:     APIError{Status: 409, Code: "subscription_active", Message: "already active", RequestID: "req_1"},
+		},
+		{
+			name:     "error code",
+			status:   http.StatusNotFound,
+			body:     map[string]string{"error": "not_found"},
+			sentinel: ErrNotFound,
+			want:     APIError{Status: 404, Code: "not_found", RequestID: "req_1"},
+		},
+		{
+			name:     "nested error",
+			status:   http.StatusServiceUnavailable,
+			body:     map[string]any{"error": map[string]string{"code": "maintenance", "message": "back soon"}},
+			sentinel: ErrUnavailable,
+			want:     APIError{Status: 503, Code: "maintenance", Message: "back soon", RequestID: "req_1"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+				w.Header().Set("X-Request-ID", "req_1")
+				writeJSON(w, tc.status, tc.body)
+			})
+			cfg := testConfig(server.URL)
+			cfg.RetryCount = 0
+			client := New(cfg)
+
+			_, err := client.GetSubscription(context.Background(), "sub_1")
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.want, *apiErr)
+			assert.ErrorIs(t, err, tc.sentinel)
+		})
+	}
+}
+
+func TestAPIError_Is(t *testing.T) {
+	assert.ErrorIs(t, &APIError{Status: 429}, ErrRateLimited)
+	assert.ErrorIs(t, &APIError{Status: 500}, ErrUnavailable)
+	assert.NotErrorIs(t, &APIError{Status: 400}, ErrUnavailable)
+	assert.NotErrorIs(t, &APIError{Status: 409}, ErrNotFound)
+	assert.ErrorIs(t, ErrCircuitOpen, ErrUnavailable)
+}
+
+func TestDoRequest_PlainTextErrorBody(t *testing.T) {
+	server, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	})
+	cfg := testConfig(server.URL)
+	cfg.RetryCount = 0
+	client := New(cfg)
+
+	_, err := client.GetProduct(context.Background(), "prod_1")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "bad gateway", apiErr.Message)
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestDoRequest_HonoursRetryAfter(t *testing.T) {
+	var first time.Time
+	var gap time.Duration
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		if n == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate_limited"})
+			return
+		}
+		gap = time.Since(first)
+		writeJSON(w, http.StatusOK, Product{ID: "prod_1"})
+	})
+	client := New(testConfig(server.URL))
+
+	_, err := client.GetProduct(context.Background(), "prod_1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), hits.Load())
+	assert.GreaterOrEqual(t, gap, time.Second)
+}
+
+func TestDoRequest_RetryAfterBeyondCapIsReturned(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.Header().Set("Retry-After", "3600")
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate_limited"})
+	})
+	client := New(testConfig(server.URL))
+
+	_, err := client.GetProduct(context.Background(), "prod_1")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, time.Hour, apiErr.RetryAfter)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+	assert.Zero(t, parseRetryAfter("", now))
+}
+
+func TestEnsureCustomer_ConflictReturnsExisting(t *testing.T) {
+	server, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		switch {
+		case r.Method == http.MethodPost:
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "customer_exists"})
+		case n == 1:
+			writeJSON(w, http.StatusOK, map[string]any{"items": []Customer{}})
+		default:
+			writeJSON(w, http.StatusOK, map[string]any{"items": []Customer{{ID: "cus_1", ExternalID: "org_1"}}})
+		}
+	})
+	client := New(testConfig(server.URL))
+
+	customer, err := client.EnsureCustomer(context.Background(), "Acme", "org_1@railzway.com", "org_1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "cus_1", customer.ID)
+}
+
+func TestGetPriceByCode_NotFound(t *testing.T) {
+	server, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		writeJSON(w, http.StatusOK, ResponseWrapper[[]Price]{Data: []Price{}})
+	})
+	client := New(testConfig(server.URL))
+
+	_, err := client.GetPriceByCode(context.Background(), "missing-monthly")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,10 @@
+package railzwayclient
+
+// IdempotencyKeyHeader carries the idempotency key of a mutating request.
+// OSS answers a repeated key with the response of the first request instead
+// of applying it again.
+//
+// Mutating methods take the key as an argument. Use one key per logical
+// operation: calls made with the same key are applied once, so two
+// different operations must not share it. An empty key sends none.
+const IdempotencyKeyHeader = "Idempotency-Key"
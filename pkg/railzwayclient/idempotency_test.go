@@ -0,0 +1,62 @@
+package railzwayclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/railzwaylabs/railzway-cloud/pkg/testhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoRequest_SendsIdempotencyKeyOnMutatingCalls(t *testing.T) {
+	keys := make(map[string]string)
+	server, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		keys[r.Method] = r.Header.Get(IdempotencyKeyHeader)
+		writeJSON(w, http.StatusOK, ResponseWrapper[Subscription]{Data: Subscription{ID: "sub_1"}})
+	})
+	client := New(testConfig(server.URL))
+	ctx := context.Background()
+
+	require.NoError(t, client.ActivateSubscription(ctx, "sub_1", "outbox:1:activate-subscription:sub_1"))
+	_, err := client.GetSubscription(ctx, "sub_1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "outbox:1:activate-subscription:sub_1", keys[http.MethodPost])
+	assert.Empty(t, keys[http.MethodGet])
+}
+
+func TestDoRequest_RetriesKeyedPosts(t *testing.T) {
+	var keys []string
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if n == 1 {
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": "bad_gateway"})
+			return
+		}
+		writeJSON(w, http.StatusOK, nil)
+	})
+	client := New(testConfig(server.URL))
+	require.NoError(t, client.CancelSubscription(context.Background(), "sub_1", false, "outbox:1:cancel-subscription:sub_1"))
+	assert.Equal(t, int32(2), hits.Load())
+	assert.Equal(t, []string{"outbox:1:cancel-subscription:sub_1", "outbox:1:cancel-subscription:sub_1"}, keys)
+}
+
+func TestCreateSubscription_RepeatedKeyCreatesOnce(t *testing.T) {
+	oss := testhelper.NewMockRailzwayServer(t)
+	client := New(testConfig(oss.Server.URL))
+	items := []CreateSubscriptionItemRequest{{PriceID: "price_starter", Quantity: 1}}
+
+	ctx := context.Background()
+	first, err := client.CreateSubscription(ctx, "cus_1", "monthly", items, "outbox:7:create-subscription")
+	require.NoError(t, err)
+	again, err := client.CreateSubscription(ctx, "cus_1", "monthly", items, "outbox:7:create-subscription")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID)
+	assert.Equal(t, 1, oss.ReplayedRequests())
+
+	other, err := client.CreateSubscription(ctx, "cus_1", "monthly", items, "outbox:8:create-subscription")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, other.ID)
+}
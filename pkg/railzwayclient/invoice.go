@@ -3,6 +3,7 @@ package railzwayclient
 import (
 	"context"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -36,26 +37,21 @@ type ListInvoicesParams struct {
 	FinalizedTo   string
 	TotalMin      int64
 	TotalMax      int64
-	PageToken     string
-	PageSize      int
+	PageParams
 }
 
-// ListInvoices lists invoices with optional filtering
+// ListInvoices lists one page of invoices with optional filtering
 func (c *Client) ListInvoices(ctx context.Context, params ListInvoicesParams) ([]Invoice, error) {
-	path := "/api/invoices"
-
-	// Build query string
-	query := buildListInvoicesQuery(params)
-	if len(query) > 0 {
-		path += "?" + query.Encode()
-	}
-
-	var invoices []Invoice
-	err := c.doRequest(ctx, http.MethodGet, path, nil, &invoices)
+	page, err := listPage[Invoice](ctx, c, "/api/invoices", buildListInvoicesQuery(params))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list invoices: %w", err)
 	}
-	return invoices, nil
+	return page.Items, nil
+}
+
+// AllInvoices iterates over the invoices matching params, page by page
+func (c *Client) AllInvoices(ctx context.Context, params ListInvoicesParams) iter.Seq2[Invoice, error] {
+	return paginate[Invoice](ctx, c, "/api/invoices", buildListInvoicesQuery(params))
 }
 
 // GetInvoice retrieves an invoice by ID
@@ -115,11 +111,6 @@ func buildListInvoicesQuery(params ListInvoicesParams) url.Values {
 	if params.TotalMax > 0 {
 		query.Set("total_max", strconv.FormatInt(params.TotalMax, 10))
 	}
-	if params.PageToken != "" {
-		query.Set("page_token", params.PageToken)
-	}
-	if params.PageSize > 0 {
-		query.Set("page_size", strconv.Itoa(params.PageSize))
-	}
+	params.PageParams.apply(query)
 	return query
 }
@@ -3,6 +3,7 @@ package railzwayclient
 import (
 	"context"
 	"fmt"
+	"iter"
 	"net/http"
 )
 
@@ -21,14 +22,18 @@ type UsageEvent struct {
 	Properties map[string]interface{} `json:"properties,omitempty"`
 }
 
-// ListMeters lists all meters
+// ListMeters lists one page of meters
 func (c *Client) ListMeters(ctx context.Context) ([]Meter, error) {
-	var meters []Meter
-	err := c.doRequest(ctx, http.MethodGet, "/api/meters", nil, &meters)
+	page, err := listPage[Meter](ctx, c, "/api/meters", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list meters: %w", err)
 	}
-	return meters, nil
+	return page.Items, nil
+}
+
+// AllMeters iterates over all meters, page by page
+func (c *Client) AllMeters(ctx context.Context, params PageParams) iter.Seq2[Meter, error] {
+	return paginate[Meter](ctx, c, "/api/meters", params.query())
 }
 
 // GetMeter retrieves a meter by ID
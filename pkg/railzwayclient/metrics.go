@@ -0,0 +1,75 @@
+package railzwayclient
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "railzway_client_requests_total",
+			Help: "Total number of HTTP requests sent to Railzway OSS",
+		},
+		[]string{"method", "resource", "status"},
+	)
+
+	requestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "railzway_client_request_duration_seconds",
+			Help:    "Railzway OSS call latencies in seconds, including rate limiting and retries",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "resource"},
+	)
+
+	retriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "railzway_client_retries_total",
+			Help: "Total number of retried Railzway OSS requests",
+		},
+		[]string{"method", "resource"},
+	)
+
+	cacheLookupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "railzway_client_cache_lookups_total",
+			Help: "Total number of Railzway OSS response cache lookups",
+		},
+		[]string{"resource", "result"},
+	)
+
+	rateLimitWaitSeconds = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "railzway_client_rate_limit_wait_seconds",
+			Help:    "Time spent waiting for the Railzway OSS client rate limiter",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	circuitBreakerRejections = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "railzway_client_circuit_breaker_rejections_total",
+			Help: "Total number of Railzway OSS requests rejected by the open circuit breaker",
+		},
+	)
+
+	circuitBreakerState = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "railzway_client_circuit_breaker_state",
+			Help: "Railzway OSS circuit breaker state: 0 closed, 1 half-open, 2 open",
+		},
+	)
+)
+
+// resource labels a request by the collection it targets, e.g. "prices" for
+// "/api/prices/price_1?x=y", so metric cardinality stays bounded.
+func resource(path string) string {
+	path = strings.TrimPrefix(path, "/api/")
+	if i := strings.IndexAny(path, "/?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
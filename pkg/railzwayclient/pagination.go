@@ -0,0 +1,115 @@
+package railzwayclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// PageParams selects a page of a list endpoint. The zero value asks for the
+// first page at the server's default size.
+type PageParams struct {
+	PageToken string
+	PageSize  int
+}
+
+func (p PageParams) query() url.Values {
+	query := url.Values{}
+	p.apply(query)
+	return query
+}
+
+func (p PageParams) apply(query url.Values) {
+	if p.PageToken != "" {
+		query.Set("page_token", p.PageToken)
+	}
+	if p.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(p.PageSize))
+	}
+}
+
+// Page is one page of a list endpoint. NextPageToken is empty on the last
+// page.
+type Page[T any] struct {
+	Items         []T
+	NextPageToken string
+}
+
+// UnmarshalJSON accepts the list shapes OSS endpoints use: {"items": [...]},
+// {"data": [...]}, either with "next_page_token", or a bare array, which is
+// a single page.
+func (p *Page[T]) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		p.NextPageToken = ""
+		return json.Unmarshal(b, &p.Items)
+	}
+
+	var body struct {
+		Items         []T    `json:"items"`
+		Data          []T    `json:"data"`
+		NextPageToken string `json:"next_page_token"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return err
+	}
+	p.Items = body.Items
+	if p.Items == nil {
+		p.Items = body.Data
+	}
+	p.NextPageToken = body.NextPageToken
+	return nil
+}
+
+// listPage fetches one page of path with query.
+func listPage[T any](ctx context.Context, c *Client, path string, query url.Values) (*Page[T], error) {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var page Page[T]
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// paginate walks every page of path, starting at the page token in query.
+// Iteration ends after the last page, when the caller stops, or with an
+// error: the failed request's, or ctx's once it is done.
+func paginate[T any](ctx context.Context, c *Client, path string, query url.Values) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		query := maps.Clone(query)
+		if query == nil {
+			query = url.Values{}
+		}
+		seen := make(map[string]bool)
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			page, err := listPage[T](ctx, c, path, query)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			// A server repeating a token would otherwise loop forever.
+			if page.NextPageToken == "" || seen[page.NextPageToken] {
+				return
+			}
+			seen[page.NextPageToken] = true
+			query.Set("page_token", page.NextPageToken)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package railzwayclient
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedSubscriptions serves three pages of subscriptions keyed by page token.
+func pagedSubscriptions(t *testing.T) (*Client, *atomic.Int32) {
+	pages := map[string]Page[Subscription]{
+		"":   {Items: []Subscription{{ID: "sub_1"}, {ID: "sub_2"}}, NextPageToken: "p2"},
+		"p2": {Items: []Subscription{{ID: "sub_3"}, {ID: "sub_4"}}, NextPageToken: "p3"},
+		"p3": {Items: []Subscription{{ID: "sub_5"}}},
+	}
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		assert.Equal(t, "2", r.URL.Query().Get("page_size"))
+		assert.Equal(t, "active", r.URL.Query().Get("status"))
+		page := pages[r.URL.Query().Get("page_token")]
+		writeJSON(w, http.StatusOK, map[string]any{"items": page.Items, "next_page_token": page.NextPageToken})
+	})
+	return New(testConfig(server.URL)), hits
+}
+
+func TestAllSubscriptions_WalksEveryPage(t *testing.T) {
+	client, hits := pagedSubscriptions(t)
+
+	var ids []string
+	for sub, err := range client.AllSubscriptions(context.Background(), ListSubscriptionsParams{Status: "active", PageParams: PageParams{PageSize: 2}}) {
+		require.NoError(t, err)
+		ids = append(ids, sub.ID)
+	}
+	assert.Equal(t, []string{"sub_1", "sub_2", "sub_3", "sub_4", "sub_5"}, ids)
+	assert.Equal(t, int32(3), hits.Load())
+}
+
+func TestAllSubscriptions_StopsWhenCallerStops(t *testing.T) {
+	client, hits := pagedSubscriptions(t)
+
+	for sub, err := range client.AllSubscriptions(context.Background(), ListSubscriptionsParams{Status: "active", PageParams: PageParams{PageSize: 2}}) {
+		require.NoError(t, err)
+		if sub.ID == "sub_2" {
+			break
+		}
+	}
+	assert.Equal(t, int32(1), hits.Load(), "the next page is not fetched")
+}
+
+func TestAllSubscriptions_StopsOnContextCancel(t *testing.T) {
+	client, hits := pagedSubscriptions(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ids []string
+	var iterErr error
+	for sub, err := range client.AllSubscriptions(ctx, ListSubscriptionsParams{Status: "active", PageParams: PageParams{PageSize: 2}}) {
+		if err != nil {
+			iterErr = err
+			break
+		}
+		ids = append(ids, sub.ID)
+		cancel()
+	}
+	assert.ErrorIs(t, iterErr, context.Canceled)
+	assert.Equal(t, []string{"sub_1", "sub_2"}, ids)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestPaginate_YieldsRequestErrors(t *testing.T) {
+	server, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		if r.URL.Query().Get("page_token") == "" {
+			writeJSON(w, http.StatusOK, map[string]any{"data": []Price{{ID: "price_1"}}, "next_page_token": "p2"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_page_token"})
+	})
+	client := New(testConfig(server.URL))
+
+	var ids []string
+	var iterErr error
+	for price, err := range client.AllPrices(context.Background(), ListPricesParams{}) {
+		if err != nil {
+			iterErr = err
+			break
+		}
+		ids = append(ids, price.ID)
+	}
+	assert.Equal(t, []string{"price_1"}, ids)
+	var apiErr *APIError
+	require.ErrorAs(t, iterErr, &apiErr)
+	assert.Equal(t, "invalid_page_token", apiErr.Code)
+}
+
+func TestPaginate_BareArrayAndRepeatedToken(t *testing.T) {
+	server, hits := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		switch r.URL.Path {
+		case "/api/meters":
+			writeJSON(w, http.StatusOK, []Meter{{ID: "meter_1"}, {ID: "meter_2"}})
+		default:
+			writeJSON(w, http.StatusOK, map[string]any{"items": []Product{{ID: "prod_1"}}, "next_page_token": "same"})
+		}
+	})
+	client := New(testConfig(server.URL))
+
+	var meters int
+	for _, err := range client.AllMeters(context.Background(), PageParams{}) {
+		require.NoError(t, err)
+		meters++
+	}
+	assert.Equal(t, 2, meters)
+	assert.Equal(t, int32(1), hits.Load())
+
+	var products int
+	for _, err := range client.AllProducts(context.Background(), PageParams{}) {
+		require.NoError(t, err)
+		products++
+	}
+	assert.Equal(t, 2, products, "a repeated page token ends the walk")
+	assert.Equal(t, int32(3), hits.Load())
+}
@@ -3,7 +3,9 @@ package railzwayclient
 import (
 	"context"
 	"fmt"
+	"iter"
 	"net/http"
+	"net/url"
 )
 
 // Price represents a price entity
@@ -45,24 +47,40 @@ type Pricing struct {
 
 // === Prices ===
 
-// PriceListOptions options for listing prices
-type PriceListOptions struct {
-	Code string `url:"code,omitempty"`
+// ListPricesParams filters prices.
+type ListPricesParams struct {
+	Code string
+	PageParams
 }
 
-// ListPrices lists all prices
-func (c *Client) ListPrices(ctx context.Context, opts *PriceListOptions) ([]Price, error) {
-	path := "/api/prices"
-	if opts != nil && opts.Code != "" {
-		path += "?code=" + opts.Code
+// PriceListOptions is the former name of ListPricesParams.
+type PriceListOptions = ListPricesParams
+
+func (params ListPricesParams) query() url.Values {
+	query := url.Values{}
+	if params.Code != "" {
+		query.Set("code", params.Code)
 	}
+	params.PageParams.apply(query)
+	return query
+}
 
-	var resp ResponseWrapper[[]Price]
-	err := c.doRequest(ctx, http.MethodGet, path, nil, &resp)
+// ListPrices lists one page of prices
+func (c *Client) ListPrices(ctx context.Context, opts *ListPricesParams) ([]Price, error) {
+	var params ListPricesParams
+	if opts != nil {
+		params = *opts
+	}
+	page, err := listPage[Price](ctx, c, "/api/prices", params.query())
 	if err != nil {
 		return nil, fmt.Errorf("failed to list prices: %w", err)
 	}
-	return resp.Data, nil
+	return page.Items, nil
+}
+
+// AllPrices iterates over the prices matching params, page by page
+func (c *Client) AllPrices(ctx context.Context, params ListPricesParams) iter.Seq2[Price, error] {
+	return paginate[Price](ctx, c, "/api/prices", params.query())
 }
 
 // GetPriceByCode retrieves a price by its unique code
@@ -72,7 +90,7 @@ func (c *Client) GetPriceByCode(ctx context.Context, code string) (*Price, error
 		return nil, err
 	}
 	if len(prices) == 0 {
-		return nil, fmt.Errorf("price with code %s not found", code)
+		return nil, fmt.Errorf("price with code %s: %w", code, ErrNotFound)
 	}
 	return &prices[0], nil
 }
@@ -100,19 +118,33 @@ func (c *Client) CreatePrice(ctx context.Context, req any) (*Price, error) {
 
 // === Price Amounts ===
 
-// ListPriceAmounts lists price amounts, optionally filtered by price ID
-func (c *Client) ListPriceAmounts(ctx context.Context, priceID string) ([]PriceAmount, error) {
-	path := "/api/price_amounts"
-	if priceID != "" {
-		path += "?price_id=" + priceID
+// ListPriceAmountsParams filters price amounts.
+type ListPriceAmountsParams struct {
+	PriceID string
+	PageParams
+}
+
+func (params ListPriceAmountsParams) query() url.Values {
+	query := url.Values{}
+	if params.PriceID != "" {
+		query.Set("price_id", params.PriceID)
 	}
+	params.PageParams.apply(query)
+	return query
+}
 
-	var resp ResponseWrapper[[]PriceAmount]
-	err := c.doRequest(ctx, http.MethodGet, path, nil, &resp)
+// ListPriceAmounts lists one page of price amounts, optionally filtered by price ID
+func (c *Client) ListPriceAmounts(ctx context.Context, priceID string) ([]PriceAmount, error) {
+	page, err := listPage[PriceAmount](ctx, c, "/api/price_amounts", ListPriceAmountsParams{PriceID: priceID}.query())
 	if err != nil {
 		return nil, fmt.Errorf("failed to list price amounts: %w", err)
 	}
-	return resp.Data, nil
+	return page.Items, nil
+}
+
+// AllPriceAmounts iterates over the price amounts matching params, page by page
+func (c *Client) AllPriceAmounts(ctx context.Context, params ListPriceAmountsParams) iter.Seq2[PriceAmount, error] {
+	return paginate[PriceAmount](ctx, c, "/api/price_amounts", params.query())
 }
 
 // GetPriceAmount retrieves a price amount by ID
@@ -138,14 +170,18 @@ func (c *Client) CreatePriceAmount(ctx context.Context, req any) (*PriceAmount,
 
 // === Price Tiers ===
 
-// ListPriceTiers lists all price tiers
+// ListPriceTiers lists one page of price tiers
 func (c *Client) ListPriceTiers(ctx context.Context) ([]PriceTier, error) {
-	var resp ResponseWrapper[[]PriceTier]
-	err := c.doRequest(ctx, http.MethodGet, "/api/price_tiers", nil, &resp)
+	page, err := listPage[PriceTier](ctx, c, "/api/price_tiers", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list price tiers: %w", err)
 	}
-	return resp.Data, nil
+	return page.Items, nil
+}
+
+// AllPriceTiers iterates over all price tiers, page by page
+func (c *Client) AllPriceTiers(ctx context.Context, params PageParams) iter.Seq2[PriceTier, error] {
+	return paginate[PriceTier](ctx, c, "/api/price_tiers", params.query())
 }
 
 // GetPriceTier retrieves a price tier by ID
@@ -171,14 +207,18 @@ func (c *Client) CreatePriceTier(ctx context.Context, req any) (*PriceTier, erro
 
 // === Pricings ===
 
-// ListPricings lists all pricings
+// ListPricings lists one page of pricings
 func (c *Client) ListPricings(ctx context.Context) ([]Pricing, error) {
-	var resp ResponseWrapper[[]Pricing]
-	err := c.doRequest(ctx, http.MethodGet, "/api/pricings", nil, &resp)
+	page, err := listPage[Pricing](ctx, c, "/api/pricings", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pricings: %w", err)
 	}
-	return resp.Data, nil
+	return page.Items, nil
+}
+
+// AllPricings iterates over all pricings, page by page
+func (c *Client) AllPricings(ctx context.Context, params PageParams) iter.Seq2[Pricing, error] {
+	return paginate[Pricing](ctx, c, "/api/pricings", params.query())
 }
 
 // GetPricing retrieves a pricing by ID
@@ -3,13 +3,15 @@ package railzwayclient
 import (
 	"context"
 	"fmt"
+	"iter"
 	"net/http"
 )
 
 type Product struct {
-	ID   string `json:"id"`
-	Code string `json:"code"`
-	Name string `json:"name"`
+	ID       string         `json:"id"`
+	Code     string         `json:"code"`
+	Name     string         `json:"name"`
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
 type CreateProductRequest struct {
@@ -20,10 +22,18 @@ type CreateProductRequest struct {
 	Metadata    map[string]any `json:"metadata,omitempty"`
 }
 
+// ListProducts lists one page of products
 func (c *Client) ListProducts(ctx context.Context) ([]Product, error) {
-	var out []Product
-	err := c.doRequest(ctx, http.MethodGet, "/api/products", nil, &out)
-	return out, err
+	page, err := listPage[Product](ctx, c, "/api/products", nil)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// AllProducts iterates over all products, page by page
+func (c *Client) AllProducts(ctx context.Context, params PageParams) iter.Seq2[Product, error] {
+	return paginate[Product](ctx, c, "/api/products", params.query())
 }
 
 func (c *Client) CreateProduct(ctx context.Context, req any) (*Product, error) {
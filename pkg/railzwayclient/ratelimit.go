@@ -2,15 +2,29 @@ package railzwayclient
 
 import (
 	"context"
+	"time"
 
 	"golang.org/x/time/rate"
 )
 
 type RateLimiter struct{ l *rate.Limiter }
 
+// NewRateLimiter allows rpm requests per minute with bursts of burst.
+// A non-positive rpm disables the limit.
 func NewRateLimiter(rpm, burst int) *RateLimiter {
+	limit := rate.Limit(rpm) / 60
+	if rpm <= 0 {
+		limit = rate.Inf
+	}
 	return &RateLimiter{
-		l: rate.NewLimiter(rate.Limit(rpm)/60, burst),
+		l: rate.NewLimiter(limit, max(burst, 1)),
 	}
 }
-func (r *RateLimiter) Wait(ctx context.Context) { r.l.Wait(ctx) }
+
+// Wait blocks until a request may be sent. It fails when ctx is done first.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := r.l.Wait(ctx)
+	rateLimitWaitSeconds.Observe(time.Since(start).Seconds())
+	return err
+}
@@ -2,22 +2,100 @@ package railzwayclient
 
 import (
 	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
 	"time"
 )
 
+// maxRetryDelay caps the exponential backoff between attempts.
+const maxRetryDelay = 30 * time.Second
+
 type RetryPolicy struct {
 	MaxRetries int
 	BaseDelay  time.Duration
 }
 
+// Do runs fn until it succeeds, fails with an error that is not worth
+// retrying, or MaxRetries retries are used up. Calls that are not safe to
+// repeat run once. A Retry-After from the server stretches the backoff; one
+// longer than maxRetryDelay ends the retries so the caller can reschedule.
+// Waiting between attempts stops when ctx is done.
 func (r RetryPolicy) Do(ctx context.Context, safe bool, fn func() error) error {
-	var err error
-	for i := 0; i <= r.MaxRetries; i++ {
-		err = fn()
-		if err == nil || !safe {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !safe || attempt >= r.MaxRetries || !retryable(err) {
 			return err
 		}
-		time.Sleep(r.BaseDelay * time.Duration(i+1))
+
+		delay := r.Backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			if apiErr.RetryAfter > maxRetryDelay {
+				return err
+			}
+			delay = apiErr.RetryAfter
+		}
+		if waitErr := sleep(ctx, delay); waitErr != nil {
+			return errors.Join(err, waitErr)
+		}
+	}
+}
+
+// Backoff returns the delay before retry attempt+1: exponential in attempt,
+// capped at maxRetryDelay, with equal jitter so clients that failed
+// together do not retry together.
+func (r RetryPolicy) Backoff(attempt int) time.Duration {
+	if r.BaseDelay <= 0 {
+		return 0
+	}
+	delay := r.BaseDelay << min(attempt, 16)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// idempotent reports whether a request with method may be sent again
+// without repeating its effect.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryable reports whether err is transient: a transport failure, a
+// throttled request or a server error. Client errors and an open circuit
+// breaker fail fast.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return errors.Is(apiErr, ErrRateLimited) || errors.Is(apiErr, ErrUnavailable)
+	}
+	return true
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	return err
 }
@@ -3,7 +3,9 @@ package railzwayclient
 import (
 	"context"
 	"fmt"
+	"iter"
 	"net/http"
+	"net/url"
 )
 
 type Subscription struct {
@@ -20,17 +22,37 @@ type SubscriptionItem struct {
 	Quantity       int    `json:"quantity"`
 }
 
-// ListSubscriptions lists all subscriptions
-func (c *Client) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
-	// Wrapper logic for list might be different. Usually list endpoints return { "items": [...] }
-	// Assuming ListSubscriptions follows the customer list pattern (wrapped in `items` or `data`?)
-	// Let's assume server returns { "data": [...] } for consistency or check ListCustomers implementation.
-	// Actually, ListCustomers returned { "items": [...] }.
-	// Let's check railzway-oss implementation for ListSubscriptions if possible.
-	// If uncertain, let's look at `GetSubscription` and `CreateSubscription` which likely return single object wrapped in `data`.
-
-	// For now, let's update Get and Create.
-	return nil, fmt.Errorf("not implemented")
+// ListSubscriptionsParams filters subscriptions.
+type ListSubscriptionsParams struct {
+	CustomerID string
+	Status     string
+	PageParams
+}
+
+func (params ListSubscriptionsParams) query() url.Values {
+	query := url.Values{}
+	if params.CustomerID != "" {
+		query.Set("customer_id", params.CustomerID)
+	}
+	if params.Status != "" {
+		query.Set("status", params.Status)
+	}
+	params.PageParams.apply(query)
+	return query
+}
+
+// ListSubscriptions lists one page of subscriptions
+func (c *Client) ListSubscriptions(ctx context.Context, params ListSubscriptionsParams) ([]Subscription, error) {
+	page, err := listPage[Subscription](ctx, c, "/api/subscriptions", params.query())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	return page.Items, nil
+}
+
+// AllSubscriptions iterates over the subscriptions matching params, page by page
+func (c *Client) AllSubscriptions(ctx context.Context, params ListSubscriptionsParams) iter.Seq2[Subscription, error] {
+	return paginate[Subscription](ctx, c, "/api/subscriptions", params.query())
 }
 
 // GetSubscription retrieves a subscription by ID
@@ -45,26 +67,27 @@ func (c *Client) GetSubscription(ctx context.Context, id string) (*Subscription,
 }
 
 // ActivateSubscription activates a subscription
-func (c *Client) ActivateSubscription(ctx context.Context, id string) error {
+func (c *Client) ActivateSubscription(ctx context.Context, id string, idempotencyKey string) error {
 	path := fmt.Sprintf("/api/subscriptions/%s/activate", id)
-	err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	err := c.doKeyedRequest(ctx, http.MethodPost, path, idempotencyKey, nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed to activate subscription: %w", err)
 	}
 	return nil
 }
 
-// ListSubscriptionItems lists items for a subscription
+// ListSubscriptionItems lists one page of items of a subscription
 func (c *Client) ListSubscriptionItems(ctx context.Context, id string) ([]SubscriptionItem, error) {
-	// CAUTION: Check if items list returns { "data": [...] } or { "items": [...] }
-	// Assuming { "data": [...] } for now based on other endpoints.
-	path := fmt.Sprintf("/api/subscriptions/%s/items", id)
-	var resp ResponseWrapper[[]SubscriptionItem]
-	err := c.doRequest(ctx, http.MethodGet, path, nil, &resp)
+	page, err := listPage[SubscriptionItem](ctx, c, fmt.Sprintf("/api/subscriptions/%s/items", id), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list subscription items: %w", err)
 	}
-	return resp.Data, nil
+	return page.Items, nil
+}
+
+// AllSubscriptionItems iterates over the items of a subscription, page by page
+func (c *Client) AllSubscriptionItems(ctx context.Context, id string, params PageParams) iter.Seq2[SubscriptionItem, error] {
+	return paginate[SubscriptionItem](ctx, c, fmt.Sprintf("/api/subscriptions/%s/items", id), params.query())
 }
 
 type CreateSubscriptionItemRequest struct {
@@ -80,7 +103,10 @@ type CreateSubscriptionRequest struct {
 	Items            []CreateSubscriptionItemRequest `json:"items"`
 }
 
-func (c *Client) CreateSubscription(ctx context.Context, customerID string, billingCycleType string, items []CreateSubscriptionItemRequest) (*Subscription, error) {
+// CreateSubscription creates a draft subscription. Callers that may repeat
+// the call should pass an idempotency key, or a timeout after OSS committed
+// leaves a duplicate subscription behind.
+func (c *Client) CreateSubscription(ctx context.Context, customerID string, billingCycleType string, items []CreateSubscriptionItemRequest, idempotencyKey string) (*Subscription, error) {
 	reqBody := CreateSubscriptionRequest{
 		CustomerID:       customerID,
 		CollectionMode:   "CHARGE_AUTOMATICALLY",
@@ -89,7 +115,7 @@ func (c *Client) CreateSubscription(ctx context.Context, customerID string, bill
 	}
 
 	var resp ResponseWrapper[Subscription]
-	err := c.doRequest(ctx, http.MethodPost, "/api/subscriptions", reqBody, &resp)
+	err := c.doKeyedRequest(ctx, http.MethodPost, "/api/subscriptions", idempotencyKey, reqBody, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create subscription: %w", err)
 	}
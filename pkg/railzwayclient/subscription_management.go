@@ -15,10 +15,10 @@ type ChangePlanRequest struct {
 }
 
 // PauseSubscription pauses an active subscription (stops billing).
-func (c *Client) PauseSubscription(ctx context.Context, subscriptionID string) error {
+func (c *Client) PauseSubscription(ctx context.Context, subscriptionID string, idempotencyKey string) error {
 	url := fmt.Sprintf("/api/subscriptions/%s/pause", subscriptionID)
 
-	err := c.doRequest(ctx, http.MethodPost, url, nil, nil)
+	err := c.doKeyedRequest(ctx, http.MethodPost, url, idempotencyKey, nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed to pause subscription: %w", err)
 	}
@@ -27,10 +27,10 @@ func (c *Client) PauseSubscription(ctx context.Context, subscriptionID string) e
 }
 
 // ResumeSubscription resumes a paused subscription (restarts billing).
-func (c *Client) ResumeSubscription(ctx context.Context, subscriptionID string) error {
+func (c *Client) ResumeSubscription(ctx context.Context, subscriptionID string, idempotencyKey string) error {
 	url := fmt.Sprintf("/api/subscriptions/%s/resume", subscriptionID)
 
-	err := c.doRequest(ctx, http.MethodPost, url, nil, nil)
+	err := c.doKeyedRequest(ctx, http.MethodPost, url, idempotencyKey, nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed to resume subscription: %w", err)
 	}
@@ -39,10 +39,10 @@ func (c *Client) ResumeSubscription(ctx context.Context, subscriptionID string)
 }
 
 // ChangePlan changes the subscription plan (upgrade/downgrade).
-func (c *Client) ChangePlan(ctx context.Context, subscriptionID string, req *ChangePlanRequest) error {
+func (c *Client) ChangePlan(ctx context.Context, subscriptionID string, req *ChangePlanRequest, idempotencyKey string) error {
 	url := fmt.Sprintf("/api/subscriptions/%s/change-plan", subscriptionID)
 
-	err := c.doRequest(ctx, http.MethodPost, url, req, nil)
+	err := c.doKeyedRequest(ctx, http.MethodPost, url, idempotencyKey, req, nil)
 	if err != nil {
 		return fmt.Errorf("failed to change plan: %w", err)
 	}
@@ -51,14 +51,14 @@ func (c *Client) ChangePlan(ctx context.Context, subscriptionID string, req *Cha
 }
 
 // CancelSubscription cancels a subscription.
-func (c *Client) CancelSubscription(ctx context.Context, subscriptionID string, cancelAtPeriodEnd bool) error {
+func (c *Client) CancelSubscription(ctx context.Context, subscriptionID string, cancelAtPeriodEnd bool, idempotencyKey string) error {
 	url := fmt.Sprintf("/api/subscriptions/%s/cancel", subscriptionID)
 
 	reqBody := map[string]interface{}{
 		"cancel_at_period_end": cancelAtPeriodEnd,
 	}
 
-	err := c.doRequest(ctx, http.MethodPost, url, reqBody, nil)
+	err := c.doKeyedRequest(ctx, http.MethodPost, url, idempotencyKey, reqBody, nil)
 	if err != nil {
 		return fmt.Errorf("failed to cancel subscription: %w", err)
 	}
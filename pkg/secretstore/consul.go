@@ -0,0 +1,108 @@
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Consul stores each value as its own key in Consul KV.
+type Consul struct {
+	addr  string
+	token string
+	http  *http.Client
+}
+
+func NewConsul(addr, token string) *Consul {
+	return &Consul{
+		addr:  strings.TrimRight(addr, "/"),
+		token: token,
+		http:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type consulTxnOp struct {
+	KV consulKVOp `json:"KV"`
+}
+
+type consulKVOp struct {
+	Verb  string `json:"Verb"`
+	Key   string `json:"Key"`
+	Value string `json:"Value,omitempty"`
+}
+
+// Put writes all values in one transaction, so a workload never reads a mix
+// of old and new credentials.
+func (c *Consul) Put(ctx context.Context, path string, values map[string]string) error {
+	ops := make([]consulTxnOp, 0, len(values)+1)
+	ops = append(ops, consulTxnOp{KV: consulKVOp{Verb: "delete-tree", Key: strings.Trim(path, "/") + "/"}})
+	for _, key := range sortedKeys(values) {
+		ops = append(ops, consulTxnOp{KV: consulKVOp{
+			Verb:  "set",
+			Key:   joinPath(path, key),
+			Value: base64.StdEncoding.EncodeToString([]byte(values[key])),
+		}})
+	}
+	body, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("encode consul transaction: %w", err)
+	}
+
+	req, err := c.request(ctx, http.MethodPut, "/v1/txn", body)
+	if err != nil {
+		return err
+	}
+	if err := do(c.http, req); err != nil {
+		return fmt.Errorf("consul put %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Consul) Delete(ctx context.Context, path string) error {
+	req, err := c.request(ctx, http.MethodDelete, "/v1/kv/"+strings.Trim(path, "/")+"?recurse=true", nil)
+	if err != nil {
+		return err
+	}
+	if err := do(c.http, req); err != nil {
+		return fmt.Errorf("consul delete %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Consul) Template(path string, keys []string) string {
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s={{ key %q }}\n", key, joinPath(path, key))
+	}
+	return b.String()
+}
+
+func (c *Consul) NeedsVaultToken() bool {
+	return false
+}
+
+func (c *Consul) request(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build consul request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	return req, nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
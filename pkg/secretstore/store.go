@@ -0,0 +1,43 @@
+// Package secretstore keeps the credentials of tenant workloads in Consul KV
+// or Vault, so job specs only reference them through templates.
+package secretstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Store holds the credentials of a workload under a path.
+type Store interface {
+	// Put replaces the values stored under path.
+	Put(ctx context.Context, path string, values map[string]string) error
+	// Delete removes everything stored under path.
+	Delete(ctx context.Context, path string) error
+	// Template returns a consul-template that renders the keys under path as
+	// KEY=value lines, for a Nomad template exported as environment.
+	Template(path string, keys []string) string
+	// NeedsVaultToken reports whether the template reads from Vault.
+	NeedsVaultToken() bool
+}
+
+// do sends a request and fails on any status outside 2xx.
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		raw, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	return strings.Trim(path, "/") + "/" + key
+}
@@ -0,0 +1,136 @@
+package secretstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsul implements the transaction and recursive delete endpoints of
+// Consul KV over a map.
+type fakeConsul struct {
+	mu  sync.Mutex
+	kv  map[string]string
+	acl string
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acl = r.Header.Get("X-Consul-Token")
+
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/txn":
+		var ops []consulTxnOp
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, op := range ops {
+			switch op.KV.Verb {
+			case "set":
+				value, _ := base64.StdEncoding.DecodeString(op.KV.Value)
+				f.kv[op.KV.Key] = string(value)
+			case "delete-tree":
+				f.deleteTree(op.KV.Key)
+			}
+		}
+	case r.Method == http.MethodDelete && r.URL.Query().Get("recurse") == "true":
+		f.deleteTree(strings.TrimPrefix(r.URL.Path, "/v1/kv/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeConsul) deleteTree(prefix string) {
+	for key := range f.kv {
+		if strings.HasPrefix(key, prefix) {
+			delete(f.kv, key)
+		}
+	}
+}
+
+func TestConsul_PutReplacesValuesAndDeleteRemovesThem(t *testing.T) {
+	fake := &fakeConsul{kv: map[string]string{"railzway-cloud/workloads/job-1/STALE": "old"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := NewConsul(server.URL, "acl-token")
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "railzway-cloud/workloads/job-1", map[string]string{"DB_PASSWORD": "s3cret"}))
+	assert.Equal(t, map[string]string{"railzway-cloud/workloads/job-1/DB_PASSWORD": "s3cret"}, fake.kv)
+	assert.Equal(t, "acl-token", fake.acl)
+
+	assert.Equal(t,
+		"DB_PASSWORD={{ key \"railzway-cloud/workloads/job-1/DB_PASSWORD\" }}\n",
+		store.Template("railzway-cloud/workloads/job-1", []string{"DB_PASSWORD"}))
+	assert.False(t, store.NeedsVaultToken())
+
+	require.NoError(t, store.Delete(ctx, "railzway-cloud/workloads/job-1"))
+	assert.Empty(t, fake.kv)
+}
+
+// fakeVault implements the data and metadata endpoints of a KV version 2
+// engine mounted at secret/.
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]string
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("X-Vault-Token") != "vault-token" {
+		http.Error(w, "permission denied", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")] = body.Data
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/"):
+		delete(f.secrets, strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestVault_PutAndDelete(t *testing.T) {
+	fake := &fakeVault{secrets: make(map[string]map[string]string)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := NewVault(server.URL, "vault-token", "")
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "railzway-cloud/workloads/job-1", map[string]string{"DB_PASSWORD": "s3cret"}))
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "s3cret"}, fake.secrets["railzway-cloud/workloads/job-1"])
+
+	assert.Equal(t,
+		"{{ with secret \"secret/data/railzway-cloud/workloads/job-1\" }}\nDB_PASSWORD={{ index .Data.data \"DB_PASSWORD\" }}\n{{ end }}\n",
+		store.Template("railzway-cloud/workloads/job-1", []string{"DB_PASSWORD"}))
+	assert.True(t, store.NeedsVaultToken())
+
+	require.NoError(t, store.Delete(ctx, "railzway-cloud/workloads/job-1"))
+	assert.Empty(t, fake.secrets)
+
+	err := NewVault(server.URL, "wrong", "secret").Put(ctx, "job-2", map[string]string{"DB_PASSWORD": "x"})
+	assert.ErrorContains(t, err, "status 403")
+}
@@ -0,0 +1,86 @@
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Vault stores the values of a path as one secret in a KV version 2 engine.
+type Vault struct {
+	addr  string
+	token string
+	mount string
+	http  *http.Client
+}
+
+func NewVault(addr, token, mount string) *Vault {
+	if mount == "" {
+		mount = "secret"
+	}
+	return &Vault{
+		addr:  strings.TrimRight(addr, "/"),
+		token: token,
+		mount: strings.Trim(mount, "/"),
+		http:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (v *Vault) Put(ctx context.Context, path string, values map[string]string) error {
+	body, err := json.Marshal(map[string]any{"data": values})
+	if err != nil {
+		return fmt.Errorf("encode vault secret: %w", err)
+	}
+	req, err := v.request(ctx, http.MethodPost, v.dataPath(path), body)
+	if err != nil {
+		return err
+	}
+	if err := do(v.http, req); err != nil {
+		return fmt.Errorf("vault put %s: %w", path, err)
+	}
+	return nil
+}
+
+// Delete removes the secret with all its versions.
+func (v *Vault) Delete(ctx context.Context, path string) error {
+	req, err := v.request(ctx, http.MethodDelete, "/v1/"+v.mount+"/metadata/"+strings.Trim(path, "/"), nil)
+	if err != nil {
+		return err
+	}
+	if err := do(v.http, req); err != nil {
+		return fmt.Errorf("vault delete %s: %w", path, err)
+	}
+	return nil
+}
+
+func (v *Vault) Template(path string, keys []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "{{ with secret %q }}\n", v.mount+"/data/"+strings.Trim(path, "/"))
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s={{ index .Data.data %q }}\n", key, key)
+	}
+	b.WriteString("{{ end }}\n")
+	return b.String()
+}
+
+func (v *Vault) NeedsVaultToken() bool {
+	return true
+}
+
+func (v *Vault) dataPath(path string) string {
+	return "/v1/" + v.mount + "/data/" + strings.Trim(path, "/")
+}
+
+func (v *Vault) request(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, v.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
@@ -0,0 +1,69 @@
+package testhelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/railzwaylabs/railzway-cloud/sql/migrations"
+	"github.com/testcontainers/testcontainers-go"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Migrate applies the repository migrations to the container database.
+func (c *PostgresContainer) Migrate() error {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return fmt.Errorf("load migration files: %w", err)
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", source, c.DSN)
+	if err != nil {
+		return fmt.Errorf("create migrate instance: %w", err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migration up failed: %w", err)
+	}
+	return nil
+}
+
+// SetupMigratedPostgres starts a Postgres container with every migration
+// applied and returns a connection to it. The container is terminated when
+// the test ends. Tests are skipped in short mode and when Docker is missing.
+func SetupMigratedPostgres(t *testing.T) *gorm.DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := context.Background()
+	pg, err := SetupPostgres(ctx)
+	if err != nil {
+		t.Fatalf("setup postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := pg.Teardown(ctx); err != nil {
+			t.Logf("failed to teardown container: %v", err)
+		}
+	})
+
+	if err := pg.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	conn, err := gorm.Open(gormpostgres.Open(pg.DSN), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return conn
+}
@@ -1,6 +1,7 @@
 package testhelper
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,6 +12,7 @@ type MockAuthServer struct {
 	Server          *httptest.Server
 	TokenRequests   int
 	ClientRequests  int
+	RotateRequests  int
 	ShouldFailToken bool
 	ShouldFailClient bool
 }
@@ -52,6 +54,13 @@ func NewMockAuthServer(t *testing.T) *MockAuthServer {
 		}`))
 	})
 
+	// OAuth client secret rotation endpoint
+	mux.HandleFunc("/admin/oauth/clients/{id}/rotate-secret", func(w http.ResponseWriter, r *http.Request) {
+		mock.RotateRequests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"client_id":%q,"client_secret":"test-client-secret-%d"}`, r.PathValue("id"), mock.RotateRequests)
+	})
+
 	mock.Server = httptest.NewServer(mux)
 	t.Cleanup(mock.Server.Close)
 
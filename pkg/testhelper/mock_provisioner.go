@@ -10,7 +10,10 @@ import (
 // MockProvisioner is a mock implementation of provisioning.Provisioner for testing
 type MockProvisioner struct {
 	DeployCalls []provisioning.DeploymentConfig
+	StopCalls   []provisioning.Workload
 	ShouldFail  bool
+	// Statuses overrides GetStatus per job ID; unknown jobs report "running".
+	Statuses map[string]string
 }
 
 // Deploy mocks the Deploy method
@@ -23,32 +26,56 @@ func (m *MockProvisioner) Deploy(ctx context.Context, cfg *provisioning.Deployme
 }
 
 // Stop mocks the Stop method
-func (m *MockProvisioner) Stop(ctx context.Context, orgID int64) error {
+func (m *MockProvisioner) Stop(ctx context.Context, workload provisioning.Workload) error {
 	if m.ShouldFail {
 		return fmt.Errorf("mock provisioner: stop failed")
 	}
+	m.StopCalls = append(m.StopCalls, workload)
 	return nil
 }
 
 // GetStatus mocks the GetStatus method
-func (m *MockProvisioner) GetStatus(ctx context.Context, orgID int64) (string, error) {
+func (m *MockProvisioner) GetStatus(ctx context.Context, workload provisioning.Workload) (string, error) {
 	if m.ShouldFail {
 		return "", fmt.Errorf("mock provisioner: get status failed")
 	}
+	if status, ok := m.Statuses[workload.JobID]; ok {
+		return status, nil
+	}
 	return "running", nil
 }
 
 // MockDatabaseProvisioner is a mock implementation of provisioning.DatabaseProvisioner
 type MockDatabaseProvisioner struct {
-	ProvisionCalls []int64
-	ShouldFail     bool
+	ProvisionCalls    []provisioning.DBConfig
+	DisableLoginCalls []provisioning.DBConfig
+	DeprovisionCalls  []provisioning.DBConfig
+	ShouldFail        bool
 }
 
 // Provision mocks the Provision method
-func (m *MockDatabaseProvisioner) Provision(ctx context.Context, orgID int64, password string) error {
+func (m *MockDatabaseProvisioner) Provision(ctx context.Context, db provisioning.DBConfig) error {
 	if m.ShouldFail {
 		return fmt.Errorf("mock db provisioner: provision failed")
 	}
-	m.ProvisionCalls = append(m.ProvisionCalls, orgID)
+	m.ProvisionCalls = append(m.ProvisionCalls, db)
+	return nil
+}
+
+// DisableLogin mocks the DisableLogin method
+func (m *MockDatabaseProvisioner) DisableLogin(ctx context.Context, db provisioning.DBConfig) error {
+	if m.ShouldFail {
+		return fmt.Errorf("mock db provisioner: disable login failed")
+	}
+	m.DisableLoginCalls = append(m.DisableLoginCalls, db)
+	return nil
+}
+
+// Deprovision mocks the Deprovision method
+func (m *MockDatabaseProvisioner) Deprovision(ctx context.Context, db provisioning.DBConfig) error {
+	if m.ShouldFail {
+		return fmt.Errorf("mock db provisioner: deprovision failed")
+	}
+	m.DeprovisionCalls = append(m.DeprovisionCalls, db)
 	return nil
 }
@@ -0,0 +1,229 @@
+package testhelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// MockRailzwayServer is an in-memory Railzway OSS billing API covering the
+// customer, subscription and product entitlement calls made while
+// provisioning.
+type MockRailzwayServer struct {
+	Server *httptest.Server
+
+	mu            sync.Mutex
+	nextID        int
+	customers     map[string]mockCustomer // by external ID
+	subscriptions map[string]*mockSubscription
+	entitlements  map[string]map[string]any             // by price ID
+	replays       map[string]*httptest.ResponseRecorder // by idempotency key
+	replayed      int
+}
+
+type mockCustomer struct {
+	ID         string `json:"id"`
+	Email      string `json:"email"`
+	Name       string `json:"name"`
+	ExternalID string `json:"external_id"`
+}
+
+type mockSubscription struct {
+	ID         string `json:"id"`
+	CustomerID string `json:"customer_id"`
+	Status     string `json:"status"`
+}
+
+// NewMockRailzwayServer creates a new mock Railzway OSS server
+func NewMockRailzwayServer(t *testing.T) *MockRailzwayServer {
+	mock := &MockRailzwayServer{
+		customers:     make(map[string]mockCustomer),
+		subscriptions: make(map[string]*mockSubscription),
+		entitlements:  make(map[string]map[string]any),
+		replays:       make(map[string]*httptest.ResponseRecorder),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/customers", mock.listCustomers)
+	mux.HandleFunc("POST /api/customers", mock.createCustomer)
+	mux.HandleFunc("POST /api/subscriptions", mock.createSubscription)
+	mux.HandleFunc("GET /api/subscriptions/{id}", mock.getSubscription)
+	mux.HandleFunc("POST /api/subscriptions/{id}/{action}", mock.subscriptionAction)
+	mux.HandleFunc("GET /api/prices/{id}", mock.getPrice)
+	mux.HandleFunc("GET /api/products/{id}", mock.getProduct)
+
+	mock.Server = httptest.NewServer(mock.idempotent(mux))
+	t.Cleanup(mock.Server.Close)
+
+	return mock
+}
+
+// SubscriptionStatus returns the status of a subscription, or "" if unknown.
+func (m *MockRailzwayServer) SubscriptionStatus(id string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sub, ok := m.subscriptions[id]; ok {
+		return sub.Status
+	}
+	return ""
+}
+
+// SetEntitlements sets the entitlements of the product behind a price.
+// Every price has its own product.
+func (m *MockRailzwayServer) SetEntitlements(priceID string, entitlements map[string]any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entitlements[priceID] = entitlements
+}
+
+// ReplayedRequests returns how many requests were answered from an earlier
+// request with the same idempotency key.
+func (m *MockRailzwayServer) ReplayedRequests() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.replayed
+}
+
+// idempotent answers a POST whose Idempotency-Key was seen before with the
+// recorded response, like OSS does.
+func (m *MockRailzwayServer) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		m.mu.Lock()
+		recorded, ok := m.replays[key]
+		if ok {
+			m.replayed++
+		}
+		m.mu.Unlock()
+		if !ok {
+			recorded = httptest.NewRecorder()
+			next.ServeHTTP(recorded, r)
+			m.mu.Lock()
+			m.replays[key] = recorded
+			m.mu.Unlock()
+		}
+
+		for k, v := range recorded.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(recorded.Code)
+		_, _ = w.Write(recorded.Body.Bytes())
+	})
+}
+
+func (m *MockRailzwayServer) listCustomers(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := []mockCustomer{}
+	if c, ok := m.customers[r.URL.Query().Get("external_id")]; ok {
+		items = append(items, c)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (m *MockRailzwayServer) createCustomer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email    string         `json:"email"`
+		Name     string         `json:"name"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	externalID, _ := req.Metadata["external_id"].(string)
+	c := mockCustomer{ID: m.newID("cus"), Email: req.Email, Name: req.Name, ExternalID: externalID}
+	m.customers[externalID] = c
+	writeJSON(w, http.StatusCreated, map[string]any{"data": c})
+}
+
+func (m *MockRailzwayServer) createSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CustomerID string `json:"customer_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub := &mockSubscription{ID: m.newID("sub"), CustomerID: req.CustomerID, Status: "draft"}
+	m.subscriptions[sub.ID] = sub
+	writeJSON(w, http.StatusCreated, map[string]any{"data": sub})
+}
+
+func (m *MockRailzwayServer) getSubscription(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subscriptions[r.PathValue("id")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": sub})
+}
+
+func (m *MockRailzwayServer) subscriptionAction(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subscriptions[r.PathValue("id")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	switch r.PathValue("action") {
+	case "activate", "resume":
+		sub.Status = "active"
+	case "pause":
+		sub.Status = "paused"
+	case "cancel":
+		sub.Status = "canceled"
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": sub})
+}
+
+func (m *MockRailzwayServer) getPrice(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "product_id": "prod_" + id, "code": id})
+}
+
+func (m *MockRailzwayServer) getProduct(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := r.PathValue("id")
+	product := map[string]any{"id": id, "code": id, "name": id}
+	if entitlements, ok := m.entitlements[strings.TrimPrefix(id, "prod_")]; ok {
+		product["metadata"] = map[string]any{"entitlements": entitlements}
+	}
+	writeJSON(w, http.StatusOK, product)
+}
+
+func (m *MockRailzwayServer) newID(prefix string) string {
+	m.nextID++
+	return fmt.Sprintf("%s_%d", prefix, m.nextID)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
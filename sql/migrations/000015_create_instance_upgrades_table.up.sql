@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS instance_upgrades (
+    id BIGSERIAL PRIMARY KEY,
+    instance_id BIGINT NOT NULL REFERENCES instances(id),
+    org_id BIGINT NOT NULL,
+    from_version VARCHAR(50) NOT NULL,
+    to_version VARCHAR(50) NOT NULL,
+    from_tier VARCHAR(50) NOT NULL,
+    to_tier VARCHAR(50) NOT NULL,
+    from_job_id VARCHAR(255) NOT NULL,
+    to_job_id VARCHAR(255) NOT NULL,
+    phase VARCHAR(50) NOT NULL,
+    readiness_status VARCHAR(50) DEFAULT 'unknown',
+    readiness_checked_at TIMESTAMP WITH TIME ZONE,
+    readiness_error TEXT,
+    last_error TEXT,
+    deadline_at TIMESTAMP WITH TIME ZONE,
+    drain_until TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- At most one in-flight upgrade per instance.
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_instance_upgrades_active
+    ON instance_upgrades(instance_id)
+    WHERE phase NOT IN ('completed', 'rolled_back');
+
+CREATE INDEX IF NOT EXISTS idx_instance_upgrades_phase
+    ON instance_upgrades(phase);
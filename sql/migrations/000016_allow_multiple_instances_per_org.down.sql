@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_instances_org_id;
+
+DROP INDEX IF EXISTS uniq_instances_org_environment_role;
+
+ALTER TABLE instances
+    ADD CONSTRAINT uniq_org_instance UNIQUE (org_id);
+
+ALTER TABLE instances
+    DROP COLUMN IF EXISTS environment;
@@ -0,0 +1,13 @@
+ALTER TABLE instances
+    ADD COLUMN IF NOT EXISTS environment VARCHAR(50) NOT NULL DEFAULT 'production';
+
+ALTER TABLE instances
+    DROP CONSTRAINT IF EXISTS uniq_org_instance;
+
+-- One live instance per (org, environment, role); terminated rows are kept for history.
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_instances_org_environment_role
+    ON instances(org_id, environment, role)
+    WHERE status <> 'terminated';
+
+CREATE INDEX IF NOT EXISTS idx_instances_org_id
+    ON instances(org_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_outbox_events_rollout;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS rollout_wave;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS rollout_id;
+
+DROP TABLE IF EXISTS rollout_targets;
+DROP TABLE IF EXISTS rollouts;
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS rollouts (
+    id BIGSERIAL PRIMARY KEY,
+    target_version VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    waves JSONB NOT NULL,
+    current_wave INT NOT NULL DEFAULT 0,
+    wave_started_at TIMESTAMP WITH TIME ZONE,
+    wave_passed_at TIMESTAMP WITH TIME ZONE,
+    halt_reason TEXT,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- At most one rollout holds the fleet at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_rollouts_active
+    ON rollouts((true))
+    WHERE status IN ('running', 'paused', 'halted');
+
+CREATE TABLE IF NOT EXISTS rollout_targets (
+    rollout_id BIGINT NOT NULL REFERENCES rollouts(id),
+    instance_id BIGINT NOT NULL REFERENCES instances(id),
+    org_id BIGINT NOT NULL,
+    tier VARCHAR(50) NOT NULL,
+    from_version VARCHAR(50),
+    wave INT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    last_error TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (rollout_id, instance_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rollout_targets_wave
+    ON rollout_targets(rollout_id, wave);
+
+-- Events of a rollout are only processed while their wave is active.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS rollout_id BIGINT;
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS rollout_wave INT;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_rollout
+    ON outbox_events(rollout_id, rollout_wave)
+    WHERE rollout_id IS NOT NULL;
@@ -0,0 +1,8 @@
+ALTER TABLE application_versions DROP COLUMN IF EXISTS suspected_at;
+ALTER TABLE application_versions DROP COLUMN IF EXISTS suspect_reason;
+ALTER TABLE application_versions DROP COLUMN IF EXISTS suspect;
+
+DROP TABLE IF EXISTS instance_rollbacks;
+
+ALTER TABLE instances DROP COLUMN IF EXISTS not_ready_since;
+ALTER TABLE instances DROP COLUMN IF EXISTS last_good_version;
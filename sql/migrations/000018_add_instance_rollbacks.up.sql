@@ -0,0 +1,31 @@
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS last_good_version VARCHAR(50);
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS not_ready_since TIMESTAMP WITH TIME ZONE;
+
+-- Instances serving and ready today are known-good on their current version.
+UPDATE instances
+SET last_good_version = current_version
+WHERE status IN ('active', 'running')
+  AND readiness_status = 'ready'
+  AND current_version IS NOT NULL
+  AND current_version <> '';
+
+CREATE TABLE IF NOT EXISTS instance_rollbacks (
+    id BIGSERIAL PRIMARY KEY,
+    instance_id BIGINT NOT NULL REFERENCES instances(id),
+    org_id BIGINT NOT NULL,
+    from_version VARCHAR(50) NOT NULL,
+    to_version VARCHAR(50) NOT NULL,
+    trigger VARCHAR(50) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_instance_rollbacks_instance_id
+    ON instance_rollbacks(instance_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_instance_rollbacks_from_version
+    ON instance_rollbacks(from_version, created_at);
+
+ALTER TABLE application_versions ADD COLUMN IF NOT EXISTS suspect BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE application_versions ADD COLUMN IF NOT EXISTS suspect_reason TEXT;
+ALTER TABLE application_versions ADD COLUMN IF NOT EXISTS suspected_at TIMESTAMP WITH TIME ZONE;
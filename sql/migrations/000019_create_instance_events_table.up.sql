@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS instance_events (
+    id BIGSERIAL PRIMARY KEY,
+    instance_id BIGINT NOT NULL REFERENCES instances(id),
+    org_id BIGINT NOT NULL,
+    actor_type VARCHAR(50) NOT NULL,
+    actor_id VARCHAR(255),
+    old_status VARCHAR(50),
+    new_status VARCHAR(50) NOT NULL,
+    old_lifecycle_state VARCHAR(50),
+    lifecycle_state VARCHAR(50),
+    desired_version VARCHAR(50),
+    current_version VARCHAR(50),
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_instance_events_instance_id
+    ON instance_events(instance_id, id DESC);
+
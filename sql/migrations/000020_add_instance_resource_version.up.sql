@@ -0,0 +1 @@
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS resource_version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE instance_upgrades DROP COLUMN IF EXISTS provisioner;
+ALTER TABLE instances DROP COLUMN IF EXISTS provisioner;
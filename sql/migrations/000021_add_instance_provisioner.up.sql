@@ -0,0 +1,2 @@
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS provisioner VARCHAR(50) NOT NULL DEFAULT 'nomad';
+ALTER TABLE instance_upgrades ADD COLUMN IF NOT EXISTS provisioner VARCHAR(50) NOT NULL DEFAULT 'nomad';
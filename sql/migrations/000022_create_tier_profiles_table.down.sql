@@ -0,0 +1,2 @@
+ALTER TABLE instances DROP COLUMN IF EXISTS tier_profile_revision;
+DROP TABLE IF EXISTS tier_profiles;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS tier_profiles (
+    tier VARCHAR(50) NOT NULL,
+    revision INT NOT NULL,
+    spec JSONB NOT NULL,
+    comment TEXT,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tier, revision)
+);
+
+-- Revision 1 of every tier matches the profiles previously built into the
+-- job generator, plus the quotas Pro was missing.
+INSERT INTO tier_profiles (tier, revision, spec, comment, created_by) VALUES
+    ('FREE_TRIAL', 1, '{"resources":{"cpu":250,"memory_mb":512,"priority":40},"placement":{"node_tier":"free-trial"},"update":{"max_parallel":1,"canary":0,"auto_revert":false,"min_healthy_seconds":10,"healthy_deadline_seconds":300,"progress_deadline_seconds":600},"quotas":{"QUOTA_ORG_API_KEY":"2","QUOTA_ORG_CUSTOMER":"10","QUOTA_ORG_DASHBOARD":"5","QUOTA_ORG_DATA_SOURCE":"2","QUOTA_ORG_SUBSCRIPTION":"5","QUOTA_ORG_USAGE_MONTHLY":"10000","QUOTA_ORG_USER":"2","QUOTA_USER_ORG":"1","USAGE_INGEST_ENDPOINT_BURST":"10","USAGE_INGEST_ENDPOINT_RATE":"5","USAGE_INGEST_ORG_BURST":"10","USAGE_INGEST_ORG_RATE":"5","WEBHOOK_RETENTION_DAYS":"7"}}', 'Initial profile', 'system'),
+    ('STARTER', 1, '{"resources":{"cpu":500,"memory_mb":1024,"priority":60},"placement":{"node_tier":"starter"},"update":{"max_parallel":1,"canary":0,"auto_revert":false,"min_healthy_seconds":10,"healthy_deadline_seconds":300,"progress_deadline_seconds":600},"quotas":{"QUOTA_ORG_API_KEY":"5","QUOTA_ORG_CUSTOMER":"100","QUOTA_ORG_DASHBOARD":"20","QUOTA_ORG_DATA_SOURCE":"5","QUOTA_ORG_SUBSCRIPTION":"10","QUOTA_ORG_USAGE_MONTHLY":"100000","QUOTA_ORG_USER":"5","QUOTA_USER_ORG":"2","USAGE_INGEST_ENDPOINT_BURST":"20","USAGE_INGEST_ENDPOINT_RATE":"10","USAGE_INGEST_ORG_BURST":"20","USAGE_INGEST_ORG_RATE":"10","WEBHOOK_RETENTION_DAYS":"30"}}', 'Initial profile', 'system'),
+    ('PRO', 1, '{"resources":{"cpu":1000,"memory_mb":2048,"priority":75},"placement":{"node_tier":"pro"},"update":{"max_parallel":1,"canary":0,"auto_revert":true,"min_healthy_seconds":10,"healthy_deadline_seconds":300,"progress_deadline_seconds":600},"quotas":{"QUOTA_ORG_API_KEY":"10","QUOTA_ORG_CUSTOMER":"1000","QUOTA_ORG_DASHBOARD":"100","QUOTA_ORG_DATA_SOURCE":"10","QUOTA_ORG_SUBSCRIPTION":"50","QUOTA_ORG_USAGE_MONTHLY":"1000000","QUOTA_ORG_USER":"10","QUOTA_USER_ORG":"10","USAGE_INGEST_ENDPOINT_BURST":"30","USAGE_INGEST_ENDPOINT_RATE":"15","USAGE_INGEST_ORG_BURST":"50","USAGE_INGEST_ORG_RATE":"25","WEBHOOK_RETENTION_DAYS":"90"}}', 'Initial profile', 'system'),
+    ('TEAM', 1, '{"resources":{"cpu":2000,"memory_mb":4096,"priority":90},"placement":{"node_tier":"team"},"update":{"max_parallel":1,"canary":1,"auto_revert":true,"min_healthy_seconds":10,"healthy_deadline_seconds":300,"progress_deadline_seconds":600},"quotas":{"QUOTA_ORG_API_KEY":"50","QUOTA_ORG_CUSTOMER":"5000","QUOTA_ORG_DASHBOARD":"500","QUOTA_ORG_DATA_SOURCE":"50","QUOTA_ORG_SUBSCRIPTION":"200","QUOTA_ORG_USAGE_MONTHLY":"10000000","QUOTA_ORG_USER":"50","QUOTA_USER_ORG":"20","USAGE_INGEST_ENDPOINT_BURST":"100","USAGE_INGEST_ENDPOINT_RATE":"50","USAGE_INGEST_ORG_BURST":"200","USAGE_INGEST_ORG_RATE":"100","WEBHOOK_RETENTION_DAYS":"180"}}', 'Initial profile', 'system'),
+    ('ENTERPRISE', 1, '{"resources":{"cpu":4000,"memory_mb":8192,"priority":100},"placement":{"node_tier":"enterprise"},"update":{"max_parallel":1,"canary":1,"auto_revert":true,"min_healthy_seconds":10,"healthy_deadline_seconds":300,"progress_deadline_seconds":600},"quotas":{"QUOTA_ORG_API_KEY":"100","QUOTA_ORG_CUSTOMER":"-1","QUOTA_ORG_DASHBOARD":"1000","QUOTA_ORG_DATA_SOURCE":"100","QUOTA_ORG_SUBSCRIPTION":"-1","QUOTA_ORG_USAGE_MONTHLY":"100000000","QUOTA_ORG_USER":"100","QUOTA_USER_ORG":"50","USAGE_INGEST_ENDPOINT_BURST":"500","USAGE_INGEST_ENDPOINT_RATE":"200","USAGE_INGEST_ORG_BURST":"1000","USAGE_INGEST_ORG_RATE":"500","WEBHOOK_RETENTION_DAYS":"365"}}', 'Initial profile', 'system')
+ON CONFLICT DO NOTHING;
+
+-- Revision of the tier profile the instance was last deployed with. 0 means
+-- the built-in profile.
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS tier_profile_revision INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Fingerprint of the product entitlements the instance was last deployed
+-- with. Empty when none applied.
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS entitlements_hash VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Arguments of the event, interpreted by the handler of its event type.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS payload JSONB;
@@ -0,0 +1,13 @@
+-- Step checkpoints of instance deploys, one row per deploy outbox event.
+CREATE TABLE IF NOT EXISTS deploy_sagas (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL UNIQUE REFERENCES outbox_events(id) ON DELETE CASCADE,
+    instance_id BIGINT NOT NULL,
+    org_id BIGINT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    steps JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_deploy_sagas_instance_id ON deploy_sagas (instance_id, id DESC);
@@ -0,0 +1,6 @@
+ALTER TABLE instance_events DROP COLUMN IF EXISTS reason;
+
+DROP INDEX IF EXISTS idx_outbox_events_dead;
+
+UPDATE outbox_events SET status = 'failed' WHERE status = 'dead';
+UPDATE outbox_events SET status = 'cancelled' WHERE status = 'discarded';
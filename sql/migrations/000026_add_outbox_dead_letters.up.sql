@@ -0,0 +1,11 @@
+-- Events that used up their attempts were left in failed; they form the
+-- dead-letter queue now.
+UPDATE outbox_events
+SET status = 'dead', next_attempt_at = NULL
+WHERE status = 'failed' AND attempts >= 10;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_dead
+    ON outbox_events(id DESC)
+    WHERE status = 'dead';
+
+ALTER TABLE instance_events ADD COLUMN IF NOT EXISTS reason TEXT;
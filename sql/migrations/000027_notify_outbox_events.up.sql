@@ -0,0 +1,16 @@
+-- Wakes outbox processors listening on outbox_events. Notifications with the
+-- same payload are folded per transaction, so a rollout enqueuing many events
+-- sends one.
+CREATE OR REPLACE FUNCTION notify_outbox_events() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('outbox_events', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS outbox_events_notify ON outbox_events;
+CREATE TRIGGER outbox_events_notify
+    AFTER INSERT OR UPDATE OF status ON outbox_events
+    FOR EACH ROW
+    WHEN (NEW.status = 'pending')
+    EXECUTE FUNCTION notify_outbox_events();
@@ -0,0 +1,2 @@
+ALTER TABLE instances ALTER COLUMN oauth_client_secret TYPE VARCHAR(255);
+ALTER TABLE instances ALTER COLUMN db_password TYPE VARCHAR(255);
@@ -0,0 +1,3 @@
+-- Sealed credentials carry a prefix, a nonce and a tag, and outgrow VARCHAR(255).
+ALTER TABLE instances ALTER COLUMN db_password TYPE TEXT;
+ALTER TABLE instances ALTER COLUMN oauth_client_secret TYPE TEXT;
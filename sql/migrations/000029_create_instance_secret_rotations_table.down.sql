@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS instance_secret_rotations;
+ALTER TABLE instances DROP COLUMN IF EXISTS previous_payment_provider_secret_expires_at;
+ALTER TABLE instances DROP COLUMN IF EXISTS previous_payment_provider_secret;
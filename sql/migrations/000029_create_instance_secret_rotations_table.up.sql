@@ -0,0 +1,26 @@
+-- Payment provider secret replaced by the last rotation, deployed next to the
+-- new one until it expires.
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS previous_payment_provider_secret TEXT;
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS previous_payment_provider_secret_expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Credential rotation history, one row per rotated credential. Rows outlive
+-- the outbox event that rotated them.
+CREATE TABLE IF NOT EXISTS instance_secret_rotations (
+    id BIGSERIAL PRIMARY KEY,
+    instance_id BIGINT NOT NULL REFERENCES instances(id),
+    org_id BIGINT NOT NULL,
+    event_id BIGINT NOT NULL,
+    secret VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    actor_type VARCHAR(50) NOT NULL,
+    actor_id VARCHAR(255),
+    grace_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_instance_secret_rotations_instance_id
+    ON instance_secret_rotations (instance_id, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_instance_secret_rotations_event_id
+    ON instance_secret_rotations (event_id);
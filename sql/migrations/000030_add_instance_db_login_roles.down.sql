@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_instances_previous_db_login_expires_at;
+ALTER TABLE instances DROP COLUMN IF EXISTS previous_db_login_expires_at;
+ALTER TABLE instances DROP COLUMN IF EXISTS previous_db_login_user;
+ALTER TABLE instances DROP COLUMN IF EXISTS db_login_user;
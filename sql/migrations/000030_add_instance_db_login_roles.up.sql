@@ -0,0 +1,10 @@
+-- Password rotations alternate the workload between two login roles that are
+-- members of db_user, so the replaced login keeps working until its grace
+-- period ends. Empty db_login_user means db_user logs in itself.
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS db_login_user VARCHAR(255);
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS previous_db_login_user VARCHAR(255);
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS previous_db_login_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_instances_previous_db_login_expires_at
+    ON instances (previous_db_login_expires_at)
+    WHERE previous_db_login_expires_at IS NOT NULL;